@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"remap/internal/config"
+	"remap/internal/errors"
+
+	"github.com/spf13/pflag"
+)
+
+// configFileName is the config file looked up in the target directory when
+// --config is not given.
+const configFileName = ".remap.yaml"
+
+// exclusiveFlags are the groups of flags that cannot be combined.
+var exclusiveFlags = [][]string{
+	{"csv", "json", "yaml", "properties"},
+	{"verbose", "quiet"},
+	{"debug", "quiet"},
+	{"backup", "nobackup"},
+	{"revert", "apply"},
+	{"backup-dir", "backup-archive"},
+	{"stable-output", "sorted-output"},
+}
+
+// configPathFlags take file paths, which a config file gives relative to
+// its own directory.
+var configPathFlags = map[string]bool{
+	"csv": true, "json": true, "yaml": true, "properties": true, "log": true, "patch": true,
+	"backup-dir": true, "backup-archive": true,
+}
+
+// findConfigFile returns the config file to load: the --config path if
+// given, otherwise the target directory's .remap.yaml if there is one.
+func findConfigFile(explicit string, args []string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if len(args) == 0 {
+		return ""
+	}
+
+	candidate := filepath.Join(args[0], configFileName)
+	if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+		return candidate
+	}
+	return ""
+}
+
+// applyConfigFile sets the flags named in the config file at path. Flags
+// already set, on the command line or from the environment, win: an option
+// is skipped when its flag, or a flag it is mutually exclusive with, was set.
+// Precedence is therefore command line, environment, config file, then flag
+// defaults.
+func applyConfigFile(flags *pflag.FlagSet, path string) error {
+	options, err := config.LoadFile(path)
+	if err != nil {
+		return err
+	}
+
+	explicit := changedFlags(flags)
+
+	names := make([]string, len(options))
+	for i, option := range options {
+		names[i] = option.Name
+	}
+	err = checkExclusive(names, func(used []string) error {
+		return errors.NewConfigErrorWithPath(path, fmt.Sprintf("options %v cannot be used together", used), nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, option := range options {
+		flag := flags.Lookup(option.Name)
+		if flag == nil || option.Name == "config" {
+			return errors.NewConfigErrorWithPath(path, fmt.Sprintf("unknown option %q", option.Name), nil)
+		}
+		if overridden(option.Name, explicit) {
+			continue
+		}
+
+		for _, value := range option.Values {
+			if configPathFlags[option.Name] && value != "-" && !filepath.IsAbs(value) {
+				value = filepath.Join(filepath.Dir(path), value)
+			}
+			if err := flags.Set(option.Name, value); err != nil {
+				return errors.NewConfigErrorWithPath(path, fmt.Sprintf("invalid value for %q", option.Name), err)
+			}
+		}
+	}
+	return nil
+}
+
+// changedFlags returns the names of the flags set so far.
+func changedFlags(flags *pflag.FlagSet) map[string]bool {
+	changed := make(map[string]bool)
+	flags.Visit(func(f *pflag.Flag) {
+		changed[f.Name] = true
+	})
+	return changed
+}
+
+// overridden reports whether name, or a flag that is mutually exclusive with
+// it, is among the explicitly set flags.
+func overridden(name string, explicit map[string]bool) bool {
+	if explicit[name] {
+		return true
+	}
+	for _, group := range exclusiveFlags {
+		if !contains(group, name) {
+			continue
+		}
+		for _, other := range group {
+			if explicit[other] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkExclusive rejects a source (config file or environment) that sets two
+// mutually exclusive flags, as the command line would, reporting the group
+// through conflict.
+func checkExclusive(names []string, conflict func(used []string) error) error {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+
+	for _, group := range exclusiveFlags {
+		var used []string
+		for _, name := range group {
+			if set[name] {
+				used = append(used, name)
+			}
+		}
+		if len(used) > 1 {
+			return conflict(used)
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// configTestFlags mirrors a few root flags on a fresh flag set.
+type configTestFlags struct {
+	flags         *pflag.FlagSet
+	csv, json     string
+	log           string
+	caseSensitive bool
+	workers       int
+	exclude       []string
+	verbose       bool
+	quiet         bool
+}
+
+func newConfigTestFlags(t *testing.T, args ...string) *configTestFlags {
+	t.Helper()
+	f := &configTestFlags{flags: pflag.NewFlagSet("remap", pflag.ContinueOnError)}
+	f.flags.StringVar(&f.csv, "csv", "", "")
+	f.flags.StringVar(&f.json, "json", "", "")
+	f.flags.StringVar(&f.log, "log", "", "")
+	f.flags.BoolVar(&f.caseSensitive, "case-sensitive", false, "")
+	f.flags.IntVar(&f.workers, "workers", 0, "")
+	f.flags.StringSliceVar(&f.exclude, "exclude", []string{"*.min.js"}, "")
+	f.flags.BoolVar(&f.verbose, "verbose", false, "")
+	f.flags.BoolVar(&f.quiet, "quiet", false, "")
+	if err := f.flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplyConfigFilePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	yamlConfig := writeConfigFile(t, dir, "remap.yaml",
+		"csv: mappings.csv\nlog: /var/log/remap.json\ncase-sensitive: true\nworkers: 4\nexclude: [\"*.lock\", vendor]\nverbose: true\n")
+
+	// Flags on the command line win over the file, and a flag from the same
+	// exclusive group (--json, --quiet) overrides the file's --csv/--verbose
+	f := newConfigTestFlags(t, "--workers", "2", "--json", "cli.json", "--quiet")
+	if err := applyConfigFile(f.flags, yamlConfig); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if f.workers != 2 {
+		t.Errorf("expected the command line workers (2), got %d", f.workers)
+	}
+	if f.csv != "" || f.json != "cli.json" {
+		t.Errorf("expected --json from the command line to override the file's csv, got csv=%q json=%q", f.csv, f.json)
+	}
+	if f.verbose {
+		t.Error("expected --quiet on the command line to override the file's verbose")
+	}
+	if !f.caseSensitive {
+		t.Error("expected case-sensitive from the file")
+	}
+	if f.log != "/var/log/remap.json" {
+		t.Errorf("expected absolute paths kept as is, got %q", f.log)
+	}
+	if strings.Join(f.exclude, ",") != "*.lock,vendor" {
+		t.Errorf("expected the file's exclude list to replace the default, got %v", f.exclude)
+	}
+
+	// Without command-line flags the file wins over defaults, and relative
+	// paths are resolved against the config file's directory
+	f = newConfigTestFlags(t)
+	if err := applyConfigFile(f.flags, yamlConfig); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.workers != 4 || !f.verbose {
+		t.Errorf("expected file values, got workers=%d verbose=%v", f.workers, f.verbose)
+	}
+	if expected := filepath.Join(dir, "mappings.csv"); f.csv != expected {
+		t.Errorf("expected %q, got %q", expected, f.csv)
+	}
+
+	jsonConfig := writeConfigFile(t, dir, "remap.json", `{"case-sensitive": true, "exclude": "a,b"}`)
+	f = newConfigTestFlags(t)
+	if err := applyConfigFile(f.flags, jsonConfig); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !f.caseSensitive || strings.Join(f.exclude, ",") != "a,b" {
+		t.Errorf("expected JSON values, got case-sensitive=%v exclude=%v", f.caseSensitive, f.exclude)
+	}
+}
+
+func TestApplyConfigFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"unknown option", "no-such-flag: true\n", `unknown option "no-such-flag"`},
+		{"invalid value", "workers: many\n", `invalid value for "workers"`},
+		{"exclusive options", "csv: a.csv\njson: b.json\n", "cannot be used together"},
+		{"nested value", "exclude:\n  a: b\n", `invalid value for "exclude"`},
+		{"malformed file", "csv: [unterminated\n", "failed to parse config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, dir, "remap.yaml", tt.content)
+			err := applyConfigFile(newConfigTestFlags(t).flags, path)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	dir := t.TempDir()
+
+	if path := findConfigFile("", []string{dir}); path != "" {
+		t.Errorf("expected no config file, got %q", path)
+	}
+
+	discovered := writeConfigFile(t, dir, configFileName, "case-sensitive: true\n")
+	if path := findConfigFile("", []string{dir}); path != discovered {
+		t.Errorf("expected %q to be discovered, got %q", discovered, path)
+	}
+	if path := findConfigFile("explicit.yaml", []string{dir}); path != "explicit.yaml" {
+		t.Errorf("expected --config to win over discovery, got %q", path)
+	}
+	if path := findConfigFile("", nil); path != "" {
+		t.Errorf("expected no config file without a directory, got %q", path)
+	}
+}
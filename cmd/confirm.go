@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// confirmInput and confirmOutput carry the --confirm prompt; tests replace them.
+var (
+	confirmInput  io.Reader = os.Stdin
+	confirmOutput io.Writer = os.Stderr
+)
+
+// confirmChanges shows the preview and asks whether to apply it. Anything but
+// an explicit yes, including a closed stdin, aborts the run before any file
+// is touched.
+func confirmChanges(p preview, in io.Reader, out io.Writer) error {
+	if p.modifiedFiles == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(out, "%d file(s) would be modified (%d replacements", p.modifiedFiles, p.replacements)
+	if p.deletedBytes > 0 {
+		fmt.Fprintf(out, ", %d bytes deleted", p.deletedBytes)
+	}
+	fmt.Fprint(out, "). Apply these changes? [y/N] ")
+
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return &exitCodeError{code: exitCodeFatal, message: "Aborted: no files were modified"}
+	}
+}
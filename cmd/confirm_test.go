@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfirmChanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		answer      string
+		expectAbort bool
+	}{
+		{name: "yes", answer: "y\n", expectAbort: false},
+		{name: "full yes", answer: "YES\n", expectAbort: false},
+		{name: "no", answer: "n\n", expectAbort: true},
+		{name: "empty answer", answer: "\n", expectAbort: true},
+		{name: "closed input", answer: "", expectAbort: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := confirmChanges(preview{modifiedFiles: 2, replacements: 5}, strings.NewReader(tt.answer), &out)
+
+			if tt.expectAbort && err == nil {
+				t.Error("expected abort, got nil")
+			}
+			if !tt.expectAbort && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !strings.Contains(out.String(), "2 file(s) would be modified (5 replacements)") {
+				t.Errorf("expected preview in prompt, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestExecuteRemapConfirm(t *testing.T) {
+	for _, answer := range []string{"n\n", "y\n"} {
+		cfg := newTestConfig(t, "foo\n", "foo,bar\n")
+		cfg.DryRun = false
+		cfg.NoBackup = true
+		cfg.Confirm = true
+
+		confirmInput, confirmOutput = strings.NewReader(answer), &bytes.Buffer{}
+		err := executeRemap(cfg)
+		confirmInput, confirmOutput = os.Stdin, os.Stderr
+
+		content, readErr := os.ReadFile(filepath.Join(cfg.Directory, "file.txt"))
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+
+		if answer == "n\n" {
+			if err == nil || string(content) != "foo\n" {
+				t.Errorf("declined run: err=%v content=%q, expected abort and untouched file", err, content)
+			}
+		} else if err != nil || string(content) != "bar\n" {
+			t.Errorf("accepted run: err=%v content=%q, expected success and rewritten file", err, content)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package cmd
+
+import "remap/internal/config"
+
+// applyDiffMode makes --diff a preview: it implies --dry-run unless the dry
+// run was set explicitly (changed reports that), so `--diff --dry-run=false`
+// shows the diff of a run that also writes the files.
+func applyDiffMode(cfg *config.Config, changed func(name string) bool) {
+	if cfg.Diff && !changed("dry-run") && !changed("fake") {
+		cfg.DryRun = true
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"testing"
+
+	"remap/internal/config"
+)
+
+func TestApplyDiffMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   config.Config
+		changed  []string
+		expected bool
+	}{
+		{name: "diff implies dry run", config: config.Config{Diff: true}, expected: true},
+		{name: "explicit write", config: config.Config{Diff: true}, changed: []string{"dry-run"}, expected: false},
+		{name: "explicit fake alias", config: config.Config{Diff: true, DryRun: true}, changed: []string{"fake"}, expected: true},
+		{name: "without diff", config: config.Config{}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := func(name string) bool {
+				for _, flag := range tt.changed {
+					if flag == name {
+						return true
+					}
+				}
+				return false
+			}
+
+			cfg := tt.config
+			applyDiffMode(&cfg, changed)
+			if cfg.DryRun != tt.expected {
+				t.Errorf("DryRun = %v, expected %v", cfg.DryRun, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"remap/internal/errors"
+
+	"github.com/spf13/pflag"
+)
+
+// envPrefix starts the name of every environment variable remap reads.
+const envPrefix = "REMAP_"
+
+// envNameOverrides gives flags whose name does not split into words on its
+// own a readable variable name.
+var envNameOverrides = map[string]string{
+	"nobackup": "REMAP_NO_BACKUP",
+}
+
+// envName returns the environment variable bound to a flag: REMAP_ followed
+// by the flag name in upper case with dashes turned into underscores, e.g.
+// --exclude-dir is REMAP_EXCLUDE_DIR.
+func envName(flagName string) string {
+	if name, ok := envNameOverrides[flagName]; ok {
+		return name
+	}
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets every flag whose environment variable is set and non-empty.
+// Flags given on the command line win, as do flags they are mutually
+// exclusive with. Boolean variables accept 1/true/yes and 0/false/no, and
+// list flags split their variable on commas, like --extensions.
+func applyEnv(flags *pflag.FlagSet) error {
+	explicit := changedFlags(flags)
+
+	var fromEnv []string
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Name == "help" {
+			return
+		}
+		value, ok := os.LookupEnv(envName(flag.Name))
+		if !ok || value == "" {
+			return
+		}
+		fromEnv = append(fromEnv, flag.Name)
+		if overridden(flag.Name, explicit) {
+			return
+		}
+
+		if flag.Value.Type() == "bool" {
+			value = normalizeEnvBool(value)
+		}
+		if setErr := flags.Set(flag.Name, value); setErr != nil {
+			err = errors.NewConfigError(fmt.Sprintf("invalid value for %s", envName(flag.Name)), setErr)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return checkExclusive(fromEnv, func(used []string) error {
+		names := make([]string, len(used))
+		for i, name := range used {
+			names[i] = envName(name)
+		}
+		return errors.NewConfigError(fmt.Sprintf("environment variables %v cannot be used together", names), nil)
+	})
+}
+
+// normalizeEnvBool maps the yes/no spellings common in environment files to
+// the values boolean flags parse.
+func normalizeEnvBool(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "yes", "y", "on":
+		return "true"
+	case "no", "n", "off":
+		return "false"
+	default:
+		return value
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"extensions":  "REMAP_EXTENSIONS",
+		"exclude-dir": "REMAP_EXCLUDE_DIR",
+		"nobackup":    "REMAP_NO_BACKUP",
+		"config":      "REMAP_CONFIG",
+	}
+	for flag, want := range tests {
+		if got := envName(flag); got != want {
+			t.Errorf("envName(%q) = %q, want %q", flag, got, want)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("REMAP_CSV", "env.csv")
+	t.Setenv("REMAP_CASE_SENSITIVE", "yes")
+	t.Setenv("REMAP_WORKERS", "3")
+	t.Setenv("REMAP_EXCLUDE", "*.lock,vendor/*")
+	t.Setenv("REMAP_VERBOSE", "1")
+	t.Setenv("REMAP_LOG", "")
+
+	f := newConfigTestFlags(t, "--workers", "2")
+	if err := applyEnv(f.flags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if f.csv != "env.csv" {
+		t.Errorf("csv = %q, want env.csv", f.csv)
+	}
+	if !f.caseSensitive || !f.verbose {
+		t.Errorf("boolean variables not applied: case-sensitive=%v verbose=%v", f.caseSensitive, f.verbose)
+	}
+	if f.workers != 2 {
+		t.Errorf("command line should win over REMAP_WORKERS, got %d", f.workers)
+	}
+	if want := []string{"*.lock", "vendor/*"}; !reflect.DeepEqual(f.exclude, want) {
+		t.Errorf("exclude = %v, want %v", f.exclude, want)
+	}
+	if f.log != "" {
+		t.Errorf("empty REMAP_LOG should be ignored, got %q", f.log)
+	}
+}
+
+func TestApplyEnvExclusiveFlags(t *testing.T) {
+	// --json on the command line overrides REMAP_CSV instead of conflicting
+	t.Setenv("REMAP_CSV", "env.csv")
+	f := newConfigTestFlags(t, "--json", "cli.json")
+	if err := applyEnv(f.flags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.csv != "" || f.json != "cli.json" {
+		t.Errorf("csv = %q, json = %q; want the command line --json only", f.csv, f.json)
+	}
+
+	t.Setenv("REMAP_JSON", "env.json")
+	f = newConfigTestFlags(t)
+	err := applyEnv(f.flags)
+	if err == nil || !strings.Contains(err.Error(), "REMAP_CSV") {
+		t.Errorf("expected conflict between REMAP_CSV and REMAP_JSON, got %v", err)
+	}
+}
+
+func TestApplyEnvErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"invalid boolean", "REMAP_VERBOSE", "maybe"},
+		{"invalid integer", "REMAP_WORKERS", "many"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			err := applyEnv(newConfigTestFlags(t).flags)
+			if err == nil || !strings.Contains(err.Error(), tt.key) {
+				t.Errorf("expected error naming %s, got %v", tt.key, err)
+			}
+		})
+	}
+}
+
+func TestApplyEnvBeforeConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfigFile(t, dir, "remap.yaml", "workers: 4\nverbose: true\n")
+	t.Setenv("REMAP_WORKERS", "6")
+
+	f := newConfigTestFlags(t)
+	if err := applyEnv(f.flags); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfigFile(f.flags, path); err != nil {
+		t.Fatal(err)
+	}
+	if f.workers != 6 {
+		t.Errorf("environment should win over the config file, got workers=%d", f.workers)
+	}
+	if !f.verbose {
+		t.Error("config file should still set options absent from the environment")
+	}
+}
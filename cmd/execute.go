@@ -5,6 +5,10 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"remap/internal/backup"
@@ -14,6 +18,7 @@ import (
 	"remap/internal/filter"
 	"remap/internal/log"
 	"remap/internal/parser"
+	"remap/internal/replacement"
 )
 
 func executeRemap(cfg *config.Config) error {
@@ -27,39 +32,356 @@ func executeRemap(cfg *config.Config) error {
 		return executeApply(cfg)
 	}
 
-	mappings, err := parser.LoadMappingTable(cfg.MappingFile, cfg.MappingType)
+	mappings, err := loadMappings(cfg)
 	if err != nil {
 		return err
 	}
 
+	if cfg.ToTemplate {
+		if err := mappings.CompileToTemplates(replacement.MatchContext{}); err != nil {
+			return err
+		}
+	}
+
+	if err := reportMappingWarnings(cfg, mappingWarnings(mappings, cfg.CaseSensitive)); err != nil {
+		return err
+	}
+
+	if cfg.ToStdout {
+		return executeToStdout(cfg, mappings)
+	}
+
+	if cfg.Filter {
+		return executeFilter(cfg, mappings)
+	}
+
 	discovery := filter.NewFileDiscovery(cfg)
+	if cfg.Explain {
+		discovery.SetExplainWriter(os.Stderr)
+	}
 	files, err := discovery.Discover()
 	if err != nil {
 		return err
 	}
+	if discovery.BudgetReached() && !cfg.Quiet {
+		fmt.Fprintf(os.Stderr, "Warning: size budget of %d bytes reached; processing the first %d file(s) and skipping the rest\n",
+			cfg.SizeBudget, len(files))
+	}
+	if err := reportWarnings(cfg, discoveryWarnings(discovery)); err != nil {
+		return err
+	}
+	orderFiles(cfg, files)
 
 	logger, err := log.NewLogger(cfg)
 	if err != nil {
 		return err
 	}
 	defer logger.Close()
-
-	processor := concurrent.NewProcessor(cfg, mappings)
+	logger.SetMappings(mappings.GetMappings())
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if err := checkBeforeWriting(ctx, cfg, mappings, files); err != nil {
+		return err
+	}
+
+	// Only installed after the --confirm prompt, so Ctrl-C still aborts it
+	ctx, stopInterrupt := handleInterrupt(ctx)
+	defer stopInterrupt()
+
+	processor := concurrent.NewProcessor(cfg, mappings)
+	if afterFileProcessed != nil {
+		processor.OnProcessed(func(concurrent.ProcessResult) { afterFileProcessed(ctx) })
+	}
+
 	results, err := processor.ProcessFiles(ctx, files)
 	if err != nil {
 		return err
 	}
 
-	for result := range results {
+	var patches, detected []concurrent.ProcessResult
+	var stopErr error
+	var processed int
+	used := make(map[int]bool)
+	record := func(result concurrent.ProcessResult) {
 		logger.LogResult(result)
+		if result.Result != nil {
+			for _, index := range result.Result.UsedMappings {
+				used[index] = true
+			}
+		}
+		if result.Patch != "" && result.Error == nil {
+			patches = append(patches, result)
+		}
+		if result.Error != nil && cfg.StopOnError && stopErr == nil {
+			// Stop queuing work but keep draining results already produced
+			stopErr = result.Error
+			cancel()
+		}
+	}
+
+	indicator := newProgress(cfg, files)
+	prompt := newInteractivePrompt(interactiveInput, interactiveOutput)
+	for result := range results {
+		processed++
+		if result.Pending && cfg.DetectOnly {
+			// Written once every file has been detected
+			indicator.Increment(result.Job.FileInfo.Size)
+			detected = append(detected, result)
+			continue
+		}
+		if result.Pending {
+			if ctx.Err() != nil {
+				// Quit or interrupted: files still waiting are left untouched
+				result = processor.Skip(result)
+			} else if result = prompt.confirm(processor, result); prompt.quit {
+				stopErr = quitError
+				cancel()
+			}
+		}
+		indicator.Increment(result.Job.FileInfo.Size)
+		record(result)
+	}
+	if ctx.Err() == nil {
+		detected = processor.Apply(detected)
+	}
+	for _, result := range detected {
+		// Apply leaves nothing Pending; after a stop or an interrupt during
+		// detection, every held back file is left untouched instead
+		record(processor.Skip(result))
+	}
+	indicator.Finish()
+	if err := processor.Close(); err != nil {
+		return err
+	}
+	interrupted := stopErr == nil && ctx.Err() != nil && processed < len(files)
+
+	if cfg.PatchFile != "" {
+		if err := writePatch(cfg.PatchFile, patches); err != nil {
+			return err
+		}
 	}
 
 	logger.SetProcessingTime(time.Since(startTime))
-	return logger.WriteReport()
+	if err := logger.WriteReport(); err != nil {
+		return err
+	}
+
+	if warning := deletionWarning(cfg, logger.Summary()); warning != "" {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+
+	if stopErr != nil {
+		return stopErr
+	}
+	if interrupted {
+		return interruptedError(processed, len(files))
+	}
+	if err := fileErrorExitCode(cfg, logger.Summary()); err != nil {
+		return err
+	}
+
+	// Unused mappings are common when a shared mapping file is applied to
+	// part of a tree, so they are only reported in verbose mode unless
+	// strict. A strict run that writes files checked them in the preview,
+	// before writing anything
+	if (cfg.Strict && cfg.DryRun) || (!cfg.Strict && cfg.IsVerbose()) {
+		if err := reportWarnings(cfg, unusedMappingWarnings(mappings, used)); err != nil {
+			return err
+		}
+	}
+
+	return summaryExitCode(cfg, logger.Summary())
+}
+
+// loadMappings combines the mapping file and the inline --map pairs. Inline
+// pairs come first so they win over file mappings with the same source; within
+// each, a source mapped twice is an error unless --allow-duplicates is set.
+// Mappings forming a cycle are always an error.
+func loadMappings(cfg *config.Config) (*parser.MappingTable, error) {
+	mappings, err := parser.NewMappingTableFromPairs(cfg.InlineMappings)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.AllowDuplicates {
+		if err := mappings.CheckDuplicates("--map", cfg.CaseSensitive); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.MappingFile != "" {
+		fileMappings, err := parser.LoadMappingTable(cfg.MappingFile, cfg.MappingType)
+		if err != nil {
+			return nil, err
+		}
+		if !cfg.AllowDuplicates {
+			if err := fileMappings.CheckDuplicates(cfg.MappingFile, cfg.CaseSensitive); err != nil {
+				return nil, err
+			}
+		}
+		mappings = parser.NewMappingTable(append(mappings.GetMappings(), fileMappings.GetMappings()...))
+	}
+
+	if cfg.NormalizeMapWhitespace {
+		mappings = mappings.CollapseWhitespace()
+	}
+
+	source := cfg.MappingFile
+	if source == "" {
+		source = "--map"
+	}
+	if err := mappings.CheckCycles(source, cfg.CaseSensitive); err != nil {
+		return nil, err
+	}
+
+	return mappings, nil
+}
+
+// orderFiles arranges the discovered files in the order they are fed to the
+// worker pool, as selected by --order.
+func orderFiles(cfg *config.Config, files []filter.FileInfo) {
+	if cfg.Order == config.OrderSizeDesc {
+		filter.SortBySizeDesc(files)
+	}
+}
+
+// writePatch writes the per-file diffs into a single patch, ordered by path so
+// the output does not depend on worker scheduling.
+func writePatch(path string, results []concurrent.ProcessResult) error {
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Job.FilePath < results[j].Job.FilePath
+	})
+
+	var patch strings.Builder
+	for _, result := range results {
+		patch.WriteString(result.Patch)
+	}
+
+	if err := os.WriteFile(path, []byte(patch.String()), 0644); err != nil {
+		return errors.WrapFileError(path, err)
+	}
+	return nil
+}
+
+// deletionWarning describes how much text a dry run would delete through
+// mappings with an empty replacement, or returns "" when there is nothing to report.
+func deletionWarning(cfg *config.Config, summary log.Summary) string {
+	if !cfg.DryRun || cfg.Quiet || summary.DeletedBytes == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Warning: this run would delete %d bytes of text (mappings with an empty replacement)", summary.DeletedBytes)
+}
+
+// checkBeforeWriting runs the safeguards that need a preview of a real run:
+// the deletion gate, which refuses runs whose empty replacements would delete
+// more than the threshold unless --confirm-deletion is given, the --strict
+// check for unused mappings, and the interactive --confirm prompt. The
+// preview is a dry-run pass, so it only costs extra work when one of them is
+// active.
+func checkBeforeWriting(ctx context.Context, cfg *config.Config, mappings *parser.MappingTable, files []filter.FileInfo) error {
+	gateDeletions := !cfg.ConfirmDeletion && canDelete(cfg, mappings)
+	if cfg.DryRun || (!gateDeletions && !cfg.Strict && !cfg.Confirm) {
+		return nil
+	}
+
+	p, err := previewChanges(ctx, cfg, mappings, files)
+	if err != nil {
+		return err
+	}
+
+	if gateDeletions && p.deletedBytes > cfg.DeletionThreshold {
+		return errors.NewConfigError(fmt.Sprintf(
+			"this run would delete %d bytes of text (threshold %d); use --confirm-deletion to proceed",
+			p.deletedBytes, cfg.DeletionThreshold), nil)
+	}
+
+	if cfg.Strict {
+		if err := reportWarnings(cfg, unusedMappingWarnings(mappings, p.used)); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Confirm {
+		return confirmChanges(p, confirmInput, confirmOutput)
+	}
+	return nil
+}
+
+// preview summarizes what a run would change, as measured by a dry-run pass.
+type preview struct {
+	modifiedFiles int
+	replacements  int
+	deletedBytes  int64
+	used          map[int]bool // indexes of the mappings that would replace text
+}
+
+func previewChanges(ctx context.Context, cfg *config.Config, mappings *parser.MappingTable, files []filter.FileInfo) (preview, error) {
+	dryRunCfg := *cfg
+	dryRunCfg.DryRun = true
+	// The real run takes the backups, and a second backup archive would
+	// collide with its own
+	dryRunCfg.NoBackup = true
+
+	results, err := concurrent.NewProcessor(&dryRunCfg, mappings).ProcessFiles(ctx, files)
+	if err != nil {
+		return preview{}, err
+	}
+
+	p := preview{used: make(map[int]bool)}
+	for result := range results {
+		if result.Result != nil && result.Result.Modified {
+			p.modifiedFiles++
+			p.replacements += len(result.Result.Replacements)
+			p.deletedBytes += result.Result.DeletedBytes
+			for _, index := range result.Result.UsedMappings {
+				p.used[index] = true
+			}
+		}
+	}
+	return p, nil
+}
+
+// canDelete reports whether any mapping, explicit or discovered by the
+// definition pre-scan, may replace text with nothing.
+func canDelete(cfg *config.Config, mappings *parser.MappingTable) bool {
+	if cfg.DefinePattern != "" && cfg.DefineReplace == "" {
+		return true
+	}
+	for _, mapping := range mappings.GetMappings() {
+		if mapping.To == "" && mapping.LineCase == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// fileErrorExitCode makes a run in which any file failed exit with
+// --error-exit-code (2 by default), unless --ignore-errors is set. By
+// default remap keeps going after a file error so one bad file does not hold
+// up a bulk job; the report lists every failure, and the exit status tells
+// scripts about them.
+func fileErrorExitCode(cfg *config.Config, summary log.Summary) error {
+	if summary.ErrorCount > 0 && !cfg.IgnoreErrors {
+		return &exitCodeError{
+			code:    cfg.ErrorExitCode,
+			message: fmt.Sprintf("%d file(s) failed", summary.ErrorCount),
+		}
+	}
+	return nil
+}
+
+// summaryExitCode maps the run summary to an exit status when requested.
+// With --summary-exit-code, any modified file yields a non-zero status so
+// pipelines can use remap (typically with --dry-run) as a "is it clean?" check.
+func summaryExitCode(cfg *config.Config, summary log.Summary) error {
+	if cfg.SummaryExitCode && summary.ModifiedFiles > 0 {
+		return &exitCodeError{
+			code:    exitCodeChanges,
+			message: fmt.Sprintf("%d file(s) modified", summary.ModifiedFiles),
+		}
+	}
+	return nil
 }
 
 func executeRevert(cfg *config.Config) error {
@@ -67,8 +389,12 @@ func executeRevert(cfg *config.Config) error {
 		return errors.NewConfigError("log file is required for revert operation", nil)
 	}
 
-	revertManager := backup.NewRevertManager()
-	return revertManager.RevertFromLogWithFormat(cfg.LogFile, string(cfg.LogFormat))
+	revertManager := backup.NewRevertManagerWithWorkers(cfg.Workers)
+	err := revertManager.RevertFromLogWithFormat(cfg.LogFile, string(cfg.LogFormat))
+	if warnErr := reportWarnings(cfg, revertManager.Warnings()); err == nil {
+		err = warnErr
+	}
+	return err
 }
 
 func executeApply(cfg *config.Config) error {
@@ -76,6 +402,10 @@ func executeApply(cfg *config.Config) error {
 		return errors.NewConfigError("log file is required for apply operation", nil)
 	}
 
-	applyManager := backup.NewApplyManager()
-	return applyManager.ApplyFromLogWithFormat(cfg.LogFile, string(cfg.LogFormat))
+	applyManager := backup.NewApplyManagerWithWorkers(cfg.Workers)
+	err := applyManager.ApplyFromLogWithFormat(cfg.LogFile, string(cfg.LogFormat))
+	if warnErr := reportWarnings(cfg, applyManager.Warnings()); err == nil {
+		err = warnErr
+	}
+	return err
 }
@@ -0,0 +1,839 @@
+package cmd
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/filter"
+	"remap/internal/log"
+)
+
+// newTestConfig creates a directory with one file and a CSV mapping, returning a validated config.
+func newTestConfig(t *testing.T, content string, mappings string) *config.Config {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	srcDir := filepath.Join(tempDir, "src")
+	if err := os.Mkdir(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "file.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mappingFile := filepath.Join(tempDir, "mappings.csv")
+	if err := os.WriteFile(mappingFile, []byte(mappings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Directory:   srcDir,
+		MappingFile: mappingFile,
+		MappingType: "csv",
+		DryRun:      true,
+		Quiet:       true,
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestSummaryExitCode(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		summaryExitCode bool
+		expectExitCode  int
+	}{
+		{name: "changes without flag", content: "foo", summaryExitCode: false, expectExitCode: 0},
+		{name: "changes with flag", content: "foo", summaryExitCode: true, expectExitCode: exitCodeChanges},
+		{name: "clean with flag", content: "nothing here", summaryExitCode: true, expectExitCode: 0},
+		{name: "clean without flag", content: "nothing here", summaryExitCode: false, expectExitCode: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t, tt.content, "foo,bar\n")
+			cfg.SummaryExitCode = tt.summaryExitCode
+
+			err := executeRemap(cfg)
+			if tt.expectExitCode == 0 {
+				if err != nil {
+					t.Errorf("expected success, got %v", err)
+				}
+				return
+			}
+
+			var exitErr *exitCodeError
+			if !stderrors.As(err, &exitErr) {
+				t.Fatalf("expected exitCodeError, got %v", err)
+			}
+			if exitErr.code != tt.expectExitCode {
+				t.Errorf("expected exit code %d, got %d", tt.expectExitCode, exitErr.code)
+			}
+		})
+	}
+}
+
+func TestExitCodesDistinct(t *testing.T) {
+	// Scripts must be able to tell changes from fatal errors and from files
+	// that failed with the default --error-exit-code
+	for _, code := range []int{exitCodeFatal, config.DefaultErrorExitCode} {
+		if exitCodeChanges == code {
+			t.Errorf("exit code for changes %d clashes with %d", exitCodeChanges, code)
+		}
+	}
+}
+
+func TestCheckErrorExitCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        int
+		changed     bool
+		expectError bool
+	}{
+		{name: "explicit zero", code: 0, changed: true, expectError: true},
+		{name: "explicit negative", code: -1, changed: true, expectError: true},
+		{name: "explicit one", code: 1, changed: true},
+		{name: "unset", code: 0, changed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ErrorExitCode: tt.code}
+			err := checkErrorExitCode(cfg, func(name string) bool { return tt.changed && name == "error-exit-code" })
+			if tt.expectError && err == nil {
+				t.Error("expected an error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDeletionWarning(t *testing.T) {
+	summary := log.Summary{DeletedBytes: 42}
+
+	cfg := &config.Config{DryRun: true}
+	if warning := deletionWarning(cfg, summary); !strings.Contains(warning, "42 bytes") {
+		t.Errorf("expected warning mentioning 42 bytes, got %q", warning)
+	}
+
+	if warning := deletionWarning(&config.Config{}, summary); warning != "" {
+		t.Errorf("expected no warning outside dry run, got %q", warning)
+	}
+	if warning := deletionWarning(cfg, log.Summary{}); warning != "" {
+		t.Errorf("expected no warning without deletions, got %q", warning)
+	}
+}
+
+func TestDeletionGate(t *testing.T) {
+	tests := []struct {
+		name            string
+		mappings        string
+		confirmDeletion bool
+		expectError     bool
+	}{
+		{name: "deletion above threshold", mappings: "secret,\n", expectError: true},
+		{name: "deletion confirmed", mappings: "secret,\n", confirmDeletion: true, expectError: false},
+		{name: "no deletion", mappings: "secret,public\n", expectError: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t, "secret secret secret\n", tt.mappings)
+			cfg.DryRun = false
+			cfg.NoBackup = true
+			cfg.DeletionThreshold = 10
+			cfg.ConfirmDeletion = tt.confirmDeletion
+
+			err := executeRemap(cfg)
+			if tt.expectError && err == nil {
+				t.Fatal("expected deletion gate error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(cfg.Directory, "file.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			unchanged := string(content) == "secret secret secret\n"
+			if tt.expectError != unchanged {
+				t.Errorf("file content %q after run (gate error expected: %v)", content, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestPatchOutputApplies(t *testing.T) {
+	patchBin, err := exec.LookPath("patch")
+	if err != nil {
+		t.Skip("patch not available")
+	}
+
+	original := "server old.example.com\nport 80\nbackup old.example.com\n"
+	cfg := newTestConfig(t, original, "old.example.com,new.example.com\n")
+	cfg.PatchFile = filepath.Join(t.TempDir(), "changes.diff")
+
+	if err := executeRemap(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	target := filepath.Join(cfg.Directory, "file.txt")
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != original {
+		t.Fatalf("dry run modified the file: %q", content)
+	}
+
+	patch, err := os.Open(cfg.PatchFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer patch.Close()
+
+	cmd := exec.Command(patchBin, "-p1", "--quiet")
+	cmd.Dir = cfg.Directory
+	cmd.Stdin = patch
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("patch failed: %v\n%s", err, output)
+	}
+
+	patched, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "server new.example.com\nport 80\nbackup new.example.com\n"; string(patched) != expected {
+		t.Errorf("patched content = %q, expected %q", patched, expected)
+	}
+}
+
+func TestStopOnError(t *testing.T) {
+	cfg := newTestConfig(t, "foo\n", "foo,bar\n")
+	cfg.DryRun = false
+	cfg.NoBackup = true
+	cfg.StopOnError = true
+
+	target := filepath.Join(cfg.Directory, "file.txt")
+	if err := os.Chmod(target, 0444); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := executeRemap(cfg); err == nil {
+		t.Error("expected the file error to stop the run")
+	}
+
+	// Without it the run continues, but the failure still sets the exit status
+	cfg.StopOnError = false
+	var exitErr *exitCodeError
+	if err := executeRemap(cfg); !stderrors.As(err, &exitErr) || exitErr.code != config.DefaultErrorExitCode {
+		t.Errorf("expected exit status %d without --stop-on-error, got %v", config.DefaultErrorExitCode, err)
+	}
+
+	cfg.ErrorExitCode = 3
+	if err := executeRemap(cfg); !stderrors.As(err, &exitErr) || exitErr.code != 3 {
+		t.Errorf("expected exit status 3 with --error-exit-code 3, got %v", err)
+	}
+
+	cfg.IgnoreErrors = true
+	if err := executeRemap(cfg); err != nil {
+		t.Errorf("expected errors to be only reported with --ignore-errors, got %v", err)
+	}
+}
+
+func TestStopOnErrorReport(t *testing.T) {
+	cfg := newTestConfig(t, "foo\n", "foo,bar\n")
+	cfg.DryRun = false
+	cfg.NoBackup = true
+	cfg.Quiet = false
+	cfg.StopOnError = true
+	cfg.Workers = 1
+	cfg.LogFile = filepath.Join(t.TempDir(), "remap.json")
+
+	if err := os.Chmod(filepath.Join(cfg.Directory, "file.txt"), 0444); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := executeRemap(cfg); err == nil {
+		t.Fatal("expected the file error to stop the run")
+	}
+
+	// The report is still written and lists the file that failed
+	logContent, err := os.ReadFile(cfg.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(logContent), `"error_count": 1`) {
+		t.Errorf("expected the failure in the report:\n%s", logContent)
+	}
+}
+
+func TestCanonicalReportIsStable(t *testing.T) {
+	cfg := newTestConfig(t, "foo foo\nfoo\n", "foo,bar\n")
+	cfg.Quiet = false
+	cfg.Canonical = true
+	// A real run with backups, whose paths carry the time of the run
+	cfg.DryRun = false
+	cfg.Backup = true
+	cfg.BackupDir = t.TempDir()
+
+	var reports [][]byte
+	for i := 0; i < 2; i++ {
+		for _, name := range []string{"b.txt", "a.txt", "c.txt"} {
+			if err := os.WriteFile(filepath.Join(cfg.Directory, name), []byte("a foo\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(cfg.Directory, "file.txt"), []byte("foo foo\nfoo\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg.LogFile = filepath.Join(t.TempDir(), "plan.json")
+		if err := executeRemap(cfg); err != nil {
+			t.Fatalf("run %d: unexpected error: %v", i+1, err)
+		}
+		report, err := os.ReadFile(cfg.LogFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reports = append(reports, report)
+	}
+
+	if string(reports[0]) != string(reports[1]) {
+		t.Errorf("expected identical reports for unchanged input, got:\n%s\nand:\n%s", reports[0], reports[1])
+	}
+}
+
+func TestOrderFiles(t *testing.T) {
+	discovered := func() []filter.FileInfo {
+		return []filter.FileInfo{
+			{Path: "/src/small.txt", Size: 10},
+			{Path: "/src/large.txt", Size: 3000},
+			{Path: "/src/medium-a.txt", Size: 200},
+			{Path: "/src/medium-b.txt", Size: 200},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		order    string
+		expected []string
+	}{
+		{
+			name:     "discovery order",
+			order:    config.OrderDiscovery,
+			expected: []string{"/src/small.txt", "/src/large.txt", "/src/medium-a.txt", "/src/medium-b.txt"},
+		},
+		{
+			name:     "size descending with ties in discovery order",
+			order:    config.OrderSizeDesc,
+			expected: []string{"/src/large.txt", "/src/medium-a.txt", "/src/medium-b.txt", "/src/small.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := discovered()
+			orderFiles(&config.Config{Order: tt.order}, files)
+
+			for i, file := range files {
+				if file.Path != tt.expected[i] {
+					t.Errorf("position %d: expected %s, got %s", i, tt.expected[i], file.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeMapWhitespace(t *testing.T) {
+	cfg := newTestConfig(t, "call old name here\n", "old  name,new_name\n")
+	cfg.DryRun = false
+	cfg.NoBackup = true
+	cfg.NormalizeMapWhitespace = true
+
+	if err := executeRemap(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(cfg.Directory, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "call new_name here\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestInlineMappings(t *testing.T) {
+	cfg := newTestConfig(t, "foo hello SEP\n", "foo,from-file\n")
+	cfg.DryRun = false
+	cfg.NoBackup = true
+	cfg.InlineMappings = []string{"foo=bar", "hello=hi", "SEP=k=v"}
+
+	if err := executeRemap(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(cfg.Directory, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Inline pairs win over the file mapping for "foo"
+	if expected := "bar hi k=v\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+
+	cfg.MappingFile = ""
+	cfg.InlineMappings = []string{"broken"}
+	if err := executeRemap(cfg); err == nil || !strings.Contains(err.Error(), "expected old=new") {
+		t.Errorf("expected an error for a pair without '=', got %v", err)
+	}
+}
+
+func TestXMLLogRoundTrip(t *testing.T) {
+	testLogRoundTrip(t, config.LogFormatXML)
+}
+
+func TestNDJSONLogRoundTrip(t *testing.T) {
+	testLogRoundTrip(t, config.LogFormatNDJSON)
+}
+
+// testLogRoundTrip rewrites a file without backups, logging in format, and
+// checks that reverting from the log alone restores the original content.
+func testLogRoundTrip(t *testing.T, format config.LogFormat) {
+	t.Helper()
+	original := "Foo and foo <tag>\nkeep & \"quote\"\n"
+	cfg := newTestConfig(t, original, "foo,bar\n")
+	cfg.DryRun = false
+	cfg.NoBackup = true
+	cfg.Quiet = false
+	cfg.Verbose = true
+	cfg.LogFormat = format
+	cfg.LogFile = filepath.Join(t.TempDir(), "remap."+string(format))
+
+	if err := executeRemap(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file := filepath.Join(cfg.Directory, "file.txt")
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "bar and bar <tag>\nkeep & \"quote\"\n" {
+		t.Fatalf("unexpected content after run: %q", content)
+	}
+
+	revertCfg := &config.Config{Revert: true, LogFile: cfg.LogFile, LogFormat: format, Quiet: true}
+	if err := executeRemap(revertCfg); err != nil {
+		t.Fatalf("unexpected revert error: %v", err)
+	}
+	content, err = os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != original {
+		t.Errorf("expected revert to restore %q, got %q", original, content)
+	}
+}
+
+func TestBackupDirRevert(t *testing.T) {
+	original := "foo\n"
+	cfg := newTestConfig(t, original, "foo,bar\n")
+	cfg.DryRun = false
+	cfg.Quiet = false
+	cfg.BackupDir = filepath.Join(t.TempDir(), "backups")
+	cfg.LogFile = filepath.Join(t.TempDir(), "remap.json")
+
+	if err := executeRemap(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := os.ReadDir(cfg.Directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected no backup next to file.txt, found %d files", len(entries))
+	}
+	logContent, err := os.ReadFile(cfg.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(logContent), cfg.BackupDir) {
+		t.Errorf("expected the log to record a backup under %s:\n%s", cfg.BackupDir, logContent)
+	}
+
+	revertCfg := &config.Config{Revert: true, LogFile: cfg.LogFile, LogFormat: config.LogFormatJSON, Quiet: true}
+	if err := executeRemap(revertCfg); err != nil {
+		t.Fatalf("unexpected revert error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(cfg.Directory, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != original {
+		t.Errorf("expected revert to restore %q, got %q", original, content)
+	}
+}
+
+func TestBackupArchiveRevert(t *testing.T) {
+	original := "foo\n"
+	cfg := newTestConfig(t, original, "foo,bar\n")
+	cfg.DryRun = false
+	cfg.Quiet = false
+	cfg.BackupArchive = filepath.Join(t.TempDir(), "backups.tar.gz")
+	cfg.LogFile = filepath.Join(t.TempDir(), "remap.json")
+
+	if err := executeRemap(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries, _ := os.ReadDir(cfg.Directory); len(entries) != 1 {
+		t.Errorf("expected no .bak file next to file.txt, found %d files", len(entries))
+	}
+	if _, err := os.Stat(cfg.BackupArchive); err != nil {
+		t.Fatalf("expected the backup archive to be written: %v", err)
+	}
+
+	revertCfg := &config.Config{Revert: true, LogFile: cfg.LogFile, LogFormat: config.LogFormatJSON, Quiet: true}
+	if err := executeRemap(revertCfg); err != nil {
+		t.Fatalf("unexpected revert error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(cfg.Directory, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != original {
+		t.Errorf("expected revert to restore %q, got %q", original, content)
+	}
+}
+
+func TestMaxReplacementsPerFile(t *testing.T) {
+	original := "a a a\n"
+	cfg := newTestConfig(t, original, "a,b\n")
+	cfg.DryRun = false
+	cfg.NoBackup = true
+	cfg.Quiet = false
+	cfg.MaxReplacementsPerFile = 2
+	cfg.LogFile = filepath.Join(t.TempDir(), "remap.json")
+
+	var exitErr *exitCodeError
+	if err := executeRemap(cfg); !stderrors.As(err, &exitErr) {
+		t.Fatalf("expected the file over the limit to fail the run, got %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(cfg.Directory, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != original {
+		t.Errorf("expected the file over the limit to be left alone, got %q", content)
+	}
+	logContent, err := os.ReadFile(cfg.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(logContent), "exceed the limit of 2 per file") {
+		t.Errorf("expected the limit error in the log:\n%s", logContent)
+	}
+}
+
+func TestDetectOnly(t *testing.T) {
+	// file.txt fails detection for having more replacements than allowed
+	cfg := newTestConfig(t, "a a a\n", "a,b\n")
+	cfg.DryRun = false
+	cfg.NoBackup = true
+	cfg.DetectOnly = true
+	cfg.MaxReplacementsPerFile = 2
+	cfg.StopOnError = true
+	other := filepath.Join(cfg.Directory, "other.txt")
+	if err := os.WriteFile(other, []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := executeRemap(cfg); err == nil {
+		t.Fatal("expected the file error to stop the run")
+	}
+	if content, err := os.ReadFile(other); err != nil || string(content) != "a\n" {
+		t.Errorf("expected no file written after a failed detection, got %q (%v)", content, err)
+	}
+
+	// Without --stop-on-error the other files are written once detected
+	cfg.StopOnError = false
+	var exitErr *exitCodeError
+	if err := executeRemap(cfg); !stderrors.As(err, &exitErr) {
+		t.Fatalf("expected the file error in the exit status, got %v", err)
+	}
+	if content, err := os.ReadFile(other); err != nil || string(content) != "b\n" {
+		t.Errorf("expected the other file written, got %q (%v)", content, err)
+	}
+}
+
+func TestLoadMappingsDuplicates(t *testing.T) {
+	cfg := newTestConfig(t, "foo\n", "foo,bar\nFOO,baz\n")
+
+	if _, err := loadMappings(cfg); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected a duplicate error naming line 2, got %v", err)
+	}
+
+	cfg.AllowDuplicates = true
+	if _, err := loadMappings(cfg); err != nil {
+		t.Errorf("unexpected error with --allow-duplicates: %v", err)
+	}
+}
+
+func TestCaseInsensitiveRunRevert(t *testing.T) {
+	// The pre-existing "bar" and "Bar" read like destinations and must survive the revert
+	original := "Foo FOO foo bar\nBar food\n"
+
+	tests := []struct {
+		name         string
+		logFormat    config.LogFormat
+		preserveCase bool
+		content      string // defaults to original
+	}{
+		{name: "json log", logFormat: config.LogFormatJSON},
+		// The Kelvin sign and Ⱥ lowercase to runes of another byte length
+		{name: "json log after length-changing runes", logFormat: config.LogFormatJSON, content: "\u212a foo\nȺȺ Foo\n"},
+		{name: "json log with preserve case", logFormat: config.LogFormatJSON, preserveCase: true},
+		{name: "csv log", logFormat: config.LogFormatCSV},
+		{name: "csv log with preserve case", logFormat: config.LogFormatCSV, preserveCase: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := original
+			if tt.content != "" {
+				original = tt.content
+			}
+			cfg := newTestConfig(t, original, "foo,bar\n")
+			cfg.DryRun = false
+			cfg.Quiet = false
+			cfg.NoBackup = true
+			cfg.PreserveCase = tt.preserveCase
+			cfg.LogFormat = tt.logFormat
+			cfg.LogFile = filepath.Join(t.TempDir(), "run.log")
+
+			if err := executeRemap(cfg); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			filePath := filepath.Join(cfg.Directory, "file.txt")
+			if content, _ := os.ReadFile(filePath); string(content) == original {
+				t.Fatal("expected the run to modify the file")
+			}
+
+			revertCfg := &config.Config{Revert: true, LogFile: cfg.LogFile, LogFormat: tt.logFormat, Quiet: true}
+			if err := executeRemap(revertCfg); err != nil {
+				t.Fatalf("unexpected revert error: %v", err)
+			}
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != original {
+				t.Errorf("expected revert to restore %q exactly, got %q", original, content)
+			}
+		})
+	}
+}
+
+func TestDryRunLogApply(t *testing.T) {
+	original := "Foo FOO foo\nfood\n"
+
+	tests := []struct {
+		name         string
+		preserveCase bool
+		expected     string
+	}{
+		{name: "case-insensitive", expected: "bar bar bar\nbard\n"},
+		{name: "preserve case", preserveCase: true, expected: "Bar BAR bar\nbard\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t, original, "foo,bar\n")
+			cfg.Quiet = false
+			cfg.NoBackup = true
+			cfg.PreserveCase = tt.preserveCase
+			cfg.LogFile = filepath.Join(t.TempDir(), "plan.json")
+
+			if err := executeRemap(cfg); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			filePath := filepath.Join(cfg.Directory, "file.txt")
+			if content, _ := os.ReadFile(filePath); string(content) != original {
+				t.Fatalf("expected the dry run to leave the file alone, got %q", content)
+			}
+
+			applyCfg := &config.Config{Apply: true, LogFile: cfg.LogFile, LogFormat: config.LogFormatJSON, Quiet: true}
+			if err := executeRemap(applyCfg); err != nil {
+				t.Fatalf("unexpected apply error: %v", err)
+			}
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("expected apply to reproduce the previewed run %q, got %q", tt.expected, content)
+			}
+
+			// The plan no longer matches the file, so applying it again fails
+			if err := executeRemap(applyCfg); err == nil {
+				t.Error("expected applying a stale plan to fail")
+			}
+			if again, _ := os.ReadFile(filePath); string(again) != tt.expected {
+				t.Errorf("expected a failed apply to leave the file alone, got %q", again)
+			}
+		})
+	}
+}
+
+func TestChainedMappingsApplyMatchesRun(t *testing.T) {
+	// The second mapping rewrites the "beta" the first one wrote
+	original := "alpha beta\nno match\n"
+	mappings := "alpha,beta\nbeta,gamma\n"
+
+	run := newTestConfig(t, original, mappings)
+	run.DryRun = false
+	run.NoBackup = true
+	if err := executeRemap(run); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected, err := os.ReadFile(filepath.Join(run.Directory, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(expected) != "gamma gamma\nno match\n" {
+		t.Fatalf("expected the mappings to chain, got %q", expected)
+	}
+
+	plan := newTestConfig(t, original, mappings)
+	plan.Quiet = false
+	plan.NoBackup = true
+	plan.LogFile = filepath.Join(t.TempDir(), "plan.json")
+	if err := executeRemap(plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The dry run reports the size the run gave the file, not an estimate
+	var report struct {
+		Entries []struct {
+			NewSize int64 `json:"new_size"`
+		} `json:"entries"`
+	}
+	planContent, err := os.ReadFile(plan.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(planContent, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Entries) != 1 || report.Entries[0].NewSize != int64(len(expected)) {
+		t.Errorf("expected the plan to record new size %d, got:\n%s", len(expected), planContent)
+	}
+
+	applyCfg := &config.Config{Apply: true, LogFile: plan.LogFile, LogFormat: config.LogFormatJSON, Quiet: true}
+	if err := executeRemap(applyCfg); err != nil {
+		t.Fatalf("unexpected apply error: %v", err)
+	}
+	applied, err := os.ReadFile(filepath.Join(plan.Directory, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(applied) != string(expected) {
+		t.Errorf("expected apply to reproduce the run %q, got %q", expected, applied)
+	}
+}
+
+func TestChainedMappingsRevert(t *testing.T) {
+	original := "alpha beta\nno match\n"
+
+	for _, logFormat := range []config.LogFormat{config.LogFormatJSON, config.LogFormatCSV} {
+		t.Run(string(logFormat), func(t *testing.T) {
+			cfg := newTestConfig(t, original, "alpha,beta\nbeta,gamma\n")
+			cfg.DryRun = false
+			cfg.Quiet = false
+			cfg.NoBackup = true
+			cfg.LogFormat = logFormat
+			cfg.LogFile = filepath.Join(t.TempDir(), "run.log")
+			if err := executeRemap(cfg); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			filePath := filepath.Join(cfg.Directory, "file.txt")
+			revertCfg := &config.Config{Revert: true, LogFile: cfg.LogFile, LogFormat: logFormat, Quiet: true}
+			if err := executeRemap(revertCfg); err != nil {
+				t.Fatalf("unexpected revert error: %v", err)
+			}
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != original {
+				t.Errorf("expected revert to restore %q, got %q", original, content)
+			}
+		})
+	}
+}
+
+func TestLineCaseWithReplacementRevertAndApply(t *testing.T) {
+	original := "a heading about foo here\nfoo\n"
+	mappings := "old,new,line_case\nheading,,upper\nfoo,bar,\n"
+
+	run := newTestConfig(t, original, mappings)
+	run.DryRun = false
+	run.Quiet = false
+	run.NoBackup = true
+	run.LogFile = filepath.Join(t.TempDir(), "run.json")
+	if err := executeRemap(run); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	filePath := filepath.Join(run.Directory, "file.txt")
+	written, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != "A HEADING ABOUT bar HERE\nbar\n" {
+		t.Fatalf("unexpected run result %q", written)
+	}
+	log, err := os.ReadFile(run.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(log), `"line_case": "upper"`) {
+		t.Errorf("expected the line-case rule to be labelled in mapping_stats, got %s", log)
+	}
+
+	revertCfg := &config.Config{Revert: true, LogFile: run.LogFile, LogFormat: config.LogFormatJSON, Quiet: true}
+	if err := executeRemap(revertCfg); err != nil {
+		t.Fatalf("unexpected revert error: %v", err)
+	}
+	if content, _ := os.ReadFile(filePath); string(content) != original {
+		t.Errorf("expected revert to restore %q, got %q", original, content)
+	}
+
+	plan := newTestConfig(t, original, mappings)
+	plan.Quiet = false
+	plan.NoBackup = true
+	plan.LogFile = filepath.Join(t.TempDir(), "plan.json")
+	if err := executeRemap(plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	applyCfg := &config.Config{Apply: true, LogFile: plan.LogFile, LogFormat: config.LogFormatJSON, Quiet: true}
+	if err := executeRemap(applyCfg); err != nil {
+		t.Fatalf("unexpected apply error: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(plan.Directory, "file.txt")); string(content) != string(written) {
+		t.Errorf("expected apply to reproduce the run %q, got %q", written, content)
+	}
+}
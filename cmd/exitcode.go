@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"remap/internal/config"
+	"remap/internal/errors"
+)
+
+// Exit codes used by the CLI. Fatal errors always exit with exitCodeFatal;
+// other codes report a run outcome that is not itself a failure. Changes get
+// a status of their own, distinct from fatal errors and from the default
+// --error-exit-code, so a script can tell all three apart.
+const (
+	exitCodeFatal   = 1
+	exitCodeChanges = 3
+)
+
+// exitCodeError carries a specific process exit status out of a command run.
+// It lets a completed run signal its outcome (e.g. "files were changed") to
+// scripts without being reported as an error on stderr.
+type exitCodeError struct {
+	code    int
+	message string
+}
+
+func (e *exitCodeError) Error() string {
+	return e.message
+}
+
+// checkErrorExitCode rejects an --error-exit-code below 1 that was set
+// explicitly (changed reports that, whether it came from the command line,
+// the environment or a config file): a run in which files failed must not
+// exit like a clean one. Only configs built in code leave it at 0, which
+// Validate takes as the default.
+func checkErrorExitCode(cfg *config.Config, changed func(name string) bool) error {
+	if changed("error-exit-code") && cfg.ErrorExitCode < 1 {
+		return errors.NewConfigError("--error-exit-code must be between 1 and 255", nil)
+	}
+	return nil
+}
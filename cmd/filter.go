@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"remap/internal/concurrent"
+	"remap/internal/config"
+	"remap/internal/errors"
+	"remap/internal/parser"
+	"remap/internal/replacement"
+)
+
+// filterInput, filterOutput and filterSummaryOutput carry the streams of
+// --filter mode; tests replace them.
+var (
+	filterInput         io.Reader = os.Stdin
+	filterOutput        io.Writer = os.Stdout
+	filterSummaryOutput io.Writer = os.Stderr
+)
+
+// executeFilter reads content from stdin and writes it to stdout with the
+// mappings applied, like sed. Nothing is discovered, backed up or logged:
+// the content has no path, so mappings scoped with applies_to never match.
+// The summary goes to stderr to keep it out of the piped output.
+func executeFilter(cfg *config.Config, mappings *parser.MappingTable) error {
+	content, err := io.ReadAll(filterInput)
+	if err != nil {
+		return errors.NewFileError("stdin", "failed to read content", err)
+	}
+
+	rendered := concurrent.NewProcessor(cfg, mappings).Render("", content)
+	if _, err := io.WriteString(filterOutput, rendered); err != nil {
+		return errors.NewFileError("stdout", "failed to write transformed content", err)
+	}
+
+	if !cfg.Quiet {
+		// Detection only, to count what Render replaced
+		countCfg := *cfg
+		countCfg.DryRun = true
+		countCfg.Diff = false
+		result := replacement.NewEngine(&countCfg).ProcessFile("", content, mappings)
+		fmt.Fprintf(filterSummaryOutput, "stdin: %d replacement(s)\n", len(result.Replacements))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	originalInput, originalOutput, originalSummary := filterInput, filterOutput, filterSummaryOutput
+	defer func() {
+		filterInput, filterOutput, filterSummaryOutput = originalInput, originalOutput, originalSummary
+	}()
+
+	tests := []struct {
+		name          string
+		input         string
+		preserveCase  bool
+		wordBoundary  bool
+		quiet         bool
+		expected      string
+		expectSummary string
+	}{
+		{
+			name:          "replaces every match",
+			input:         "foo and Foo\nfood\n",
+			expected:      "bar and bar\nbard\n",
+			expectSummary: "stdin: 3 replacement(s)\n",
+		},
+		{
+			name:          "replacement flags apply",
+			input:         "foo and Foo\nfood\n",
+			preserveCase:  true,
+			wordBoundary:  true,
+			expected:      "bar and Bar\nfood\n",
+			expectSummary: "stdin: 2 replacement(s)\n",
+		},
+		{
+			name:     "quiet leaves stderr empty",
+			input:    "foo\n",
+			quiet:    true,
+			expected: "bar\n",
+		},
+		{
+			name:          "no match",
+			input:         "nothing\n",
+			expected:      "nothing\n",
+			expectSummary: "stdin: 0 replacement(s)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, summary bytes.Buffer
+			filterInput = strings.NewReader(tt.input)
+			filterOutput = &out
+			filterSummaryOutput = &summary
+
+			cfg := newTestConfig(t, "foo\n", "foo,bar\n")
+			cfg.Directory = ""
+			cfg.Filter = true
+			cfg.DryRun = false
+			cfg.Quiet = tt.quiet
+			cfg.PreserveCase = tt.preserveCase
+			cfg.WordBoundary = tt.wordBoundary
+
+			if err := executeRemap(cfg); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("stdout = %q, want %q", out.String(), tt.expected)
+			}
+			if summary.String() != tt.expectSummary {
+				t.Errorf("stderr = %q, want %q", summary.String(), tt.expectSummary)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"remap/internal/concurrent"
+)
+
+// interactiveInput and interactiveOutput carry the --interactive prompts;
+// tests replace them.
+var (
+	interactiveInput  io.Reader = os.Stdin
+	interactiveOutput io.Writer = os.Stderr
+)
+
+// quitError is returned when the user quits an --interactive run.
+var quitError = &exitCodeError{code: exitCodeFatal, message: "Quit: the remaining files were not modified"}
+
+// interactivePrompt asks, file by file, whether to write a pending change.
+type interactivePrompt struct {
+	in   *bufio.Reader
+	out  io.Writer
+	all  bool
+	quit bool
+}
+
+func newInteractivePrompt(in io.Reader, out io.Writer) *interactivePrompt {
+	return &interactivePrompt{in: bufio.NewReader(in), out: out}
+}
+
+// confirm shows the diff of a pending result and returns it committed or
+// skipped according to the answer. Once the user has answered "all" every
+// later file is committed without asking. Quitting, or closing stdin, skips
+// the file and sets quit, after which the caller stops the run.
+func (ip *interactivePrompt) confirm(processor *concurrent.Processor, result concurrent.ProcessResult) concurrent.ProcessResult {
+	if !result.Pending {
+		return result
+	}
+	if ip.all {
+		return processor.Commit(result)
+	}
+
+	fmt.Fprint(ip.out, processor.Preview(result))
+	for {
+		fmt.Fprintf(ip.out, "Apply %d replacement(s) to %s? [y,n,a,q] ", len(result.Result.Replacements), result.Job.FilePath)
+		answer, err := ip.in.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return processor.Commit(result)
+		case "n", "no":
+			return processor.Skip(result)
+		case "a", "all":
+			ip.all = true
+			return processor.Commit(result)
+		case "q", "quit":
+			ip.quit = true
+			return processor.Skip(result)
+		}
+		if err != nil {
+			// Without an answer nothing more is written
+			fmt.Fprintln(ip.out)
+			ip.quit = true
+			return processor.Skip(result)
+		}
+		fmt.Fprintln(ip.out, "y: write this file, n: skip it, a: write it and all remaining files, q: quit")
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecuteRemapInteractive(t *testing.T) {
+	tests := []struct {
+		name       string
+		answers    string
+		expected   []string
+		expectQuit bool
+	}{
+		{name: "yes and no", answers: "y\nn\ny\n", expected: []string{"bar\n", "foo\n", "bar\n"}},
+		{name: "all", answers: "n\na\n", expected: []string{"foo\n", "bar\n", "bar\n"}},
+		{name: "quit", answers: "y\nq\n", expected: []string{"bar\n", "foo\n", "foo\n"}, expectQuit: true},
+		{name: "invalid answer asked again", answers: "maybe\ny\ny\ny\n", expected: []string{"bar\n", "bar\n", "bar\n"}},
+		{name: "closed input", answers: "y\n", expected: []string{"bar\n", "foo\n", "foo\n"}, expectQuit: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t, "foo\n", "foo,bar\n")
+			cfg.DryRun = false
+			cfg.NoBackup = true
+			cfg.Interactive = true
+			names := []string{"file.txt", "g.txt", "h.txt"}
+			for _, name := range names[1:] {
+				if err := os.WriteFile(filepath.Join(cfg.Directory, name), []byte("foo\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var out bytes.Buffer
+			interactiveInput, interactiveOutput = strings.NewReader(tt.answers), &out
+			err := executeRemap(cfg)
+			interactiveInput, interactiveOutput = os.Stdin, os.Stderr
+
+			if tt.expectQuit != (err == quitError) {
+				t.Errorf("err = %v, expected quit %v", err, tt.expectQuit)
+			} else if !tt.expectQuit && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			for i, name := range names {
+				content, err := os.ReadFile(filepath.Join(cfg.Directory, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(content) != tt.expected[i] {
+					t.Errorf("%s: expected %q, got %q", name, tt.expected[i], content)
+				}
+			}
+			if !strings.Contains(out.String(), "-foo\n+bar\n") {
+				t.Errorf("expected the diff in the prompt, got %q", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// exitCodeInterrupted is the conventional status of a run stopped by SIGINT.
+const exitCodeInterrupted = 130
+
+// afterFileProcessed, when set, runs in the worker after each file, before it
+// takes the next one, with the run's context; tests use it to interrupt a run
+// at a known point.
+var afterFileProcessed func(ctx context.Context)
+
+// handleInterrupt returns a context cancelled on SIGINT, so Ctrl-C stops the
+// workers from starting new files while the files in flight finish their
+// atomic temp-file-and-rename write. Once the first interrupt arrives the
+// handler is removed, so a second Ctrl-C kills the process right away.
+// stop must be called to release the handler.
+func handleInterrupt(parent context.Context) (ctx context.Context, stop context.CancelFunc) {
+	ctx, stop = signal.NotifyContext(parent, os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// interruptedError reports a run stopped by SIGINT after the partial report
+// was written.
+func interruptedError(processed, total int) error {
+	return &exitCodeError{
+		code:    exitCodeInterrupted,
+		message: fmt.Sprintf("Interrupted: %d of %d file(s) processed; the report covers only those files", processed, total),
+	}
+}
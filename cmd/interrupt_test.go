@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"remap/internal/log"
+)
+
+// interruptAfterFirstFile sends SIGINT to the test process once the worker
+// has processed its first file, and holds the worker until the signal has
+// cancelled the run, so the run stops at a known point instead of racing the
+// signal against the remaining files.
+type interruptAfterFirstFile struct {
+	sent bool
+	err  error
+}
+
+func (i *interruptAfterFirstFile) hook(ctx context.Context) {
+	if i.sent {
+		return
+	}
+	i.sent = true
+
+	process, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = process.Signal(os.Interrupt)
+	}
+	if err != nil {
+		i.err = err
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		i.err = fmt.Errorf("SIGINT did not cancel the run")
+	}
+}
+
+func TestInterruptFinishesInFlightFiles(t *testing.T) {
+	interrupt := &interruptAfterFirstFile{}
+	afterFileProcessed = interrupt.hook
+	defer func() { afterFileProcessed = nil }()
+
+	const fileCount = 10
+	cfg := newTestConfig(t, "foo\n", "foo,bar\n")
+	for i := 1; i < fileCount; i++ {
+		path := filepath.Join(cfg.Directory, fmt.Sprintf("file%03d.txt", i))
+		if err := os.WriteFile(path, []byte("foo\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg.DryRun = false
+	cfg.NoBackup = true
+	cfg.Workers = 1
+	cfg.Quiet = false
+	cfg.LogFile = filepath.Join(t.TempDir(), "report.json")
+
+	err := executeRemap(cfg)
+	if interrupt.err != nil {
+		t.Skipf("cannot interrupt the run with SIGINT on this platform: %v", interrupt.err)
+	}
+	var exitErr *exitCodeError
+	if !stderrors.As(err, &exitErr) || exitErr.code != exitCodeInterrupted {
+		t.Fatalf("expected an interrupted exit, got %v", err)
+	}
+
+	// Every file is either untouched or completely rewritten, and no
+	// temporary file is left behind
+	changed := make(map[string]bool)
+	entries, err := os.ReadDir(cfg.Directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("temporary file left behind: %s", entry.Name())
+			continue
+		}
+		path := filepath.Join(cfg.Directory, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch string(content) {
+		case "bar\n":
+			changed[path] = true
+		case "foo\n":
+		default:
+			t.Errorf("%s is partially written: %q", entry.Name(), content)
+		}
+	}
+	if len(changed) == 0 || len(changed) == fileCount {
+		t.Fatalf("expected the run to stop part way, %d of %d files changed", len(changed), fileCount)
+	}
+
+	// The partial report lists exactly the files that were rewritten
+	data, err := os.ReadFile(cfg.LogFile)
+	if err != nil {
+		t.Fatalf("expected a report, got %v", err)
+	}
+	var report struct {
+		Entries []log.Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	reported := make(map[string]bool)
+	for _, entry := range report.Entries {
+		if entry.Modified {
+			reported[entry.FilePath] = true
+		}
+	}
+	for path := range changed {
+		if !reported[path] {
+			t.Errorf("%s was rewritten but is missing from the report", path)
+		}
+	}
+	if len(reported) != len(changed) {
+		t.Errorf("report lists %d modified files, %d were rewritten", len(reported), len(changed))
+	}
+}
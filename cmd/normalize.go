@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"remap/internal/errors"
+	"remap/internal/parser"
+
+	"github.com/spf13/cobra"
+)
+
+var normalizeOpts struct {
+	csvFile  string
+	jsonFile string
+	out      string
+}
+
+var normalizeMapCmd = &cobra.Command{
+	Use:   "normalize-map (--csv <file> | --json <file>) --out <file>",
+	Short: "Rewrite a mapping file in canonical form",
+	Long: `Normalize-map loads a mapping file, removes duplicate rules, sorts the
+remaining ones longest source first and writes them back in canonical form.
+Sources mapped to different destinations are reported as conflicts; the first
+destination in the file is kept.`,
+	Args: cobra.NoArgs,
+	RunE: runNormalizeMap,
+}
+
+func init() {
+	normalizeMapCmd.Flags().StringVar(&normalizeOpts.csvFile, "csv", "", "CSV mapping file to normalize")
+	normalizeMapCmd.Flags().StringVar(&normalizeOpts.jsonFile, "json", "", "JSON mapping file to normalize")
+	normalizeMapCmd.Flags().StringVar(&normalizeOpts.out, "out", "", "Output file (format follows its .csv/.json extension, default: input format)")
+
+	normalizeMapCmd.MarkFlagsMutuallyExclusive("csv", "json")
+	normalizeMapCmd.MarkFlagsOneRequired("csv", "json")
+	_ = normalizeMapCmd.MarkFlagRequired("out")
+
+	rootCmd.AddCommand(normalizeMapCmd)
+}
+
+func runNormalizeMap(cmd *cobra.Command, _ []string) error {
+	input, format := normalizeOpts.csvFile, "csv"
+	if normalizeOpts.jsonFile != "" {
+		input, format = normalizeOpts.jsonFile, "json"
+	}
+
+	return normalizeMappingFile(input, format, normalizeOpts.out, cmd.ErrOrStderr())
+}
+
+// normalizeMappingFile rewrites input into output in canonical form and
+// reports conflicting rules to report.
+func normalizeMappingFile(input, format, output string, report io.Writer) error {
+	table, err := parser.LoadMappingTable(input, format)
+	if err != nil {
+		return err
+	}
+
+	mappings, conflicts := table.Normalize()
+	for _, conflict := range conflicts {
+		scope := ""
+		if conflict.AppliesTo != "" {
+			scope = fmt.Sprintf(" (applies_to %s)", conflict.AppliesTo)
+		}
+		fmt.Fprintf(report, "conflict: %q%s maps to %q and %q, keeping %q\n",
+			conflict.From, scope, conflict.Kept, conflict.Dropped, conflict.Kept)
+	}
+
+	outputFormat := format
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".csv":
+		outputFormat = "csv"
+	case ".json":
+		outputFormat = "json"
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return errors.WrapFileError(output, err)
+	}
+
+	if err := parser.WriteMappings(file, mappings, outputFormat); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return errors.WrapFileError(output, err)
+	}
+
+	fmt.Fprintf(report, "Wrote %d mappings to %s (%d removed, %d conflicts)\n",
+		len(mappings), output, table.Size()-len(mappings), len(conflicts))
+	return nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMappingFile(t *testing.T) {
+	tempDir := t.TempDir()
+	input := filepath.Join(tempDir, "messy.csv")
+	messy := "# legacy names\nsource,destination\nfoo, bar\nlonger_name,short\n\nfoo,bar\nfoo,baz\na,b\n"
+	if err := os.WriteFile(input, []byte(messy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(tempDir, "clean.csv")
+	var report bytes.Buffer
+	if err := normalizeMappingFile(input, "csv", output, &report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "old,new\nlonger_name,short\nfoo,bar\na,b\n"; string(content) != expected {
+		t.Errorf("normalized output = %q, expected %q", content, expected)
+	}
+
+	if !strings.Contains(report.String(), `conflict: "foo" maps to "bar" and "baz"`) {
+		t.Errorf("expected conflict in report, got %q", report.String())
+	}
+
+	jsonOutput := filepath.Join(tempDir, "clean.json")
+	if err := normalizeMappingFile(input, "csv", jsonOutput, &bytes.Buffer{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	jsonContent, err := os.ReadFile(jsonOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(jsonContent), "[\n  {\n    \"old\": \"longer_name\"") {
+		t.Errorf("expected JSON output, got %q", jsonContent)
+	}
+}
+
+func TestNormalizeMappingFileComments(t *testing.T) {
+	tempDir := t.TempDir()
+	input := filepath.Join(tempDir, "commented.csv")
+	commented := "old,new,comment\nfoo,bar,renamed in v2\nlonger_name,short,\n"
+	if err := os.WriteFile(input, []byte(commented), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(tempDir, "clean.csv")
+	if err := normalizeMappingFile(input, "csv", output, &bytes.Buffer{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "old,new,comment\nlonger_name,short,\nfoo,bar,renamed in v2\n"; string(content) != expected {
+		t.Errorf("normalized output = %q, expected %q", content, expected)
+	}
+}
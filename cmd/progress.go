@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"remap/internal/config"
+	"remap/internal/filter"
+)
+
+// progressOutput receives the progress line and progressIsTerminal reports
+// whether it is a terminal; both are variables so tests can replace them.
+var (
+	progressOutput     io.Writer = os.Stderr
+	progressIsTerminal           = func() bool { return isTerminal(os.Stderr) }
+)
+
+// progressInterval is the minimum delay between two redraws of the line.
+const progressInterval = 100 * time.Millisecond
+
+// progress draws a single, self-overwriting "processed/total" line while
+// results drain. The completed fraction and the ETA are weighted by file
+// size, since one large file takes longer than many small ones. A nil
+// *progress is valid and draws nothing, so callers do not have to check
+// whether the indicator is enabled.
+type progress struct {
+	out            io.Writer
+	total          int
+	processed      int
+	totalBytes     int64
+	processedBytes int64
+	start          time.Time
+	lastDraw       time.Time
+	now            func() time.Time
+}
+
+// newProgress returns an indicator for files, or nil when --progress is off,
+// output is quiet, or stderr is not a terminal (e.g. redirected to a file,
+// where carriage returns would only add noise).
+func newProgress(cfg *config.Config, files []filter.FileInfo) *progress {
+	if !cfg.Progress || cfg.Quiet || !progressIsTerminal() {
+		return nil
+	}
+
+	p := &progress{out: progressOutput, total: len(files), start: time.Now(), now: time.Now}
+	for _, file := range files {
+		p.totalBytes += file.Size
+	}
+	return p
+}
+
+// Increment records one more processed file of the given size and redraws
+// the line at most once per progressInterval, plus once for the last file.
+func (p *progress) Increment(size int64) {
+	if p == nil {
+		return
+	}
+	p.processed++
+	p.processedBytes += size
+
+	now := p.now()
+	if p.processed < p.total && now.Sub(p.lastDraw) < progressInterval {
+		return
+	}
+	p.lastDraw = now
+	p.draw(now)
+}
+
+// Finish ends the progress line so the report starts on a fresh line.
+func (p *progress) Finish() {
+	if p == nil {
+		return
+	}
+	p.draw(p.now())
+	fmt.Fprintln(p.out)
+}
+
+// fraction returns the completed share of the work by bytes. Runs made only
+// of empty files fall back to the file count.
+func (p *progress) fraction() float64 {
+	if p.totalBytes > 0 {
+		return float64(p.processedBytes) / float64(p.totalBytes)
+	}
+	if p.total > 0 {
+		return float64(p.processed) / float64(p.total)
+	}
+	return 1
+}
+
+// eta extrapolates the remaining time from the byte throughput so far.
+// It reports false until some bytes have been processed.
+func (p *progress) eta(now time.Time) (time.Duration, bool) {
+	fraction := p.fraction()
+	if fraction <= 0 {
+		return 0, false
+	}
+	elapsed := now.Sub(p.start)
+	return time.Duration(float64(elapsed) * (1 - fraction) / fraction), true
+}
+
+func (p *progress) draw(now time.Time) {
+	rate := 0.0
+	if elapsed := now.Sub(p.start).Seconds(); elapsed > 0 {
+		rate = float64(p.processed) / elapsed
+	}
+
+	eta := "--"
+	if remaining, ok := p.eta(now); ok {
+		eta = remaining.Round(time.Second).String()
+	}
+
+	// \r returns to the start of the line and \033[K clears what remains of
+	// a longer previous line
+	fmt.Fprintf(p.out, "\r%d/%d files (%.1f files/s), %.0f%% of bytes, ETA %s\033[K",
+		p.processed, p.total, rate, p.fraction()*100, eta)
+}
+
+// isTerminal reports whether f is attached to a character device.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
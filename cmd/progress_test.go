@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"remap/internal/config"
+	"remap/internal/filter"
+)
+
+func TestProgress(t *testing.T) {
+	var out bytes.Buffer
+	start := time.Unix(0, 0)
+	clock := start
+	p := &progress{out: &out, total: 3, totalBytes: 400, start: start, now: func() time.Time { return clock }}
+
+	clock = start.Add(time.Second)
+	p.Increment(100)
+	// Within the redraw interval and not the last file: no redraw
+	clock = clock.Add(10 * time.Millisecond)
+	p.Increment(100)
+	clock = clock.Add(10 * time.Millisecond)
+	p.Increment(200)
+	p.Finish()
+
+	output := out.String()
+	for _, expected := range []string{"\r1/3 files (1.0 files/s), 25% of bytes, ETA 3s", "\r3/3 files", "100% of bytes, ETA 0s"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in %q", expected, output)
+		}
+	}
+	if strings.Contains(output, "2/3") {
+		t.Errorf("expected redraws to be throttled, got %q", output)
+	}
+	if !strings.HasSuffix(output, "\n") {
+		t.Errorf("expected the line to be terminated, got %q", output)
+	}
+}
+
+func TestNewProgress(t *testing.T) {
+	originalTerminal := progressIsTerminal
+	defer func() { progressIsTerminal = originalTerminal }()
+
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		terminal bool
+		enabled  bool
+	}{
+		{"enabled on a terminal", config.Config{Progress: true}, true, true},
+		{"flag not set", config.Config{}, true, false},
+		{"quiet", config.Config{Progress: true, Quiet: true}, true, false},
+		{"not a terminal", config.Config{Progress: true}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progressIsTerminal = func() bool { return tt.terminal }
+			if enabled := newProgress(&tt.cfg, nil) != nil; enabled != tt.enabled {
+				t.Errorf("expected enabled=%v, got %v", tt.enabled, enabled)
+			}
+		})
+	}
+
+	// A disabled indicator is safe to use
+	var disabled *progress
+	disabled.Increment(10)
+	disabled.Finish()
+}
+
+func TestProgressFraction(t *testing.T) {
+	originalTerminal := progressIsTerminal
+	defer func() { progressIsTerminal = originalTerminal }()
+	progressIsTerminal = func() bool { return true }
+
+	files := []filter.FileInfo{{Size: 900}, {Size: 50}, {Size: 50}}
+	p := newProgress(&config.Config{Progress: true}, files)
+	p.out = &bytes.Buffer{}
+
+	// Fractions follow bytes, not files: the small files are 10% of the work
+	steps := []struct {
+		size     int64
+		expected float64
+	}{
+		{50, 0.05},
+		{50, 0.10},
+		{900, 1},
+	}
+	for _, step := range steps {
+		p.Increment(step.size)
+		if fraction := p.fraction(); fraction != step.expected {
+			t.Errorf("after %d/%d bytes: expected fraction %v, got %v", p.processedBytes, p.totalBytes, step.expected, fraction)
+		}
+	}
+
+	empty := newProgress(&config.Config{Progress: true}, []filter.FileInfo{{}, {}})
+	empty.out = &bytes.Buffer{}
+	empty.Increment(0)
+	if fraction := empty.fraction(); fraction != 0.5 {
+		t.Errorf("expected empty files to count by file, got %v", fraction)
+	}
+
+	start := time.Unix(0, 0)
+	p = &progress{total: 2, totalBytes: 1000, processedBytes: 250, start: start}
+	if eta, ok := p.eta(start.Add(10 * time.Second)); !ok || eta != 30*time.Second {
+		t.Errorf("expected an ETA of 30s, got %v (%v)", eta, ok)
+	}
+	p.processedBytes = 0
+	if _, ok := p.eta(start.Add(time.Second)); ok {
+		t.Error("expected no ETA before any bytes are processed")
+	}
+}
+
+func TestProgressKeepsReportClean(t *testing.T) {
+	originalOutput, originalTerminal := progressOutput, progressIsTerminal
+	defer func() { progressOutput, progressIsTerminal = originalOutput, originalTerminal }()
+
+	var progressBuf bytes.Buffer
+	progressOutput = &progressBuf
+	progressIsTerminal = func() bool { return true }
+
+	cfg := newTestConfig(t, "foo\n", "foo,bar\n")
+	cfg.Progress = true
+	cfg.Quiet = false
+	cfg.LogFile = filepath.Join(t.TempDir(), "report.json")
+
+	if err := executeRemap(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(progressBuf.String(), "1/1 files") {
+		t.Errorf("expected progress output, got %q", progressBuf.String())
+	}
+	report, err := os.ReadFile(cfg.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(report), "files/s") {
+		t.Errorf("expected the report to be free of progress output, got %q", report)
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"remap/internal/backup"
+	"remap/internal/errors"
+
+	"github.com/spf13/cobra"
+)
+
+var restoreOpts struct {
+	backupDir string
+	oldest    bool
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [directory] [--backup-dir <path>] [--oldest]",
+	Short: "Restore every file from its backup, without a log file",
+	Long: `Restore walks the backups of a run and copies each one back over the file it
+was taken from. Backups written with --backup-dir are mapped back under
+directory, which must be the directory the run was given; without
+--backup-dir the .bak files next to the files in directory are used.
+
+When a file has several timestamped backups the most recent one is restored,
+or the oldest one with --oldest. Backups are kept. The command exits with a
+non-zero status when a file could not be restored.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRestore,
+}
+
+func init() {
+	restoreCmd.Flags().StringVar(&restoreOpts.backupDir, "backup-dir", "", "Directory the backups were written to (default: next to the files)")
+	restoreCmd.Flags().BoolVar(&restoreOpts.oldest, "oldest", false, "Restore the oldest backup of each file instead of the most recent")
+
+	rootCmd.AddCommand(restoreCmd)
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	directory := "."
+	if len(args) > 0 {
+		directory = args[0]
+	}
+	return restoreBackups(directory, restoreOpts.backupDir, restoreOpts.oldest, cmd.OutOrStdout())
+}
+
+// restoreBackups restores every file under directory from the backups in
+// backupDir, or from those next to the files when backupDir is empty, and
+// reports the result to report.
+func restoreBackups(directory, backupDir string, oldest bool, report io.Writer) error {
+	if backupDir == "" {
+		backupDir = directory
+	}
+
+	backups, err := backup.FindBackups(backupDir, directory, oldest)
+	if err != nil {
+		return err
+	}
+
+	manager := backup.NewBackupManager(true)
+	failed := 0
+	for _, b := range backups {
+		if err := manager.RestoreFile(b.OriginalPath, b.BackupPath); err != nil {
+			fmt.Fprintf(report, "failed: %s: %v\n", b.OriginalPath, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(report, "restored: %s (from %s)\n", b.OriginalPath, b.BackupPath)
+	}
+
+	fmt.Fprintf(report, "Restored %d of %d file(s)\n", len(backups)-failed, len(backups))
+	if failed > 0 {
+		return errors.NewBackupError(backupDir, fmt.Sprintf("%d file(s) could not be restored", failed), nil)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRestoreBackups(t *testing.T) {
+	tests := []struct {
+		name      string
+		backupDir bool
+	}{
+		{name: "backups next to files"},
+		{name: "backup directory", backupDir: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := "foo\n"
+			cfg := newTestConfig(t, original, "foo,bar\n")
+			cfg.DryRun = false
+			if tt.backupDir {
+				cfg.BackupDir = filepath.Join(t.TempDir(), "backups")
+			}
+			if err := executeRemap(cfg); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			file := filepath.Join(cfg.Directory, "file.txt")
+			if content, _ := os.ReadFile(file); string(content) != "bar\n" {
+				t.Fatalf("unexpected content after run: %q", content)
+			}
+
+			var report bytes.Buffer
+			if err := restoreBackups(cfg.Directory, cfg.BackupDir, false, &report); err != nil {
+				t.Fatalf("unexpected restore error: %v", err)
+			}
+			if content, _ := os.ReadFile(file); string(content) != original {
+				t.Errorf("expected restore to give %q, got %q", original, content)
+			}
+			if !strings.Contains(report.String(), "Restored 1 of 1 file(s)") {
+				t.Errorf("unexpected report %q", report.String())
+			}
+		})
+	}
+}
+
+func TestRestoreBackupsReportsFailures(t *testing.T) {
+	root := t.TempDir()
+	backupDir := t.TempDir()
+	// The original's directory does not exist, so the restore cannot create it
+	backupPath := filepath.Join(backupDir, "gone", "file.txt.20260101_000000.bak")
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(backupPath, []byte("foo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var report bytes.Buffer
+	if err := restoreBackups(root, backupDir, false, &report); err == nil {
+		t.Fatal("expected an error when a file cannot be restored")
+	}
+	if !strings.Contains(report.String(), "failed: "+filepath.Join(root, "gone", "file.txt")) ||
+		!strings.Contains(report.String(), "Restored 0 of 1 file(s)") {
+		t.Errorf("unexpected report %q", report.String())
+	}
+}
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	stderrors "errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"remap/internal/config"
@@ -13,6 +15,7 @@ import (
 
 var cfg = &config.Config{}
 var extensionsStr string
+var configFile string
 
 var rootCmd = &cobra.Command{
 	Use:   "remap [options] <directory>",
@@ -21,8 +24,8 @@ var rootCmd = &cobra.Command{
 string occurrences according to a mapping table. It supports CSV and JSON mapping
 formats and provides extensive filtering and logging capabilities.`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		// Directory is required except in revert or apply mode
-		if cfg.Revert || cfg.Apply {
+		// Directory is required except in revert, apply or filter mode
+		if cfg.Revert || cfg.Apply || cfg.Filter {
 			return cobra.RangeArgs(0, 1)(cmd, args)
 		}
 		return cobra.ExactArgs(1)(cmd, args)
@@ -35,21 +38,44 @@ formats and provides extensive filtering and logging capabilities.`,
 // consistent error formatting and exit code management for all command failures.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitCodeError
+		if stderrors.As(err, &exitErr) {
+			if exitErr.message != "" {
+				fmt.Fprintln(os.Stderr, exitErr.message)
+			}
+			os.Exit(exitErr.code)
+		}
+
 		if te, ok := err.(*errors.RemapError); ok {
 			fmt.Fprintf(os.Stderr, "Error: %s\n", te.Error())
 		} else {
 			fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
 		}
-		os.Exit(1)
+		os.Exit(exitCodeFatal)
 	}
 }
 
 func init() {
+	rootCmd.Flags().StringVar(&configFile, "config", "", "Config file (YAML or JSON) of flag defaults, keyed by flag name (default: .remap.yaml in the target directory)")
 	rootCmd.Flags().StringVar(&cfg.MappingFile, "csv", "", "CSV mapping file (columns: source,destination)")
 	rootCmd.Flags().StringVar(&cfg.MappingFile, "json", "", "JSON mapping file")
+	rootCmd.Flags().StringArrayVar(&cfg.InlineMappings, "map", []string{}, "Inline mapping old=new (repeatable, split on the first '=')")
+	rootCmd.Flags().BoolVar(&cfg.NormalizeMapWhitespace, "normalize-map-whitespace", false, "Collapse runs of whitespace inside mapping sources to a single space")
+	rootCmd.Flags().StringVar(&cfg.MappingFile, "yaml", "", "YAML mapping file (list of {old, new} entries)")
+	rootCmd.Flags().StringVar(&cfg.MappingFile, "properties", "", "Properties mapping file ('from = to' lines, # comments, \\ escapes)")
 	rootCmd.Flags().StringSliceVar(&cfg.Include, "include", []string{}, "Include file patterns (glob, repeatable)")
 	rootCmd.Flags().StringSliceVar(&cfg.Exclude, "exclude", []string{}, "Exclude file patterns (glob, repeatable)")
 	rootCmd.Flags().StringSliceVar(&cfg.ExcludeDir, "exclude-dir", []string{}, "Exclude directories (repeatable)")
+	rootCmd.Flags().BoolVar(&cfg.FollowSymlinks, "follow-symlinks", false, "Follow symbolic links to files and directories outside the tree")
+	rootCmd.Flags().BoolVar(&cfg.IgnoreSymlinkedDirs, "ignore-symlinked-dirs", false, "Follow symbolic links to files but never descend into linked directories")
+	rootCmd.Flags().BoolVar(&cfg.IncludeHidden, "include-hidden", false, "Process dotfiles such as .env and dot-directories such as .git, which are skipped by default")
+	rootCmd.Flags().BoolVar(&cfg.ProcessBak, "process-bak", false, "Also process remap's own timestamped backups (name.YYYYMMDD_HHMMSS.bak)")
+	rootCmd.Flags().BoolVar(&cfg.SkipBinary, "skip-binary", false, "Skip binary files (NUL bytes in their first 8000 bytes)")
+	rootCmd.Flags().StringVar(&cfg.IncludeContent, "include-content", "", "Process only files whose content matches this regular expression")
+	rootCmd.Flags().StringVar(&cfg.ExcludeContent, "exclude-content", "", "Skip files whose content matches this regular expression")
+	rootCmd.Flags().StringVar(&cfg.ModifiedSince, "modified-since", "", "Only process files modified at or after this time (duration such as 24h, or RFC3339 timestamp)")
+	rootCmd.Flags().StringVar(&cfg.ModifiedBefore, "modified-before", "", "Only process files modified before this time (duration such as 24h, or RFC3339 timestamp)")
+	rootCmd.Flags().StringSliceVar(&cfg.MimeTypes, "mime-type", []string{}, "Process only files whose sniffed content type matches (e.g. text/*, repeatable)")
 	rootCmd.Flags().StringVar(&extensionsStr, "extensions", "", "File extensions to process (.txt,.go, etc.)")
 	rootCmd.Flags().BoolVar(&cfg.DryRun, "dry-run", false, "Simulate without making changes")
 	rootCmd.Flags().BoolVar(&cfg.DryRun, "fake", false, "Simulate without making changes (alias for --dry-run)")
@@ -57,21 +83,87 @@ func init() {
 	rootCmd.Flags().BoolVar(&cfg.Apply, "apply", false, "Apply transformations from log file (ignores commented lines)")
 	rootCmd.Flags().BoolVar(&cfg.Backup, "backup", false, "Create .bak files (deprecated, backups enabled by default)")
 	rootCmd.Flags().BoolVar(&cfg.NoBackup, "nobackup", false, "Disable backup file creation")
+	rootCmd.Flags().BoolVar(&cfg.HardLinkBackups, "hardlink-backups", false, "Create backups as hard links instead of copies when on the same filesystem")
+	rootCmd.Flags().StringVar(&cfg.BackupDir, "backup-dir", "", "Write backups into a tree mirroring the target under this directory instead of next to each file")
+	rootCmd.Flags().StringVar(&cfg.BackupArchive, "backup-archive", "", "Write every backup into this new .tar.gz instead of separate .bak files")
+	rootCmd.Flags().BoolVar(&cfg.TemplateMappings, "template", false, "Treat a single '*' in a mapping source as a word capture, inserted with '{}' in the destination")
+	rootCmd.Flags().BoolVar(&cfg.WordBoundary, "word-boundary", false, "Only replace whole words: matches must not touch [A-Za-z0-9_] characters")
+	rootCmd.Flags().BoolVar(&cfg.PreserveIndent, "preserve-indent", false, "Indent the lines of a multi-line replacement like the line the match is on")
+	rootCmd.Flags().BoolVar(&cfg.ToTemplate, "to-template", false, "Evaluate each mapping destination as a Go text/template ({{.File}}, {{.Path}}, {{.Line}}, {{.Match}})")
+	rootCmd.Flags().BoolVar(&cfg.GraphemeAware, "grapheme-aware", false, "Only replace matches that cover whole grapheme clusters (keeps emoji and combining sequences intact)")
+	rootCmd.Flags().StringVar(&cfg.SectionBegin, "section-begin", "", "Only replace inside sections starting at a line containing this marker (e.g. '# BEGIN managed')")
+	rootCmd.Flags().StringVar(&cfg.SectionEnd, "section-end", "", "Marker of the line closing a section opened by --section-begin (e.g. '# END managed')")
+	rootCmd.Flags().StringArrayVar(&cfg.Lines, "lines", []string{}, "Only replace on lines within START:END (inclusive, either bound may be omitted, repeatable)")
+	rootCmd.Flags().BoolVar(&cfg.VerifyWrites, "verify-writes", false, "Re-read each rewritten file and fail if its checksum differs from the intended content")
+	rootCmd.Flags().BoolVar(&cfg.PreserveXattrs, "preserve-xattrs", false, "Preserve extended attributes (xattrs/ACLs) on rewritten and backed-up files (Linux and macOS)")
+	rootCmd.Flags().BoolVar(&cfg.PreserveMtime, "preserve-mtime", false, "Keep the modification time of rewritten files")
 	rootCmd.Flags().BoolVar(&cfg.CaseSensitive, "case-sensitive", false, "Case-sensitive search (default: insensitive)")
+	rootCmd.Flags().BoolVar(&cfg.AllowDuplicates, "allow-duplicates", false, "Accept sources mapped more than once, using the first mapping for each")
+	rootCmd.Flags().BoolVar(&cfg.PreserveCase, "preserve-case", false, "Give each replacement the casing of the text it replaces (case-insensitive mode only)")
 	rootCmd.Flags().BoolVarP(&cfg.Verbose, "verbose", "v", false, "Verbose mode")
 	rootCmd.Flags().BoolVar(&cfg.Debug, "debug", false, "Debug mode")
 	rootCmd.Flags().BoolVarP(&cfg.Quiet, "quiet", "q", false, "Quiet mode")
+	rootCmd.Flags().BoolVar(&cfg.Progress, "progress", false, "Show a processed/total progress line on stderr (only when stderr is a terminal)")
+	rootCmd.Flags().BoolVar(&cfg.Explain, "explain", false, "Print to stderr which filter accepted or rejected each path")
+	rootCmd.Flags().BoolVar(&cfg.Safe, "safe", false, "Safe mode: backups, preview and confirm, skip binary files, stop on first error")
+	rootCmd.Flags().BoolVar(&cfg.Confirm, "confirm", false, "Preview the changes and ask for confirmation before modifying files")
+	rootCmd.Flags().BoolVar(&cfg.Interactive, "interactive", false, "Show the diff of each file and ask y/n/a/q before writing it (processes files serially)")
+	rootCmd.Flags().BoolVar(&cfg.DetectOnly, "detect-only", false, "Detect the replacements in every file before backing up or writing any of them")
+	rootCmd.Flags().BoolVar(&cfg.StopOnError, "stop-on-error", false, "Stop processing at the first file error (default: report it and continue)")
+	rootCmd.Flags().BoolVar(&cfg.StopOnError, "fail-fast", false, "Alias of --stop-on-error")
+	rootCmd.Flags().IntVar(&cfg.ErrorExitCode, "error-exit-code", config.DefaultErrorExitCode, "Exit status of a run in which some files failed (fatal errors exit with 1)")
+	rootCmd.Flags().BoolVar(&cfg.IgnoreErrors, "ignore-errors", false, "Exit with status 0 even when some files failed (they are still reported)")
+	rootCmd.Flags().BoolVar(&cfg.ConfirmDeletion, "confirm-deletion", false, "Allow runs whose empty replacements delete more than --deletion-threshold bytes")
+	rootCmd.Flags().Int64Var(&cfg.DeletionThreshold, "deletion-threshold", config.DefaultDeletionThreshold, "Bytes of deleted text above which --confirm-deletion is required")
+	rootCmd.Flags().BoolVar(&cfg.SummaryExitCode, "summary-exit-code", false, "Exit with status 3 when any file was (or would be) modified")
+	rootCmd.Flags().BoolVar(&cfg.ToStdout, "to-stdout", false, "Print the transformed content of a single target file to stdout instead of writing it")
+	rootCmd.Flags().BoolVar(&cfg.Filter, "filter", false, "Read content from stdin and write it transformed to stdout, touching no files (same as directory -)")
+	rootCmd.Flags().BoolVar(&cfg.Diff, "diff", false, "Print a unified diff of each modified file (implies --dry-run unless --dry-run=false is given)")
+	rootCmd.Flags().IntVar(&cfg.ContextLines, "context", 0, "Lines of context shown around each change in verbose output and diffs (diffs default to 3)")
+	rootCmd.Flags().StringVar(&cfg.PatchFile, "patch", "", "Write all changes as a unified diff for git apply / patch -p1 (works with --dry-run)")
 	rootCmd.Flags().StringVar(&cfg.LogFile, "log", "", "Log file (default: stdout)")
-	rootCmd.Flags().Var((*logFormatFlag)(&cfg.LogFormat), "log-format", "Log format (json, csv)")
-
-	rootCmd.MarkFlagsMutuallyExclusive("csv", "json")
-	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
-	rootCmd.MarkFlagsMutuallyExclusive("debug", "quiet")
-	rootCmd.MarkFlagsMutuallyExclusive("backup", "nobackup")
-	rootCmd.MarkFlagsMutuallyExclusive("revert", "apply")
+	rootCmd.Flags().Var((*logFormatFlag)(&cfg.LogFormat), "log-format", "Log format (json, csv, xml, ndjson)")
+	rootCmd.Flags().StringVar(&cfg.Color, "color", config.ColorAuto, "Color verbose and summary output (auto, always, never); auto colors terminals unless NO_COLOR is set")
+	rootCmd.Flags().StringVar(&cfg.SignKey, "sign-key", "", "Append an HMAC-SHA256 signature keyed by this secret to the JSON report (check it with verify-log)")
+	rootCmd.Flags().BoolVar(&cfg.ErrorsToStderr, "report-only-errors-to-stderr", false, "Also print error lines and an error count to stderr, keeping them out of the structured report output")
+	rootCmd.Flags().BoolVar(&cfg.Strict, "strict", false, "Treat warnings (unused, conflicting or empty mappings, skipped binary files) as errors")
+	rootCmd.Flags().BoolVar(&cfg.StrictMappings, "strict-mappings", false, "Turn mapping warnings (conflicts, empty replacements, chained mappings) into errors")
+	rootCmd.Flags().StringVar(&cfg.LogSink, "log-sink", "", "Send per-file entries and the summary to a sink instead of stdout/--log (syslog)")
+	rootCmd.Flags().IntVar(&cfg.Workers, "workers", 0, "Number of parallel workers (0: CPU count, max 8; 1 processes files serially)")
+	rootCmd.Flags().Var((*sizeFlag)(&cfg.SizeBudget), "size-budget", "Stop selecting files once their total size would exceed this budget (e.g. 500MB)")
+	rootCmd.Flags().IntVar(&cfg.MaxPerDir, "max-per-dir", 0, "Maximum files processed concurrently per directory (0: unlimited)")
+	rootCmd.Flags().IntVar(&cfg.MaxReplacementsPerFile, "max-replacements-per-file", 0, "Skip and report as an error any file with more replacements than this (0: unlimited)")
+	rootCmd.Flags().IntVar(&cfg.Limit, "limit", 0, "Stop after this many replacements in total, only rewriting files that fit entirely (0: unlimited)")
+	rootCmd.Flags().BoolVar(&cfg.CSVMappingID, "csv-mapping-id", false, "Add a mapping_index column identifying the mapping behind each CSV row")
+	rootCmd.Flags().BoolVar(&cfg.CSVSizeDelta, "csv-size-delta", false, "Add a size_delta column with the size change in bytes of the file behind each CSV row")
+	rootCmd.Flags().StringVar(&cfg.ReportPathPrefixStrip, "report-path-prefix-strip", "", "Strip this leading directory from file and backup paths in reports")
+	rootCmd.Flags().BoolVar(&cfg.DeduplicateEntries, "deduplicate-entries", false, "Merge report entries referring to the same file")
+	rootCmd.Flags().StringVar(&cfg.Order, "order", config.OrderDiscovery, "Order files are processed in (discovery, size-desc)")
+	rootCmd.Flags().IntVar(&cfg.TopGrowth, "top-growth", 0, "In dry-run summaries, list the N files that would grow the most (0: off)")
+	rootCmd.Flags().BoolVar(&cfg.Canonical, "canonical", false, "Write the JSON report in canonical form (sorted, without timings) so unchanged runs are byte-identical")
+	rootCmd.Flags().BoolVar(&cfg.ChangedFilesJSON, "changed-files-json", false, "Write only a JSON list of modified files with their replacement counts instead of the full report")
+	rootCmd.Flags().BoolVar(&cfg.StableOutput, "stable-output", false, "Report files in discovery order instead of completion order")
+	rootCmd.Flags().BoolVar(&cfg.SortedOutput, "sorted-output", false, "Report files sorted by path instead of completion order")
+	rootCmd.Flags().StringVar(&cfg.DefinePattern, "define-pattern", "", "Regex whose first capture group names symbols to rename across all files (two-pass mode)")
+	rootCmd.Flags().StringVar(&cfg.DefineReplace, "define-replace", "", "Replacement template for symbols found by --define-pattern ($1, $2... expand captures)")
+	rootCmd.Flags().BoolVar(&cfg.DefineIgnoreCase, "define-ignore-case", false, "Match symbols found by --define-pattern case-insensitively (they match case-sensitively by default)")
+
+	for _, group := range exclusiveFlags {
+		rootCmd.MarkFlagsMutuallyExclusive(group...)
+	}
 }
 
 func runRemap(cmd *cobra.Command, args []string) error {
+	if err := applyEnv(cmd.Flags()); err != nil {
+		return err
+	}
+
+	if path := findConfigFile(configFile, args); path != "" {
+		if err := applyConfigFile(cmd.Flags(), path); err != nil {
+			return err
+		}
+	}
+
 	if len(args) > 0 {
 		cfg.Directory = args[0]
 	}
@@ -80,17 +172,34 @@ func runRemap(cmd *cobra.Command, args []string) error {
 		cfg.MappingType = "csv"
 	} else if cmd.Flag("json").Changed {
 		cfg.MappingType = "json"
+	} else if cmd.Flag("yaml").Changed {
+		cfg.MappingType = "yaml"
+	} else if cmd.Flag("properties").Changed {
+		cfg.MappingType = "properties"
 	}
 
 	if extensionsStr != "" {
 		cfg.Extensions = strings.Split(extensionsStr, ",")
 	}
 
+	applyDiffMode(cfg, cmd.Flags().Changed)
+	applySafeMode(cfg, cmd.Flags().Changed)
+
+	if err := checkErrorExitCode(cfg, cmd.Flags().Changed); err != nil {
+		return err
+	}
 	if err := cfg.Validate(); err != nil {
 		return err
 	}
 
-	return executeRemap(cfg)
+	err := executeRemap(cfg)
+	var exitErr *exitCodeError
+	if stderrors.As(err, &exitErr) {
+		// The run itself succeeded; only the exit status carries the outcome
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+	}
+	return err
 }
 
 type logFormatFlag config.LogFormat
@@ -101,14 +210,37 @@ func (f *logFormatFlag) String() string {
 
 func (f *logFormatFlag) Set(v string) error {
 	switch v {
-	case "json", "csv":
+	case "json", "csv", "xml", "ndjson":
 		*f = logFormatFlag(v)
 		return nil
 	default:
-		return fmt.Errorf("must be 'json' or 'csv'")
+		return fmt.Errorf("must be 'json', 'csv', 'xml' or 'ndjson'")
 	}
 }
 
 func (f *logFormatFlag) Type() string {
 	return "string"
 }
+
+// sizeFlag parses human-readable sizes such as "500MB" into bytes.
+type sizeFlag int64
+
+func (f *sizeFlag) String() string {
+	if *f == 0 {
+		return ""
+	}
+	return strconv.FormatInt(int64(*f), 10)
+}
+
+func (f *sizeFlag) Set(v string) error {
+	size, err := config.ParseSize(v)
+	if err != nil {
+		return err
+	}
+	*f = sizeFlag(size)
+	return nil
+}
+
+func (f *sizeFlag) Type() string {
+	return "size"
+}
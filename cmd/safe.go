@@ -0,0 +1,28 @@
+package cmd
+
+import "remap/internal/config"
+
+// applySafeMode expands --safe into its individual safeguards: backups,
+// a dry-run preview confirmed interactively, binary file skipping and
+// stopping at the first error. A flag given explicitly on the command line
+// (changed reports those) always wins over the safe-mode default, so
+// `--safe --nobackup` still disables backups.
+func applySafeMode(cfg *config.Config, changed func(name string) bool) {
+	if !cfg.Safe {
+		return
+	}
+
+	if !changed("nobackup") {
+		cfg.Backup = true
+		cfg.NoBackup = false
+	}
+	if !changed("confirm") && !cfg.DryRun {
+		cfg.Confirm = true
+	}
+	if !changed("skip-binary") {
+		cfg.SkipBinary = true
+	}
+	if !changed("stop-on-error") && !changed("fail-fast") {
+		cfg.StopOnError = true
+	}
+}
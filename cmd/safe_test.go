@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"testing"
+
+	"remap/internal/config"
+)
+
+func TestApplySafeMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   config.Config
+		changed  []string
+		expected config.Config
+	}{
+		{
+			name:     "safe mode defaults",
+			config:   config.Config{Safe: true},
+			expected: config.Config{Safe: true, Backup: true, Confirm: true, SkipBinary: true, StopOnError: true},
+		},
+		{
+			name:     "explicit nobackup wins",
+			config:   config.Config{Safe: true, NoBackup: true},
+			changed:  []string{"nobackup"},
+			expected: config.Config{Safe: true, NoBackup: true, Confirm: true, SkipBinary: true, StopOnError: true},
+		},
+		{
+			name:     "dry run needs no confirmation",
+			config:   config.Config{Safe: true, DryRun: true},
+			expected: config.Config{Safe: true, DryRun: true, Backup: true, SkipBinary: true, StopOnError: true},
+		},
+		{
+			name:     "explicitly disabled safeguards stay off",
+			config:   config.Config{Safe: true},
+			changed:  []string{"confirm", "skip-binary", "stop-on-error"},
+			expected: config.Config{Safe: true, Backup: true},
+		},
+		{
+			name:     "explicit fail-fast off stays off",
+			config:   config.Config{Safe: true},
+			changed:  []string{"fail-fast"},
+			expected: config.Config{Safe: true, Backup: true, Confirm: true, SkipBinary: true},
+		},
+		{
+			name:     "without safe mode",
+			config:   config.Config{},
+			expected: config.Config{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := func(name string) bool {
+				for _, flag := range tt.changed {
+					if flag == name {
+						return true
+					}
+				}
+				return false
+			}
+
+			cfg := tt.config
+			applySafeMode(&cfg, changed)
+
+			if cfg.Backup != tt.expected.Backup || cfg.NoBackup != tt.expected.NoBackup ||
+				cfg.Confirm != tt.expected.Confirm || cfg.SkipBinary != tt.expected.SkipBinary ||
+				cfg.StopOnError != tt.expected.StopOnError || cfg.DryRun != tt.expected.DryRun {
+				t.Errorf("got %+v, expected %+v", cfg, tt.expected)
+			}
+		})
+	}
+}
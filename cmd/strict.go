@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"remap/internal/config"
+	"remap/internal/errors"
+	"remap/internal/filter"
+	"remap/internal/parser"
+)
+
+// warningOutput receives warnings; it is a variable so tests can capture it.
+var warningOutput io.Writer = os.Stderr
+
+// reportWarnings prints each warning unless quiet. With --strict the
+// warnings are fatal instead and are returned as a single error.
+func reportWarnings(cfg *config.Config, warnings []string) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	if cfg.Strict {
+		return strictError("strict mode", warnings)
+	}
+
+	if !cfg.Quiet {
+		for _, warning := range warnings {
+			fmt.Fprintf(warningOutput, "Warning: %s\n", warning)
+		}
+	}
+	return nil
+}
+
+// strictError turns warnings into a single error for a strict mode.
+func strictError(mode string, warnings []string) error {
+	return errors.NewConfigError(mode+": "+strings.Join(warnings, "; "), nil)
+}
+
+// reportMappingWarnings reports warnings about the mappings, which
+// --strict-mappings makes fatal on its own.
+func reportMappingWarnings(cfg *config.Config, warnings []string) error {
+	if cfg.StrictMappings && len(warnings) > 0 {
+		return strictError("strict mappings", warnings)
+	}
+	return reportWarnings(cfg, warnings)
+}
+
+// mappingWarnings flags mappings that are likely mistakes: a source mapped to
+// different destinations within the same scope, empty replacements, which
+// delete the matched text, and chains, where a destination is the source of
+// another mapping and the result depends on the order mappings run in.
+func mappingWarnings(mappings *parser.MappingTable, caseSensitive bool) []string {
+	var warnings []string
+
+	_, conflicts := mappings.Normalize()
+	for _, conflict := range conflicts {
+		warnings = append(warnings, fmt.Sprintf("conflicting mappings: %q maps to %q and %q, keeping %q",
+			conflict.From, conflict.Kept, conflict.Dropped, conflict.Kept))
+	}
+
+	for _, mapping := range mappings.GetMappings() {
+		if mapping.To == "" && mapping.LineCase == "" {
+			warnings = append(warnings, fmt.Sprintf("mapping %q has an empty replacement and deletes matched text", mapping.From))
+		}
+	}
+
+	for _, chain := range mappings.Chains(caseSensitive) {
+		warnings = append(warnings, fmt.Sprintf("chained mappings: %q → %q is the source of %q → %q, so the result depends on mapping order",
+			chain.First.From, chain.First.To, chain.Next.From, chain.Next.To))
+	}
+	return warnings
+}
+
+// discoveryWarnings reports files left out of the run that the user may have
+// expected to be processed.
+func discoveryWarnings(discovery *filter.FileDiscovery) []string {
+	if skipped := discovery.RejectedBy("binary"); skipped > 0 {
+		return []string{fmt.Sprintf("%d binary file(s) skipped", skipped)}
+	}
+	return nil
+}
+
+// unusedMappingWarnings lists the mappings that matched nothing, given the
+// indexes of those that produced at least one replacement.
+func unusedMappingWarnings(mappings *parser.MappingTable, used map[int]bool) []string {
+	var warnings []string
+	for _, mapping := range mappings.GetMappings() {
+		if !used[mapping.Index] {
+			warnings = append(warnings, fmt.Sprintf("mapping %q -> %q is never used", mapping.From, mapping.To))
+		}
+	}
+	return warnings
+}
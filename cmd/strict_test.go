@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStrictMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		mappings string
+		setup    func(t *testing.T, dir string)
+		warning  string
+
+		allowDuplicates bool
+	}{
+		{
+			name:     "unused mapping",
+			content:  "foo\n",
+			mappings: "foo,bar\nmissing,present\n",
+			warning:  `mapping "missing" -> "present" is never used`,
+		},
+		{
+			name:     "conflicting mappings",
+			content:  "foo\n",
+			mappings: "foo,bar\nfoo,baz\n",
+			warning:  `conflicting mappings: "foo" maps to "bar" and "baz"`,
+
+			allowDuplicates: true,
+		},
+		{
+			name:     "chained mappings",
+			content:  "foo\n",
+			mappings: "foo,bar\nbar,baz\n",
+			warning:  `chained mappings: "foo" → "bar" is the source of "bar" → "baz"`,
+		},
+		{
+			name:     "empty replacement",
+			content:  "foo secret\n",
+			mappings: "foo,bar\nsecret,\n",
+			warning:  `mapping "secret" has an empty replacement`,
+		},
+		{
+			name:     "skipped binary file",
+			content:  "foo\n",
+			mappings: "foo,bar\n",
+			setup: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "blob.bin"), []byte("foo\x00bar"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			warning: "1 binary file(s) skipped",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := func(strict bool) (string, error) {
+				cfg := newTestConfig(t, tt.content, tt.mappings)
+				cfg.Quiet = false
+				cfg.Verbose = true
+				cfg.SkipBinary = true
+				cfg.LogFile = filepath.Join(t.TempDir(), "report.json")
+				cfg.Strict = strict
+				cfg.AllowDuplicates = tt.allowDuplicates
+				if tt.setup != nil {
+					tt.setup(t, cfg.Directory)
+				}
+
+				var output bytes.Buffer
+				warningOutput = &output
+				defer func() { warningOutput = os.Stderr }()
+
+				err := executeRemap(cfg)
+				return output.String(), err
+			}
+
+			output, err := run(false)
+			if err != nil {
+				t.Fatalf("expected a warning only, got error: %v", err)
+			}
+			if !strings.Contains(output, "Warning: "+tt.warning) {
+				t.Errorf("expected warning %q, got %q", tt.warning, output)
+			}
+
+			_, err = run(true)
+			if err == nil {
+				t.Fatal("expected strict mode to fail")
+			}
+			if !strings.Contains(err.Error(), tt.warning) {
+				t.Errorf("expected error mentioning %q, got %v", tt.warning, err)
+			}
+		})
+	}
+}
+
+func TestStrictModeClean(t *testing.T) {
+	cfg := newTestConfig(t, "foo\n", "foo,bar\n")
+	cfg.Strict = true
+
+	if err := executeRemap(cfg); err != nil {
+		t.Errorf("expected a clean run to pass in strict mode, got %v", err)
+	}
+}
+
+func TestStrictUnusedMappingBeforeWriting(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		mappings    string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:        "unused mapping",
+			content:     "foo\n",
+			mappings:    "foo,bar\nmissing,present\n",
+			expected:    "foo\n",
+			expectError: true,
+		},
+		{
+			// Both mappings land in one whole-line record
+			name:     "replacement on a line-case line",
+			content:  "heading foo\n",
+			mappings: "old,new,line_case\nheading,,upper\nfoo,bar,\n",
+			expected: "HEADING bar\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t, tt.content, tt.mappings)
+			cfg.DryRun = false
+			cfg.NoBackup = true
+			cfg.Strict = true
+
+			err := executeRemap(cfg)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			content, readErr := os.ReadFile(filepath.Join(cfg.Directory, "file.txt"))
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestStrictMappings(t *testing.T) {
+	cfg := newTestConfig(t, "foo\n", "foo,bar\nbar,baz\n")
+	cfg.StrictMappings = true
+	err := executeRemap(cfg)
+	if err == nil || !strings.Contains(err.Error(), "strict mappings: chained mappings") {
+		t.Errorf("expected chained mappings to fail with --strict-mappings, got %v", err)
+	}
+
+	// Other warnings stay warnings
+	cfg = newTestConfig(t, "foo\n", "foo,bar\nmissing,present\n")
+	cfg.StrictMappings = true
+	cfg.Verbose = true
+	if err := executeRemap(cfg); err != nil {
+		t.Errorf("expected an unused mapping to pass with --strict-mappings, got %v", err)
+	}
+}
+
+func TestMappingCycle(t *testing.T) {
+	cfg := newTestConfig(t, "foo\n", "foo,bar\nBAR,foo\n")
+	err := executeRemap(cfg)
+	if err == nil || !strings.Contains(err.Error(), "mapping cycle") {
+		t.Errorf("expected a mapping cycle error, got %v", err)
+	}
+
+	cfg.CaseSensitive = true
+	if err := executeRemap(cfg); err != nil {
+		t.Errorf("expected no cycle between case-sensitive sources, got %v", err)
+	}
+}
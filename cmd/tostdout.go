@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"remap/internal/concurrent"
+	"remap/internal/config"
+	"remap/internal/errors"
+	"remap/internal/parser"
+)
+
+// stdoutOutput receives the content printed by --to-stdout; it is a variable
+// so tests can capture it.
+var stdoutOutput io.Writer = os.Stdout
+
+// executeToStdout prints the single target file with the mappings applied,
+// leaving the file itself untouched. The file was named explicitly, so it
+// is not run through the discovery filters.
+func executeToStdout(cfg *config.Config, mappings *parser.MappingTable) error {
+	info, err := os.Stat(cfg.Directory)
+	if err != nil {
+		return errors.WrapFileError(cfg.Directory, err)
+	}
+	if info.IsDir() {
+		return errors.NewConfigErrorWithPath(cfg.Directory, "--to-stdout requires a single file, not a directory", nil)
+	}
+
+	content, err := os.ReadFile(cfg.Directory)
+	if err != nil {
+		return errors.WrapFileError(cfg.Directory, err)
+	}
+
+	rendered := concurrent.NewProcessor(cfg, mappings).Render(cfg.Directory, content)
+	if _, err := io.WriteString(stdoutOutput, rendered); err != nil {
+		return errors.NewFileError("stdout", "failed to write transformed content", err)
+	}
+	return nil
+}
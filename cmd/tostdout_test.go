@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestToStdout(t *testing.T) {
+	originalOutput := stdoutOutput
+	defer func() { stdoutOutput = originalOutput }()
+
+	var out bytes.Buffer
+	stdoutOutput = &out
+
+	cfg := newTestConfig(t, "foo and foo\nkeep\n", "foo,bar\n")
+	file := filepath.Join(cfg.Directory, "file.txt")
+	cfg.Directory = file
+	cfg.DryRun = false
+	cfg.ToStdout = true
+
+	if err := executeRemap(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "bar and bar\nkeep\n"; out.String() != want {
+		t.Errorf("stdout = %q, want %q", out.String(), want)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "foo and foo\nkeep\n" {
+		t.Errorf("expected the file to be unchanged, got %q", content)
+	}
+	if matches, _ := filepath.Glob(file + ".*"); len(matches) > 0 {
+		t.Errorf("expected no backup or temporary file, found %v", matches)
+	}
+}
+
+func TestToStdoutRequiresFile(t *testing.T) {
+	cfg := newTestConfig(t, "foo\n", "foo,bar\n")
+	cfg.ToStdout = true
+
+	if err := executeRemap(cfg); err == nil {
+		t.Error("expected an error when the target is a directory")
+	}
+}
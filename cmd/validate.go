@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"remap/internal/backup"
+	"remap/internal/errors"
+
+	"github.com/spf13/cobra"
+)
+
+var validateLogOpts struct {
+	logFile   string
+	logFormat string
+}
+
+var validateLogCmd = &cobra.Command{
+	Use:   "validate-log --log <file> [--log-format json|csv|xml|ndjson]",
+	Short: "Check that a log file can be reverted, without changing anything",
+	Long: `Validate-log parses an operation log the same way --revert does and reports
+entries that a revert could not act on: missing target files, missing
+backups and malformed records. No file is modified. The command exits with a
+non-zero status when a problem is found.`,
+	Args: cobra.NoArgs,
+	RunE: runValidateLog,
+}
+
+func init() {
+	validateLogCmd.Flags().StringVar(&validateLogOpts.logFile, "log", "", "Log file to validate")
+	validateLogCmd.Flags().StringVar(&validateLogOpts.logFormat, "log-format", "json", "Log format (json, csv, xml or ndjson)")
+	_ = validateLogCmd.MarkFlagRequired("log")
+
+	rootCmd.AddCommand(validateLogCmd)
+}
+
+func runValidateLog(cmd *cobra.Command, _ []string) error {
+	return validateLogFile(validateLogOpts.logFile, validateLogOpts.logFormat, cmd.OutOrStdout())
+}
+
+// validateLogFile reports the problems found in logFile to report and
+// returns an error when there is at least one.
+func validateLogFile(logFile, logFormat string, report io.Writer) error {
+	switch logFormat {
+	case "json", "csv", "xml", "ndjson":
+	default:
+		return errors.NewConfigError("log format must be 'json', 'csv', 'xml' or 'ndjson'", nil)
+	}
+
+	problems, err := backup.NewRevertManager().ValidateLog(logFile, logFormat)
+	if err != nil {
+		return err
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintln(report, problem)
+	}
+	if len(problems) > 0 {
+		return errors.NewParsingError(logFile, fmt.Sprintf("%d problem(s) found", len(problems)), nil)
+	}
+
+	fmt.Fprintf(report, "%s: no problems found\n", logFile)
+	return nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateLogFile(t *testing.T) {
+	cfg := newTestConfig(t, "foo\n", "foo,bar\n")
+	cfg.DryRun = false
+	cfg.Quiet = false
+	cfg.LogFile = filepath.Join(t.TempDir(), "remap.json")
+	if err := executeRemap(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var report bytes.Buffer
+	if err := validateLogFile(cfg.LogFile, "json", &report); err != nil {
+		t.Fatalf("expected a clean log, got %v: %s", err, report.String())
+	}
+	if !strings.Contains(report.String(), "no problems found") {
+		t.Errorf("expected a clean report, got %q", report.String())
+	}
+
+	// Removing the rewritten file and its backup breaks the log
+	backups, err := filepath.Glob(filepath.Join(cfg.Directory, "*.bak"))
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("expected one backup, got %v (%v)", backups, err)
+	}
+	for _, path := range []string{backups[0], filepath.Join(cfg.Directory, "file.txt")} {
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report.Reset()
+	err = validateLogFile(cfg.LogFile, "json", &report)
+	if err == nil || !strings.Contains(err.Error(), "2 problem(s) found") {
+		t.Errorf("expected two problems, got %v", err)
+	}
+	for _, expected := range []string{"file not found", backups[0] + " not found"} {
+		if !strings.Contains(report.String(), expected) {
+			t.Errorf("expected %q in report, got %q", expected, report.String())
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"remap/internal/errors"
+	"remap/internal/log"
+
+	"github.com/spf13/cobra"
+)
+
+var verifyLogOpts struct {
+	logFile string
+	key     string
+}
+
+var verifyLogCmd = &cobra.Command{
+	Use:   "verify-log --log <file> --key <key>",
+	Short: "Check the signature of a JSON log written with --sign-key",
+	Long: `Verify-log recomputes the HMAC-SHA256 of a JSON report written with
+--sign-key and compares it with the signature stored in the report. The
+command exits with a non-zero status when the log is unsigned, was modified
+after it was written, or was signed with another key.`,
+	Args: cobra.NoArgs,
+	RunE: runVerifyLog,
+}
+
+func init() {
+	verifyLogCmd.Flags().StringVar(&verifyLogOpts.logFile, "log", "", "Signed JSON log file to verify")
+	verifyLogCmd.Flags().StringVar(&verifyLogOpts.key, "key", "", "Key the log was signed with (--sign-key)")
+	_ = verifyLogCmd.MarkFlagRequired("log")
+	_ = verifyLogCmd.MarkFlagRequired("key")
+
+	rootCmd.AddCommand(verifyLogCmd)
+}
+
+func runVerifyLog(cmd *cobra.Command, _ []string) error {
+	return verifyLogFile(verifyLogOpts.logFile, verifyLogOpts.key, cmd.OutOrStdout())
+}
+
+// verifyLogFile checks the signature of logFile with key, reporting success
+// to report.
+func verifyLogFile(logFile, key string, report io.Writer) error {
+	if key == "" {
+		return errors.NewConfigError("--key must not be empty", nil)
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		return errors.WrapFileError(logFile, err)
+	}
+
+	if err := log.VerifyReport(content, key); err != nil {
+		return errors.NewParsingError(logFile, "signature verification failed", err)
+	}
+
+	fmt.Fprintf(report, "%s: signature OK\n", logFile)
+	return nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/log"
+)
+
+func TestVerifyLogFile(t *testing.T) {
+	cfg := newTestConfig(t, "foo\n", "foo,bar\n")
+	cfg.DryRun = false
+	cfg.Quiet = false
+	cfg.Verbose = true
+	cfg.SignKey = "s3cret"
+	cfg.LogFile = filepath.Join(t.TempDir(), "remap.json")
+	if err := executeRemap(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var report bytes.Buffer
+	if err := verifyLogFile(cfg.LogFile, "s3cret", &report); err != nil {
+		t.Fatalf("expected a valid signature, got %v", err)
+	}
+	if !strings.Contains(report.String(), "signature OK") {
+		t.Errorf("unexpected output %q", report.String())
+	}
+
+	signed, err := os.ReadFile(cfg.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		content string
+		key     string
+		want    error
+	}{
+		{"wrong key", string(signed), "other", log.ErrSignatureMismatch},
+		{"tampered entry", strings.Replace(string(signed), `"To": "bar"`, `"To": "baz"`, 1), "s3cret", log.ErrSignatureMismatch},
+		{"tampered summary", strings.Replace(string(signed), `"modified_files": 1`, `"modified_files": 0`, 1), "s3cret", log.ErrSignatureMismatch},
+		{"tampered signature", strings.Replace(string(signed), `"signature": "hmac-sha256:`, `"signature": "hmac-sha256:00`, 1), "s3cret", log.ErrSignatureMismatch},
+		{"unsigned", strings.Replace(string(signed), `"signature"`, `"note"`, 1), "s3cret", log.ErrUnsigned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.key == "s3cret" && tt.content == string(signed) {
+				t.Fatal("test case does not tamper with the log")
+			}
+			path := filepath.Join(t.TempDir(), "remap.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			err := verifyLogFile(path, tt.key, &bytes.Buffer{})
+			if !stderrors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSignedLogReverts(t *testing.T) {
+	cfg := newTestConfig(t, "foo\n", "foo,bar\n")
+	cfg.DryRun = false
+	cfg.NoBackup = true
+	cfg.Quiet = false
+	cfg.SignKey = "s3cret"
+	cfg.LogFile = filepath.Join(t.TempDir(), "remap.json")
+	if err := executeRemap(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	revertCfg := &config.Config{Revert: true, LogFile: cfg.LogFile, LogFormat: config.LogFormatJSON, Quiet: true}
+	if err := executeRemap(revertCfg); err != nil {
+		t.Fatalf("unexpected revert error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(cfg.Directory, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "foo\n" {
+		t.Errorf("expected the signed log to revert the file, got %q", content)
+	}
+}
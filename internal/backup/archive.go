@@ -0,0 +1,184 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"remap/internal/errors"
+)
+
+// archiveSeparator joins an archive path and a member name in the backup
+// path recorded for a file backed up into an archive.
+const archiveSeparator = "#"
+
+// archiveWriter appends the backups of a run to a single .tar.gz. Workers
+// back up concurrently, so every write holds mu. The archive is created on
+// the first backup and must be closed to be complete; each member is flushed
+// as it is written so it can be restored before then.
+type archiveWriter struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	gz   *gzip.Writer
+	tw   *tar.Writer
+
+	// refs maps each member to its backup path, so a file backed up twice
+	// keeps its first backup: the content it had before the run.
+	refs map[string]string
+}
+
+func newArchiveWriter(path string) *archiveWriter {
+	return &archiveWriter{path: path, refs: make(map[string]string)}
+}
+
+// add appends filePath to the archive and returns its backup path.
+func (a *archiveWriter) add(filePath string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	member := archiveMember(filePath)
+	if ref, ok := a.refs[member]; ok {
+		return ref, nil
+	}
+
+	if a.file == nil {
+		file, err := os.OpenFile(a.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return "", errors.NewBackupError(a.path, "failed to create backup archive", err)
+		}
+		a.file = file
+		a.gz = gzip.NewWriter(file)
+		a.tw = tar.NewWriter(a.gz)
+	}
+
+	src, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.NewBackupError(filePath, "failed to open source file", err)
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return "", errors.NewBackupError(filePath, "failed to stat source file", err)
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return "", errors.NewBackupError(filePath, "failed to create archive header", err)
+	}
+	header.Name = member
+
+	if err := a.tw.WriteHeader(header); err != nil {
+		return "", errors.NewBackupError(a.path, "failed to write backup archive", err)
+	}
+	if _, err := io.Copy(a.tw, src); err != nil {
+		return "", errors.NewBackupError(a.path, "failed to write backup archive", err)
+	}
+	if err := a.tw.Flush(); err != nil {
+		return "", errors.NewBackupError(a.path, "failed to write backup archive", err)
+	}
+	if err := a.gz.Flush(); err != nil {
+		return "", errors.NewBackupError(a.path, "failed to write backup archive", err)
+	}
+
+	ref := a.path + archiveSeparator + member
+	a.refs[member] = ref
+	return ref, nil
+}
+
+// close writes the end of the archive. It does nothing if no file was
+// backed up.
+func (a *archiveWriter) close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+	defer func() { a.file = nil }()
+
+	if err := a.tw.Close(); err != nil {
+		_ = a.file.Close()
+		return errors.NewBackupError(a.path, "failed to finish backup archive", err)
+	}
+	if err := a.gz.Close(); err != nil {
+		_ = a.file.Close()
+		return errors.NewBackupError(a.path, "failed to finish backup archive", err)
+	}
+	if err := a.file.Close(); err != nil {
+		return errors.NewBackupError(a.path, "failed to finish backup archive", err)
+	}
+	return nil
+}
+
+// archiveMember returns the member name of filePath: its absolute path with
+// forward slashes and without the volume name or leading slash.
+func archiveMember(filePath string) string {
+	if abs, err := filepath.Abs(filePath); err == nil {
+		filePath = strings.TrimPrefix(abs, filepath.VolumeName(abs))
+	}
+	return strings.TrimPrefix(filepath.ToSlash(filePath), "/")
+}
+
+// splitArchiveRef splits a backup path recorded for an archived backup into
+// the archive path and member name. Backup files that exist are never
+// archive references.
+func splitArchiveRef(backupPath string) (archive, member string, ok bool) {
+	if _, err := os.Stat(backupPath); err == nil {
+		return "", "", false
+	}
+	archive, member, found := strings.Cut(backupPath, archiveSeparator)
+	if !found || archive == "" || member == "" {
+		return "", "", false
+	}
+	if info, err := os.Stat(archive); err != nil || !info.Mode().IsRegular() {
+		return "", "", false
+	}
+	return archive, member, true
+}
+
+// restoreFromArchive overwrites originalPath with the member of archive.
+// The archive may still be open for writing: every member written so far
+// can be read even though the end of the archive is missing.
+func restoreFromArchive(originalPath, archive, member string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return errors.NewBackupError(archive, "failed to open backup archive", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return errors.NewBackupError(archive, "invalid backup archive", err)
+	}
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return errors.NewBackupError(archive, "backup of "+member+" not found in archive", nil)
+		}
+		if err != nil {
+			return errors.NewBackupError(archive, "invalid backup archive", err)
+		}
+		if header.Name != member {
+			continue
+		}
+
+		dst, err := os.Create(originalPath)
+		if err != nil {
+			return errors.NewBackupError(originalPath, "failed to create original file", err)
+		}
+		defer dst.Close()
+
+		if _, err := io.Copy(dst, tr); err != nil {
+			return errors.NewBackupError(originalPath, "failed to restore file content", err)
+		}
+		_ = os.Chmod(originalPath, header.FileInfo().Mode())
+		return nil
+	}
+}
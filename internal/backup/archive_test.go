@@ -0,0 +1,114 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBackupArchive(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(t.TempDir(), "backups.tar.gz")
+
+	manager := NewBackupManager(true)
+	manager.SetBackupArchive(archive)
+
+	var paths []string
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("sub%d", i%2), "config.yaml")
+		if i >= 2 {
+			path = filepath.Join(dir, fmt.Sprintf("file%d.txt", i))
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("original "+path), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	refs := make([]string, len(paths))
+	errs := make([]error, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			refs[i], errs[i] = manager.BackupFile(path)
+		}(i, path)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("BackupFile(%s): %v", paths[i], err)
+		}
+		if !strings.HasPrefix(refs[i], archive+"#") {
+			t.Errorf("expected an archive reference for %s, got %s", paths[i], refs[i])
+		}
+	}
+
+	// A second backup in the same run keeps the content from before the run
+	if err := os.WriteFile(paths[0], []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := manager.BackupFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != refs[0] {
+		t.Errorf("expected the same reference for a repeated backup, got %s and %s", refs[0], ref)
+	}
+
+	// Members are readable before the archive is closed
+	if err := manager.RestoreFile(paths[0], refs[0]); err != nil {
+		t.Fatalf("restore before close: %v", err)
+	}
+	if content, _ := os.ReadFile(paths[0]); string(content) != "original "+paths[0] {
+		t.Errorf("restore before close gave %q", content)
+	}
+
+	if err := manager.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for i, path := range paths {
+		if err := os.WriteFile(path, []byte("changed"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewBackupManager(true).RestoreFile(path, refs[i]); err != nil {
+			t.Fatalf("RestoreFile(%s): %v", path, err)
+		}
+		if content, _ := os.ReadFile(path); string(content) != "original "+path {
+			t.Errorf("restored %s = %q", path, content)
+		}
+	}
+
+	if err := NewBackupManager(true).RestoreFile(paths[0], archive+"#missing.txt"); err == nil {
+		t.Error("expected an error for a member missing from the archive")
+	}
+}
+
+func TestBackupArchiveExists(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "backups.tar.gz")
+	if err := os.WriteFile(archive, []byte("previous run"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewBackupManager(true)
+	manager.SetBackupArchive(archive)
+	if _, err := manager.BackupFile(file); err == nil {
+		t.Error("expected an error when the archive already exists")
+	}
+	if content, _ := os.ReadFile(archive); string(content) != "previous run" {
+		t.Errorf("existing archive was overwritten: %q", content)
+	}
+}
@@ -6,22 +6,44 @@ package backup
 import (
 	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"remap/internal/errors"
 	"remap/internal/replacement"
+	"remap/internal/xattr"
 )
 
 // Manager handles file backup and restoration operations.
 // It provides configurable backup behavior and ensures data safety
 // during file modification operations through automatic backup creation.
 type Manager struct {
-	enabled bool
+	enabled        bool
+	preserveXattrs bool
+	hardLinks      bool
+
+	// backupDir, when set, holds the backups in a tree mirroring the files'
+	// paths relative to root instead of next to each file.
+	backupDir string
+	root      string
+
+	// archive, when set, receives every backup instead of separate files.
+	archive *archiveWriter
+
+	// link creates hard links; it defaults to os.Link and is replaced in
+	// tests to simulate cross-device backups.
+	link func(oldname, newname string) error
 }
 
 // NewBackupManager creates a Manager with the specified behavior.
@@ -30,9 +52,49 @@ type Manager struct {
 func NewBackupManager(enabled bool) *Manager {
 	return &Manager{
 		enabled: enabled,
+		link:    os.Link,
 	}
 }
 
+// SetHardLinks makes backups hard links to the original instead of copies
+// when possible. This is only safe when the original is then replaced by a
+// rename, which leaves the backup holding the old content; a file edited in
+// place would change its backup too.
+func (bm *Manager) SetHardLinks(hardLinks bool) {
+	bm.hardLinks = hardLinks
+}
+
+// SetPreserveXattrs enables best-effort copying of extended attributes.
+// When enabled, backups carry the original's xattrs and restores put them back,
+// so security labels and ACLs survive a backup/restore round trip.
+func (bm *Manager) SetPreserveXattrs(preserve bool) {
+	bm.preserveXattrs = preserve
+}
+
+// SetBackupDir makes backups go into dir, under the path each file has
+// relative to root, so files with the same name in different directories
+// never share a backup name. Files outside root are mirrored under their
+// absolute path. An empty dir keeps backups next to the originals.
+func (bm *Manager) SetBackupDir(dir, root string) {
+	bm.backupDir = dir
+	bm.root = root
+}
+
+// SetBackupArchive makes backups go into a single .tar.gz at path, keyed by
+// each file's absolute path. The archive must not exist yet and is only
+// complete once Close is called.
+func (bm *Manager) SetBackupArchive(path string) {
+	bm.archive = newArchiveWriter(path)
+}
+
+// Close finishes the backup archive, if any.
+func (bm *Manager) Close() error {
+	if bm.archive == nil {
+		return nil
+	}
+	return bm.archive.close()
+}
+
 // BackupFile creates a timestamped backup copy of the specified file.
 // This method provides atomic backup creation with unique naming to prevent
 // conflicts, enabling safe file modifications with recovery options.
@@ -41,37 +103,79 @@ func (bm *Manager) BackupFile(filePath string) (string, error) {
 		return "", nil
 	}
 
-	backupPath := generateBackupPath(filePath)
+	if bm.archive != nil {
+		return bm.archive.add(filePath)
+	}
+
+	backupPath := generateBackupPath(filePath, bm.backupDir, bm.root)
+	if bm.backupDir != "" {
+		if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+			return "", errors.NewBackupError(backupPath, "failed to create backup directory", err)
+		}
+	}
+
+	if bm.hardLinks {
+		if err := bm.link(filePath, backupPath); err == nil {
+			return backupPath, nil
+		}
+		// Cross-device (EXDEV) or unsupported by the filesystem: copy instead
+	}
+
+	if err := bm.copyFile(filePath, backupPath); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
 
-	srcFile, err := os.Open(filePath)
+// copyFile copies src to dst with its mode and, when enabled, its xattrs.
+func (bm *Manager) copyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
 	if err != nil {
-		return "", errors.NewBackupError(filePath, "failed to open source file", err)
+		return errors.NewBackupError(src, "failed to open source file", err)
 	}
 	defer srcFile.Close()
 
-	dstFile, err := os.Create(backupPath)
+	dstFile, err := os.Create(dst)
 	if err != nil {
-		return "", errors.NewBackupError(backupPath, "failed to create backup file", err)
+		return errors.NewBackupError(dst, "failed to create backup file", err)
 	}
 	defer dstFile.Close()
 
 	_, err = io.Copy(dstFile, srcFile)
 	if err != nil {
-		_ = os.Remove(backupPath)
-		return "", errors.NewBackupError(backupPath, "failed to copy file content", err)
+		_ = os.Remove(dst)
+		return errors.NewBackupError(dst, "failed to copy file content", err)
 	}
 
-	srcInfo, err := os.Stat(filePath)
+	srcInfo, err := os.Stat(src)
 	if err != nil {
-		return backupPath, nil
+		return nil
 	}
 
-	err = os.Chmod(backupPath, srcInfo.Mode())
+	err = os.Chmod(dst, srcInfo.Mode())
 	if err != nil {
-		return backupPath, nil
+		return nil
 	}
 
-	return backupPath, nil
+	if bm.preserveXattrs {
+		_ = xattr.Copy(src, dst)
+	}
+
+	return nil
+}
+
+// detachBackup turns a hard-link backup into an independent copy, so the
+// original can be edited in place without changing its backup.
+func (bm *Manager) detachBackup(backupPath string) error {
+	tempPath := backupPath + ".tmp"
+	if err := bm.copyFile(backupPath, tempPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, backupPath); err != nil {
+		_ = os.Remove(tempPath)
+		return errors.NewBackupError(backupPath, "failed to detach backup", err)
+	}
+	return nil
 }
 
 // RestoreFile overwrites the original file with contents from the backup.
@@ -82,10 +186,22 @@ func (bm *Manager) RestoreFile(originalPath, backupPath string) error {
 		return nil
 	}
 
-	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+	if archive, member, ok := splitArchiveRef(backupPath); ok {
+		return restoreFromArchive(originalPath, archive, member)
+	}
+
+	backupStat, err := os.Stat(backupPath)
+	if os.IsNotExist(err) {
 		return errors.NewBackupError(backupPath, "backup file not found", err)
 	}
 
+	if originalStat, statErr := os.Stat(originalPath); statErr == nil && err == nil && os.SameFile(originalStat, backupStat) {
+		// A hard-link backup of a file that was never replaced: the content is
+		// already the original one, and copying the file onto itself would
+		// truncate both. Detach the backup so it stays safe from later edits.
+		return bm.detachBackup(backupPath)
+	}
+
 	srcFile, err := os.Open(backupPath)
 	if err != nil {
 		return errors.NewBackupError(backupPath, "failed to open backup file", err)
@@ -113,6 +229,10 @@ func (bm *Manager) RestoreFile(originalPath, backupPath string) error {
 		return nil
 	}
 
+	if bm.preserveXattrs {
+		_ = xattr.Copy(backupPath, originalPath)
+	}
+
 	return nil
 }
 
@@ -132,54 +252,92 @@ func (bm *Manager) CleanupBackup(backupPath string) error {
 	return nil
 }
 
-func generateBackupPath(originalPath string) string {
+// generateBackupPath returns the timestamped backup path of originalPath:
+// next to it, or in backupDir at its path relative to root when backupDir
+// is set.
+func generateBackupPath(originalPath, backupDir, root string) string {
 	dir := filepath.Dir(originalPath)
 	base := filepath.Base(originalPath)
 	timestamp := time.Now().Format("20060102_150405")
 
+	if backupDir != "" {
+		dir = filepath.Join(backupDir, mirroredDir(dir, root))
+	}
+
 	return filepath.Join(dir, fmt.Sprintf("%s.%s.bak", base, timestamp))
 }
 
+// mirroredDir returns where dir goes in a backup tree: its path relative to
+// root, or its absolute path without the volume name when it is outside root.
+func mirroredDir(dir, root string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	if absRoot, err := filepath.Abs(root); err == nil && root != "" {
+		if rel, err := filepath.Rel(absRoot, absDir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return rel
+		}
+	}
+	return strings.TrimPrefix(absDir, filepath.VolumeName(absDir))
+}
+
 // RevertManager handles reverting changes from operation log files.
 // This component enables undo functionality by parsing operation logs
 // and applying reverse transformations to restore previous file states.
-type RevertManager struct{}
+type RevertManager struct {
+	workers  int
+	warnings []string
+}
 
 // NewRevertManager creates a RevertManager for undo operations.
 // This constructor initializes the revert system, which reads operation logs
 // and applies inverse transformations to restore files to their previous state.
 func NewRevertManager() *RevertManager {
-	return &RevertManager{}
+	return NewRevertManagerWithWorkers(0)
+}
+
+// NewRevertManagerWithWorkers creates a RevertManager reverting files in parallel.
+// A worker count of 0 selects the same CPU-based default as file processing,
+// keeping large log reverts fast without oversubscribing the machine.
+func NewRevertManagerWithWorkers(workers int) *RevertManager {
+	return &RevertManager{
+		workers: resolveWorkerCount(workers),
+	}
+}
+
+// Warnings returns the problems found reading the last log that did not stop
+// the revert, such as CSV rows skipped for a malformed line or column.
+func (rm *RevertManager) Warnings() []string {
+	return rm.warnings
 }
 
 // RevertFromLog reverses operations recorded in the specified log file.
 // This method reads the operation log and applies inverse transformations,
 // enabling users to undo bulk string replacement operations when needed.
+// Large JSON logs are streamed so they never have to fit in memory.
 func (rm *RevertManager) RevertFromLogWithFormat(logFilePath string, logFormat string) error {
-	logEntries, err := rm.parseLogFileWithFormat(logFilePath, logFormat)
-	if err != nil {
-		return err
-	}
-
+	var revertedCount int
 	var revertErrors []error
-	revertedCount := 0
 
-	for _, entry := range logEntries {
-		if !entry.Modified || entry.Error != "" {
-			continue // Skip entries that weren't modified or had errors
+	if logFormat == "json" && shouldStream(logFilePath) {
+		var err error
+		revertedCount, revertErrors, err = rm.revertFromJSONStream(logFilePath)
+		if err != nil {
+			return err
 		}
-
-		if err := rm.revertEntry(entry); err != nil {
-			revertErrors = append(revertErrors, err)
-		} else {
-			revertedCount++
+	} else {
+		logEntries, err := rm.parseLogFileWithFormat(logFilePath, logFormat)
+		if err != nil {
+			return err
 		}
+		revertedCount, revertErrors = processEntries(logEntries, rm.workers, rm.revertEntry)
 	}
 
 	if len(revertErrors) > 0 {
 		return errors.NewBackupError(logFilePath,
 			fmt.Sprintf("revert completed with %d successes and %d errors", revertedCount, len(revertErrors)),
-			revertErrors[0])
+			stderrors.Join(revertErrors...))
 	}
 
 	return nil
@@ -194,54 +352,102 @@ func (rm *RevertManager) RevertFromLog(logFilePath string) error {
 
 // LogEntry represents a single entry from a remap log file
 type LogEntry struct {
-	Timestamp    string                    `json:"timestamp"`
-	FilePath     string                    `json:"file_path"`
-	OriginalSize int64                     `json:"original_size"`
-	NewSize      int64                     `json:"new_size"`
-	Modified     bool                      `json:"modified"`
-	Replacements []replacement.Replacement `json:"replacements,omitempty"`
-	BackupPath   string                    `json:"backup_path,omitempty"`
-	Error        string                    `json:"error,omitempty"`
+	Timestamp    string                    `json:"timestamp" xml:"timestamp"`
+	FilePath     string                    `json:"file_path" xml:"file_path"`
+	OriginalSize int64                     `json:"original_size" xml:"original_size"`
+	NewSize      int64                     `json:"new_size" xml:"new_size"`
+	Modified     bool                      `json:"modified" xml:"modified"`
+	Replacements []replacement.Replacement `json:"replacements,omitempty" xml:"replacements>replacement"`
+	BackupPath   string                    `json:"backup_path,omitempty" xml:"backup_path"`
+	Error        string                    `json:"error,omitempty" xml:"error"`
+
+	// options are the matching options recorded in the log's summary
+	options LogOptions
 }
 
 // parseLogFileWithFormat reads and parses a log file in the specified format
 func (rm *RevertManager) parseLogFileWithFormat(logFilePath string, logFormat string) ([]LogEntry, error) {
+	entries, warnings, err := readLog(logFilePath, logFormat)
+	rm.warnings = warnings
+	return entries, err
+}
+
+// parseLogFile reads and parses a log file in either JSON or CSV format
+func (rm *RevertManager) parseLogFile(logFilePath string) ([]LogEntry, error) {
+	return rm.parseLogFileWithFormat(logFilePath, "json") // Default to JSON
+}
+
+// readLog reads and parses a log file in the specified format, recording on
+// every entry the matching options of the log's summary. Revert and apply
+// both read their logs through it.
+func readLog(logFilePath string, logFormat string) ([]LogEntry, []string, error) {
 	file, err := os.Open(logFilePath)
 	if err != nil {
-		return nil, errors.NewFileError(logFilePath, "failed to open log file", err)
+		return nil, nil, errors.NewFileError(logFilePath, "failed to open log file", err)
 	}
 	defer file.Close()
 
 	content, err := io.ReadAll(file)
 	if err != nil {
-		return nil, errors.NewFileError(logFilePath, "failed to read log file", err)
+		return nil, nil, errors.NewFileError(logFilePath, "failed to read log file", err)
 	}
 
 	contentStr := string(content)
 
+	entries, warnings, err := parseLog(logFilePath, logFormat, contentStr)
+	if err != nil {
+		return nil, warnings, err
+	}
+	return withLogOptions(entries, readLogOptions(logFormat, contentStr)), warnings, nil
+}
+
+// parseLog parses the entries of a log in the specified format. The
+// warnings list the problems that did not stop parsing, such as CSV rows
+// skipped for a malformed line or column.
+func parseLog(logFilePath, logFormat, content string) ([]LogEntry, []string, error) {
+	var entries []LogEntry
+	var err error
 	switch logFormat {
 	case "json":
 		// Extract JSON from the content (skip any verbose output lines)
-		jsonStart := strings.Index(contentStr, "{")
+		jsonStart := JSONReportStart(content)
 		if jsonStart == -1 {
-			return nil, errors.NewParsingError(logFilePath, "no JSON content found in log file", nil)
+			return nil, nil, errors.NewParsingError(logFilePath, "no JSON content found in log file", nil)
 		}
-		jsonContent := contentStr[jsonStart:]
-		return rm.parseJSONLog([]byte(jsonContent))
+		entries, err = parseJSONLog([]byte(content[jsonStart:]))
 	case "csv":
-		return rm.parseCSVLog(contentStr)
+		return parseCSVLog(content)
+	case "xml":
+		entries, err = parseXMLLog(logFilePath, content)
+	case "ndjson":
+		entries, err = parseNDJSONLog(logFilePath, content)
 	default:
-		return nil, errors.NewParsingError(logFilePath, fmt.Sprintf("unsupported log format: %s", logFormat), nil)
+		err = errors.NewParsingError(logFilePath, fmt.Sprintf("unsupported log format: %s", logFormat), nil)
 	}
+	return entries, nil, err
 }
 
-// parseLogFile reads and parses a log file in either JSON or CSV format
-func (rm *RevertManager) parseLogFile(logFilePath string) ([]LogEntry, error) {
-	return rm.parseLogFileWithFormat(logFilePath, "json") // Default to JSON
+// jsonReportOpening is how the logger starts a JSON report: indented, with
+// the summary first.
+const jsonReportOpening = "{\n  \"summary\""
+
+// JSONReportStart returns the offset of the JSON report in the content of a
+// log, after any verbose output, or -1 when there is none. Verbose lines can
+// hold braces, as in a path like t/{d}/b.txt, so the report is found by its
+// opening line; a log without one, such as a hand-written report, starts at
+// its first brace.
+func JSONReportStart(content string) int {
+	if strings.HasPrefix(content, jsonReportOpening) {
+		return 0
+	}
+	if i := strings.Index(content, "\n"+jsonReportOpening); i != -1 {
+		return i + 1
+	}
+	return strings.Index(content, "{")
 }
 
 // parseJSONLog parses a JSON format log file
-func (rm *RevertManager) parseJSONLog(content []byte) ([]LogEntry, error) {
+func parseJSONLog(content []byte) ([]LogEntry, error) {
 	var report struct {
 		Entries []LogEntry `json:"entries"`
 	}
@@ -253,8 +459,58 @@ func (rm *RevertManager) parseJSONLog(content []byte) ([]LogEntry, error) {
 	return report.Entries, nil
 }
 
-// parseCSVLog parses a CSV format log file
-func (rm *RevertManager) parseCSVLog(content string) ([]LogEntry, error) {
+// parseXMLLog parses an XML format log file, skipping any verbose output
+// lines written before the report.
+func parseXMLLog(logFilePath, content string) ([]LogEntry, error) {
+	xmlStart := strings.Index(content, "<?xml")
+	if xmlStart == -1 {
+		xmlStart = strings.Index(content, "<remap_report")
+	}
+	if xmlStart == -1 {
+		return nil, errors.NewParsingError(logFilePath, "no XML content found in log file", nil)
+	}
+
+	var report struct {
+		Entries []LogEntry `xml:"entries>entry"`
+	}
+	if err := xml.Unmarshal([]byte(content[xmlStart:]), &report); err != nil {
+		return nil, errors.NewParsingError(logFilePath, "invalid XML log", err)
+	}
+
+	return report.Entries, nil
+}
+
+// parseNDJSONLog parses an NDJSON log: one JSON entry per line and a final
+// summary line, which is skipped like any verbose output line that is not a
+// JSON object.
+func parseNDJSONLog(logFilePath, content string) ([]LogEntry, error) {
+	var entries []LogEntry
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var record struct {
+			LogEntry
+			Summary json.RawMessage `json:"summary"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, errors.NewParsingError(logFilePath, fmt.Sprintf("invalid JSON on line %d", i+1), err)
+		}
+		if record.Summary != nil {
+			continue
+		}
+		entries = append(entries, record.LogEntry)
+	}
+
+	return entries, nil
+}
+
+// parseCSVLog parses a CSV format log file, skipping commented lines. The
+// warnings list the rows skipped for a malformed line or column.
+func parseCSVLog(content string) ([]LogEntry, []string, error) {
+	var warnings []string
 	lines := strings.Split(content, "\n")
 
 	// Find the start of CSV data (skip comment lines)
@@ -268,22 +524,23 @@ func (rm *RevertManager) parseCSVLog(content string) ([]LogEntry, error) {
 	}
 
 	if len(csvLines) == 0 {
-		return nil, errors.NewParsingError("", "no CSV data found in log file", nil)
+		return nil, nil, errors.NewParsingError("", "no CSV data found in log file", nil)
 	}
 
 	reader := csv.NewReader(strings.NewReader(strings.Join(csvLines, "\n")))
 	reader.FieldsPerRecord = -1 // Allow variable number of fields
 	records, err := reader.ReadAll()
 	if err != nil {
-		return nil, errors.NewParsingError("", "failed to parse CSV data", err)
+		return nil, nil, errors.NewParsingError("", "failed to parse CSV data", err)
 	}
 
 	if len(records) == 0 {
-		return nil, errors.NewParsingError("", "no records found in CSV", nil)
+		return nil, nil, errors.NewParsingError("", "no records found in CSV", nil)
 	}
 
 	// Group CSV records by file path to reconstruct log entries
 	entryMap := make(map[string]*LogEntry)
+	columns := newCSVReportColumns(records[0])
 
 	for i, record := range records {
 		if i == 0 {
@@ -298,8 +555,12 @@ func (rm *RevertManager) parseCSVLog(content string) ([]LogEntry, error) {
 		filePath := record[0]
 		from := record[1]
 		to := record[2]
-		// line := record[3]
-		// column := record[4]
+		// Positions let revert touch only the text the run wrote
+		line, column, err := parseCSVPosition(record)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("skipped CSV row %d for %s: %v", i+1, filePath, err))
+			continue
+		}
 
 		entry, exists := entryMap[filePath]
 		if !exists {
@@ -313,9 +574,12 @@ func (rm *RevertManager) parseCSVLog(content string) ([]LogEntry, error) {
 
 		// Add this replacement to the entry
 		replacement := replacement.Replacement{
-			From: from,
-			To:   to,
+			From:   from,
+			To:     to,
+			Line:   line,
+			Column: column,
 		}
+		replacement.OriginalText, replacement.ByteOffset = columns.recordedText(record)
 		entry.Replacements = append(entry.Replacements, replacement)
 	}
 
@@ -325,7 +589,55 @@ func (rm *RevertManager) parseCSVLog(content string) ([]LogEntry, error) {
 		entries = append(entries, *entry)
 	}
 
-	return entries, nil
+	return entries, warnings, nil
+}
+
+// csvReportColumns locates the optional columns of a CSV report by name, so
+// reports written before they existed, or with --csv-mapping-id, still parse.
+type csvReportColumns struct {
+	originalText int
+	byteOffset   int
+}
+
+func newCSVReportColumns(header []string) csvReportColumns {
+	columns := csvReportColumns{originalText: -1, byteOffset: -1}
+	for i, name := range header {
+		switch strings.TrimSpace(name) {
+		case "original_text":
+			columns.originalText = i
+		case "byte_offset":
+			columns.byteOffset = i
+		}
+	}
+	return columns
+}
+
+// recordedText returns the text a CSV report row matched and its byte
+// offset in the file. Without both, the text is empty and the row is undone
+// by line and column instead.
+func (c csvReportColumns) recordedText(record []string) (string, int64) {
+	if c.originalText < 0 || c.byteOffset < 0 || c.originalText >= len(record) || c.byteOffset >= len(record) {
+		return "", 0
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(record[c.byteOffset]), 10, 64)
+	if err != nil || offset < 0 {
+		return "", 0
+	}
+	return record[c.originalText], offset
+}
+
+// parseCSVPosition reads the 1-based line and column fields of a CSV report
+// row.
+func parseCSVPosition(record []string) (int, int, error) {
+	line, err := strconv.Atoi(strings.TrimSpace(record[3]))
+	if err != nil || line < 1 {
+		return 0, 0, fmt.Errorf("invalid line %q", record[3])
+	}
+	column, err := strconv.Atoi(strings.TrimSpace(record[4]))
+	if err != nil || column < 1 {
+		return 0, 0, fmt.Errorf("invalid column %q", record[4])
+	}
+	return line, column, nil
 }
 
 // revertEntry reverts a single log entry by either restoring from backup or applying reverse replacements
@@ -341,9 +653,11 @@ func (rm *RevertManager) revertEntry(entry LogEntry) error {
 
 // restoreFromBackup restores a file from its backup
 func (rm *RevertManager) restoreFromBackup(originalPath, backupPath string) error {
-	// Check if backup exists
-	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
-		return errors.NewBackupError(backupPath, "backup file not found", err)
+	// Check if backup exists, as a file or as an archive member
+	if _, _, ok := splitArchiveRef(backupPath); !ok {
+		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+			return errors.NewBackupError(backupPath, "backup file not found", err)
+		}
 	}
 
 	backupManager := NewBackupManager(true)
@@ -358,181 +672,296 @@ func (rm *RevertManager) reverseReplacements(entry LogEntry) error {
 		return errors.NewFileError(entry.FilePath, "failed to read file for revert", err)
 	}
 
-	// Apply reverse replacements (swap From and To)
-	modifiedContent := string(content)
-	for _, repl := range entry.Replacements {
-		// Apply the reverse replacement: replace `To` with `From`
-		modifiedContent = strings.ReplaceAll(modifiedContent, repl.To, repl.From)
+	modifiedContent, err := revertContent(entry.FilePath, string(content), entry.Replacements, entry.options)
+	if err != nil {
+		return err
 	}
 
-	// Write the reverted content back to the file
-	return os.WriteFile(entry.FilePath, []byte(modifiedContent), 0644)
+	// Write the reverted content back to the file, atomically: revert is the recovery path
+	return replaceFile(entry.FilePath, []byte(modifiedContent))
 }
 
-// ApplyManager handles applying changes from operation log files.
-// This component enables redo functionality by parsing operation logs
-// and applying the transformations to restore or apply previous file states.
-type ApplyManager struct{}
-
-// NewApplyManager creates an ApplyManager for apply operations.
-// This constructor initializes the apply system, which reads operation logs
-// and applies transformations to files based on the logged operations.
-func NewApplyManager() *ApplyManager {
-	return &ApplyManager{}
-}
-
-// ApplyFromLogWithFormat applies operations recorded in the specified log file.
-// This method reads the operation log and applies transformations,
-// enabling users to reapply bulk string replacement operations from logs.
-// It skips commented lines in CSV format.
-func (am *ApplyManager) ApplyFromLogWithFormat(logFilePath string, logFormat string) error {
-	logEntries, err := am.parseLogFileWithFormat(logFilePath, logFormat)
-	if err != nil {
-		return err
+// revertContent undoes the replacements of a log entry in content. Only the
+// text the run wrote is touched: replacements are undone at the positions the
+// log records, so a destination that also occurs in untouched text (or inside
+// a longer word) is left alone. A file that no longer holds each destination
+// where expected is reported as an error rather than reverted blindly. Logs
+// that record no positions at all fall back to swapping every occurrence of
+// To for From.
+func revertContent(filePath, content string, replacements []replacement.Replacement, options LogOptions) (string, error) {
+	switch {
+	case hasOriginalText(replacements):
+		return restoreOriginalText(filePath, content, replacements)
+	case hasLinePositions(replacements):
+		return restoreAtLines(filePath, content, replacements, options)
 	}
 
-	var applyErrors []error
-	appliedCount := 0
+	// Match the destinations the way the logged run wrote them
+	opts := options.reverseMatchOptions()
+	for _, repl := range replacements {
+		content = replacement.ReplaceMatches(content, repl.To, repl.From, opts)
+	}
+	return content, nil
+}
 
-	for _, entry := range logEntries {
-		if !entry.Modified || entry.Error != "" {
-			continue // Skip entries that weren't modified or had errors
+// restoreOriginalText undoes replacements at their recorded byte offsets,
+// writing back the exact text each one matched so case-insensitive runs
+// revert to the original casing (e.g. "Color" and "COLOR" rather than "color"
+// twice).
+func restoreOriginalText(filePath, content string, replacements []replacement.Replacement) (string, error) {
+	ordered := append([]replacement.Replacement(nil), replacements...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].ByteOffset < ordered[j].ByteOffset
+	})
+
+	var result strings.Builder
+	written := 0
+	// shift is how far text has moved from its original offset by earlier replacements
+	shift := 0
+	for _, repl := range ordered {
+		start := int(repl.ByteOffset) + shift
+		end := start + len(repl.To)
+		if start < written {
+			return "", errors.NewReplacementError(filePath,
+				fmt.Sprintf("replacements overlap at line %d and cannot be reverted as logged", repl.Line), nil)
 		}
-
-		if err := am.applyEntry(entry); err != nil {
-			applyErrors = append(applyErrors, err)
-		} else {
-			appliedCount++
+		if end > len(content) || content[start:end] != repl.To {
+			return "", staleRevertError(filePath, repl)
 		}
-	}
 
-	if len(applyErrors) > 0 {
-		return errors.NewBackupError(logFilePath,
-			fmt.Sprintf("apply completed with %d successes and %d errors", appliedCount, len(applyErrors)),
-			applyErrors[0])
+		result.WriteString(content[written:start])
+		result.WriteString(repl.OriginalText)
+		written = end
+		shift += len(repl.To) - len(repl.OriginalText)
 	}
+	result.WriteString(content[written:])
 
-	return nil
+	return result.String(), nil
 }
 
-// parseLogFileWithFormat reads and parses a log file in the specified format
-// This reuses the same parsing logic as RevertManager, including comment skipping
-func (am *ApplyManager) parseLogFileWithFormat(logFilePath string, logFormat string) ([]LogEntry, error) {
-	file, err := os.Open(logFilePath)
-	if err != nil {
-		return nil, errors.NewFileError(logFilePath, "failed to open log file", err)
+// hasLinePositions reports whether every replacement records its line and
+// column, as CSV reports and logs written before OriginalText existed do.
+func hasLinePositions(replacements []replacement.Replacement) bool {
+	for _, repl := range replacements {
+		if repl.Line <= 0 || repl.Column <= 0 {
+			return false
+		}
 	}
-	defer file.Close()
+	return len(replacements) > 0
+}
 
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return nil, errors.NewFileError(logFilePath, "failed to read log file", err)
+// restoreAtLines undoes replacements at their recorded lines and columns,
+// for logs that hold neither the matched text nor byte offsets. Columns count
+// runes on the line as it was before the run, so each is shifted by the
+// replacements already made earlier on its line. The source is written back
+// in the casing options imply: as mapped, or with --preserve-case in the
+// casing the destination was given.
+func restoreAtLines(filePath, content string, replacements []replacement.Replacement, options LogOptions) (string, error) {
+	for _, repl := range replacements {
+		if strings.Contains(repl.From, "\n") || strings.Contains(repl.To, "\n") {
+			// Line numbers no longer match the file; only the swap remains
+			return revertContent(filePath, content, stripPositions(replacements), options)
+		}
 	}
 
-	contentStr := string(content)
+	ordered := append([]replacement.Replacement(nil), replacements...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Line != ordered[j].Line {
+			return ordered[i].Line < ordered[j].Line
+		}
+		return ordered[i].Column < ordered[j].Column
+	})
+
+	preserveCase := options.PreserveCase && !options.CaseSensitive
+	lineStarts := lineOffsets(content)
+
+	var result strings.Builder
+	written := 0
+	line, runeShift := 0, 0
+	for _, repl := range ordered {
+		if repl.Line != line {
+			line, runeShift = repl.Line, 0
+		}
+		if repl.Line > len(lineStarts) {
+			return "", staleRevertError(filePath, repl)
+		}
 
-	switch logFormat {
-	case "json":
-		// Extract JSON from the content (skip any verbose output lines)
-		jsonStart := strings.Index(contentStr, "{")
-		if jsonStart == -1 {
-			return nil, errors.NewParsingError(logFilePath, "no JSON content found in log file", nil)
+		lineStart := lineStarts[repl.Line-1]
+		offset, ok := runeOffset(content[lineStart:], repl.Column-1+runeShift)
+		if !ok {
+			return "", staleRevertError(filePath, repl)
+		}
+		start := lineStart + offset
+		end := start + len(repl.To)
+		if start < written {
+			return "", errors.NewReplacementError(filePath,
+				fmt.Sprintf("replacements overlap at line %d and cannot be reverted as logged", repl.Line), nil)
+		}
+		if end > len(content) {
+			return "", staleRevertError(filePath, repl)
 		}
-		jsonContent := contentStr[jsonStart:]
-		return am.parseJSONLog([]byte(jsonContent))
-	case "csv":
-		return am.parseCSVLog(contentStr)
-	default:
-		return nil, errors.NewParsingError(logFilePath, fmt.Sprintf("unsupported log format: %s", logFormat), nil)
-	}
-}
 
-// parseJSONLog parses a JSON format log file
-func (am *ApplyManager) parseJSONLog(content []byte) ([]LogEntry, error) {
-	var report struct {
-		Entries []LogEntry `json:"entries"`
-	}
+		found, source := content[start:end], repl.From
+		switch {
+		case preserveCase && strings.EqualFold(found, repl.To):
+			source = replacement.MatchCase(found, repl.From)
+		case found != repl.To:
+			return "", staleRevertError(filePath, repl)
+		}
 
-	if err := json.Unmarshal(content, &report); err != nil {
-		return nil, err
+		result.WriteString(content[written:start])
+		result.WriteString(source)
+		written = end
+		runeShift += utf8.RuneCountInString(repl.To) - utf8.RuneCountInString(repl.From)
 	}
+	result.WriteString(content[written:])
 
-	return report.Entries, nil
+	return result.String(), nil
 }
 
-// parseCSVLog parses a CSV format log file, skipping commented lines
-func (am *ApplyManager) parseCSVLog(content string) ([]LogEntry, error) {
-	lines := strings.Split(content, "\n")
+// stripPositions returns copies of replacements without their positions.
+func stripPositions(replacements []replacement.Replacement) []replacement.Replacement {
+	stripped := make([]replacement.Replacement, len(replacements))
+	for i, repl := range replacements {
+		stripped[i] = replacement.Replacement{From: repl.From, To: repl.To}
+	}
+	return stripped
+}
 
-	// Find the start of CSV data (skip comment lines starting with #)
-	var csvLines []string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue // Skip empty lines and commented lines
+// lineOffsets returns the byte offset at which each line of content starts.
+func lineOffsets(content string) []int {
+	offsets := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			offsets = append(offsets, i+1)
 		}
-		csvLines = append(csvLines, line)
 	}
+	return offsets
+}
 
-	if len(csvLines) == 0 {
-		return nil, errors.NewParsingError("", "no CSV data found in log file", nil)
+// runeOffset returns the byte offset of the rune at index n of line, which
+// must lie within the line.
+func runeOffset(line string, n int) (int, bool) {
+	if n < 0 {
+		return 0, false
 	}
+	offset := 0
+	for ; n > 0; n-- {
+		if offset >= len(line) || line[offset] == '\n' {
+			return 0, false
+		}
+		_, size := utf8.DecodeRuneInString(line[offset:])
+		offset += size
+	}
+	return offset, true
+}
 
-	reader := csv.NewReader(strings.NewReader(strings.Join(csvLines, "\n")))
-	reader.FieldsPerRecord = -1 // Allow variable number of fields
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, errors.NewParsingError("", "failed to parse CSV data", err)
+// staleRevertError reports a replacement the file no longer holds where the
+// log recorded it.
+func staleRevertError(filePath string, repl replacement.Replacement) error {
+	return errors.NewReplacementError(filePath,
+		fmt.Sprintf("line %d no longer holds %q where the log recorded it; the file changed since the log was written", repl.Line, repl.To), nil)
+}
+
+// hasOriginalText reports whether every replacement records the text it
+// matched, which logs written before OriginalText existed lack.
+func hasOriginalText(replacements []replacement.Replacement) bool {
+	for _, repl := range replacements {
+		if repl.OriginalText == "" {
+			return false
+		}
 	}
+	return len(replacements) > 0
+}
 
-	if len(records) == 0 {
-		return nil, errors.NewParsingError("", "no records found in CSV", nil)
+// applyRecordedText replaces the text each replacement matched at its
+// recorded position with its destination. It fails rather than diverge from
+// the logged plan when the file no longer holds the matched text where it was
+// found, or when two replacements overlap.
+func applyRecordedText(filePath, content string, replacements []replacement.Replacement) (string, error) {
+	ordered := append([]replacement.Replacement(nil), replacements...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].ByteOffset < ordered[j].ByteOffset
+	})
+
+	var result strings.Builder
+	written := 0
+	for _, repl := range ordered {
+		start := int(repl.ByteOffset)
+		end := start + len(repl.OriginalText)
+		if start < written {
+			return "", errors.NewReplacementError(filePath,
+				fmt.Sprintf("replacements overlap at line %d and cannot be applied as logged", repl.Line), nil)
+		}
+		if end > len(content) || content[start:end] != repl.OriginalText {
+			return "", errors.NewReplacementError(filePath,
+				fmt.Sprintf("line %d no longer holds %q where the log found it; the file changed since the log was written", repl.Line, repl.OriginalText), nil)
+		}
+
+		result.WriteString(content[written:start])
+		result.WriteString(repl.To)
+		written = end
 	}
+	result.WriteString(content[written:])
 
-	// Group CSV records by file path to reconstruct log entries
-	entryMap := make(map[string]*LogEntry)
+	return result.String(), nil
+}
 
-	for i, record := range records {
-		if i == 0 {
-			// Skip header row
-			continue
-		}
+// ApplyManager handles applying changes from operation log files.
+// This component enables redo functionality by parsing operation logs
+// and applying the transformations to restore or apply previous file states.
+type ApplyManager struct {
+	workers  int
+	warnings []string
+}
 
-		if len(record) < 5 {
-			continue // Skip malformed records
-		}
+// NewApplyManager creates an ApplyManager for apply operations.
+// This constructor initializes the apply system, which reads operation logs
+// and applies transformations to files based on the logged operations.
+func NewApplyManager() *ApplyManager {
+	return NewApplyManagerWithWorkers(0)
+}
 
-		filePath := record[0]
-		from := record[1]
-		to := record[2]
-		// line := record[3]
-		// column := record[4]
+// NewApplyManagerWithWorkers creates an ApplyManager applying files in parallel.
+// A worker count of 0 selects the same CPU-based default as file processing.
+func NewApplyManagerWithWorkers(workers int) *ApplyManager {
+	return &ApplyManager{
+		workers: resolveWorkerCount(workers),
+	}
+}
 
-		entry, exists := entryMap[filePath]
-		if !exists {
-			entry = &LogEntry{
-				FilePath:     filePath,
-				Modified:     true,
-				Replacements: []replacement.Replacement{},
-			}
-			entryMap[filePath] = entry
-		}
+// Warnings returns the problems found reading the last log that did not stop
+// the apply, such as CSV rows skipped for a malformed line or column.
+func (am *ApplyManager) Warnings() []string {
+	return am.warnings
+}
 
-		// Add this replacement to the entry
-		replacement := replacement.Replacement{
-			From: from,
-			To:   to,
-		}
-		entry.Replacements = append(entry.Replacements, replacement)
+// ApplyFromLogWithFormat applies operations recorded in the specified log file.
+// This method reads the operation log and applies transformations,
+// enabling users to reapply bulk string replacement operations from logs.
+// It skips commented lines in CSV format.
+func (am *ApplyManager) ApplyFromLogWithFormat(logFilePath string, logFormat string) error {
+	logEntries, err := am.parseLogFileWithFormat(logFilePath, logFormat)
+	if err != nil {
+		return err
 	}
 
-	// Convert map to slice
-	var entries []LogEntry
-	for _, entry := range entryMap {
-		entries = append(entries, *entry)
+	appliedCount, applyErrors := processEntries(logEntries, am.workers, am.applyEntry)
+
+	if len(applyErrors) > 0 {
+		return errors.NewBackupError(logFilePath,
+			fmt.Sprintf("apply completed with %d successes and %d errors", appliedCount, len(applyErrors)),
+			stderrors.Join(applyErrors...))
 	}
 
-	return entries, nil
+	return nil
+}
+
+// parseLogFileWithFormat reads and parses a log file in the specified format
+func (am *ApplyManager) parseLogFileWithFormat(logFilePath string, logFormat string) ([]LogEntry, error) {
+	entries, warnings, err := readLog(logFilePath, logFormat)
+	am.warnings = warnings
+	return entries, err
 }
 
 // applyEntry applies a single log entry by applying the replacements
@@ -540,7 +969,12 @@ func (am *ApplyManager) applyEntry(entry LogEntry) error {
 	return am.applyReplacements(entry)
 }
 
-// applyReplacements applies the replacements to a file
+// applyReplacements applies the replacements to a file. Replacements logged
+// with the text they matched are applied at their recorded positions, so the
+// file ends up exactly as the logged run (or dry run) made it or showed it,
+// whatever its case sensitivity; logs without that text (CSV reports, older
+// logs) fall back to replacing every occurrence of From, matched with the
+// options recorded in the log.
 func (am *ApplyManager) applyReplacements(entry LogEntry) error {
 	// Read the current file content
 	content, err := os.ReadFile(entry.FilePath)
@@ -548,13 +982,95 @@ func (am *ApplyManager) applyReplacements(entry LogEntry) error {
 		return errors.NewFileError(entry.FilePath, "failed to read file for apply", err)
 	}
 
-	// Apply replacements (From -> To)
-	modifiedContent := string(content)
-	for _, repl := range entry.Replacements {
-		// Apply the replacement: replace `From` with `To`
-		modifiedContent = strings.ReplaceAll(modifiedContent, repl.From, repl.To)
+	var modifiedContent string
+	if hasOriginalText(entry.Replacements) {
+		modifiedContent, err = applyRecordedText(entry.FilePath, string(content), entry.Replacements)
+		if err != nil {
+			return err
+		}
+	} else {
+		// Match the sources the way the logged run did
+		modifiedContent = string(content)
+		opts := entry.options.forwardMatchOptions()
+		for _, repl := range entry.Replacements {
+			modifiedContent = replacement.ReplaceMatches(modifiedContent, repl.From, repl.To, opts)
+		}
 	}
 
 	// Write the modified content back to the file
-	return os.WriteFile(entry.FilePath, []byte(modifiedContent), 0644)
+	return replaceFile(entry.FilePath, []byte(modifiedContent))
+}
+
+// resolveWorkerCount returns the worker pool size for revert and apply operations.
+// Zero or negative values fall back to the CPU count capped at 8, matching the
+// default used by the concurrent file processor.
+func resolveWorkerCount(workers int) int {
+	if workers > 0 {
+		return workers
+	}
+
+	workers = runtime.NumCPU()
+	if workers > 8 {
+		workers = 8
+	}
+	return workers
+}
+
+// processEntries runs fn over every modified, error-free log entry using a bounded
+// worker pool. Entries are grouped by file path and each group is handled by a
+// single worker, so files stay independent while repeated entries for the same
+// file are still applied in log order. Errors are returned in log order.
+func processEntries(entries []LogEntry, workers int, fn func(LogEntry) error) (int, []error) {
+	var groups [][]LogEntry
+	groupIndex := make(map[string]int)
+
+	for _, entry := range entries {
+		if !entry.Modified || entry.Error != "" {
+			continue // Skip entries that weren't modified or had errors
+		}
+
+		index, exists := groupIndex[entry.FilePath]
+		if !exists {
+			index = len(groups)
+			groupIndex[entry.FilePath] = index
+			groups = append(groups, nil)
+		}
+		groups[index] = append(groups[index], entry)
+	}
+
+	groupErrors := make([][]error, len(groups))
+	groupSuccesses := make([]int, len(groups))
+
+	jobs := make(chan int, len(groups))
+	for i := range groups {
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(groups); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				for _, entry := range groups[index] {
+					if err := fn(entry); err != nil {
+						groupErrors[index] = append(groupErrors[index], err)
+					} else {
+						groupSuccesses[index]++
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	successCount := 0
+	var allErrors []error
+	for i := range groups {
+		successCount += groupSuccesses[i]
+		allErrors = append(allErrors, groupErrors[i]...)
+	}
+
+	return successCount, allErrors
 }
@@ -2,11 +2,15 @@ package backup
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 
+	"remap/internal/config"
+	"remap/internal/parser"
 	"remap/internal/replacement"
 )
 
@@ -211,6 +215,95 @@ func TestRestoreFile(t *testing.T) {
 	}
 }
 
+func TestBackupFileHardLinks(t *testing.T) {
+	tests := []struct {
+		name       string
+		link       func(oldname, newname string) error
+		expectLink bool
+	}{
+		{name: "same filesystem", link: os.Link, expectLink: true},
+		{
+			name: "cross-device falls back to copy",
+			link: func(oldname, newname string) error {
+				return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: syscall.EXDEV}
+			},
+			expectLink: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "test.txt")
+			if err := os.WriteFile(filePath, []byte("original"), 0640); err != nil {
+				t.Fatal(err)
+			}
+
+			manager := NewBackupManager(true)
+			manager.SetHardLinks(true)
+			manager.link = tt.link
+
+			backupPath, err := manager.BackupFile(filePath)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			originalInfo, err := os.Stat(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			backupInfo, err := os.Stat(backupPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if linked := os.SameFile(originalInfo, backupInfo); linked != tt.expectLink {
+				t.Errorf("expected hard link %v, got %v", tt.expectLink, linked)
+			}
+			if backupInfo.Mode() != originalInfo.Mode() {
+				t.Errorf("expected backup mode %v, got %v", originalInfo.Mode(), backupInfo.Mode())
+			}
+
+			content, err := os.ReadFile(backupPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != "original" {
+				t.Errorf("expected backup content %q, got %q", "original", content)
+			}
+		})
+	}
+}
+
+func TestRestoreFileDetachesHardLinkBackup(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.txt")
+	if err := os.WriteFile(filePath, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewBackupManager(true)
+	manager.SetHardLinks(true)
+	backupPath, err := manager.BackupFile(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A failed write leaves the original linked to its backup; restoring it
+	// must neither truncate the shared content nor keep the link
+	if err := manager.RestoreFile(filePath, backupPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filePath, []byte("edited in place"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "original" {
+		t.Errorf("expected backup to keep %q, got %q", "original", content)
+	}
+}
+
 func TestCleanupBackup(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -301,7 +394,7 @@ func TestGenerateBackupPath(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := generateBackupPath(tt.originalPath)
+			result := generateBackupPath(tt.originalPath, "", "")
 
 			if result == "" {
 				t.Error("expected non-empty backup path")
@@ -327,6 +420,62 @@ func TestGenerateBackupPath(t *testing.T) {
 	}
 }
 
+func TestBackupFileBackupDir(t *testing.T) {
+	root := t.TempDir()
+	backupDir := filepath.Join(t.TempDir(), "backups")
+	outside := t.TempDir()
+
+	files := map[string]string{
+		filepath.Join(root, "a", "config.yaml"): "a: 1\n",
+		filepath.Join(root, "b", "config.yaml"): "b: 2\n",
+		filepath.Join(outside, "config.yaml"):   "outside\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manager := NewBackupManager(true)
+	manager.SetBackupDir(backupDir, root)
+
+	tests := []struct {
+		path    string
+		wantDir string
+	}{
+		{path: filepath.Join(root, "a", "config.yaml"), wantDir: filepath.Join(backupDir, "a")},
+		{path: filepath.Join(root, "b", "config.yaml"), wantDir: filepath.Join(backupDir, "b")},
+		{path: filepath.Join(outside, "config.yaml"), wantDir: filepath.Join(backupDir, outside)},
+	}
+
+	for _, tt := range tests {
+		backupPath, err := manager.BackupFile(tt.path)
+		if err != nil {
+			t.Fatalf("BackupFile(%s): %v", tt.path, err)
+		}
+		if filepath.Dir(backupPath) != tt.wantDir {
+			t.Errorf("backup of %s in %s, want %s", tt.path, filepath.Dir(backupPath), tt.wantDir)
+		}
+
+		if err := os.WriteFile(tt.path, []byte("changed\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := manager.RestoreFile(tt.path, backupPath); err != nil {
+			t.Fatalf("RestoreFile(%s): %v", tt.path, err)
+		}
+		if content, _ := os.ReadFile(tt.path); string(content) != files[tt.path] {
+			t.Errorf("restored %s = %q, want %q", tt.path, content, files[tt.path])
+		}
+	}
+
+	if entries, _ := os.ReadDir(filepath.Join(root, "a")); len(entries) != 1 {
+		t.Errorf("expected no backup next to the original, found %d files", len(entries))
+	}
+}
+
 func TestRevertManager(t *testing.T) {
 	manager := NewRevertManager()
 	if manager == nil {
@@ -341,8 +490,6 @@ func TestRevertManager(t *testing.T) {
 }
 
 func TestParseJSONLog(t *testing.T) {
-	manager := NewRevertManager()
-
 	tests := []struct {
 		name        string
 		jsonContent string
@@ -393,7 +540,7 @@ func TestParseJSONLog(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			entries, err := manager.parseJSONLog([]byte(tt.jsonContent))
+			entries, err := parseJSONLog([]byte(tt.jsonContent))
 
 			if tt.expectError && err == nil {
 				t.Error("expected error, got nil")
@@ -408,9 +555,45 @@ func TestParseJSONLog(t *testing.T) {
 	}
 }
 
-func TestParseCSVLog(t *testing.T) {
-	manager := NewRevertManager()
+func TestJSONReportStart(t *testing.T) {
+	report := "{\n  \"summary\": {},\n  \"entries\": []\n}\n"
+	tests := []struct {
+		name     string
+		content  string
+		expected int
+	}{
+		{
+			name:     "report only",
+			content:  report,
+			expected: 0,
+		},
+		{
+			name:     "verbose path with braces",
+			content:  "MODIFIED: t/{d}/b.txt (1 replacements)\n" + report,
+			expected: len("MODIFIED: t/{d}/b.txt (1 replacements)\n"),
+		},
+		{
+			name:     "compact report",
+			content:  `{"entries": []}`,
+			expected: 0,
+		},
+		{
+			name:     "no report",
+			content:  "MODIFIED: a.txt\n",
+			expected: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := JSONReportStart(tt.content); got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
 
+func TestParseCSVLog(t *testing.T) {
 	tests := []struct {
 		name        string
 		csvContent  string
@@ -454,7 +637,7 @@ file_path,old_string,new_string,line,column
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			entries, err := manager.parseCSVLog(tt.csvContent)
+			entries, _, err := parseCSVLog(tt.csvContent)
 
 			if tt.expectError && err == nil {
 				t.Error("expected error, got nil")
@@ -469,6 +652,173 @@ file_path,old_string,new_string,line,column
 	}
 }
 
+func TestParseCSVLogPositions(t *testing.T) {
+	content := `file_path,from,to,line,column,size_delta
+/path/a.txt,old,new,3,7,0
+/path/a.txt,old,new,x,1,0
+/path/a.txt,old,new,4,0,0
+`
+	logPath := filepath.Join(t.TempDir(), "remap.csv")
+	if err := os.WriteFile(logPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		manager interface {
+			parseLogFileWithFormat(string, string) ([]LogEntry, error)
+			Warnings() []string
+		}
+	}{
+		{name: "revert", manager: NewRevertManager()},
+		{name: "apply", manager: NewApplyManager()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := tt.manager.parseLogFileWithFormat(logPath, "csv")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(entries) != 1 || len(entries[0].Replacements) != 1 {
+				t.Fatalf("expected the malformed rows to be skipped, got %+v", entries)
+			}
+			if repl := entries[0].Replacements[0]; repl.Line != 3 || repl.Column != 7 {
+				t.Errorf("expected line 3 column 7, got line %d column %d", repl.Line, repl.Column)
+			}
+
+			warnings := tt.manager.Warnings()
+			if len(warnings) != 2 {
+				t.Fatalf("expected 2 warnings, got %q", warnings)
+			}
+			if !strings.Contains(warnings[0], `invalid line "x"`) || !strings.Contains(warnings[1], `invalid column "0"`) {
+				t.Errorf("unexpected warnings %q", warnings)
+			}
+		})
+	}
+}
+
+func TestParseCSVLogOriginalText(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		originalText string
+		byteOffset   int64
+	}{
+		{
+			name:         "recorded text",
+			content:      "file_path,old_string,new_string,line,column,size_delta,original_text,byte_offset\n/path/a.txt,color,colour,2,3,1,COLOR,14\n",
+			originalText: "COLOR",
+			byteOffset:   14,
+		},
+		{
+			name:         "after mapping index",
+			content:      "file_path,old_string,new_string,line,column,size_delta,mapping_index,original_text,byte_offset\n/path/a.txt,color,colour,2,3,1,4,Color,14\n",
+			originalText: "Color",
+			byteOffset:   14,
+		},
+		{
+			name:    "report without recorded text",
+			content: "file_path,old_string,new_string,line,column,size_delta\n/path/a.txt,color,colour,2,3,1\n",
+		},
+		{
+			name:    "invalid byte offset",
+			content: "file_path,old_string,new_string,line,column,size_delta,original_text,byte_offset\n/path/a.txt,color,colour,2,3,1,COLOR,x\n",
+		},
+	}
+
+	for _, tt := range tests {
+		entries, _, err := parseCSVLog(tt.content)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if len(entries) != 1 || len(entries[0].Replacements) != 1 {
+			t.Fatalf("%s: expected one replacement, got %+v", tt.name, entries)
+		}
+		repl := entries[0].Replacements[0]
+		if repl.OriginalText != tt.originalText || repl.ByteOffset != tt.byteOffset {
+			t.Errorf("%s: expected %q at %d, got %q at %d", tt.name, tt.originalText, tt.byteOffset, repl.OriginalText, repl.ByteOffset)
+		}
+	}
+}
+
+func TestParseXMLLog(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+		entryCount  int
+	}{
+		{
+			name: "valid XML log after verbose output",
+			content: `MODIFIED: /path/to/file.txt (1 replacements)
+<?xml version="1.0" encoding="UTF-8"?>
+<remap_report>
+  <summary><total_files>2</total_files></summary>
+  <entries>
+    <entry>
+      <file_path>/path/to/file.txt</file_path>
+      <modified>true</modified>
+      <replacements>
+        <replacement><from>old</from><to>new</to><original_text>Old</original_text><line>1</line><column>5</column></replacement>
+      </replacements>
+      <backup_path>/path/to/file.txt.bak</backup_path>
+    </entry>
+    <entry><file_path>/path/to/other.txt</file_path><modified>false</modified></entry>
+  </entries>
+</remap_report>`,
+			entryCount: 2,
+		},
+		{name: "no XML content", content: "not a report", expectError: true},
+		{name: "malformed XML", content: "<remap_report><entries>", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := parseXMLLog("log.xml", tt.content)
+
+			if tt.expectError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if len(entries) != tt.entryCount {
+				t.Fatalf("expected %d entries, got %d", tt.entryCount, len(entries))
+			}
+			if tt.entryCount == 0 {
+				return
+			}
+			entry := entries[0]
+			if !entry.Modified || entry.BackupPath != "/path/to/file.txt.bak" || len(entry.Replacements) != 1 {
+				t.Fatalf("unexpected entry: %+v", entry)
+			}
+			if r := entry.Replacements[0]; r.From != "old" || r.To != "new" || r.OriginalText != "Old" || r.Line != 1 || r.Column != 5 {
+				t.Errorf("unexpected replacement: %+v", r)
+			}
+		})
+	}
+}
+
+func TestParseNDJSONLog(t *testing.T) {
+	content := `MODIFIED: /path/to/a.txt (1 replacements)
+{"file_path":"/path/to/a.txt","modified":true,"replacements":[{"From":"old","To":"new","original_text":"Old","mapping_index":2,"Line":1,"Column":5}]}
+{"file_path":"/path/to/b.txt","modified":false}
+{"summary":{"total_files":2,"modified_files":1}}
+`
+	entries, err := parseNDJSONLog("log.ndjson", content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries without the summary, got %d", len(entries))
+	}
+	if r := entries[0].Replacements; !entries[0].Modified || len(r) != 1 || r[0].OriginalText != "Old" || r[0].MappingIndex != 2 {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+
+	if _, err := parseNDJSONLog("log.ndjson", "{\"file_path\": \"/a.txt\"\n"); err == nil {
+		t.Error("expected an error for a truncated line")
+	}
+}
+
 func TestRevertFromBackup(t *testing.T) {
 	tempDir := t.TempDir()
 	manager := NewRevertManager()
@@ -547,6 +897,196 @@ func TestReverseReplacements(t *testing.T) {
 	}
 }
 
+func TestReverseReplacementsPreservesCase(t *testing.T) {
+	original := "Color: red\nCOLOR = blue\nthe color green\n"
+	cfg := &config.Config{CaseSensitive: false, DryRun: true}
+	mappings := parser.NewMappingTable([]parser.Mapping{{From: "color", To: "hue"}})
+
+	result := replacement.NewEngine(cfg).ProcessFile("test.txt", []byte(original), mappings)
+	if len(result.Replacements) != 3 {
+		t.Fatalf("expected 3 replacements, got %d", len(result.Replacements))
+	}
+
+	// Round-trip through the JSON log so revert sees what a real run records
+	logged, err := json.Marshal(result.Replacements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replacements []replacement.Replacement
+	if err := json.Unmarshal(logged, &replacements); err != nil {
+		t.Fatal(err)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "test.txt")
+	if err := os.WriteFile(filePath, []byte("hue: red\nhue = blue\nthe hue green\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := LogEntry{FilePath: filePath, Modified: true, Replacements: replacements}
+	if err := NewRevertManager().reverseReplacements(entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reverted, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reverted) != original {
+		t.Errorf("expected %q, got %q", original, reverted)
+	}
+}
+
+func TestRevertContent(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		replacements []replacement.Replacement
+		options      LogOptions
+		expected     string
+		expectError  bool
+	}{
+		{
+			name:    "destination also in untouched text",
+			content: "new line\nthe newest new\n",
+			replacements: []replacement.Replacement{
+				{From: "old", To: "new", OriginalText: "old", Line: 2, Column: 12, ByteOffset: 20},
+			},
+			expected: "new line\nthe newest old\n",
+		},
+		{
+			name:    "line positions keep untouched text",
+			content: "new line\nthe newest new new\n",
+			replacements: []replacement.Replacement{
+				{From: "old", To: "new", Line: 2, Column: 12},
+				{From: "old", To: "new", Line: 2, Column: 16},
+			},
+			options:  legacyLogOptions,
+			expected: "new line\nthe newest old old\n",
+		},
+		{
+			name:    "line positions shift by earlier replacements on the line",
+			content: "ab longer ab\n",
+			replacements: []replacement.Replacement{
+				{From: "x", To: "longer", Line: 1, Column: 4},
+				{From: "yz", To: "ab", Line: 1, Column: 6},
+			},
+			options:  legacyLogOptions,
+			expected: "ab x yz\n",
+		},
+		{
+			name:    "line positions with preserve case",
+			content: "Bar BAR bar\n",
+			replacements: []replacement.Replacement{
+				{From: "foo", To: "bar", Line: 1, Column: 1},
+				{From: "foo", To: "bar", Line: 1, Column: 5},
+				{From: "foo", To: "bar", Line: 1, Column: 9},
+			},
+			options:  LogOptions{PreserveCase: true},
+			expected: "Foo FOO foo\n",
+		},
+		{
+			name:    "line positions with multibyte text",
+			content: "héllo new\n",
+			replacements: []replacement.Replacement{
+				{From: "old", To: "new", Line: 1, Column: 7},
+			},
+			options:  legacyLogOptions,
+			expected: "héllo old\n",
+		},
+		{
+			name:         "log without positions",
+			content:      "new and new",
+			replacements: []replacement.Replacement{{From: "old", To: "new"}},
+			options:      legacyLogOptions,
+			expected:     "old and old",
+		},
+		{
+			name:         "file changed since the run",
+			content:      "edited hue",
+			replacements: []replacement.Replacement{{From: "color", To: "hue", OriginalText: "Color", Line: 1, Column: 1}},
+			expectError:  true,
+		},
+		{
+			name:         "line no longer holds the destination",
+			content:      "edited\n",
+			replacements: []replacement.Replacement{{From: "old", To: "new", Line: 3, Column: 1}},
+			options:      legacyLogOptions,
+			expectError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := revertContent("test.txt", tt.content, tt.replacements, tt.options)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestApplyRecordedText(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		replacements []replacement.Replacement
+		expected     string
+		expectError  bool
+	}{
+		{
+			name:    "case-insensitive matches",
+			content: "Color and COLOR",
+			replacements: []replacement.Replacement{
+				{From: "color", To: "hue", OriginalText: "COLOR", ByteOffset: 10, Line: 1},
+				{From: "color", To: "hue", OriginalText: "Color", ByteOffset: 0, Line: 1},
+			},
+			expected: "hue and hue",
+		},
+		{
+			name:         "file changed since the log",
+			content:      "edited Color",
+			replacements: []replacement.Replacement{{From: "color", To: "hue", OriginalText: "Color", ByteOffset: 0, Line: 1}},
+			expectError:  true,
+		},
+		{
+			name:    "overlapping replacements",
+			content: "abc",
+			replacements: []replacement.Replacement{
+				{From: "ab", To: "x", OriginalText: "ab", ByteOffset: 0, Line: 1},
+				{From: "bc", To: "y", OriginalText: "bc", ByteOffset: 1, Line: 1},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := applyRecordedText("test.txt", tt.content, tt.replacements)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestRevertFromLogIntegration(t *testing.T) {
 	tempDir := t.TempDir()
 	manager := NewRevertManager()
@@ -764,3 +1304,180 @@ func TestBackupFileEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestRevertFromLogParallel(t *testing.T) {
+	tempDir := t.TempDir()
+	const fileCount = 64
+
+	var entries []LogEntry
+	for i := 0; i < fileCount; i++ {
+		filePath := filepath.Join(tempDir, fmt.Sprintf("file%02d.txt", i))
+		if err := os.WriteFile(filePath, []byte(fmt.Sprintf("modified %d", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		entry := LogEntry{
+			FilePath: filePath,
+			Modified: true,
+			Replacements: []replacement.Replacement{
+				{From: "original", To: "modified"},
+			},
+		}
+
+		// Half the files are restored from backups, the rest by reverse replacement
+		if i%2 == 0 {
+			entry.BackupPath = filePath + ".backup"
+			if err := os.WriteFile(entry.BackupPath, []byte(fmt.Sprintf("original %d", i)), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	logBytes, err := json.Marshal(struct {
+		Entries []LogEntry `json:"entries"`
+	}{Entries: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(tempDir, "test.log")
+	if err := os.WriteFile(logPath, logBytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewRevertManagerWithWorkers(8)
+	if err := manager.RevertFromLogWithFormat(logPath, "json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < fileCount; i++ {
+		content, err := os.ReadFile(filepath.Join(tempDir, fmt.Sprintf("file%02d.txt", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := fmt.Sprintf("original %d", i); string(content) != expected {
+			t.Errorf("file%02d not restored: expected %q, got %q", i, expected, content)
+		}
+	}
+}
+
+func TestProcessEntriesCollectsErrors(t *testing.T) {
+	entries := []LogEntry{
+		{FilePath: "a", Modified: true},
+		{FilePath: "b", Modified: true},
+		{FilePath: "a", Modified: true},
+		{FilePath: "c", Modified: false},
+		{FilePath: "d", Modified: true, Error: "failed"},
+	}
+
+	var calls []string
+	successes, errs := processEntries(entries, 1, func(entry LogEntry) error {
+		calls = append(calls, entry.FilePath)
+		if entry.FilePath == "b" {
+			return fmt.Errorf("cannot process %s", entry.FilePath)
+		}
+		return nil
+	})
+
+	if successes != 2 {
+		t.Errorf("expected 2 successes, got %d", successes)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected 1 error, got %d", len(errs))
+	}
+	if strings.Join(calls, ",") != "a,a,b" {
+		t.Errorf("expected entries for the same file to be grouped, got %v", calls)
+	}
+}
+
+func TestStreamJSONEntries(t *testing.T) {
+	content := `MODIFIED: /tmp/a.txt (1 replacements)
+{
+  "summary": {"total_files": 3, "nested": {"entries": []}},
+  "entries": [
+    {"file_path": "/tmp/a.txt", "modified": true},
+    {"file_path": "/tmp/b.txt", "modified": false},
+    {"file_path": "/tmp/c.txt", "modified": true, "replacements": [{"From": "x", "To": "y"}]}
+  ],
+  "trailer": "ignored"
+}`
+
+	var paths []string
+	err := streamJSONEntries(strings.NewReader(content), "test.log", func(entry LogEntry) {
+		paths = append(paths, entry.FilePath)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(paths, ",") != "/tmp/a.txt,/tmp/b.txt,/tmp/c.txt" {
+		t.Errorf("unexpected entries: %v", paths)
+	}
+
+	for name, invalid := range map[string]string{
+		"no JSON":        "no json here",
+		"entries object": `{"entries": {}}`,
+		"truncated":      `{"entries": [{"file_path": "/tmp/a.txt"`,
+	} {
+		if err := streamJSONEntries(strings.NewReader(invalid), "test.log", func(LogEntry) {}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestRevertFromLogStreaming(t *testing.T) {
+	previousThreshold := streamingThreshold
+	streamingThreshold = 0 // Force the streaming path for any non-empty log
+	defer func() { streamingThreshold = previousThreshold }()
+
+	tempDir := t.TempDir()
+	const fileCount = 500
+
+	logPath := filepath.Join(tempDir, "large.log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Synthesize the log entry by entry, as a large run would produce it
+	fmt.Fprint(logFile, `{"summary": {"total_files": 500}, "entries": [`)
+	encoder := json.NewEncoder(logFile)
+	for i := 0; i < fileCount; i++ {
+		filePath := filepath.Join(tempDir, fmt.Sprintf("file%03d.txt", i))
+		if err := os.WriteFile(filePath, []byte("modified text"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 {
+			fmt.Fprint(logFile, ",")
+		}
+		if err := encoder.Encode(LogEntry{
+			FilePath:     filePath,
+			Modified:     true,
+			Replacements: []replacement.Replacement{{From: "original", To: "modified"}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fmt.Fprint(logFile, "]}")
+	if err := logFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !shouldStream(logPath) {
+		t.Fatal("expected log to be streamed")
+	}
+
+	manager := NewRevertManagerWithWorkers(4)
+	if err := manager.RevertFromLogWithFormat(logPath, "json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < fileCount; i++ {
+		content, err := os.ReadFile(filepath.Join(tempDir, fmt.Sprintf("file%03d.txt", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "original text" {
+			t.Fatalf("file%03d not reverted: %q", i, content)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+
+	"remap/internal/replacement"
+)
+
+// LogOptions are the matching options of the run that wrote a log, recorded
+// in its summary. Revert and apply use them when replacements cannot be
+// undone or redone at their recorded positions, so the fallback matches text
+// the way the run did.
+type LogOptions struct {
+	CaseSensitive bool
+	WordBoundary  bool
+	PreserveCase  bool
+}
+
+// legacyLogOptions are assumed for logs written before the options were
+// recorded, keeping the plain case-sensitive fallback they always had.
+var legacyLogOptions = LogOptions{CaseSensitive: true}
+
+// loggedOptions is how LogOptions appear in a report summary. A missing
+// case_sensitive field marks a log written before options were recorded.
+type loggedOptions struct {
+	CaseSensitive *bool `json:"case_sensitive" xml:"case_sensitive"`
+	WordBoundary  bool  `json:"word_boundary" xml:"word_boundary"`
+	PreserveCase  bool  `json:"preserve_case" xml:"preserve_case"`
+}
+
+func (o loggedOptions) options() LogOptions {
+	if o.CaseSensitive == nil {
+		return legacyLogOptions
+	}
+	return LogOptions{CaseSensitive: *o.CaseSensitive, WordBoundary: o.WordBoundary, PreserveCase: o.PreserveCase}
+}
+
+// csvOptionPrefixes are the comment lines of a CSV report recording its options.
+const (
+	csvCaseSensitivePrefix = "# Case sensitive: "
+	csvWordBoundaryPrefix  = "# Word boundary: "
+	csvPreserveCasePrefix  = "# Preserve case: "
+)
+
+// readLogOptions returns the options recorded in the summary of a log in the
+// given format, or legacyLogOptions when it records none.
+func readLogOptions(logFormat, content string) LogOptions {
+	switch logFormat {
+	case "json":
+		if start := JSONReportStart(content); start != -1 {
+			return decodeJSONSummary(json.NewDecoder(strings.NewReader(content[start:])))
+		}
+	case "ndjson":
+		// The summary is the last JSON line
+		lines := strings.Split(strings.TrimSpace(content), "\n")
+		for i := len(lines) - 1; i >= 0; i-- {
+			var record struct {
+				Summary *loggedOptions `json:"summary"`
+			}
+			if json.Unmarshal([]byte(strings.TrimSpace(lines[i])), &record) == nil && record.Summary != nil {
+				return record.Summary.options()
+			}
+		}
+	case "xml":
+		return decodeXMLSummary(content)
+	case "csv":
+		return readCSVOptions(content)
+	}
+	return legacyLogOptions
+}
+
+// decodeJSONSummary reads the options from the "summary" object of a JSON
+// report, which comes before its entries, without decoding the entries.
+func decodeJSONSummary(decoder *json.Decoder) LogOptions {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return legacyLogOptions
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return legacyLogOptions
+		}
+		if key, _ := token.(string); key == "summary" {
+			var summary loggedOptions
+			if decoder.Decode(&summary) != nil {
+				return legacyLogOptions
+			}
+			return summary.options()
+		}
+		var skipped json.RawMessage
+		if decoder.Decode(&skipped) != nil {
+			return legacyLogOptions
+		}
+	}
+	return legacyLogOptions
+}
+
+// decodeXMLSummary reads the options from the <summary> element of an XML report.
+func decodeXMLSummary(content string) LogOptions {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return legacyLogOptions
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "summary" {
+			var summary loggedOptions
+			if decoder.DecodeElement(&summary, &start) != nil {
+				return legacyLogOptions
+			}
+			return summary.options()
+		}
+	}
+}
+
+// readCSVOptions reads the options from the comment lines of a CSV report.
+func readCSVOptions(content string) LogOptions {
+	var summary loggedOptions
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, csvCaseSensitivePrefix):
+			caseSensitive := strings.TrimPrefix(line, csvCaseSensitivePrefix) == "true"
+			summary.CaseSensitive = &caseSensitive
+		case strings.HasPrefix(line, csvWordBoundaryPrefix):
+			summary.WordBoundary = strings.TrimPrefix(line, csvWordBoundaryPrefix) == "true"
+		case strings.HasPrefix(line, csvPreserveCasePrefix):
+			summary.PreserveCase = strings.TrimPrefix(line, csvPreserveCasePrefix) == "true"
+		}
+	}
+	return summary.options()
+}
+
+// withLogOptions records options on every entry, for the fallbacks of
+// revertEntry and applyEntry.
+func withLogOptions(entries []LogEntry, options LogOptions) []LogEntry {
+	for i := range entries {
+		entries[i].options = options
+	}
+	return entries
+}
+
+// forwardMatchOptions matches mapping sources the way the logged run did.
+func (o LogOptions) forwardMatchOptions() replacement.MatchOptions {
+	return replacement.MatchOptions{
+		CaseSensitive: o.CaseSensitive,
+		WordBoundary:  o.WordBoundary,
+		PreserveCase:  o.PreserveCase && !o.CaseSensitive,
+	}
+}
+
+// reverseMatchOptions matches the destinations the logged run wrote. They
+// were written exactly as mapped, so they are matched case-sensitively,
+// unless --preserve-case gave each the casing of the text it replaced; the
+// source then gets that casing back.
+func (o LogOptions) reverseMatchOptions() replacement.MatchOptions {
+	preserveCase := o.PreserveCase && !o.CaseSensitive
+	return replacement.MatchOptions{
+		CaseSensitive: !preserveCase,
+		WordBoundary:  o.WordBoundary,
+		PreserveCase:  preserveCase,
+	}
+}
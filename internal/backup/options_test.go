@@ -0,0 +1,73 @@
+package backup
+
+import (
+	"testing"
+)
+
+func TestReadLogOptions(t *testing.T) {
+	insensitive := LogOptions{WordBoundary: true, PreserveCase: true}
+
+	tests := []struct {
+		name      string
+		logFormat string
+		content   string
+		expected  LogOptions
+	}{
+		{
+			name:      "json summary",
+			logFormat: "json",
+			content:   `{"summary": {"case_sensitive": false, "word_boundary": true, "preserve_case": true}, "entries": []}`,
+			expected:  insensitive,
+		},
+		{
+			name:      "json after leading text",
+			logFormat: "json",
+			content:   "Processing...\n" + `{"summary": {"case_sensitive": false, "word_boundary": true, "preserve_case": true}, "entries": []}`,
+			expected:  insensitive,
+		},
+		{
+			name:      "json without options",
+			logFormat: "json",
+			content:   `{"summary": {"total_files": 1}, "entries": []}`,
+			expected:  legacyLogOptions,
+		},
+		{
+			name:      "ndjson summary line",
+			logFormat: "ndjson",
+			content:   `{"file_path": "a.txt"}` + "\n" + `{"summary": {"case_sensitive": false, "word_boundary": true, "preserve_case": true}}` + "\n",
+			expected:  insensitive,
+		},
+		{
+			name:      "xml summary",
+			logFormat: "xml",
+			content:   "<remap_report><summary><case_sensitive>false</case_sensitive><word_boundary>true</word_boundary><preserve_case>true</preserve_case></summary></remap_report>",
+			expected:  insensitive,
+		},
+		{
+			name:      "csv comments",
+			logFormat: "csv",
+			content:   "file_path,from,to\n# Remap CSV Report (Applied)\n# Case sensitive: false\n# Word boundary: true\n# Preserve case: true\n#\n",
+			expected:  insensitive,
+		},
+		{
+			name:      "csv without options",
+			logFormat: "csv",
+			content:   "file_path,from,to\n# Remap CSV Report (Applied)\n#\n",
+			expected:  legacyLogOptions,
+		},
+		{
+			name:      "invalid json",
+			logFormat: "json",
+			content:   `{"summary": [`,
+			expected:  legacyLogOptions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readLogOptions(tt.logFormat, tt.content); got != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package backup
+
+import (
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"remap/internal/errors"
+)
+
+// backupNamePattern matches the names generateBackupPath gives backups:
+// the original base name, a timestamp and the .bak extension.
+var backupNamePattern = regexp.MustCompile(`^(.+)\.(\d{8}_\d{6})\.bak$`)
+
+// Backup pairs a backup file with the file it was taken from.
+type Backup struct {
+	OriginalPath string
+	BackupPath   string
+	Timestamp    string
+}
+
+// parseBackupName returns the original base name and timestamp encoded in
+// the name of a backup file.
+func parseBackupName(name string) (base, timestamp string, ok bool) {
+	match := backupNamePattern.FindStringSubmatch(name)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// IsBackupName reports whether name is a backup file name as generated by
+// generateBackupPath, such as "main.go.20240301_120000.bak".
+func IsBackupName(name string) bool {
+	return backupNamePattern.MatchString(name)
+}
+
+// FindBackups walks backupDir for timestamped backups and maps each one
+// back to its original under root, reversing generateBackupPath. Backups
+// kept next to their files are found by passing the same directory twice.
+// When a file has several backups the most recent one is returned, or the
+// oldest one if oldest is set. Backups are returned sorted by original path.
+func FindBackups(backupDir, root string, oldest bool) ([]Backup, error) {
+	chosen := make(map[string]Backup)
+
+	err := filepath.WalkDir(backupDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		base, timestamp, ok := parseBackupName(d.Name())
+		if !ok {
+			return nil
+		}
+
+		rel, err := filepath.Rel(backupDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		original := filepath.Join(root, rel, base)
+
+		if previous, seen := chosen[original]; seen {
+			newer := timestamp > previous.Timestamp
+			if newer == oldest {
+				return nil
+			}
+		}
+		chosen[original] = Backup{OriginalPath: original, BackupPath: path, Timestamp: timestamp}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.NewBackupError(backupDir, "failed to read backup directory", err)
+	}
+
+	backups := make([]Backup, 0, len(chosen))
+	for _, b := range chosen {
+		backups = append(backups, b)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].OriginalPath < backups[j].OriginalPath
+	})
+	return backups, nil
+}
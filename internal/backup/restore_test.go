@@ -0,0 +1,86 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseBackupName(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		timestamp string
+		ok        bool
+	}{
+		{name: "config.yaml.20260102_150405.bak", base: "config.yaml", timestamp: "20260102_150405", ok: true},
+		{name: "archive.tar.gz.20260102_150405.bak", base: "archive.tar.gz", timestamp: "20260102_150405", ok: true},
+		{name: "notes.bak"},
+		{name: "file.txt.2026_150405.bak"},
+		{name: ".20260102_150405.bak"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, timestamp, ok := parseBackupName(tt.name)
+			if ok != tt.ok || base != tt.base || timestamp != tt.timestamp {
+				t.Errorf("parseBackupName(%q) = %q, %q, %v; want %q, %q, %v",
+					tt.name, base, timestamp, ok, tt.base, tt.timestamp, tt.ok)
+			}
+			if IsBackupName(tt.name) != tt.ok {
+				t.Errorf("IsBackupName(%q) = %v, want %v", tt.name, !tt.ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestFindBackups(t *testing.T) {
+	root := t.TempDir()
+	backupDir := t.TempDir()
+
+	for _, name := range []string{
+		"a/config.yaml.20260101_000000.bak",
+		"a/config.yaml.20260301_000000.bak",
+		"a/config.yaml.20260201_000000.bak",
+		"b/config.yaml.20260101_000000.bak",
+		"b/unrelated.txt",
+	} {
+		path := filepath.Join(backupDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		oldest bool
+		wantA  string
+	}{
+		{name: "most recent", wantA: "20260301_000000"},
+		{name: "oldest", oldest: true, wantA: "20260101_000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backups, err := FindBackups(backupDir, root, tt.oldest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(backups) != 2 {
+				t.Fatalf("expected 2 backups, got %+v", backups)
+			}
+			if want := filepath.Join(root, "a", "config.yaml"); backups[0].OriginalPath != want {
+				t.Errorf("original path = %s, want %s", backups[0].OriginalPath, want)
+			}
+			if backups[0].Timestamp != tt.wantA {
+				t.Errorf("timestamp = %s, want %s", backups[0].Timestamp, tt.wantA)
+			}
+			if want := filepath.Join(root, "b", "config.yaml"); backups[1].OriginalPath != want {
+				t.Errorf("original path = %s, want %s", backups[1].OriginalPath, want)
+			}
+		})
+	}
+}
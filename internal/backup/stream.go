@@ -0,0 +1,168 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"sync"
+
+	"remap/internal/errors"
+)
+
+// streamingThreshold is the log size above which JSON logs are reverted while
+// being decoded instead of being unmarshalled into memory first.
+var streamingThreshold int64 = 64 * 1024 * 1024
+
+// shouldStream reports whether a JSON log is large enough to be streamed.
+func shouldStream(logFilePath string) bool {
+	info, err := os.Stat(logFilePath)
+	return err == nil && info.Size() > streamingThreshold
+}
+
+// streamJSONEntries decodes the "entries" array of a JSON report one entry at a
+// time using json.Decoder tokens, calling fn for each entry as it is read.
+// Memory use is bounded by the largest single entry rather than the whole log.
+// Like the in-memory parser, any text before the first '{' is skipped. The
+// options of the "summary" object, written before the entries, are recorded on
+// each entry.
+func streamJSONEntries(reader io.Reader, logFilePath string, fn func(LogEntry)) error {
+	buffered := bufio.NewReader(reader)
+	if err := skipToJSONObject(buffered); err != nil {
+		return errors.NewParsingError(logFilePath, "no JSON content found in log file", err)
+	}
+
+	decoder := json.NewDecoder(buffered)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return errors.NewParsingError(logFilePath, "invalid JSON log", err)
+	}
+
+	options := legacyLogOptions
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return errors.NewParsingError(logFilePath, "invalid JSON log", err)
+		}
+
+		key, _ := token.(string)
+		if key == "summary" {
+			var summary loggedOptions
+			if err := decoder.Decode(&summary); err != nil {
+				return errors.NewParsingError(logFilePath, "invalid JSON log", err)
+			}
+			options = summary.options()
+			continue
+		}
+		if key != "entries" {
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return errors.NewParsingError(logFilePath, "invalid JSON log", err)
+			}
+			continue
+		}
+
+		if err := expectDelim(decoder, '['); err != nil {
+			return errors.NewParsingError(logFilePath, "invalid entries array", err)
+		}
+		for decoder.More() {
+			var entry LogEntry
+			if err := decoder.Decode(&entry); err != nil {
+				return errors.NewParsingError(logFilePath, "invalid log entry", err)
+			}
+			entry.options = options
+			fn(entry)
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return errors.NewParsingError(logFilePath, "invalid entries array", err)
+		}
+	}
+
+	return nil
+}
+
+func skipToJSONObject(reader *bufio.Reader) error {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b == '{' {
+			return reader.UnreadByte()
+		}
+	}
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// processEntryStream runs fn over entries produced by stream with a bounded pool.
+// Entries are sharded by file path so that every entry for a given file goes to
+// the same worker and is applied in log order, keeping files independent.
+func processEntryStream(workers int, fn func(LogEntry) error, stream func(func(LogEntry)) error) (int, []error, error) {
+	shards := make([]chan LogEntry, workers)
+	for i := range shards {
+		shards[i] = make(chan LogEntry, 16)
+	}
+
+	var mu sync.Mutex
+	var entryErrors []error
+	successCount := 0
+
+	var wg sync.WaitGroup
+	for _, shard := range shards {
+		wg.Add(1)
+		go func(entries <-chan LogEntry) {
+			defer wg.Done()
+			for entry := range entries {
+				err := fn(entry)
+
+				mu.Lock()
+				if err != nil {
+					entryErrors = append(entryErrors, err)
+				} else {
+					successCount++
+				}
+				mu.Unlock()
+			}
+		}(shard)
+	}
+
+	streamErr := stream(func(entry LogEntry) {
+		if !entry.Modified || entry.Error != "" {
+			return // Skip entries that weren't modified or had errors
+		}
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(entry.FilePath))
+		shards[hash.Sum32()%uint32(workers)] <- entry
+	})
+
+	for _, shard := range shards {
+		close(shard)
+	}
+	wg.Wait()
+
+	return successCount, entryErrors, streamErr
+}
+
+// revertFromJSONStream reverts a large JSON log while it is being decoded.
+func (rm *RevertManager) revertFromJSONStream(logFilePath string) (int, []error, error) {
+	file, err := os.Open(logFilePath)
+	if err != nil {
+		return 0, nil, errors.NewFileError(logFilePath, "failed to open log file", err)
+	}
+	defer file.Close()
+
+	return processEntryStream(rm.workers, rm.revertEntry, func(emit func(LogEntry)) error {
+		return streamJSONEntries(file, logFilePath, emit)
+	})
+}
@@ -0,0 +1,73 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+)
+
+// LogProblem describes a log entry that a revert could not act on.
+type LogProblem struct {
+	FilePath string
+	Message  string
+}
+
+func (p LogProblem) String() string {
+	if p.FilePath == "" {
+		return p.Message
+	}
+	return p.FilePath + ": " + p.Message
+}
+
+// ValidateLog parses a log file with the revert parser and checks that every
+// entry a revert would act on can be reverted: the target file and its backup
+// exist, and the entry records a way back. Nothing is modified. The returned
+// error is reserved for logs that cannot be parsed at all.
+func (rm *RevertManager) ValidateLog(logFilePath string, logFormat string) ([]LogProblem, error) {
+	entries, err := rm.parseLogFileWithFormat(logFilePath, logFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []LogProblem
+	for i, entry := range entries {
+		// Revert skips failed and unmodified entries, so they need no checks
+		if !entry.Modified || entry.Error != "" {
+			continue
+		}
+		problems = append(problems, validateEntry(i, entry)...)
+	}
+	return problems, nil
+}
+
+// validateEntry reports the problems of a single modified log entry.
+func validateEntry(index int, entry LogEntry) []LogProblem {
+	if entry.FilePath == "" {
+		return []LogProblem{{Message: fmt.Sprintf("entry %d has no file path", index+1)}}
+	}
+
+	var problems []LogProblem
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, LogProblem{FilePath: entry.FilePath, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if _, err := os.Stat(entry.FilePath); err != nil {
+		report("file not found")
+	}
+
+	if entry.BackupPath != "" {
+		if _, err := os.Stat(entry.BackupPath); err != nil {
+			report("backup %s not found", entry.BackupPath)
+		}
+		return problems
+	}
+
+	if len(entry.Replacements) == 0 {
+		report("modified without a backup or replacements to reverse")
+	}
+	for j, r := range entry.Replacements {
+		if r.From == "" {
+			report("replacement %d has an empty source", j+1)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"remap/internal/replacement"
+)
+
+func TestValidateLog(t *testing.T) {
+	tempDir := t.TempDir()
+
+	present := filepath.Join(tempDir, "present.txt")
+	backupPath := filepath.Join(tempDir, "present.txt.bak")
+	for _, path := range []string{present, backupPath} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	missing := filepath.Join(tempDir, "missing.txt")
+	missingBackup := filepath.Join(tempDir, "gone.txt.bak")
+
+	entries := []LogEntry{
+		{FilePath: present, Modified: true, BackupPath: backupPath},
+		{FilePath: present, Modified: true, BackupPath: missingBackup},
+		{FilePath: missing, Modified: true, Replacements: []replacement.Replacement{{From: "a", To: "b"}}},
+		{FilePath: present, Modified: true},
+		{FilePath: present, Modified: true, Replacements: []replacement.Replacement{{From: "", To: "b"}}},
+		{Modified: true, BackupPath: backupPath},
+		// Skipped by revert, so never reported
+		{FilePath: missing, Modified: false},
+		{FilePath: missing, Modified: true, Error: "permission denied"},
+	}
+	content, err := json.Marshal(map[string]interface{}{"entries": entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(tempDir, "remap.json")
+	if err := os.WriteFile(logPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := NewRevertManager().ValidateLog(logPath, "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		present + ": backup " + missingBackup + " not found",
+		missing + ": file not found",
+		present + ": modified without a backup or replacements to reverse",
+		present + ": replacement 1 has an empty source",
+		"entry 6 has no file path",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %d: %v", len(expected), len(problems), problems)
+	}
+	for i, problem := range problems {
+		if problem.String() != expected[i] {
+			t.Errorf("problem %d: expected %q, got %q", i, expected[i], problem.String())
+		}
+	}
+
+	if _, err := os.Stat(present); err != nil {
+		t.Errorf("validation must not touch files: %v", err)
+	}
+
+	if err := os.WriteFile(logPath, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRevertManager().ValidateLog(logPath, "json"); err == nil {
+		t.Error("expected an error for a malformed log")
+	}
+}
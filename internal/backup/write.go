@@ -0,0 +1,50 @@
+package backup
+
+import (
+	"os"
+
+	"remap/internal/errors"
+	"remap/internal/owner"
+)
+
+// replaceFile writes content over filePath the way file processing does:
+// through a temporary file that is synced and then renamed into place, so a
+// crash during revert or apply never leaves the file truncated. The file
+// keeps its mode and owner.
+func replaceFile(filePath string, content []byte) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return errors.WrapFileError(filePath, err)
+	}
+
+	tempFile := filePath + ".tmp"
+
+	file, err := os.Create(tempFile)
+	if err != nil {
+		return errors.NewFileNotWritableError(filePath, err)
+	}
+	defer file.Close()
+	defer os.Remove(tempFile)
+
+	if _, err := file.Write(content); err != nil {
+		return errors.NewFileNotWritableError(filePath, err)
+	}
+	if err := file.Sync(); err != nil {
+		return errors.NewFileNotWritableError(filePath, err)
+	}
+	_ = file.Close()
+
+	// Changing the owner clears setuid and setgid bits, so it comes before the mode
+	if err := owner.Copy(filePath, tempFile, info); err != nil {
+		return err
+	}
+	if err := os.Chmod(tempFile, info.Mode()); err != nil {
+		return errors.NewFileNotWritableError(filePath, err)
+	}
+
+	if err := os.Rename(tempFile, filePath); err != nil {
+		return errors.NewFileNotWritableError(filePath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"remap/internal/replacement"
+)
+
+func TestReplaceFile(t *testing.T) {
+	for _, mode := range []os.FileMode{0755, 0600} {
+		t.Run(mode.String(), func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "script.sh")
+			if err := os.WriteFile(filePath, []byte("old"), mode); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := replaceFile(filePath, []byte("new")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != "new" {
+				t.Errorf("expected %q, got %q", "new", content)
+			}
+			info, err := os.Stat(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != mode {
+				t.Errorf("expected mode %v, got %v", mode, info.Mode().Perm())
+			}
+			if _, err := os.Stat(filePath + ".tmp"); !os.IsNotExist(err) {
+				t.Errorf("expected the temporary file to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRevertAndApplyKeepMode(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "run.sh")
+	if err := os.WriteFile(filePath, []byte("echo new\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	entry := LogEntry{
+		FilePath:     filePath,
+		Modified:     true,
+		Replacements: []replacement.Replacement{{From: "old", To: "new", OriginalText: "old", Line: 1, Column: 6, ByteOffset: 5}},
+	}
+
+	if err := NewRevertManager().reverseReplacements(entry); err != nil {
+		t.Fatalf("unexpected revert error: %v", err)
+	}
+	assertFile(t, filePath, "echo old\n", 0755)
+
+	if err := NewApplyManager().applyReplacements(entry); err != nil {
+		t.Fatalf("unexpected apply error: %v", err)
+	}
+	assertFile(t, filePath, "echo new\n", 0755)
+}
+
+// assertFile checks the content and permissions of filePath.
+func assertFile(t *testing.T, filePath, expected string, mode os.FileMode) {
+	t.Helper()
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != mode {
+		t.Errorf("expected mode %v, got %v", mode, info.Mode().Perm())
+	}
+}
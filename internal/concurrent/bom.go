@@ -0,0 +1,33 @@
+package concurrent
+
+import (
+	"bytes"
+
+	"remap/internal/replacement"
+)
+
+// utf8BOM is the UTF-8 encoded byte order mark some editors put at the start of files.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// splitBOM separates a leading UTF-8 byte order mark from the content, so the
+// mark is neither matched as part of the first line nor lost on rewrite.
+func splitBOM(content []byte) (bom, body []byte) {
+	if bytes.HasPrefix(content, utf8BOM) {
+		return content[:len(utf8BOM)], content[len(utf8BOM):]
+	}
+	return nil, content
+}
+
+// includeBOM shifts a result computed on the content after the byte order
+// mark back to whole-file terms: sizes and byte offsets count the mark,
+// while lines and columns stay relative to the real text.
+func includeBOM(result *replacement.FileResult, bomLen int) {
+	result.HasBOM = true
+	result.OriginalSize += int64(bomLen)
+	if result.NewSize > 0 {
+		result.NewSize += int64(bomLen)
+	}
+	for i := range result.Replacements {
+		result.Replacements[i].ByteOffset += int64(bomLen)
+	}
+}
@@ -0,0 +1,65 @@
+package concurrent
+
+import (
+	"os"
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestProcessFilePreservesBOM(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		hasBOM   bool
+	}{
+		{
+			name:     "file with BOM",
+			content:  "\xEF\xBB\xBFfoo bar\nfoo\n",
+			expected: "\xEF\xBB\xBFbaz bar\nbaz\n",
+			hasBOM:   true,
+		},
+		{
+			name:     "file without BOM",
+			content:  "foo bar\n",
+			expected: "baz bar\n",
+			hasBOM:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			files := writeTestFiles(t, tempDir, []string{"file.txt"}, map[string]string{"file.txt": tt.content})
+
+			cfg := &config.Config{Directory: tempDir, NoBackup: true, CaseSensitive: true}
+			processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "foo", To: "baz"}}))
+
+			result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+			if result.Error != nil {
+				t.Fatalf("unexpected error: %v", result.Error)
+			}
+
+			if result.Result.HasBOM != tt.hasBOM {
+				t.Errorf("expected HasBOM=%v, got %v", tt.hasBOM, result.Result.HasBOM)
+			}
+			first := result.Result.Replacements[0]
+			if first.Line != 1 || first.Column != 1 {
+				t.Errorf("expected first match at 1:1 on the real first line, got %d:%d", first.Line, first.Column)
+			}
+			if result.Result.OriginalSize != int64(len(tt.content)) {
+				t.Errorf("expected original size %d, got %d", len(tt.content), result.Result.OriginalSize)
+			}
+
+			content, err := os.ReadFile(files[0].Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package concurrent
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// replacementBudget is the number of replacements --limit still allows across
+// all files. Workers take a whole file's replacements at once, so a file is
+// either rewritten completely or left untouched, and the budget stops the run
+// as soon as it is spent or a file no longer fits.
+type replacementBudget struct {
+	remaining atomic.Int64
+	stop      context.CancelFunc
+}
+
+// newReplacementBudget returns a budget of limit replacements, or nil when
+// limit is 0 (unlimited).
+func newReplacementBudget(limit int) *replacementBudget {
+	if limit <= 0 {
+		return nil
+	}
+	b := &replacementBudget{}
+	b.remaining.Store(int64(limit))
+	return b
+}
+
+// take reserves n replacements, reporting false and stopping the run when
+// fewer than n are left.
+func (b *replacementBudget) take(n int) bool {
+	for {
+		remaining := b.remaining.Load()
+		if int64(n) > remaining {
+			b.exhausted()
+			return false
+		}
+		if b.remaining.CompareAndSwap(remaining, remaining-int64(n)) {
+			if remaining == int64(n) {
+				b.exhausted()
+			}
+			return true
+		}
+	}
+}
+
+// exhausted stops workers from starting new files; files already being
+// processed finish and are reported.
+func (b *replacementBudget) exhausted() {
+	if b.stop != nil {
+		b.stop()
+	}
+}
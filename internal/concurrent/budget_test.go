@@ -0,0 +1,98 @@
+package concurrent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestReplacementBudgetTake(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         int
+		takes         []int
+		expected      []bool
+		wantExhausted bool
+	}{
+		{name: "within budget", limit: 5, takes: []int{2, 2}, expected: []bool{true, true}},
+		{name: "spent exactly", limit: 4, takes: []int{2, 2}, expected: []bool{true, true}, wantExhausted: true},
+		{name: "file overshooting", limit: 5, takes: []int{2, 4, 1}, expected: []bool{true, false, true}, wantExhausted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exhausted := false
+			budget := newReplacementBudget(tt.limit)
+			budget.stop = func() { exhausted = true }
+
+			for i, n := range tt.takes {
+				if got := budget.take(n); got != tt.expected[i] {
+					t.Errorf("take(%d) #%d = %v, expected %v", n, i+1, got, tt.expected[i])
+				}
+			}
+			if exhausted != tt.wantExhausted {
+				t.Errorf("exhausted = %v, expected %v", exhausted, tt.wantExhausted)
+			}
+		})
+	}
+
+	if newReplacementBudget(0) != nil {
+		t.Error("expected no budget without a limit")
+	}
+}
+
+func TestProcessFilesLimit(t *testing.T) {
+	tempDir := t.TempDir()
+	var names []string
+	contents := make(map[string]string)
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("file%d.txt", i)
+		names = append(names, name)
+		contents[name] = "foo foo\n"
+	}
+	files := writeTestFiles(t, tempDir, names, contents)
+
+	// Two replacements per file: the first two files fit, the third would
+	// overshoot and is left whole, and the rest are never started
+	cfg := &config.Config{Directory: tempDir, NoBackup: true, Workers: 1, Limit: 5}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "foo", To: "bar"}}))
+
+	results, err := processor.ProcessFiles(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	processed, modified, replacements := 0, 0, 0
+	for result := range results {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+		processed++
+		if result.Result.Modified {
+			modified++
+			replacements += len(result.Result.Replacements)
+		}
+	}
+
+	if processed != 3 || modified != 2 || replacements != 4 {
+		t.Errorf("expected 3 files processed, 2 modified with 4 replacements; got %d, %d, %d", processed, modified, replacements)
+	}
+
+	for i, file := range files {
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "foo foo\n"
+		if i < 2 {
+			expected = "bar bar\n"
+		}
+		if string(content) != expected {
+			t.Errorf("%s: expected %q, got %q", names[i], expected, content)
+		}
+	}
+}
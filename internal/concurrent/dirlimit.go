@@ -0,0 +1,54 @@
+package concurrent
+
+import (
+	"context"
+	"sync"
+)
+
+// dirLimiter bounds how many files of the same directory are processed at once.
+// Each directory gets its own counting semaphore, created on first use, which
+// spreads load across directories on network mounts instead of hammering one.
+type dirLimiter struct {
+	limit int
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// newDirLimiter returns a limiter allowing limit concurrent files per directory,
+// or nil when limit is 0 (unlimited).
+func newDirLimiter(limit int) *dirLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &dirLimiter{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+func (dl *dirLimiter) semaphore(dir string) chan struct{} {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	sem, exists := dl.slots[dir]
+	if !exists {
+		sem = make(chan struct{}, dl.limit)
+		dl.slots[dir] = sem
+	}
+	return sem
+}
+
+// acquire blocks until a slot for dir is free, returning false if ctx is cancelled first.
+func (dl *dirLimiter) acquire(ctx context.Context, dir string) bool {
+	select {
+	case dl.semaphore(dir) <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release frees a slot previously obtained with acquire.
+func (dl *dirLimiter) release(dir string) {
+	<-dl.semaphore(dir)
+}
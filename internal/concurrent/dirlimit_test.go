@@ -0,0 +1,84 @@
+package concurrent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"remap/internal/config"
+	"remap/internal/filter"
+	"remap/internal/parser"
+)
+
+func TestProcessFilesMaxPerDir(t *testing.T) {
+	const limit = 2
+
+	var files []filter.FileInfo
+	for _, dirName := range []string{"a", "b"} {
+		dir := filepath.Join(t.TempDir(), dirName)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		contents := make(map[string]string)
+		for i := 0; i < 6; i++ {
+			name := fmt.Sprintf("file%d.txt", i)
+			names = append(names, name)
+			contents[name] = "foo\n"
+		}
+		files = append(files, writeTestFiles(t, dir, names, contents)...)
+	}
+
+	cfg := &config.Config{NoBackup: true, MaxPerDir: limit}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "foo", To: "bar"}}))
+	processor.workerCount = 8
+
+	var mu sync.Mutex
+	inFlight := make(map[string]int)
+	maxInFlight := make(map[string]int)
+	processor.process = func(job ProcessJob) ProcessResult {
+		dir := filepath.Dir(job.FilePath)
+
+		mu.Lock()
+		inFlight[dir]++
+		if inFlight[dir] > maxInFlight[dir] {
+			maxInFlight[dir] = inFlight[dir]
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		inFlight[dir]--
+		mu.Unlock()
+
+		return ProcessResult{Job: job}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results, err := processor.ProcessFiles(ctx, files)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	count := 0
+	for range results {
+		count++
+	}
+	if count != len(files) {
+		t.Errorf("expected %d results, got %d", len(files), count)
+	}
+
+	for dir, peak := range maxInFlight {
+		if peak > limit {
+			t.Errorf("%s had %d files in flight, expected at most %d", dir, peak, limit)
+		}
+	}
+}
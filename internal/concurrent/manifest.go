@@ -0,0 +1,62 @@
+package concurrent
+
+import (
+	"context"
+	"os"
+	"regexp"
+
+	"remap/internal/errors"
+	"remap/internal/filter"
+	"remap/internal/parser"
+)
+
+// scanDefinitions runs the first pass of the two-pass mode.
+// Every file is searched for the definition pattern; the first capture group
+// of each match names a symbol, and the replacement template (expanded against
+// the same match) gives its new name. The resulting manifest is shared by all
+// workers in the second pass so a symbol defined in one file is renamed everywhere.
+// Symbols are identifiers, so they are matched case-sensitively whatever
+// --case-sensitive says, unless ignoreCase is set.
+func scanDefinitions(ctx context.Context, files []filter.FileInfo, pattern, template string, ignoreCase bool) ([]parser.Mapping, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.NewConfigError("invalid define pattern: "+pattern, err)
+	}
+
+	var manifest []parser.Mapping
+	seen := make(map[string]bool)
+	caseSensitive := !ignoreCase
+
+	for _, fileInfo := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		content, err := os.ReadFile(fileInfo.Path)
+		if err != nil {
+			// Unreadable files are reported by the second pass
+			continue
+		}
+
+		for _, match := range re.FindAllSubmatchIndex(content, -1) {
+			if len(match) < 4 || match[2] < 0 {
+				continue
+			}
+
+			symbol := string(content[match[2]:match[3]])
+			if symbol == "" || seen[symbol] {
+				continue
+			}
+
+			to := string(re.Expand(nil, []byte(template), content, match))
+			if to == symbol {
+				continue
+			}
+
+			seen[symbol] = true
+			manifest = append(manifest, parser.Mapping{From: symbol, To: to, CaseSensitive: &caseSensitive})
+		}
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,179 @@
+package concurrent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"remap/internal/config"
+	"remap/internal/filter"
+	"remap/internal/parser"
+)
+
+// writeTestFiles creates the given files in dir and returns their FileInfo in name order.
+func writeTestFiles(t *testing.T, dir string, names []string, contents map[string]string) []filter.FileInfo {
+	t.Helper()
+
+	var fileInfos []filter.FileInfo
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fileInfos = append(fileInfos, filter.FileInfo{
+			Path:    path,
+			Size:    info.Size(),
+			ModTime: info.ModTime().Unix(),
+		})
+	}
+	return fileInfos
+}
+
+func TestScanDefinitions(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"def.go", "use.go"}, map[string]string{
+		"def.go": "func legacyLoad() {}\nfunc legacySave() {}\n",
+		"use.go": "legacyLoad()\nlegacySave()\n",
+	})
+
+	manifest, err := scanDefinitions(context.Background(), files, `func (legacy(\w+))\(`, "modern$2", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []parser.Mapping{
+		{From: "legacyLoad", To: "modernLoad"},
+		{From: "legacySave", To: "modernSave"},
+	}
+	if len(manifest) != len(expected) {
+		t.Fatalf("expected %d manifest entries, got %d: %v", len(expected), len(manifest), manifest)
+	}
+	for i, mapping := range expected {
+		if manifest[i].From != mapping.From || manifest[i].To != mapping.To {
+			t.Errorf("manifest[%d] = %v, expected %v", i, manifest[i], mapping)
+		}
+		if !manifest[i].IsCaseSensitive(false) {
+			t.Errorf("manifest[%d] should match case-sensitively", i)
+		}
+	}
+}
+
+func TestScanDefinitionsInvalidPattern(t *testing.T) {
+	_, err := scanDefinitions(context.Background(), nil, `func (`, "x", false)
+	if err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestProcessFilesTwoPass(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"a_def.go", "b_use.go", "c_use.txt"}, map[string]string{
+		"a_def.go":  "func legacyLoad() {}\n",
+		"b_use.go":  "x := legacyLoad()\n",
+		"c_use.txt": "see legacyLoad for details\n",
+	})
+
+	cfg := &config.Config{
+		Directory:     tempDir,
+		NoBackup:      true,
+		CaseSensitive: true,
+		DefinePattern: `func (legacy(\w+))\(`,
+		DefineReplace: "modern$2",
+	}
+	processor := NewProcessor(cfg, parser.NewMappingTable(nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results, err := processor.ProcessFiles(ctx, files)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for result := range results {
+		if result.Error != nil {
+			t.Errorf("unexpected error for %s: %v", result.Job.FilePath, result.Error)
+		}
+	}
+
+	expected := map[string]string{
+		"a_def.go":  "func modernLoad() {}\n",
+		"b_use.go":  "x := modernLoad()\n",
+		"c_use.txt": "see modernLoad for details\n",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, expected %q", name, got, want)
+		}
+	}
+}
+
+func TestProcessFilesTwoPassCase(t *testing.T) {
+	tests := []struct {
+		name       string
+		ignoreCase bool
+		expected   string
+	}{
+		{
+			name:     "symbols match case-sensitively by default",
+			expected: "x := modernLoad()\nLEGACYLOAD = 1\n",
+		},
+		{
+			name:       "symbols match case-insensitively on request",
+			ignoreCase: true,
+			expected:   "x := modernLoad()\nmodernLoad = 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			files := writeTestFiles(t, tempDir, []string{"a_def.go", "b_use.go"}, map[string]string{
+				"a_def.go": "func legacyLoad() {}\n",
+				"b_use.go": "x := legacyLoad()\nLEGACYLOAD = 1\n",
+			})
+
+			// --case-sensitive is off, as it is by default
+			cfg := &config.Config{
+				Directory:        tempDir,
+				NoBackup:         true,
+				DefinePattern:    `func (legacy(\w+))\(`,
+				DefineReplace:    "modern$2",
+				DefineIgnoreCase: tt.ignoreCase,
+			}
+			processor := NewProcessor(cfg, parser.NewMappingTable(nil))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			results, err := processor.ProcessFiles(ctx, files)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for result := range results {
+				if result.Error != nil {
+					t.Errorf("unexpected error for %s: %v", result.Job.FilePath, result.Error)
+				}
+			}
+
+			got, err := os.ReadFile(filepath.Join(tempDir, "b_use.go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("b_use.go = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
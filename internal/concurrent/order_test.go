@@ -0,0 +1,49 @@
+package concurrent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"remap/internal/config"
+	"remap/internal/filter"
+	"remap/internal/parser"
+)
+
+// BenchmarkProcessFilesOrder compares wall-clock time when one large file is
+// discovered last. Fed in discovery order it starts only after the small files
+// and the pool idles while it finishes; sorted by size it overlaps with them.
+func BenchmarkProcessFilesOrder(b *testing.B) {
+	var files []filter.FileInfo
+	for i := 0; i < 15; i++ {
+		files = append(files, filter.FileInfo{Path: fmt.Sprintf("small%d.txt", i), Size: 1})
+	}
+	files = append(files, filter.FileInfo{Path: "large.txt", Size: 10})
+
+	for _, order := range []string{config.OrderDiscovery, config.OrderSizeDesc} {
+		b.Run(order, func(b *testing.B) {
+			ordered := append([]filter.FileInfo(nil), files...)
+			if order == config.OrderSizeDesc {
+				filter.SortBySizeDesc(ordered)
+			}
+
+			processor := NewProcessor(&config.Config{NoBackup: true}, parser.NewMappingTable(nil))
+			processor.workerCount = 4
+			processor.process = func(job ProcessJob) ProcessResult {
+				// Simulated work proportional to file size
+				time.Sleep(time.Duration(job.FileInfo.Size) * 2 * time.Millisecond)
+				return ProcessResult{Job: job}
+			}
+
+			for i := 0; i < b.N; i++ {
+				results, err := processor.ProcessFiles(context.Background(), ordered)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for range results {
+				}
+			}
+		})
+	}
+}
@@ -6,16 +6,20 @@ package concurrent
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"runtime"
-	"strings"
 	"sync"
+	"time"
 
 	"remap/internal/backup"
 	"remap/internal/config"
+	"remap/internal/diff"
 	"remap/internal/errors"
 	"remap/internal/filter"
+	"remap/internal/owner"
 	"remap/internal/parser"
 	"remap/internal/replacement"
+	"remap/internal/xattr"
 )
 
 // ProcessJob represents a single file processing task.
@@ -24,6 +28,7 @@ import (
 type ProcessJob struct {
 	FilePath string
 	FileInfo filter.FileInfo
+	Sequence int
 }
 
 // ProcessResult contains the complete result of processing a single file.
@@ -33,7 +38,13 @@ type ProcessResult struct {
 	Job        ProcessJob
 	Result     *replacement.FileResult
 	BackupPath string
+	Patch      string
 	Error      error
+
+	// Pending marks a modified file whose backup and write were held back
+	// by detect-only mode: Commit or Apply performs them, Skip drops the change.
+	Pending bool
+	content []byte
 }
 
 // Processor orchestrates concurrent file processing operations.
@@ -45,30 +56,118 @@ type Processor struct {
 	engine        *replacement.Engine
 	backupManager *backup.Manager
 	workerCount   int
+	dirLimiter    *dirLimiter
+	budget        *replacementBudget
+
+	// detectOnly holds back the backup and write of modified files, which
+	// are returned Pending; see SetDetectOnly.
+	detectOnly bool
+
+	// process handles a single job; it defaults to processFile and is
+	// replaced in tests to instrument scheduling.
+	process func(ProcessJob) ProcessResult
+
+	// writeContent writes rendered content to a temporary file; it is
+	// replaced in tests to simulate faulty storage.
+	writeContent func(file *os.File, content string) error
 }
 
 // NewProcessor creates a Processor with optimal worker pool sizing.
-// This constructor automatically determines the ideal number of workers
-// based on CPU cores while capping it to prevent resource contention.
+// The pool uses cfg.Workers when set; otherwise it sizes itself from the
+// CPU count, capped at 8 to prevent resource contention. A single worker
+// processes files serially in the order they are given.
 func NewProcessor(cfg *config.Config, mappings *parser.MappingTable) *Processor {
-	workerCount := runtime.NumCPU()
-	if workerCount > 8 {
-		workerCount = 8
+	workerCount := cfg.Workers
+	if workerCount <= 0 {
+		workerCount = runtime.NumCPU()
+		if workerCount > 8 {
+			workerCount = 8
+		}
+	}
+	if cfg.Interactive {
+		// Files are confirmed one at a time, in the order they are given
+		workerCount = 1
+	}
+
+	backupManager := backup.NewBackupManager(cfg.ShouldCreateBackup())
+	backupManager.SetPreserveXattrs(cfg.PreserveXattrs)
+	// Files are replaced by rename, which leaves a hard-link backup holding
+	// the old content; dry runs leave the file in place, so they copy
+	backupManager.SetHardLinks(cfg.HardLinkBackups && !cfg.DryRun)
+	backupManager.SetBackupDir(cfg.BackupDir, backupRoot(cfg.Directory))
+	if cfg.BackupArchive != "" {
+		backupManager.SetBackupArchive(cfg.BackupArchive)
 	}
 
-	return &Processor{
+	p := &Processor{
 		config:        cfg,
 		mappings:      mappings,
 		engine:        replacement.NewEngine(cfg),
-		backupManager: backup.NewBackupManager(cfg.ShouldCreateBackup()),
+		backupManager: backupManager,
 		workerCount:   workerCount,
+		dirLimiter:    newDirLimiter(cfg.MaxPerDir),
+		budget:        newReplacementBudget(cfg.Limit),
+		detectOnly:    cfg.Interactive || cfg.DetectOnly,
+	}
+	p.process = p.processFile
+	p.writeContent = writeString
+
+	return p
+}
+
+// SetDetectOnly selects detect-only processing: workers run the engine and
+// return their results without taking backups or writing, leaving modified
+// files Pending so the caller can preview or filter the changes before
+// committing them with Apply or Commit. --interactive and --detect-only use
+// this mode.
+func (p *Processor) SetDetectOnly(detectOnly bool) {
+	p.detectOnly = detectOnly
+}
+
+// OnProcessed registers fn to run in the worker after each job, before the
+// worker takes its next one.
+func (p *Processor) OnProcessed(fn func(ProcessResult)) {
+	process := p.process
+	p.process = func(job ProcessJob) ProcessResult {
+		result := process(job)
+		fn(result)
+		return result
 	}
 }
 
+// Close finishes the backup archive once every result has been received.
+func (p *Processor) Close() error {
+	return p.backupManager.Close()
+}
+
+// backupRoot returns the directory a --backup-dir tree mirrors: the target
+// directory, or the directory holding the target when it is a single file.
+func backupRoot(target string) string {
+	if info, err := os.Stat(target); err == nil && !info.IsDir() {
+		return filepath.Dir(target)
+	}
+	return target
+}
+
 // ProcessFiles processes multiple files concurrently using a worker pool.
 // This method coordinates parallel file processing with proper cancellation
 // support and resource cleanup, returning results through a channel.
 func (p *Processor) ProcessFiles(ctx context.Context, files []filter.FileInfo) (<-chan ProcessResult, error) {
+	if p.config.DefinePattern != "" {
+		manifest, err := scanDefinitions(ctx, files, p.config.DefinePattern, p.config.DefineReplace, p.config.DefineIgnoreCase)
+		if err != nil {
+			return nil, err
+		}
+		p.mappings = p.mappings.WithMappings(manifest)
+	}
+
+	// A spent --limit budget cancels this context, so workers stop taking
+	// jobs without the run being reported as interrupted
+	ctx, cancel := context.WithCancel(ctx)
+	if p.budget != nil {
+		p.budget.stop = cancel
+	}
+
 	jobs := make(chan ProcessJob, len(files))
 	results := make(chan ProcessResult, len(files))
 
@@ -84,9 +183,9 @@ func (p *Processor) ProcessFiles(ctx context.Context, files []filter.FileInfo) (
 
 	go func() {
 		defer close(jobs)
-		for _, fileInfo := range files {
+		for i, fileInfo := range files {
 			select {
-			case jobs <- ProcessJob{FilePath: fileInfo.Path, FileInfo: fileInfo}:
+			case jobs <- ProcessJob{FilePath: fileInfo.Path, FileInfo: fileInfo, Sequence: i}:
 			case <-ctx.Done():
 				return
 			}
@@ -95,31 +194,55 @@ func (p *Processor) ProcessFiles(ctx context.Context, files []filter.FileInfo) (
 
 	go func() {
 		wg.Wait()
+		cancel()
 		close(results)
 	}()
 
 	return results, nil
 }
 
+// worker processes jobs until the queue is drained or the context is
+// cancelled. Cancellation is only checked between jobs: a file being
+// processed is always finished (its write is a temp file and rename, so it
+// either happens completely or not at all) and its result delivered, so the
+// report covers every file that was modified. results is buffered for every
+// job, so delivering never blocks.
 func (p *Processor) worker(ctx context.Context, workerID int, jobs <-chan ProcessJob, results chan<- ProcessResult) {
 	for {
+		if ctx.Err() != nil {
+			return
+		}
 		select {
 		case job, ok := <-jobs:
-			if !ok {
+			if !ok || ctx.Err() != nil {
 				return
 			}
-			result := p.processFile(job)
-			select {
-			case results <- result:
-			case <-ctx.Done():
+			result, ok := p.runJob(ctx, job)
+			if !ok {
 				return
 			}
+			results <- result
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// runJob processes a job while holding its directory slot when a
+// per-directory limit is configured. It returns false if the context was
+// cancelled while waiting for a slot.
+func (p *Processor) runJob(ctx context.Context, job ProcessJob) (ProcessResult, bool) {
+	if p.dirLimiter != nil {
+		dir := filepath.Dir(job.FilePath)
+		if !p.dirLimiter.acquire(ctx, dir) {
+			return ProcessResult{}, false
+		}
+		defer p.dirLimiter.release(dir)
+	}
+
+	return p.process(job), true
+}
+
 func (p *Processor) processFile(job ProcessJob) ProcessResult {
 	result := ProcessResult{
 		Job: job,
@@ -131,15 +254,60 @@ func (p *Processor) processFile(job ProcessJob) ProcessResult {
 		return result
 	}
 
-	replacementResult := p.engine.ProcessFile(job.FilePath, content, p.mappings)
+	bom, body := splitBOM(content)
+	replacementResult := p.engine.ProcessFile(job.FilePath, body, p.mappings)
+	if len(bom) > 0 {
+		includeBOM(replacementResult, len(bom))
+	}
 	result.Result = replacementResult
+	if replacementResult.Error != nil {
+		result.Error = replacementResult.Error
+		return result
+	}
 
 	if !replacementResult.Modified {
 		return result
 	}
 
+	if p.budget != nil && !p.budget.take(len(replacementResult.Replacements)) {
+		// Over the --limit budget: the file is left untouched rather than
+		// partially rewritten
+		leaveUntouched(replacementResult)
+		return result
+	}
+
+	if p.config.PatchFile != "" {
+		result.Patch = p.patchFor(job.FilePath, content)
+	}
+
+	if !p.config.DryRun {
+		info, err := os.Stat(job.FilePath)
+		if err != nil {
+			result.Error = errors.WrapFileError(job.FilePath, err)
+			return result
+		}
+		if err := checkWritable(job.FilePath, info); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	if p.detectOnly {
+		result.Pending = true
+		result.content = content
+		return result
+	}
+
+	return p.commit(result, content)
+}
+
+// commit backs up the file of a modified result and writes its new content,
+// rendered from content.
+func (p *Processor) commit(result ProcessResult, content []byte) ProcessResult {
+	filePath := result.Job.FilePath
+
 	if p.config.ShouldCreateBackup() {
-		backupPath, backupErr := p.backupManager.BackupFile(job.FilePath)
+		backupPath, backupErr := p.backupManager.BackupFile(filePath)
 		if backupErr != nil {
 			result.Error = backupErr
 			return result
@@ -148,10 +316,10 @@ func (p *Processor) processFile(job ProcessJob) ProcessResult {
 	}
 
 	if !p.config.DryRun {
-		err = p.writeFile(job.FilePath, result.Result.Replacements, content)
+		err := p.writeFile(filePath, result.Result.Replacements, content)
 		if err != nil {
 			if result.BackupPath != "" {
-				_ = p.backupManager.RestoreFile(job.FilePath, result.BackupPath)
+				_ = p.backupManager.RestoreFile(filePath, result.BackupPath)
 			}
 			result.Error = err
 			return result
@@ -161,6 +329,71 @@ func (p *Processor) processFile(job ProcessJob) ProcessResult {
 	return result
 }
 
+// Apply commits every Pending result, spreading the writes over the worker
+// pool, and returns the results in the same order. Results that are not
+// Pending are returned as they are.
+func (p *Processor) Apply(results []ProcessResult) []ProcessResult {
+	applied := make([]ProcessResult, len(results))
+	indexes := make(chan int, len(results))
+	for i := range results {
+		indexes <- i
+	}
+	close(indexes)
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.workerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				applied[index] = p.Commit(results[index])
+			}
+		}()
+	}
+	wg.Wait()
+
+	return applied
+}
+
+// Commit performs the backup and write held back for a Pending result once
+// the change has been accepted.
+func (p *Processor) Commit(result ProcessResult) ProcessResult {
+	if !result.Pending {
+		return result
+	}
+	content := result.content
+	result.Pending, result.content = false, nil
+	return p.commit(result, content)
+}
+
+// Skip drops the change held back for a Pending result, which is then
+// reported as an unmodified file.
+func (p *Processor) Skip(result ProcessResult) ProcessResult {
+	if !result.Pending {
+		return result
+	}
+	result.Pending, result.content = false, nil
+	result.Patch = ""
+	leaveUntouched(result.Result)
+	return result
+}
+
+// Preview returns the unified diff of the change held back for a Pending
+// result, for the --interactive prompt.
+func (p *Processor) Preview(result ProcessResult) string {
+	return p.patchFor(result.Job.FilePath, result.content)
+}
+
+// leaveUntouched turns the result of a modified file into that of a file
+// left as it is.
+func leaveUntouched(result *replacement.FileResult) {
+	result.Modified = false
+	result.Replacements = nil
+	result.UsedMappings = nil
+	result.DeletedBytes = 0
+	result.NewContent = nil
+}
+
 func (p *Processor) writeFile(filePath string, _ []replacement.Replacement, originalContent []byte) error {
 	info, err := os.Stat(filePath)
 	if err != nil {
@@ -176,17 +409,9 @@ func (p *Processor) writeFile(filePath string, _ []replacement.Replacement, orig
 	defer file.Close()
 	defer os.Remove(tempFile)
 
-	content := string(originalContent)
+	content := p.render(filePath, originalContent)
 
-	for _, mapping := range p.mappings.GetSortedMappings() {
-		if p.config.CaseSensitive {
-			content = replaceAll(content, mapping.From, mapping.To)
-		} else {
-			content = caseInsensitiveReplaceAll(content, mapping.From, mapping.To)
-		}
-	}
-
-	_, err = file.WriteString(content)
+	err = p.writeContent(file, content)
 	if err != nil {
 		return errors.NewFileNotWritableError(filePath, err)
 	}
@@ -198,131 +423,69 @@ func (p *Processor) writeFile(filePath string, _ []replacement.Replacement, orig
 
 	_ = file.Close()
 
-	err = os.Chmod(tempFile, info.Mode())
+	// Changing the owner clears setuid and setgid bits, so it comes before the mode
+	err = owner.Copy(filePath, tempFile, info)
 	if err != nil {
-		return errors.NewFileNotWritableError(filePath, err)
+		return err
 	}
 
-	err = os.Rename(tempFile, filePath)
+	err = os.Chmod(tempFile, info.Mode())
 	if err != nil {
 		return errors.NewFileNotWritableError(filePath, err)
 	}
 
-	return nil
-}
-
-func replaceAll(content, from, to string) string {
-	if from == "" {
-		return content
+	if p.config.PreserveXattrs {
+		// Best effort: a file whose attributes cannot be copied is still rewritten
+		_ = xattr.Copy(filePath, tempFile)
 	}
 
-	var result strings.Builder
-	start := 0
-
-	for start < len(content) {
-		index := findStringFrom(content, from, start)
-		if index == -1 {
-			// No more matches, append the rest
-			result.WriteString(content[start:])
-			break
+	if p.config.PreserveMtime {
+		// Set on the temporary file so the rename publishes content and time together
+		err = os.Chtimes(tempFile, time.Time{}, info.ModTime())
+		if err != nil {
+			return errors.NewFileNotWritableError(filePath, err)
 		}
-
-		// Append content before the match
-		result.WriteString(content[start:index])
-		// Append the replacement
-		result.WriteString(to)
-		// Move past this match to prevent re-processing
-		start = index + len(from)
 	}
 
-	return result.String()
-}
-
-func replaceFirst(content, from, to string) string {
-	index := findString(content, from)
-	if index == -1 {
-		return content
+	err = os.Rename(tempFile, filePath)
+	if err != nil {
+		return errors.NewFileNotWritableError(filePath, err)
 	}
-	return content[:index] + to + content[index+len(from):]
-}
 
-func findStringFrom(content, search string, start int) int {
-	for i := start; i <= len(content)-len(search); i++ {
-		if content[i:i+len(search)] == search {
-			return i
-		}
+	if p.config.VerifyWrites {
+		return verifyWrite(filePath, content)
 	}
-	return -1
-}
 
-func findString(content, search string) int {
-	for i := 0; i <= len(content)-len(search); i++ {
-		if content[i:i+len(search)] == search {
-			return i
-		}
-	}
-	return -1
+	return nil
 }
 
-func caseInsensitiveReplaceAll(content, from, to string) string {
-	if from == "" {
-		return content
-	}
-
-	var result strings.Builder
-	start := 0
-
-	for start < len(content) {
-		index := caseInsensitiveFindStringFrom(content, from, start)
-		if index == -1 {
-			// No more matches, append the rest
-			result.WriteString(content[start:])
-			break
-		}
-
-		// Append content before the match
-		result.WriteString(content[start:index])
-		// Append the replacement
-		result.WriteString(to)
-		// Move past this match to prevent re-processing
-		start = index + len(from)
-	}
-
-	return result.String()
+func writeString(file *os.File, content string) error {
+	_, err := file.WriteString(content)
+	return err
 }
 
-func caseInsensitiveReplaceFirst(content, from, to string) string {
-	index := caseInsensitiveFindString(content, from)
-	if index == -1 {
-		return content
-	}
-	return content[:index] + to + content[index+len(from):]
+// Render returns content, read from filePath, with every mapping applied
+// exactly as it would be written, without touching the file. It serves
+// --to-stdout, which prints the result instead of writing it.
+func (p *Processor) Render(filePath string, content []byte) string {
+	return p.render(filePath, content)
 }
 
-func caseInsensitiveFindString(content, search string) int {
-	return caseInsensitiveFindStringFrom(content, search, 0)
+// render returns the file content with all replacements applied. A leading
+// byte order mark is kept out of matching and written back unchanged.
+func (p *Processor) render(filePath string, content []byte) string {
+	bom, body := splitBOM(content)
+	return string(bom) + replacement.Render(p.config, p.mappings, filePath, string(body))
 }
 
-func caseInsensitiveFindStringFrom(content, search string, start int) int {
-	contentLower := toLower(content)
-	searchLower := toLower(search)
-
-	for i := start; i <= len(contentLower)-len(searchLower); i++ {
-		if contentLower[i:i+len(searchLower)] == searchLower {
-			return i
-		}
+// patchFor renders the change to filePath as a unified diff with a/ and b/
+// prefixes relative to the processed directory, ready for `patch -p1`.
+func (p *Processor) patchFor(filePath string, content []byte) string {
+	name := filePath
+	if rel, err := filepath.Rel(p.config.Directory, filePath); err == nil {
+		name = rel
 	}
-	return -1
-}
+	name = filepath.ToSlash(name)
 
-func toLower(s string) string {
-	result := make([]byte, len(s))
-	for i, b := range []byte(s) {
-		if b >= 'A' && b <= 'Z' {
-			result[i] = b + 32
-		} else {
-			result[i] = b
-		}
-	}
-	return string(result)
+	return diff.Unified("a/"+name, "b/"+name, string(content), p.render(filePath, content))
 }
@@ -2,6 +2,7 @@ package concurrent
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -76,6 +77,59 @@ func TestNewProcessor(t *testing.T) {
 	}
 }
 
+func TestNewProcessorWorkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		workers  int
+		expected int
+	}{
+		{"explicit pool above the default cap", 32, 32},
+		{"serial", 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := NewProcessor(&config.Config{Workers: tt.workers}, parser.NewMappingTable(nil))
+			if processor.workerCount != tt.expected {
+				t.Errorf("expected %d workers, got %d", tt.expected, processor.workerCount)
+			}
+		})
+	}
+}
+
+func TestProcessFilesSerialOrder(t *testing.T) {
+	var files []filter.FileInfo
+	for i := 0; i < 20; i++ {
+		files = append(files, filter.FileInfo{Path: fmt.Sprintf("/src/file%02d.txt", i)})
+	}
+
+	processor := NewProcessor(&config.Config{Workers: 1}, parser.NewMappingTable(nil))
+	var processed []string
+	processor.process = func(job ProcessJob) ProcessResult {
+		processed = append(processed, job.FilePath)
+		return ProcessResult{Job: job}
+	}
+
+	results, err := processor.ProcessFiles(context.Background(), files)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var sequences []int
+	for result := range results {
+		sequences = append(sequences, result.Job.Sequence)
+	}
+
+	for i, file := range files {
+		if processed[i] != file.Path {
+			t.Fatalf("expected files processed in order, got %v", processed)
+		}
+		if sequences[i] != i {
+			t.Fatalf("expected results in order, got %v", sequences)
+		}
+	}
+}
+
 func TestProcessFiles(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -301,242 +355,6 @@ func TestProcessFileInternal(t *testing.T) {
 	}
 }
 
-func TestReplaceAll(t *testing.T) {
-	tests := []struct {
-		name     string
-		content  string
-		from     string
-		to       string
-		expected string
-	}{
-		{
-			name:     "simple replacement",
-			content:  "hello world hello",
-			from:     "hello",
-			to:       "hi",
-			expected: "hi world hi",
-		},
-		{
-			name:     "no matches",
-			content:  "hello world",
-			from:     "foo",
-			to:       "bar",
-			expected: "hello world",
-		},
-		{
-			name:     "empty from string",
-			content:  "hello world",
-			from:     "",
-			to:       "replacement",
-			expected: "hello world",
-		},
-		{
-			name:     "overlapping replacements",
-			content:  "aaaa",
-			from:     "aa",
-			to:       "b",
-			expected: "bb",
-		},
-		{
-			name:     "replacement creates new matches",
-			content:  "abc",
-			from:     "bc",
-			to:       "bbc",
-			expected: "abbc",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := replaceAll(tt.content, tt.from, tt.to)
-			if result != tt.expected {
-				t.Errorf("expected %q, got %q", tt.expected, result)
-			}
-		})
-	}
-}
-
-func TestReplaceFirst(t *testing.T) {
-	tests := []struct {
-		name     string
-		content  string
-		from     string
-		to       string
-		expected string
-	}{
-		{
-			name:     "first occurrence replacement",
-			content:  "hello world hello",
-			from:     "hello",
-			to:       "hi",
-			expected: "hi world hello",
-		},
-		{
-			name:     "no match",
-			content:  "hello world",
-			from:     "foo",
-			to:       "bar",
-			expected: "hello world",
-		},
-		{
-			name:     "replacement at beginning",
-			content:  "hello world",
-			from:     "hello",
-			to:       "hi",
-			expected: "hi world",
-		},
-		{
-			name:     "replacement at end",
-			content:  "hello world",
-			from:     "world",
-			to:       "universe",
-			expected: "hello universe",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := replaceFirst(tt.content, tt.from, tt.to)
-			if result != tt.expected {
-				t.Errorf("expected %q, got %q", tt.expected, result)
-			}
-		})
-	}
-}
-
-func TestFindString(t *testing.T) {
-	tests := []struct {
-		name     string
-		content  string
-		search   string
-		expected int
-	}{
-		{
-			name:     "found at beginning",
-			content:  "hello world",
-			search:   "hello",
-			expected: 0,
-		},
-		{
-			name:     "found in middle",
-			content:  "hello world",
-			search:   "lo wo",
-			expected: 3,
-		},
-		{
-			name:     "not found",
-			content:  "hello world",
-			search:   "foo",
-			expected: -1,
-		},
-		{
-			name:     "empty search",
-			content:  "hello world",
-			search:   "",
-			expected: 0,
-		},
-		{
-			name:     "search longer than content",
-			content:  "hi",
-			search:   "hello",
-			expected: -1,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := findString(tt.content, tt.search)
-			if result != tt.expected {
-				t.Errorf("expected %d, got %d", tt.expected, result)
-			}
-		})
-	}
-}
-
-func TestCaseInsensitiveReplace(t *testing.T) {
-	tests := []struct {
-		name     string
-		content  string
-		from     string
-		to       string
-		expected string
-	}{
-		{
-			name:     "case insensitive replacement",
-			content:  "Hello WORLD hello",
-			from:     "hello",
-			to:       "hi",
-			expected: "hi WORLD hi",
-		},
-		{
-			name:     "mixed case replacement",
-			content:  "FoO bar FOO",
-			from:     "foo",
-			to:       "baz",
-			expected: "baz bar baz",
-		},
-		{
-			name:     "no matches",
-			content:  "hello world",
-			from:     "xyz",
-			to:       "abc",
-			expected: "hello world",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := caseInsensitiveReplaceAll(tt.content, tt.from, tt.to)
-			if result != tt.expected {
-				t.Errorf("expected %q, got %q", tt.expected, result)
-			}
-		})
-	}
-}
-
-func TestToLower(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{
-			name:     "uppercase letters",
-			input:    "HELLO",
-			expected: "hello",
-		},
-		{
-			name:     "mixed case",
-			input:    "Hello World",
-			expected: "hello world",
-		},
-		{
-			name:     "already lowercase",
-			input:    "hello world",
-			expected: "hello world",
-		},
-		{
-			name:     "with numbers and symbols",
-			input:    "Hello123!@#",
-			expected: "hello123!@#",
-		},
-		{
-			name:     "empty string",
-			input:    "",
-			expected: "",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := toLower(tt.input)
-			if result != tt.expected {
-				t.Errorf("expected %q, got %q", tt.expected, result)
-			}
-		})
-	}
-}
-
 func TestWriteFile(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -686,3 +504,695 @@ func TestContextCancellation(t *testing.T) {
 	// timing is non-deterministic, but we verify the mechanism works
 	t.Logf("Processed %d results with cancelled context", resultCount)
 }
+
+func TestCancellationFinishesInFlightFile(t *testing.T) {
+	var files []filter.FileInfo
+	for i := 0; i < 5; i++ {
+		files = append(files, filter.FileInfo{Path: fmt.Sprintf("/src/file%d.txt", i)})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	processor := NewProcessor(&config.Config{Workers: 1}, parser.NewMappingTable(nil))
+	var processed []string
+	processor.process = func(job ProcessJob) ProcessResult {
+		// The interrupt arrives while the first file is being written
+		cancel()
+		processed = append(processed, job.FilePath)
+		return ProcessResult{Job: job}
+	}
+
+	results, err := processor.ProcessFiles(ctx, files)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var delivered []string
+	for result := range results {
+		delivered = append(delivered, result.Job.FilePath)
+	}
+
+	if len(processed) != 1 {
+		t.Fatalf("expected no job to start after cancellation, processed %v", processed)
+	}
+	if len(delivered) != 1 || delivered[0] != processed[0] {
+		t.Errorf("expected the in-flight result to be delivered, got %v", delivered)
+	}
+}
+
+func TestWriteFileScopedMappings(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"main.go", "notes.txt"}, map[string]string{
+		"main.go":   "foo hello",
+		"notes.txt": "foo hello",
+	})
+
+	cfg := &config.Config{Directory: tempDir, NoBackup: true, CaseSensitive: true}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{
+		{From: "foo", To: "bar", AppliesTo: "*.go"},
+		{From: "hello", To: "hi"},
+	}))
+
+	for _, file := range files {
+		if result := processor.processFile(ProcessJob{FilePath: file.Path, FileInfo: file}); result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+	}
+
+	expected := map[string]string{"main.go": "bar hi", "notes.txt": "foo hi"}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, expected %q", name, got, want)
+		}
+	}
+}
+
+func TestWriteFileTemplateMappings(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"main.go"}, map[string]string{
+		"main.go": "x := getFooBar()\ny := getFooBaz()\n",
+	})
+
+	cfg := &config.Config{Directory: tempDir, NoBackup: true, CaseSensitive: true, TemplateMappings: true}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "getFoo*()", To: "get{}()"}}))
+
+	result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "x := getBar()\ny := getBaz()\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestWriteFileGraphemeAware(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"notes.txt"}, map[string]string{
+		"notes.txt": "👍🏽 and 👍\n",
+	})
+
+	cfg := &config.Config{Directory: tempDir, NoBackup: true, CaseSensitive: true, GraphemeAware: true}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "👍", To: "ok"}}))
+
+	result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "👍🏽 and ok\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestWriteFilePreserveIndent(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"main.go"}, map[string]string{
+		"main.go": "func main() {\n\t// INIT\n}\n",
+	})
+
+	cfg := &config.Config{Directory: tempDir, NoBackup: true, CaseSensitive: true, PreserveIndent: true}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "// INIT", To: "setup()\ndefer teardown()"}}))
+
+	result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "func main() {\n\tsetup()\n\tdefer teardown()\n}\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+	if to := result.Result.Replacements[0].To; to != "setup()\n\tdefer teardown()" {
+		t.Errorf("expected reported replacement to be re-indented, got %q", to)
+	}
+}
+
+func TestWriteFileCRLF(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"main.go"}, map[string]string{
+		"main.go": "func main() {\r\n\t// INIT\r\n}\r\n",
+	})
+
+	cfg := &config.Config{Directory: tempDir, NoBackup: true, CaseSensitive: true, PreserveIndent: true}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "// INIT", To: "setup()\ndefer teardown()"}}))
+
+	result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "func main() {\r\n\tsetup()\r\n\tdefer teardown()\r\n}\r\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+	if to := result.Result.Replacements[0].To; to != "setup()\r\n\tdefer teardown()" {
+		t.Errorf("expected the reported replacement to use CRLF, got %q", to)
+	}
+}
+
+func TestWriteFileWordBoundary(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"main.go"}, map[string]string{
+		"main.go": "id := valid(width)\nreturn id.id",
+	})
+
+	cfg := &config.Config{Directory: tempDir, NoBackup: true, CaseSensitive: true, WordBoundary: true}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "id", To: "identifier"}}))
+
+	result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if len(result.Result.Replacements) != 3 {
+		t.Errorf("expected 3 detected replacements, got %d", len(result.Result.Replacements))
+	}
+
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "identifier := valid(width)\nreturn identifier.identifier"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestWriteFileLineCase(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"notes.md"}, map[string]string{
+		"notes.md": "## Warning: old api\nuse the old api\n",
+	})
+
+	cfg := &config.Config{Directory: tempDir, NoBackup: true}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{
+		{From: "warning", LineCase: parser.LineCaseUpper},
+		{From: "old api", To: "new api"},
+	}))
+
+	result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	// The converted line, recorded with the "old api" on it, and the
+	// replacement on the second line
+	if len(result.Result.Replacements) != 2 {
+		t.Errorf("expected 2 recorded replacements, got %d", len(result.Result.Replacements))
+	}
+
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "## WARNING: new api\nuse the new api\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestWriteFileOccurrence(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"file.txt"}, map[string]string{
+		"file.txt": "foo\n# BEGIN\nfoo foo\n# END\nfoo\n",
+	})
+
+	// Sections split the file, but the occurrence is counted in the whole file
+	cfg := &config.Config{Directory: tempDir, NoBackup: true, SectionBegin: "# BEGIN", SectionEnd: "# END"}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "foo", To: "bar", OccurrenceIndex: 2}}))
+
+	result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if len(result.Result.Replacements) != 1 {
+		t.Errorf("expected 1 detected replacement, got %d", len(result.Result.Replacements))
+	}
+
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "foo\n# BEGIN\nfoo bar\n# END\nfoo\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestProcessFileHardLinkBackup(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"config.txt"}, map[string]string{
+		"config.txt": "host=old.example.com\n",
+	})
+
+	cfg := &config.Config{Directory: tempDir, CaseSensitive: true, HardLinkBackups: true}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "old.example.com", To: "new.example.com"}}))
+
+	result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if result.BackupPath == "" {
+		t.Fatal("expected a backup")
+	}
+
+	// The rename-based write gives the file a new inode; the backup keeps the old one
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "host=new.example.com\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+
+	backup, err := os.ReadFile(result.BackupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "host=old.example.com\n"; string(backup) != expected {
+		t.Errorf("expected backup %q, got %q", expected, backup)
+	}
+}
+
+func TestWriteFileSections(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"hosts"}, map[string]string{
+		"hosts": "10.0.0.1 db\n# BEGIN managed\n10.0.0.1 app\n# END managed\n",
+	})
+
+	cfg := &config.Config{
+		Directory:    tempDir,
+		NoBackup:     true,
+		SectionBegin: "# BEGIN managed",
+		SectionEnd:   "# END managed",
+	}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "10.0.0.1", To: "10.0.0.2"}}))
+
+	result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "10.0.0.1 db\n# BEGIN managed\n10.0.0.2 app\n# END managed\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestWriteFileLineRanges(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"hosts"}, map[string]string{
+		"hosts": "10.0.0.1 a\n10.0.0.1 b\n10.0.0.1 c\n10.0.0.1 d\n10.0.0.1 e\n",
+	})
+
+	cfg := &config.Config{Directory: tempDir, NoBackup: true, Lines: []string{":1", "3:4"}}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "10.0.0.1", To: "10.0.0.2"}}))
+
+	result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if len(result.Result.Replacements) != 3 {
+		t.Errorf("expected 3 replacements within the ranges, got %d", len(result.Result.Replacements))
+	}
+
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "10.0.0.2 a\n10.0.0.1 b\n10.0.0.2 c\n10.0.0.2 d\n10.0.0.1 e\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestWriteFileRuleOptions(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"notes.txt"}, map[string]string{
+		"notes.txt": "Foo foo v1.2\nbaz BAZ V3.4\n",
+	})
+
+	sensitive := true
+	table := parser.NewMappingTable([]parser.Mapping{
+		{From: "Foo", To: "Bar", CaseSensitive: &sensitive},
+		{From: "baz", To: "qux"},
+		{From: `v(\d+)\.(\d+)`, To: "$1-$2", Regex: true},
+	})
+	processor := NewProcessor(&config.Config{Directory: tempDir, NoBackup: true}, table)
+
+	result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if len(result.Result.Replacements) != 5 {
+		t.Errorf("expected 5 replacements, got %d", len(result.Result.Replacements))
+	}
+
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "Bar foo 1-2\nqux qux 3-4\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestWriteFileToTemplate(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"service.conf"}, map[string]string{
+		"service.conf": "# generated for @FILE@\nname = @FILE@\n",
+	})
+
+	table := parser.NewMappingTable([]parser.Mapping{{From: "@FILE@", To: "{{.File}}"}})
+	if err := table.CompileToTemplates(replacement.MatchContext{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	processor := NewProcessor(&config.Config{Directory: tempDir, NoBackup: true, CaseSensitive: true}, table)
+
+	result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	content, err := os.ReadFile(files[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "# generated for service.conf\nname = service.conf\n"; string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+}
+
+func TestWriteFileMappingPrecedence(t *testing.T) {
+	tempDir := t.TempDir()
+	localDir := filepath.Join(tempDir, "legacy")
+	if err := os.Mkdir(localDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	globalFiles := writeTestFiles(t, tempDir, []string{"app.conf"}, map[string]string{"app.conf": "host=db.old\n"})
+	localFiles := writeTestFiles(t, localDir, []string{"app.conf"}, map[string]string{"app.conf": "host=db.old\n"})
+
+	cfg := &config.Config{Directory: tempDir, NoBackup: true}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{
+		{From: "db.old", To: "db.new"},
+		{From: "db.old", To: "db.legacy", AppliesTo: filepath.Join(localDir, "*")},
+	}))
+
+	expected := map[string]string{
+		globalFiles[0].Path: "db.new",
+		localFiles[0].Path:  "db.legacy",
+	}
+	for _, file := range append(globalFiles, localFiles...) {
+		result := processor.processFile(ProcessJob{FilePath: file.Path, FileInfo: file})
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+		if len(result.Result.Replacements) != 1 {
+			t.Errorf("%s: expected a single replacement, got %v", file.Path, result.Result.Replacements)
+		}
+
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "host=" + expected[file.Path] + "\n"; string(content) != want {
+			t.Errorf("%s = %q, expected %q", file.Path, content, want)
+		}
+	}
+}
+
+func TestProcessFileUnchangedContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		mappings []parser.Mapping
+	}{
+		{name: "identity mapping", mappings: []parser.Mapping{{From: "localhost", To: "localhost"}}},
+		{name: "mappings cancelling out", mappings: []parser.Mapping{
+			{From: "localhost", To: "127.0.0.1"},
+			{From: "127.0.0.1", To: "localhost"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			files := writeTestFiles(t, tempDir, []string{"hosts"}, map[string]string{
+				"hosts": "db=localhost\n",
+			})
+			past := time.Now().Add(-time.Hour).Truncate(time.Second)
+			if err := os.Chtimes(files[0].Path, past, past); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := &config.Config{Directory: tempDir}
+			processor := NewProcessor(cfg, parser.NewMappingTable(tt.mappings))
+
+			result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+			if result.Error != nil {
+				t.Fatalf("unexpected error: %v", result.Error)
+			}
+			if result.Result.Modified {
+				t.Error("expected the file to be reported as not modified")
+			}
+			if result.BackupPath != "" {
+				t.Errorf("expected no backup, got %s", result.BackupPath)
+			}
+
+			info, err := os.Stat(files[0].Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !info.ModTime().Equal(past) {
+				t.Errorf("expected the file not to be rewritten, modified at %v", info.ModTime())
+			}
+			backups, _ := filepath.Glob(files[0].Path + ".*.bak")
+			if len(backups) != 0 {
+				t.Errorf("expected no backup files, got %v", backups)
+			}
+		})
+	}
+}
+
+func TestWriteFilePreserveMtime(t *testing.T) {
+	tests := []struct {
+		name          string
+		preserveMtime bool
+	}{
+		{name: "mtime bumped by default", preserveMtime: false},
+		{name: "mtime preserved", preserveMtime: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			files := writeTestFiles(t, tempDir, []string{"main.go"}, map[string]string{
+				"main.go": "package old\n",
+			})
+			past := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+			if err := os.Chtimes(files[0].Path, past, past); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := &config.Config{Directory: tempDir, NoBackup: true, PreserveMtime: tt.preserveMtime}
+			processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "old", To: "new"}}))
+
+			result := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+			if result.Error != nil {
+				t.Fatalf("unexpected error: %v", result.Error)
+			}
+
+			content, err := os.ReadFile(files[0].Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := "package new\n"; string(content) != expected {
+				t.Errorf("expected %q, got %q", expected, content)
+			}
+
+			info, err := os.Stat(files[0].Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.ModTime().Equal(past) != tt.preserveMtime {
+				t.Errorf("modification time %v, original %v, preserve %v", info.ModTime(), past, tt.preserveMtime)
+			}
+		})
+	}
+}
+
+func TestProcessFileInteractiveCommit(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"a.txt", "b.txt"}, map[string]string{
+		"a.txt": "foo\n",
+		"b.txt": "foo\n",
+	})
+
+	cfg := &config.Config{Directory: tempDir, Interactive: true}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "foo", To: "bar"}}))
+	if processor.workerCount != 1 {
+		t.Errorf("expected a single worker in interactive mode, got %d", processor.workerCount)
+	}
+
+	accepted := processor.processFile(ProcessJob{FilePath: files[0].Path, FileInfo: files[0]})
+	declined := processor.processFile(ProcessJob{FilePath: files[1].Path, FileInfo: files[1]})
+	for _, result := range []ProcessResult{accepted, declined} {
+		if !result.Pending || result.BackupPath != "" {
+			t.Fatalf("expected the write to wait for confirmation, got pending=%v backup=%q", result.Pending, result.BackupPath)
+		}
+		if content, _ := os.ReadFile(result.Job.FilePath); string(content) != "foo\n" {
+			t.Errorf("expected %s untouched before confirmation, got %q", result.Job.FilePath, content)
+		}
+	}
+	if preview := processor.Preview(accepted); !strings.Contains(preview, "-foo\n+bar\n") {
+		t.Errorf("expected a diff preview, got %q", preview)
+	}
+
+	accepted = processor.Commit(accepted)
+	declined = processor.Skip(declined)
+
+	if accepted.Error != nil || accepted.BackupPath == "" || !accepted.Result.Modified {
+		t.Errorf("expected a committed write with a backup, got %+v", accepted)
+	}
+	if content, _ := os.ReadFile(files[0].Path); string(content) != "bar\n" {
+		t.Errorf("expected the accepted file rewritten, got %q", content)
+	}
+	if declined.Result.Modified || len(declined.Result.Replacements) != 0 {
+		t.Errorf("expected the declined file reported as unmodified, got %+v", declined.Result)
+	}
+	if content, _ := os.ReadFile(files[1].Path); string(content) != "foo\n" {
+		t.Errorf("expected the declined file untouched, got %q", content)
+	}
+}
+
+func TestProcessFilesDetectOnlyApply(t *testing.T) {
+	tempDir := t.TempDir()
+	files := writeTestFiles(t, tempDir, []string{"keep.txt", "skip.txt", "clean.txt"}, map[string]string{
+		"keep.txt":  "foo\n",
+		"skip.txt":  "foo\n",
+		"clean.txt": "nothing to do\n",
+	})
+
+	cfg := &config.Config{Directory: tempDir}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "foo", To: "bar"}}))
+	processor.SetDetectOnly(true)
+
+	resultsChan, err := processor.ProcessFiles(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []ProcessResult
+	for result := range resultsChan {
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+		if result.Pending != result.Result.Modified {
+			t.Errorf("%s: pending=%v modified=%v, expected modified files to be pending", result.Job.FilePath, result.Pending, result.Result.Modified)
+		}
+		if result.BackupPath != "" {
+			t.Errorf("%s: expected no backup before Apply, got %s", result.Job.FilePath, result.BackupPath)
+		}
+		if filepath.Base(result.Job.FilePath) == "skip.txt" {
+			result = processor.Skip(result)
+		}
+		results = append(results, result)
+	}
+
+	for _, file := range files {
+		if content, _ := os.ReadFile(file.Path); strings.Contains(string(content), "bar") {
+			t.Errorf("%s: expected no write before Apply, got %q", file.Path, content)
+		}
+	}
+
+	applied := processor.Apply(results)
+	if len(applied) != len(results) {
+		t.Fatalf("expected %d results, got %d", len(results), len(applied))
+	}
+	for i, result := range applied {
+		if result.Job.FilePath != results[i].Job.FilePath {
+			t.Errorf("expected results in their original order, got %s at %d", result.Job.FilePath, i)
+		}
+		if result.Pending || result.Error != nil {
+			t.Errorf("%s: pending=%v error=%v after Apply", result.Job.FilePath, result.Pending, result.Error)
+		}
+	}
+
+	expected := map[string]string{"keep.txt": "bar\n", "skip.txt": "foo\n", "clean.txt": "nothing to do\n"}
+	for _, file := range files {
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := expected[filepath.Base(file.Path)]; string(content) != want {
+			t.Errorf("%s: expected %q, got %q", file.Path, want, content)
+		}
+	}
+}
+
+func TestRenderMatchesDiffPreview(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		mappings []parser.Mapping
+		content  string
+	}{
+		{
+			name:     "preserve case",
+			cfg:      config.Config{PreserveCase: true},
+			mappings: []parser.Mapping{{From: "color", To: "colour"}},
+			content:  "color Color COLOR\n",
+		},
+		{
+			name:     "length-changing runes",
+			mappings: []parser.Mapping{{From: "foo", To: "bar"}},
+			content:  "K foo\nȺȺ Foo\n",
+		},
+		{
+			name: "line case before replacements",
+			mappings: []parser.Mapping{
+				{From: "warning", LineCase: parser.LineCaseUpper},
+				{From: "old api", To: "new api"},
+			},
+			content: "## Warning: old api\nuse the old api\n",
+		},
+		{
+			name:     "occurrence",
+			cfg:      config.Config{CaseSensitive: true},
+			mappings: []parser.Mapping{{From: "foo", To: "bar", OccurrenceIndex: 2}},
+			content:  "foo foo\nfoo\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := parser.NewMappingTable(tt.mappings)
+
+			written := NewProcessor(&tt.cfg, table).Render("test.txt", []byte(tt.content))
+
+			previewCfg := tt.cfg
+			previewCfg.DryRun = true
+			previewCfg.Diff = true
+			result := replacement.NewEngine(&previewCfg).ProcessFile("test.txt", []byte(tt.content), table)
+			if string(result.NewContent) != written {
+				t.Errorf("diff preview %q differs from written content %q", result.NewContent, written)
+			}
+		})
+	}
+}
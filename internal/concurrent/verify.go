@@ -0,0 +1,23 @@
+package concurrent
+
+import (
+	"crypto/sha256"
+	"os"
+
+	"remap/internal/errors"
+)
+
+// verifyWrite re-reads a rewritten file and checks that its checksum matches
+// the content remap intended to write. It catches storage that acknowledges
+// writes but persists something else.
+func verifyWrite(filePath string, expected string) error {
+	written, err := os.ReadFile(filePath)
+	if err != nil {
+		return errors.WrapFileError(filePath, err)
+	}
+
+	if sha256.Sum256(written) != sha256.Sum256([]byte(expected)) {
+		return errors.NewFileError(filePath, "write verification failed: content on disk differs from the intended output", nil)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+//go:build !unix
+
+package concurrent
+
+import (
+	"io/fs"
+	"os"
+
+	"remap/internal/errors"
+)
+
+// checkWritable reports whether a file can be rewritten before any backup is taken.
+// On Windows, opening for read-write fails with a sharing violation when another
+// process holds the file open exclusively, which is the usual cause of late write failures.
+func checkWritable(filePath string, info os.FileInfo) error {
+	if info.Mode().Perm()&0200 == 0 {
+		return errors.NewFileNotWritableError(filePath, fs.ErrPermission)
+	}
+
+	file, err := os.OpenFile(filePath, os.O_RDWR, 0)
+	if err != nil {
+		return errors.NewFileNotWritableError(filePath, err)
+	}
+	return file.Close()
+}
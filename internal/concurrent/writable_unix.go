@@ -0,0 +1,37 @@
+//go:build unix
+
+package concurrent
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"remap/internal/errors"
+)
+
+// accessWriteOK mirrors W_OK from unistd.h, which syscall does not export on every Unix.
+const accessWriteOK = 0x2
+
+// checkWritable reports whether a file can be rewritten before any backup is taken.
+// Files without write permission bits are rejected even for privileged users so
+// read-only files are never modified, and the parent directory must accept the
+// temporary file used by the atomic rename.
+func checkWritable(filePath string, info os.FileInfo) error {
+	if info.Mode().Perm()&0222 == 0 {
+		return errors.NewFileNotWritableError(filePath, fs.ErrPermission)
+	}
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY, 0)
+	if err != nil {
+		return errors.NewFileNotWritableError(filePath, err)
+	}
+	_ = file.Close()
+
+	if err := syscall.Access(filepath.Dir(filePath), accessWriteOK); err != nil {
+		return errors.NewFileNotWritableError(filePath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+//go:build unix
+
+package concurrent
+
+import (
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/errors"
+	"remap/internal/parser"
+)
+
+func TestProcessFileSkipsReadOnlyFile(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "readonly.txt")
+	if err := os.WriteFile(filePath, []byte("hello world"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(filePath, 0644) // Cleanup
+
+	cfg := &config.Config{Directory: tempDir}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "hello", To: "hi"}}))
+
+	result := processor.processFile(ProcessJob{FilePath: filePath})
+
+	var notWritable *errors.FileNotWritableError
+	if !stderrors.As(result.Error, &notWritable) {
+		t.Fatalf("expected FileNotWritableError, got %v", result.Error)
+	}
+	if result.BackupPath != "" {
+		t.Errorf("expected no backup for skipped file, got %s", result.BackupPath)
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the original file, found %d entries (orphaned backup?)", len(entries))
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "hello world" {
+		t.Errorf("read-only file was modified: %q", content)
+	}
+}
+
+func TestCheckWritable(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "writable.txt")
+	if err := os.WriteFile(filePath, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkWritable(filePath, info); err != nil {
+		t.Errorf("unexpected error for writable file: %v", err)
+	}
+}
+
+func TestWriteFileKeepsOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing a file's owner requires root")
+	}
+
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "owned.conf")
+	if err := os.WriteFile(filePath, []byte("host=old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	const uid, gid = 1234, 5678
+	if err := os.Chown(filePath, uid, gid); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Directory: tempDir, NoBackup: true}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "old", To: "new"}}))
+
+	result := processor.processFile(ProcessJob{FilePath: filePath})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat := info.Sys().(*syscall.Stat_t)
+	if stat.Uid != uid || stat.Gid != gid {
+		t.Errorf("expected owner %d:%d, got %d:%d", uid, gid, stat.Uid, stat.Gid)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package concurrent
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestProcessFilePreservesXattrs(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "labelled.txt")
+	if err := os.WriteFile(filePath, []byte("hello world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Setxattr(filePath, "user.remap.label", []byte("secret"), 0); err != nil {
+		t.Skipf("filesystem does not support user xattrs: %v", err)
+	}
+
+	cfg := &config.Config{Directory: tempDir, PreserveXattrs: true}
+	processor := NewProcessor(cfg, parser.NewMappingTable([]parser.Mapping{{From: "hello", To: "hi"}}))
+
+	result := processor.processFile(ProcessJob{FilePath: filePath})
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	for _, path := range []string{filePath, result.BackupPath} {
+		value := make([]byte, 64)
+		n, err := syscall.Getxattr(path, "user.remap.label", value)
+		if err != nil {
+			t.Errorf("%s: xattr lost: %v", filepath.Base(path), err)
+			continue
+		}
+		if string(value[:n]) != "secret" {
+			t.Errorf("%s: expected xattr 'secret', got %q", filepath.Base(path), value[:n])
+		}
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "hi world" {
+		t.Errorf("expected replacement to be applied, got %q", content)
+	}
+}
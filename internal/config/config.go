@@ -5,9 +5,11 @@ package config
 
 import (
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"remap/internal/errors"
+	"remap/internal/xattr"
 )
 
 // LogFormat represents the supported output formats for operation logs.
@@ -21,6 +23,31 @@ type LogFormat string
 const (
 	LogFormatJSON LogFormat = "json"
 	LogFormatCSV  LogFormat = "csv"
+	LogFormatXML  LogFormat = "xml"
+	// LogFormatNDJSON streams one JSON entry per line as files complete,
+	// followed by a summary line, instead of buffering the whole report.
+	LogFormatNDJSON LogFormat = "ndjson"
+)
+
+// DefaultDeletionThreshold is the amount of text, in bytes, that mappings with
+// an empty replacement may delete before a run requires --confirm-deletion.
+const DefaultDeletionThreshold = 64 * 1024
+
+// DefaultErrorExitCode is the exit status of a run that completed but in
+// which some files failed, set apart from the status 1 of fatal errors.
+const DefaultErrorExitCode = 2
+
+// Color modes accepted by --color.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// Processing orders accepted by --order.
+const (
+	OrderDiscovery = "discovery"
+	OrderSizeDesc  = "size-desc"
 )
 
 // Config holds all runtime configuration options for remap operations.
@@ -28,24 +55,93 @@ const (
 // behavior across all components and simplifying dependency injection throughout
 // the application architecture.
 type Config struct {
-	Directory     string
-	MappingFile   string
-	MappingType   string
-	Include       []string
-	Exclude       []string
-	ExcludeDir    []string
-	Extensions    []string
-	DryRun        bool
-	Revert        bool
-	Apply         bool
-	Backup        bool
-	NoBackup      bool
-	CaseSensitive bool
-	Verbose       bool
-	Debug         bool
-	Quiet         bool
-	LogFile       string
-	LogFormat     LogFormat
+	Directory              string
+	MappingFile            string
+	MappingType            string
+	Include                []string
+	Exclude                []string
+	ExcludeDir             []string
+	Extensions             []string
+	DryRun                 bool
+	Revert                 bool
+	Apply                  bool
+	Backup                 bool
+	NoBackup               bool
+	CaseSensitive          bool
+	Verbose                bool
+	Debug                  bool
+	Quiet                  bool
+	LogFile                string
+	LogFormat              LogFormat
+	DefinePattern          string
+	DefineReplace          string
+	DefineIgnoreCase       bool
+	StableOutput           bool
+	SortedOutput           bool
+	CSVMappingID           bool
+	CSVSizeDelta           bool
+	Workers                int
+	MaxPerDir              int
+	Explain                bool
+	MimeTypes              []string
+	SummaryExitCode        bool
+	PreserveXattrs         bool
+	PreserveMtime          bool
+	DeduplicateEntries     bool
+	TemplateMappings       bool
+	GraphemeAware          bool
+	SectionBegin           string
+	SectionEnd             string
+	PatchFile              string
+	VerifyWrites           bool
+	Safe                   bool
+	Confirm                bool
+	SkipBinary             bool
+	StopOnError            bool
+	ToTemplate             bool
+	SizeBudget             int64
+	LogSink                string
+	Strict                 bool
+	PreserveIndent         bool
+	Canonical              bool
+	FollowSymlinks         bool
+	IgnoreSymlinkedDirs    bool
+	Order                  string
+	WordBoundary           bool
+	InlineMappings         []string
+	TopGrowth              int
+	HardLinkBackups        bool
+	ErrorsToStderr         bool
+	NormalizeMapWhitespace bool
+	Progress               bool
+	IncludeContent         string
+	ExcludeContent         string
+	ChangedFilesJSON       bool
+	ReportPathPrefixStrip  string
+	ConfirmDeletion        bool
+	DeletionThreshold      int64
+	Diff                   bool
+	ToStdout               bool
+	Filter                 bool
+	SignKey                string
+	Color                  string
+	PreserveCase           bool
+	BackupDir              string
+	BackupArchive          string
+	MaxReplacementsPerFile int
+	Limit                  int
+	Interactive            bool
+	DetectOnly             bool
+	Lines                  []string
+	ModifiedSince          string
+	ModifiedBefore         string
+	IncludeHidden          bool
+	ErrorExitCode          int
+	IgnoreErrors           bool
+	ProcessBak             bool
+	ContextLines           int
+	AllowDuplicates        bool
+	StrictMappings         bool
 }
 
 // Validate performs comprehensive validation of configuration settings.
@@ -69,11 +165,115 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := c.validateLogSink(); err != nil {
+		return err
+	}
+
+	if err := c.validateDefinePattern(); err != nil {
+		return err
+	}
+
+	if c.Workers < 0 {
+		return errors.NewConfigError("workers must be zero or greater", nil)
+	}
+
+	if c.MaxReplacementsPerFile < 0 {
+		return errors.NewConfigError("--max-replacements-per-file must be zero or greater", nil)
+	}
+
+	if c.Limit < 0 {
+		return errors.NewConfigError("--limit must be zero or greater", nil)
+	}
+
+	if c.ErrorExitCode < 0 || c.ErrorExitCode > 255 {
+		return errors.NewConfigError("--error-exit-code must be between 1 and 255", nil)
+	}
+
+	if c.ContextLines < 0 {
+		return errors.NewConfigError("--context must be zero or greater", nil)
+	}
+
+	if c.PreserveXattrs && !xattr.Supported {
+		return errors.NewConfigError("--preserve-xattrs is only supported on Linux and macOS", nil)
+	}
+
+	for _, value := range c.Lines {
+		if _, err := ParseLineRange(value); err != nil {
+			return err
+		}
+	}
+
+	if err := c.validateModifiedWindow(); err != nil {
+		return err
+	}
+
+	if c.Order != "" && c.Order != OrderDiscovery && c.Order != OrderSizeDesc {
+		return errors.NewConfigError("order must be 'discovery' or 'size-desc'", nil)
+	}
+
+	switch c.Color {
+	case "", ColorAuto, ColorAlways, ColorNever:
+	default:
+		return errors.NewConfigError("color must be 'auto', 'always' or 'never'", nil)
+	}
+
+	if err := c.validateFilterPatterns(); err != nil {
+		return err
+	}
+
+	if err := c.validateContentPatterns(); err != nil {
+		return err
+	}
+
+	if c.ToStdout && (c.Revert || c.Apply || c.DefinePattern != "") {
+		return errors.NewConfigError("--to-stdout cannot be combined with --revert, --apply or --define-pattern", nil)
+	}
+
+	if c.Filter && (c.Revert || c.Apply || c.ToStdout || c.DefinePattern != "" || c.Confirm || c.Interactive) {
+		return errors.NewConfigError("--filter cannot be combined with --revert, --apply, --to-stdout, --define-pattern, --confirm or --interactive", nil)
+	}
+
+	if c.Interactive && (c.DryRun || c.ToStdout || c.Revert || c.Apply) {
+		return errors.NewConfigError("--interactive asks before writing files and cannot be combined with --dry-run, --to-stdout, --revert or --apply", nil)
+	}
+
+	if c.DetectOnly && (c.DryRun || c.ToStdout || c.Filter || c.Revert || c.Apply || c.Interactive) {
+		return errors.NewConfigError("--detect-only cannot be combined with --dry-run, --to-stdout, --filter, --revert, --apply or --interactive", nil)
+	}
+
+	if c.BackupDir != "" && c.NoBackup {
+		return errors.NewConfigError("--backup-dir cannot be combined with --nobackup", nil)
+	}
+
+	if err := c.validateBackupArchive(); err != nil {
+		return err
+	}
+
+	if c.PreserveCase && c.CaseSensitive {
+		return errors.NewConfigError("--preserve-case only applies to case-insensitive matching", nil)
+	}
+
+	if (c.SectionBegin == "") != (c.SectionEnd == "") {
+		return errors.NewConfigError("--section-begin and --section-end must be used together", nil)
+	}
+
 	c.normalizeConfig()
 	return nil
 }
 
 func (c *Config) validateDirectory() error {
+	// A directory of "-" selects filter mode, which reads stdin instead
+	if c.Directory == "-" {
+		c.Filter = true
+		c.Directory = ""
+	}
+	if c.Filter {
+		if c.Directory != "" {
+			return errors.NewConfigErrorWithPath(c.Directory, "--filter reads stdin and takes no directory", nil)
+		}
+		return nil
+	}
+
 	// Directory is not required in revert or apply mode
 	if (c.Revert || c.Apply) && c.Directory == "" {
 		return nil
@@ -92,8 +292,22 @@ func (c *Config) validateDirectory() error {
 }
 
 func (c *Config) validateMappingFile() error {
-	if c.MappingFile == "" && !c.Revert && !c.Apply {
-		return errors.NewConfigError("mapping file is required (use --csv or --json)", nil)
+	if c.MappingFile == "" && len(c.InlineMappings) == 0 && !c.Revert && !c.Apply && c.DefinePattern == "" {
+		return errors.NewConfigError("mapping file is required (use --csv, --json, --yaml, --properties or --map)", nil)
+	}
+
+	if c.MappingFile == "-" {
+		// Standard input holds the mappings, so nothing else may read from it
+		if c.Revert || c.Apply {
+			return errors.NewConfigError("mappings cannot be read from stdin in revert or apply mode", nil)
+		}
+		if c.Confirm || c.Interactive {
+			return errors.NewConfigError("--confirm and --interactive read their answers from stdin and cannot be combined with mappings read from stdin", nil)
+		}
+		if c.Filter {
+			return errors.NewConfigError("--filter reads content from stdin and cannot be combined with mappings read from stdin", nil)
+		}
+		return nil
 	}
 
 	if c.MappingFile != "" {
@@ -107,15 +321,120 @@ func (c *Config) validateMappingFile() error {
 }
 
 func (c *Config) validateMappingType() error {
-	if c.MappingType != "" && c.MappingType != "csv" && c.MappingType != "json" {
-		return errors.NewConfigError("mapping type must be 'csv' or 'json'", nil)
+	switch c.MappingType {
+	case "", "csv", "json", "yaml", "properties":
+	default:
+		return errors.NewConfigError("mapping type must be 'csv', 'json', 'yaml' or 'properties'", nil)
 	}
 	return nil
 }
 
 func (c *Config) validateLogFormat() error {
-	if c.LogFormat != "" && c.LogFormat != LogFormatJSON && c.LogFormat != LogFormatCSV {
-		return errors.NewConfigError("log format must be 'json' or 'csv'", nil)
+	switch c.LogFormat {
+	case "", LogFormatJSON, LogFormatCSV, LogFormatXML, LogFormatNDJSON:
+	default:
+		return errors.NewConfigError("log format must be 'json', 'csv', 'xml' or 'ndjson'", nil)
+	}
+	// Streamed entries are gone by the time the report could reorder or merge them
+	if c.LogFormat == LogFormatNDJSON && (c.StableOutput || c.SortedOutput || c.DeduplicateEntries) {
+		return errors.NewConfigError("--stable-output, --sorted-output and --deduplicate-entries cannot be combined with --log-format ndjson", nil)
+	}
+	if c.StableOutput && c.SortedOutput {
+		return errors.NewConfigError("--stable-output and --sorted-output select different orders and cannot be combined", nil)
+	}
+	jsonReport := c.LogFormat == "" || c.LogFormat == LogFormatJSON
+	if c.Canonical && !jsonReport {
+		return errors.NewConfigError("--canonical only applies to JSON reports", nil)
+	}
+	if c.ChangedFilesJSON && !jsonReport {
+		return errors.NewConfigError("--changed-files-json cannot be combined with --log-format "+string(c.LogFormat), nil)
+	}
+	// The key only signs written reports; revert and apply read logs
+	if c.SignKey != "" && !c.Revert && !c.Apply && (!jsonReport || c.ChangedFilesJSON || c.LogSink != "") {
+		return errors.NewConfigError("--sign-key only applies to the full JSON report", nil)
+	}
+	return nil
+}
+
+func (c *Config) validateLogSink() error {
+	switch c.LogSink {
+	case "":
+	case "syslog":
+		// In revert/apply mode --log names the input log, not an output
+		if c.LogFile != "" && !c.Revert && !c.Apply {
+			return errors.NewConfigError("--log cannot be combined with --log-sink syslog", nil)
+		}
+	default:
+		return errors.NewConfigError("log sink must be 'syslog'", nil)
+	}
+	return nil
+}
+
+// validateDefinePattern checks the definition pre-scan settings used by the
+// two-pass mode. The pattern must compile and expose a capture group holding
+// the symbol, otherwise the pre-scan would have nothing to rename.
+func (c *Config) validateDefinePattern() error {
+	if c.DefinePattern == "" {
+		if c.DefineReplace != "" {
+			return errors.NewConfigError("--define-replace requires --define-pattern", nil)
+		}
+		if c.DefineIgnoreCase {
+			return errors.NewConfigError("--define-ignore-case requires --define-pattern", nil)
+		}
+		return nil
+	}
+
+	re, err := regexp.Compile(c.DefinePattern)
+	if err != nil {
+		return errors.NewConfigError("invalid define pattern: "+c.DefinePattern, err)
+	}
+	if re.NumSubexp() < 1 {
+		return errors.NewConfigError("define pattern must contain a capture group for the symbol", nil)
+	}
+	return nil
+}
+
+// validateFilterPatterns checks that every include, exclude and exclude-dir
+// glob is well-formed. The filter ignores match errors, so a malformed
+// pattern would otherwise silently never match.
+func (c *Config) validateFilterPatterns() error {
+	patternSets := []struct {
+		flag     string
+		patterns []string
+	}{
+		{"include", c.Include},
+		{"exclude", c.Exclude},
+		{"exclude-dir", c.ExcludeDir},
+	}
+
+	for _, set := range patternSets {
+		for _, pattern := range set.patterns {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return errors.NewConfigError("invalid --"+set.flag+" pattern: "+pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateContentPatterns checks that the --include-content and
+// --exclude-content regular expressions compile.
+func (c *Config) validateContentPatterns() error {
+	patterns := []struct {
+		flag    string
+		pattern string
+	}{
+		{"include-content", c.IncludeContent},
+		{"exclude-content", c.ExcludeContent},
+	}
+
+	for _, p := range patterns {
+		if p.pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(p.pattern); err != nil {
+			return errors.NewConfigError("invalid --"+p.flag+" pattern: "+p.pattern, err)
+		}
 	}
 	return nil
 }
@@ -124,6 +443,14 @@ func (c *Config) normalizeConfig() {
 	if c.LogFormat == "" {
 		c.LogFormat = LogFormatJSON
 	}
+	if c.DeletionThreshold <= 0 {
+		c.DeletionThreshold = DefaultDeletionThreshold
+	}
+	if c.ErrorExitCode == 0 {
+		// Left unset by configs built in code; an explicit 0 on the command
+		// line is rejected before validation
+		c.ErrorExitCode = DefaultErrorExitCode
+	}
 	c.Extensions = c.normalizeExtensions()
 }
 
@@ -187,6 +514,24 @@ func (c *Config) ShouldLog() bool {
 	return !c.Quiet
 }
 
+// validateBackupArchive checks that --backup-archive replaces the other
+// ways of storing backups rather than being combined with them.
+func (c *Config) validateBackupArchive() error {
+	if c.BackupArchive == "" {
+		return nil
+	}
+	if c.NoBackup || c.BackupDir != "" {
+		return errors.NewConfigError("--backup-archive cannot be combined with --nobackup or --backup-dir", nil)
+	}
+	if c.HardLinkBackups || c.PreserveXattrs {
+		return errors.NewConfigError("--backup-archive cannot be combined with --hardlink-backups or --preserve-xattrs", nil)
+	}
+	if strings.Contains(c.BackupArchive, "#") {
+		return errors.NewConfigError("--backup-archive path cannot contain '#'", nil)
+	}
+	return nil
+}
+
 // ShouldCreateBackup determines if backup files should be created.
 // This method implements the precedence logic where NoBackup takes precedence
 // over Backup. By default, backups are enabled unless explicitly disabled.
@@ -2,6 +2,8 @@ package config
 
 import (
 	"testing"
+
+	"remap/internal/xattr"
 )
 
 func TestConfigValidation(t *testing.T) {
@@ -52,6 +54,405 @@ func TestConfigValidation(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "define pattern without mapping file",
+			config: Config{
+				Directory:     ".",
+				DefinePattern: `func (\w+)\(`,
+				DefineReplace: "new$1",
+			},
+			expectError: false,
+		},
+		{
+			name: "define ignore case without define pattern",
+			config: Config{
+				Directory:        ".",
+				MappingFile:      "test.csv",
+				DefineIgnoreCase: true,
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid define pattern",
+			config: Config{
+				Directory:     ".",
+				DefinePattern: `func (`,
+			},
+			expectError: true,
+		},
+		{
+			name: "define pattern without capture group",
+			config: Config{
+				Directory:     ".",
+				DefinePattern: `func \w+`,
+			},
+			expectError: true,
+		},
+		{
+			name: "negative workers",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				Workers:     -1,
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid include-content pattern",
+			config: Config{
+				Directory:      ".",
+				MappingFile:    "test.csv",
+				IncludeContent: "package (main",
+			},
+			expectError: true,
+		},
+		{
+			name: "changed files list with csv log format",
+			config: Config{
+				Directory:        ".",
+				MappingFile:      "test.csv",
+				LogFormat:        LogFormatCSV,
+				ChangedFilesJSON: true,
+			},
+			expectError: true,
+		},
+		{
+			name: "to-stdout in revert mode",
+			config: Config{
+				Directory: ".",
+				Revert:    true,
+				ToStdout:  true,
+			},
+			expectError: true,
+		},
+		{
+			name: "xml log format",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				LogFormat:   LogFormatXML,
+			},
+			expectError: false,
+		},
+		{
+			name: "canonical XML report",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				LogFormat:   LogFormatXML,
+				Canonical:   true,
+			},
+			expectError: true,
+		},
+		{
+			name: "signed CSV report",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				LogFormat:   LogFormatCSV,
+				SignKey:     "secret",
+			},
+			expectError: true,
+		},
+		{
+			name: "ndjson log with stable output",
+			config: Config{
+				Directory:    ".",
+				MappingFile:  "test.csv",
+				LogFormat:    LogFormatNDJSON,
+				StableOutput: true,
+			},
+			expectError: true,
+		},
+		{
+			name: "ndjson log with sorted output",
+			config: Config{
+				Directory:    ".",
+				MappingFile:  "test.csv",
+				LogFormat:    LogFormatNDJSON,
+				SortedOutput: true,
+			},
+			expectError: true,
+		},
+		{
+			name: "sorted and stable output",
+			config: Config{
+				Directory:    ".",
+				MappingFile:  "test.csv",
+				StableOutput: true,
+				SortedOutput: true,
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid color mode",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				Color:       "sometimes",
+			},
+			expectError: true,
+		},
+		{
+			name: "preserve case with case-sensitive matching",
+			config: Config{
+				Directory:     ".",
+				MappingFile:   "test.csv",
+				CaseSensitive: true,
+				PreserveCase:  true,
+			},
+			expectError: true,
+		},
+		{
+			name: "backup dir with backups disabled",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				NoBackup:    true,
+				BackupDir:   "backups",
+			},
+			expectError: true,
+		},
+		{
+			name: "backup archive with backup dir",
+			config: Config{
+				Directory:     ".",
+				MappingFile:   "test.csv",
+				BackupDir:     "backups",
+				BackupArchive: "backups.tar.gz",
+			},
+			expectError: true,
+		},
+		{
+			name: "backup archive with hard links",
+			config: Config{
+				Directory:       ".",
+				MappingFile:     "test.csv",
+				HardLinkBackups: true,
+				BackupArchive:   "backups.tar.gz",
+			},
+			expectError: true,
+		},
+		{
+			name: "negative replacement limit",
+			config: Config{
+				Directory:              ".",
+				MappingFile:            "test.csv",
+				MaxReplacementsPerFile: -1,
+			},
+			expectError: true,
+		},
+		{
+			name: "negative global replacement limit",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				Limit:       -1,
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid line range",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				Lines:       []string{"50:120", "120:50"},
+			},
+			expectError: true,
+		},
+		{
+			name: "filter with mappings from stdin",
+			config: Config{
+				Directory:   "-",
+				MappingFile: "-",
+			},
+			expectError: true,
+		},
+		{
+			name: "filter with revert",
+			config: Config{
+				Filter:      true,
+				MappingFile: "test.csv",
+				Revert:      true,
+			},
+			expectError: true,
+		},
+		{
+			name: "filter with a directory",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				Filter:      true,
+			},
+			expectError: true,
+		},
+		{
+			name: "error exit code out of range",
+			config: Config{
+				Directory:     ".",
+				MappingFile:   "test.csv",
+				ErrorExitCode: 256,
+			},
+			expectError: true,
+		},
+		{
+			name: "unparseable modified-since",
+			config: Config{
+				Directory:     ".",
+				MappingFile:   "test.csv",
+				ModifiedSince: "yesterday",
+			},
+			expectError: true,
+		},
+		{
+			name: "modified window out of order",
+			config: Config{
+				Directory:      ".",
+				MappingFile:    "test.csv",
+				ModifiedSince:  "1h",
+				ModifiedBefore: "48h",
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid include pattern",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				Include:     []string{"*.go", "["},
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid exclude pattern",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				Exclude:     []string{"["},
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid exclude-dir pattern",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				ExcludeDir:  []string{"vendor", "["},
+			},
+			expectError: true,
+		},
+		{
+			name: "section begin without end",
+			config: Config{
+				Directory:    ".",
+				MappingFile:  "test.csv",
+				SectionBegin: "# BEGIN managed",
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown log sink",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				LogSink:     "kafka",
+			},
+			expectError: true,
+		},
+		{
+			name: "syslog sink with log file",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				LogSink:     "syslog",
+				LogFile:     "out.json",
+			},
+			expectError: true,
+		},
+		{
+			name: "mappings from stdin",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "-",
+			},
+			expectError: false,
+		},
+		{
+			name: "mappings from stdin with confirm",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "-",
+				Confirm:     true,
+			},
+			expectError: true,
+		},
+		{
+			name: "inline mappings without mapping file",
+			config: Config{
+				Directory:      ".",
+				InlineMappings: []string{"foo=bar"},
+			},
+			expectError: false,
+		},
+		{
+			name: "mappings from stdin with interactive",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "-",
+				Interactive: true,
+			},
+			expectError: true,
+		},
+		{
+			name: "interactive dry run",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				DryRun:      true,
+				Interactive: true,
+			},
+			expectError: true,
+		},
+		{
+			name: "detect-only dry run",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				DryRun:      true,
+				DetectOnly:  true,
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid order",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				Order:       "random",
+			},
+			expectError: true,
+		},
+		{
+			name: "canonical CSV report",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				LogFormat:   LogFormatCSV,
+				Canonical:   true,
+			},
+			expectError: true,
+		},
+		{
+			name: "valid filter patterns",
+			config: Config{
+				Directory:   ".",
+				MappingFile: "test.csv",
+				Include:     []string{"*.go"},
+				Exclude:     []string{"*_test.go"},
+				ExcludeDir:  []string{"vendor", ".git"},
+			},
+			expectError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -96,3 +497,34 @@ func TestShouldProcessExtension(t *testing.T) {
 		t.Errorf("empty extensions should allow any extension")
 	}
 }
+
+func TestValidatePreserveXattrs(t *testing.T) {
+	cfg := Config{Directory: ".", MappingFile: "test.csv", PreserveXattrs: true}
+	err := cfg.Validate()
+	if xattr.Supported && err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !xattr.Supported && err == nil {
+		t.Error("expected --preserve-xattrs to be rejected where it does nothing")
+	}
+}
+
+func TestValidateKeepsStdinMappingFile(t *testing.T) {
+	cfg := Config{Directory: ".", MappingFile: "-"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MappingFile != "-" {
+		t.Errorf("expected stdin sentinel to be kept, got %q", cfg.MappingFile)
+	}
+}
+
+func TestValidateFilterDirectory(t *testing.T) {
+	cfg := &Config{Directory: "-", MappingFile: "test.csv"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Filter || cfg.Directory != "" {
+		t.Errorf("expected directory - to select filter mode, got Filter=%v Directory=%q", cfg.Filter, cfg.Directory)
+	}
+}
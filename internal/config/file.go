@@ -0,0 +1,108 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"remap/internal/errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileOption is one setting read from a config file. Name is the long flag
+// name (e.g. "case-sensitive") and Values holds the setting as command-line
+// text: one element for scalars, one per item for lists.
+type FileOption struct {
+	Name   string
+	Values []string
+}
+
+// LoadFile reads a YAML or JSON config file whose keys are long flag names,
+// for example:
+//
+//	csv: mappings.csv
+//	case-sensitive: true
+//	exclude-dir: [vendor, node_modules]
+//
+// Files ending in .json are decoded as JSON, anything else as YAML. Options
+// are returned sorted by name so they are applied in a stable order.
+func LoadFile(path string) ([]FileOption, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewConfigErrorWithPath(path, "failed to read config file", err)
+	}
+
+	var raw map[string]interface{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		// Numbers keep their text: as float64, 1000000 would read 1e+06
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		err = decoder.Decode(&raw)
+		if _, trailing := decoder.Token(); err == nil && trailing != io.EOF {
+			err = fmt.Errorf("unexpected data after the top-level value")
+		}
+	} else {
+		err = yaml.Unmarshal(content, &raw)
+	}
+	if err != nil {
+		return nil, errors.NewConfigErrorWithPath(path, "failed to parse config file", err)
+	}
+
+	options := make([]FileOption, 0, len(raw))
+	for name, value := range raw {
+		values, err := optionValues(value)
+		if err != nil {
+			return nil, errors.NewConfigErrorWithPath(path, fmt.Sprintf("invalid value for %q", name), err)
+		}
+		options = append(options, FileOption{Name: name, Values: values})
+	}
+
+	sort.Slice(options, func(i, j int) bool {
+		return options[i].Name < options[j].Name
+	})
+	return options, nil
+}
+
+// optionValues renders a decoded value as command-line text.
+func optionValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			text, err := scalarValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, text)
+		}
+		return values, nil
+	default:
+		text, err := scalarValue(v)
+		if err != nil {
+			return nil, err
+		}
+		return []string{text}, nil
+	}
+}
+
+func scalarValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool, int, int64:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("expected a string, number, boolean or list, got %T", value)
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		expected    []FileOption
+		expectError bool
+	}{
+		{
+			name:    "json numbers keep their text",
+			file:    "remap.json",
+			content: `{"deletion-threshold": 1000000, "workers": 4, "max-size": 1.5}`,
+			expected: []FileOption{
+				{Name: "deletion-threshold", Values: []string{"1000000"}},
+				{Name: "max-size", Values: []string{"1.5"}},
+				{Name: "workers", Values: []string{"4"}},
+			},
+		},
+		{
+			name:    "yaml numbers keep their text",
+			file:    "remap.yaml",
+			content: "deletion-threshold: 1e6\nexclude-dir: [vendor, node_modules]\ncase-sensitive: true\n",
+			expected: []FileOption{
+				{Name: "case-sensitive", Values: []string{"true"}},
+				{Name: "deletion-threshold", Values: []string{"1000000"}},
+				{Name: "exclude-dir", Values: []string{"vendor", "node_modules"}},
+			},
+		},
+		{
+			name:        "trailing json data",
+			file:        "remap.json",
+			content:     `{"workers": 4} {}`,
+			expectError: true,
+		},
+		{
+			name:        "nested value",
+			file:        "remap.json",
+			content:     `{"workers": {"count": 4}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			options, err := LoadFile(path)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if len(options) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, options)
+			}
+			for i, option := range options {
+				if option.Name != tt.expected[i].Name || strings.Join(option.Values, ",") != strings.Join(tt.expected[i].Values, ",") {
+					t.Errorf("option %d: expected %v, got %v", i, tt.expected[i], option)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	"remap/internal/errors"
+)
+
+// LineRange is an inclusive range of 1-based line numbers given to --lines.
+// A zero Start or End leaves that side of the range open.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// Contains reports whether line lies within the range.
+func (r LineRange) Contains(line int) bool {
+	return (r.Start == 0 || line >= r.Start) && (r.End == 0 || line <= r.End)
+}
+
+// ParseLineRange parses a range written START:END, where either bound may be
+// left out ("50:" runs to the end of the file, ":120" starts at its top).
+func ParseLineRange(value string) (LineRange, error) {
+	startText, endText, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found {
+		return LineRange{}, errors.NewConfigError("invalid line range "+strconv.Quote(value)+": expected START:END", nil)
+	}
+
+	var r LineRange
+	var err error
+	if startText != "" {
+		if r.Start, err = parseLineNumber(startText); err != nil {
+			return LineRange{}, errors.NewConfigError("invalid line range "+strconv.Quote(value), err)
+		}
+	}
+	if endText != "" {
+		if r.End, err = parseLineNumber(endText); err != nil {
+			return LineRange{}, errors.NewConfigError("invalid line range "+strconv.Quote(value), err)
+		}
+	}
+	if r.Start != 0 && r.End != 0 && r.Start > r.End {
+		return LineRange{}, errors.NewConfigError("invalid line range "+strconv.Quote(value)+": start is after end", nil)
+	}
+	return r, nil
+}
+
+func parseLineNumber(text string) (int, error) {
+	line, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil {
+		return 0, err
+	}
+	if line < 1 {
+		return 0, errors.NewConfigError("line numbers start at 1", nil)
+	}
+	return line, nil
+}
+
+// LineRanges returns the ranges given to --lines, which Validate has checked.
+func (c *Config) LineRanges() []LineRange {
+	var ranges []LineRange
+	for _, value := range c.Lines {
+		if r, err := ParseLineRange(value); err == nil {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
@@ -0,0 +1,60 @@
+package config
+
+import "testing"
+
+func TestParseLineRange(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    LineRange
+		expectError bool
+	}{
+		{"50:120", LineRange{Start: 50, End: 120}, false},
+		{"50:", LineRange{Start: 50}, false},
+		{":120", LineRange{End: 120}, false},
+		{" 7:7 ", LineRange{Start: 7, End: 7}, false},
+		{":", LineRange{}, false},
+		{"50", LineRange{}, true},
+		{"120:50", LineRange{}, true},
+		{"0:10", LineRange{}, true},
+		{"a:b", LineRange{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			r, err := ParseLineRange(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error for %q, got %+v", tt.input, r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if r != tt.expected {
+				t.Errorf("ParseLineRange(%q) = %+v, expected %+v", tt.input, r, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLineRangeContains(t *testing.T) {
+	tests := []struct {
+		r        LineRange
+		line     int
+		expected bool
+	}{
+		{LineRange{Start: 50, End: 120}, 49, false},
+		{LineRange{Start: 50, End: 120}, 50, true},
+		{LineRange{Start: 50, End: 120}, 120, true},
+		{LineRange{Start: 50, End: 120}, 121, false},
+		{LineRange{Start: 50}, 10000, true},
+		{LineRange{End: 120}, 1, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.r.Contains(tt.line); got != tt.expected {
+			t.Errorf("%+v.Contains(%d) = %v, expected %v", tt.r, tt.line, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"remap/internal/errors"
+)
+
+// ParseTimeBound converts a value given to --modified-since or
+// --modified-before into an instant. A duration such as "24h" or "90m" counts
+// back from now; anything else must be an RFC3339 timestamp.
+func ParseTimeBound(value string, now time.Time) (time.Time, error) {
+	text := strings.TrimSpace(value)
+
+	if d, err := time.ParseDuration(text); err == nil {
+		if d < 0 {
+			return time.Time{}, errors.NewConfigError("invalid time "+strconv.Quote(value)+": duration must not be negative", nil)
+		}
+		return now.Add(-d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, text)
+	if err != nil {
+		return time.Time{}, errors.NewConfigError("invalid time "+strconv.Quote(value)+": expected a duration (e.g. 24h) or an RFC3339 timestamp", err)
+	}
+	return t, nil
+}
+
+// ModifiedWindow returns the bounds given to --modified-since and
+// --modified-before, resolving durations against now. An unset bound is the
+// zero time. Validate has already checked both values.
+func (c *Config) ModifiedWindow(now time.Time) (since, before time.Time) {
+	if c.ModifiedSince != "" {
+		since, _ = ParseTimeBound(c.ModifiedSince, now)
+	}
+	if c.ModifiedBefore != "" {
+		before, _ = ParseTimeBound(c.ModifiedBefore, now)
+	}
+	return since, before
+}
+
+func (c *Config) validateModifiedWindow() error {
+	now := time.Now()
+	for _, value := range []string{c.ModifiedSince, c.ModifiedBefore} {
+		if value == "" {
+			continue
+		}
+		if _, err := ParseTimeBound(value, now); err != nil {
+			return err
+		}
+	}
+
+	since, before := c.ModifiedWindow(now)
+	if !since.IsZero() && !before.IsZero() && !since.Before(before) {
+		return errors.NewConfigError("--modified-since must be earlier than --modified-before", nil)
+	}
+	return nil
+}
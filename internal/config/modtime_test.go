@@ -0,0 +1,62 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		input       string
+		expected    time.Time
+		expectError bool
+	}{
+		{"24h", now.Add(-24 * time.Hour), false},
+		{" 90m ", now.Add(-90 * time.Minute), false},
+		{"0s", now, false},
+		{"2024-03-01T08:30:00Z", time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC), false},
+		{"2024-03-01T08:30:00+02:00", time.Date(2024, 3, 1, 6, 30, 0, 0, time.UTC), false},
+		{"-1h", time.Time{}, true},
+		{"2024-03-01", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+		{"", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTimeBound(tt.input, now)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error for %q, got %v", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.expected) {
+				t.Errorf("ParseTimeBound(%q) = %v, expected %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestModifiedWindow(t *testing.T) {
+	now := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	cfg := &Config{ModifiedSince: "24h", ModifiedBefore: "2024-03-02T06:00:00Z"}
+
+	since, before := cfg.ModifiedWindow(now)
+	if !since.Equal(now.Add(-24 * time.Hour)) {
+		t.Errorf("since = %v, expected %v", since, now.Add(-24*time.Hour))
+	}
+	if !before.Equal(time.Date(2024, 3, 2, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("before = %v", before)
+	}
+
+	since, before = (&Config{}).ModifiedWindow(now)
+	if !since.IsZero() || !before.IsZero() {
+		t.Errorf("expected an open window, got %v and %v", since, before)
+	}
+}
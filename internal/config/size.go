@@ -0,0 +1,47 @@
+package config
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"remap/internal/errors"
+)
+
+// sizeUnits maps size suffixes to their multipliers. Units are binary
+// (1KB = 1024 bytes), matching how sizes are rendered in reports.
+var sizeUnits = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize converts a human-readable size such as "500MB", "1.5G" or "4096"
+// into bytes. Suffixes are case-insensitive and a bare number means bytes.
+// Negative, non-finite ("inf", "nan") and out-of-range sizes are rejected.
+func ParseSize(value string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(value))
+
+	multiplier := 1.0
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(text, unit.suffix) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil || number < 0 || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, errors.NewConfigError("invalid size: "+value, err)
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, the first value out of range
+	bytes := number * multiplier
+	if bytes >= float64(math.MaxInt64) {
+		return 0, errors.NewConfigError("size out of range: "+value, nil)
+	}
+	return int64(bytes), nil
+}
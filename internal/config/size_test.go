@@ -0,0 +1,48 @@
+package config
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    int64
+		expectError bool
+	}{
+		{"4096", 4096, false},
+		{"10B", 10, false},
+		{"2KB", 2048, false},
+		{"500MB", 500 << 20, false},
+		{"500mb", 500 << 20, false},
+		{"1.5G", 3 << 29, false},
+		{" 1 TB ", 1 << 40, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"-1MB", 0, true},
+		{"ten", 0, true},
+		{"inf", 0, true},
+		{"+Inf", 0, true},
+		{"infMB", 0, true},
+		{"NaN", 0, true},
+		{"nanKB", 0, true},
+		{"8388608T", 0, true},
+		{"1e300", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			size, err := ParseSize(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error for %q, got %d", tt.input, size)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if size != tt.expected {
+				t.Errorf("ParseSize(%q) = %d, expected %d", tt.input, size, tt.expected)
+			}
+		})
+	}
+}
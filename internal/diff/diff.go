@@ -0,0 +1,273 @@
+// Package diff renders unified diffs between two versions of a text file.
+// The output follows the format read by `git apply` and `patch -p1`, so
+// remap can hand its changes to the usual review and patching tools.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change,
+// matching the default of diff -u and git diff.
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// edit is one line of the edit script, with its 0-based position in the old
+// and new line lists at the point where it applies.
+type edit struct {
+	kind     opKind
+	line     string
+	oldIndex int
+	newIndex int
+}
+
+// Unified returns the unified diff turning oldText into newText, labelled
+// with oldName and newName (e.g. "a/src/main.go" and "b/src/main.go").
+// It returns "" when both texts are identical.
+func Unified(oldName, newName, oldText, newText string) string {
+	return UnifiedContext(oldName, newName, oldText, newText, contextLines)
+}
+
+// UnifiedContext is Unified with context unchanged lines around each change
+// instead of the default three.
+func UnifiedContext(oldName, newName, oldText, newText string, context int) string {
+	if oldText == newText {
+		return ""
+	}
+
+	edits := lineEdits(splitLines(oldText), splitLines(newText))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	for _, h := range hunks(edits, context) {
+		writeHunk(&out, edits[h[0]:h[1]])
+	}
+	return out.String()
+}
+
+// splitLines splits text into lines that keep their terminating newline, so a
+// missing newline at end of file shows up as a difference.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineEdits computes a shortest edit script between a and b with the
+// linear-space variant of Myers' algorithm: it finds where the forward and
+// backward searches meet, then recurses on either side. Memory stays
+// proportional to the number of lines however many of them changed.
+func lineEdits(a, b []string) []edit {
+	var edits []edit
+	diffRange(a, b, 0, len(a), 0, len(b), &edits)
+	return edits
+}
+
+// diffRange appends the edit script turning a[aLo:aHi] into b[bLo:bHi].
+func diffRange(a, b []string, aLo, aHi, bLo, bHi int, edits *[]edit) {
+	for aLo < aHi && bLo < bHi && a[aLo] == b[bLo] {
+		*edits = append(*edits, edit{kind: opEqual, line: a[aLo], oldIndex: aLo, newIndex: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && a[aHi-1-suffix] == b[bHi-1-suffix] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	x, y, ok := bisect(a, b, aLo, aHi, bLo, bHi)
+	if ok {
+		diffRange(a, b, aLo, x, bLo, y, edits)
+		diffRange(a, b, x, aHi, y, bHi, edits)
+	} else {
+		// No common line to split on (or nothing left on one side)
+		for i := aLo; i < aHi; i++ {
+			*edits = append(*edits, edit{kind: opDelete, line: a[i], oldIndex: i, newIndex: bLo})
+		}
+		for j := bLo; j < bHi; j++ {
+			*edits = append(*edits, edit{kind: opInsert, line: b[j], oldIndex: aHi, newIndex: j})
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		*edits = append(*edits, edit{kind: opEqual, line: a[aHi+i], oldIndex: aHi + i, newIndex: bHi + i})
+	}
+}
+
+// bisect finds a point on a shortest edit path through a[aLo:aHi] and
+// b[bLo:bHi] where the path can be split in two smaller ones, by running the
+// Myers search from both ends until they overlap. It reports false when the
+// ranges cannot be split, e.g. when one of them is empty.
+func bisect(a, b []string, aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	size := 2*maxD + 2
+	forward := make([]int, size)
+	backward := make([]int, size)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+	// With an odd delta the searches meet on a forward step, otherwise on
+	// a backward one
+	front := delta%2 != 0
+	// Diagonals that left the grid are not searched any further
+	kStart, kEnd, kStartBack, kEndBack := 0, 0, 0, 0
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + kStart; k <= d-kEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[aLo+x] == b[bLo+y] {
+				x++
+				y++
+			}
+			forward[i] = x
+
+			switch {
+			case x > n:
+				kEnd += 2
+			case y > m:
+				kStart += 2
+			case front:
+				j := offset + delta - k
+				if j >= 0 && j < size && backward[j] != -1 && x >= n-backward[j] {
+					return splitAt(aLo, aHi, bLo, bHi, x, y)
+				}
+			}
+		}
+
+		for k := -d + kStartBack; k <= d-kEndBack; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && backward[i-1] < backward[i+1]) {
+				x = backward[i+1]
+			} else {
+				x = backward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[aHi-1-x] == b[bHi-1-y] {
+				x++
+				y++
+			}
+			backward[i] = x
+
+			switch {
+			case x > n:
+				kEndBack += 2
+			case y > m:
+				kStartBack += 2
+			case !front:
+				j := offset + delta - k
+				if j >= 0 && j < size && forward[j] != -1 {
+					fx := forward[j]
+					fy := fx - (j - offset)
+					if fx >= n-x {
+						return splitAt(aLo, aHi, bLo, bHi, fx, fy)
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// splitAt turns a split point relative to the ranges into line indexes,
+// refusing points that would leave one side as large as the whole.
+func splitAt(aLo, aHi, bLo, bHi, x, y int) (int, int, bool) {
+	if (x == 0 && y == 0) || (aLo+x == aHi && bLo+y == bHi) {
+		return 0, 0, false
+	}
+	return aLo + x, bLo + y, true
+}
+
+// hunks groups changes into [start, end) ranges of the edit script, each
+// padded with context lines. Changes separated by at most two contexts' worth
+// of unchanged lines share a hunk.
+func hunks(edits []edit, context int) [][2]int {
+	var ranges [][2]int
+
+	for i := 0; i < len(edits); i++ {
+		if edits[i].kind == opEqual {
+			continue
+		}
+
+		start := max(0, i-context)
+		end := i + 1
+		for j := i + 1; j < len(edits) && j <= end+2*context; j++ {
+			if edits[j].kind != opEqual {
+				end = j + 1
+			}
+		}
+		end = min(len(edits), end+context)
+
+		ranges = append(ranges, [2]int{start, end})
+		i = end - 1
+	}
+	return ranges
+}
+
+func writeHunk(out *strings.Builder, edits []edit) {
+	oldCount, newCount := 0, 0
+	for _, e := range edits {
+		if e.kind != opInsert {
+			oldCount++
+		}
+		if e.kind != opDelete {
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n",
+		hunkRange(edits[0].oldIndex, oldCount), hunkRange(edits[0].newIndex, newCount))
+
+	for _, e := range edits {
+		prefix := " "
+		switch e.kind {
+		case opDelete:
+			prefix = "-"
+		case opInsert:
+			prefix = "+"
+		}
+
+		out.WriteString(prefix)
+		out.WriteString(e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats a hunk's line range. An empty range refers to the line
+// before the insertion point, as diff -u does.
+func hunkRange(index, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", index)
+	}
+	return fmt.Sprintf("%d,%d", index+1, count)
+}
@@ -0,0 +1,174 @@
+package diff
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name:     "identical",
+			old:      "a\nb\n",
+			new:      "a\nb\n",
+			expected: "",
+		},
+		{
+			name:     "single change",
+			old:      "a\nb\nc\n",
+			new:      "a\nB\nc\n",
+			expected: "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "missing final newline",
+			old:      "a",
+			new:      "b",
+			expected: "--- a/f\n+++ b/f\n@@ -1,1 +1,1 @@\n-a\n\\ No newline at end of file\n+b\n\\ No newline at end of file\n",
+		},
+		{
+			name:     "insertion into empty file",
+			old:      "",
+			new:      "a\n",
+			expected: "--- a/f\n+++ b/f\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		{
+			name:     "distant changes get separate hunks",
+			old:      "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			new:      "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			expected: "--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Unified("a/f", "b/f", tt.old, tt.new); result != tt.expected {
+				t.Errorf("Unified() =\n%s\nexpected\n%s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnifiedContext(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n"
+	new := "1\n2\n3\nfour\n5\n6\n7\n"
+
+	expected := "--- a/f\n+++ b/f\n@@ -3,3 +3,3 @@\n 3\n-4\n+four\n 5\n"
+	if result := UnifiedContext("a/f", "b/f", old, new, 1); result != expected {
+		t.Errorf("UnifiedContext() =\n%s\nexpected\n%s", result, expected)
+	}
+}
+
+func TestUnifiedAppliesWithPatch(t *testing.T) {
+	patchBin, err := exec.LookPath("patch")
+	if err != nil {
+		t.Skip("patch not available")
+	}
+
+	oldText := "header\n" + strings.Repeat("same\n", 10) + "old value\nfooter"
+	newText := "header line\n" + strings.Repeat("same\n", 10) + "new value\nextra\nfooter"
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(target, []byte(oldText), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(patchBin, "-p1", "--quiet")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(Unified("a/file.txt", "b/file.txt", oldText, newText))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("patch failed: %v\n%s", err, output)
+	}
+
+	patched, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(patched) != newText {
+		t.Errorf("patched content = %q, expected %q", patched, newText)
+	}
+}
+
+func TestLineEditsShortest(t *testing.T) {
+	// Small inputs over a tiny alphabet exercise many overlapping matches;
+	// each script must rebuild b and be as short as the LCS allows
+	alphabet := []string{"a\n", "b\n", "c\n"}
+	seed := uint32(1)
+	next := func(n int) int {
+		seed = seed*1664525 + 1013904223
+		return int(seed>>16) % n
+	}
+	randomLines := func() []string {
+		lines := make([]string, next(9))
+		for i := range lines {
+			lines[i] = alphabet[next(len(alphabet))]
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		edits := lineEdits(a, b)
+
+		var rebuilt []string
+		changes := 0
+		for _, e := range edits {
+			if e.kind != opDelete {
+				rebuilt = append(rebuilt, e.line)
+			}
+			if e.kind != opEqual {
+				changes++
+			}
+		}
+		if strings.Join(rebuilt, "") != strings.Join(b, "") {
+			t.Fatalf("edits of %q -> %q rebuild %q", a, b, rebuilt)
+		}
+		if shortest := len(a) + len(b) - 2*lcsLength(a, b); changes != shortest {
+			t.Fatalf("edits of %q -> %q have %d changes, expected %d", a, b, changes, shortest)
+		}
+	}
+}
+
+func lcsLength(a, b []string) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	return table[0][0]
+}
+
+func TestUnifiedManyChanges(t *testing.T) {
+	// Every fifth line of a large file changes; the edit script must not
+	// keep a search frontier per edit
+	var oldText, newText strings.Builder
+	for i := 0; i < 20000; i++ {
+		line := "line " + strings.Repeat("x", i%7) + "\n"
+		oldText.WriteString(line)
+		if i%5 == 0 {
+			line = "changed " + line
+		}
+		newText.WriteString(line)
+	}
+
+	patch := Unified("a/big.txt", "b/big.txt", oldText.String(), newText.String())
+	if got := strings.Count(patch, "\n+changed "); got != 4000 {
+		t.Errorf("expected 4000 changed lines, got %d", got)
+	}
+}
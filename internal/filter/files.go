@@ -4,10 +4,20 @@
 package filter
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
+	"remap/internal/backup"
 	"remap/internal/config"
 	"remap/internal/errors"
 )
@@ -27,14 +37,18 @@ type FileInfo struct {
 // filters can be chained together for complex file selection criteria.
 type FileFilter func(path string, info os.FileInfo) (bool, error)
 
+// namedFilter pairs a FileFilter with a short name.
+// The name identifies which filter rejected a file when explaining decisions.
+type namedFilter struct {
+	name   string
+	filter FileFilter
+}
+
 // NewFileDiscovery creates a FileDiscovery with configured filters.
 // This constructor builds an optimized filter chain based on configuration,
 // enabling efficient file traversal with early rejection of unwanted files.
 func NewFileDiscovery(cfg *config.Config) *FileDiscovery {
-	return &FileDiscovery{
-		config:  cfg,
-		filters: buildFilters(cfg),
-	}
+	return &FileDiscovery{config: cfg, filters: buildFilters(cfg)}
 }
 
 // FileDiscovery handles recursive directory traversal with filtering.
@@ -42,7 +56,21 @@ func NewFileDiscovery(cfg *config.Config) *FileDiscovery {
 // enabling complex file selection rules while maintaining performance.
 type FileDiscovery struct {
 	config  *config.Config
-	filters []FileFilter
+	filters []namedFilter
+	explain io.Writer
+
+	selectedSize  int64
+	budgetReached bool
+	rejected      map[string]int
+	root          string
+	visited       map[string]bool
+}
+
+// SetExplainWriter enables explanations of every filtering decision.
+// Each visited path is reported with the filter that rejected it, which makes
+// it easy to debug why a file was or wasn't processed. A nil writer disables it.
+func (fd *FileDiscovery) SetExplainWriter(w io.Writer) {
+	fd.explain = w
 }
 
 // Discover recursively traverses the configured directory and returns filtered files.
@@ -50,8 +78,15 @@ type FileDiscovery struct {
 // reducing memory usage and processing time by excluding unwanted files immediately.
 func (fd *FileDiscovery) Discover() ([]FileInfo, error) {
 	var files []FileInfo
+	fd.rejected = make(map[string]int)
+	fd.visited = make(map[string]bool)
+	fd.root = fd.config.Directory
+	if root, err := filepath.EvalSymlinks(fd.config.Directory); err == nil {
+		fd.root = root
+	}
 
-	err := filepath.Walk(fd.config.Directory, func(path string, info os.FileInfo, err error) error {
+	var walkFn filepath.WalkFunc
+	walkFn = func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			if os.IsPermission(err) {
 				return nil
@@ -59,19 +94,62 @@ func (fd *FileDiscovery) Discover() ([]FileInfo, error) {
 			return errors.WrapFileError(path, err)
 		}
 
+		if fd.budgetReached {
+			// A walk of a linked directory stopped at the budget
+			return filepath.SkipAll
+		}
+
+		// A followed link is replaced by its target, which filters see and
+		// which gets rewritten
+		if info.Mode()&os.ModeSymlink != 0 && fd.followsSymlinks() {
+			target, targetInfo, follow := fd.resolveSymlink(path)
+			if !follow {
+				return nil
+			}
+			if targetInfo.IsDir() {
+				return filepath.Walk(target, walkFn)
+			}
+			path, info = target, targetInfo
+		}
+
 		if info.IsDir() {
 			// Check if this directory should be excluded
 			if fd.shouldExcludeDirectory(path) {
+				fd.explainf("SKIP DIR: %s (rejected by exclude-dir)\n", path)
+				return filepath.SkipDir
+			}
+			if path != fd.config.Directory && fd.isHiddenDirectory(path) {
+				fd.explainf("SKIP DIR: %s (rejected by include-hidden)\n", path)
+				return filepath.SkipDir
+			}
+			if fd.alreadyVisited(path) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
-		shouldProcess, err := fd.shouldProcessFile(path, info)
+		if fd.alreadyVisited(path) {
+			return nil
+		}
+
+		shouldProcess, decidedBy, err := fd.shouldProcessFile(path, info)
 		if err != nil {
 			return err
 		}
 
+		if shouldProcess {
+			fd.explainf("ACCEPT: %s (passed all filters)\n", path)
+		} else {
+			fd.explainf("REJECT: %s (rejected by %s)\n", path, decidedBy)
+			fd.rejected[decidedBy]++
+		}
+
+		if shouldProcess && fd.exceedsBudget(info.Size()) {
+			fd.explainf("STOP: %s (size budget reached)\n", path)
+			fd.budgetReached = true
+			return filepath.SkipAll
+		}
+
 		if shouldProcess {
 			files = append(files, FileInfo{
 				Path:    path,
@@ -82,42 +160,180 @@ func (fd *FileDiscovery) Discover() ([]FileInfo, error) {
 		}
 
 		return nil
-	})
+	}
 
-	if err != nil {
+	if err := filepath.Walk(fd.config.Directory, walkFn); err != nil {
 		return nil, err
 	}
 
 	return files, nil
 }
 
-func (fd *FileDiscovery) shouldProcessFile(path string, info os.FileInfo) (bool, error) {
-	for _, filter := range fd.filters {
-		should, err := filter(path, info)
+// resolveSymlink decides whether a symbolic link met during the walk is
+// followed, returning its resolved target. Links into the tree are skipped
+// because the walk reaches their targets anyway, dangling links and already
+// visited targets (including link cycles) are skipped, and linked directories
+// are skipped with --ignore-symlinked-dirs, which follows links to files
+// only, so the walk cannot escape the tree. Targets are resolved paths, so
+// the walks of linked directories only meet resolved paths too.
+func (fd *FileDiscovery) resolveSymlink(path string) (string, os.FileInfo, bool) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		fd.explainf("REJECT: %s (rejected by symlink: %v)\n", path, err)
+		return "", nil, false
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		fd.explainf("REJECT: %s (rejected by symlink: %v)\n", path, err)
+		return "", nil, false
+	}
+
+	if info.IsDir() {
+		if fd.config.IgnoreSymlinkedDirs {
+			fd.explainf("SKIP DIR: %s (rejected by ignore-symlinked-dirs)\n", path)
+			return "", nil, false
+		}
+		if fd.shouldExcludeDirectory(path) {
+			fd.explainf("SKIP DIR: %s (rejected by exclude-dir)\n", path)
+			return "", nil, false
+		}
+		if fd.isHiddenDirectory(path) {
+			fd.explainf("SKIP DIR: %s (rejected by include-hidden)\n", path)
+			return "", nil, false
+		}
+	}
+
+	if target == fd.root || strings.HasPrefix(target, fd.root+string(filepath.Separator)) || fd.visited[target] {
+		fd.explainf("SKIP: %s (symlink target %s already visited)\n", path, target)
+		return "", nil, false
+	}
+
+	return target, info, true
+}
+
+// isHiddenDirectory reports whether the directory at path is a dot-directory
+// such as .git or .github, which is not walked unless --include-hidden is set.
+func (fd *FileDiscovery) isHiddenDirectory(path string) bool {
+	return !fd.config.IncludeHidden && strings.HasPrefix(filepath.Base(path), ".")
+}
+
+// alreadyVisited records a path reached while following symlinks and reports
+// whether it was reached before. A directory or file outside the tree can be
+// reached through several links, or through a link to one of its ancestors,
+// and must still be walked and listed once. When links are not followed every
+// path is reached exactly once, so nothing is recorded.
+func (fd *FileDiscovery) alreadyVisited(path string) bool {
+	if !fd.followsSymlinks() {
+		return false
+	}
+	if fd.visited[path] {
+		fd.explainf("SKIP: %s (already visited through a symlink)\n", path)
+		return true
+	}
+	fd.visited[path] = true
+	return false
+}
+
+// followsSymlinks reports whether symbolic links met during the walk are
+// followed, which --ignore-symlinked-dirs implies for links to files.
+func (fd *FileDiscovery) followsSymlinks() bool {
+	return fd.config.FollowSymlinks || fd.config.IgnoreSymlinkedDirs
+}
+
+// exceedsBudget adds a selected file's size to the running total and reports
+// whether it would push the total past the configured --size-budget.
+func (fd *FileDiscovery) exceedsBudget(size int64) bool {
+	if fd.config.SizeBudget <= 0 {
+		return false
+	}
+	if fd.selectedSize+size > fd.config.SizeBudget {
+		return true
+	}
+	fd.selectedSize += size
+	return false
+}
+
+// SortBySizeDesc orders files largest first so a worker pool starts the
+// longest jobs early instead of finishing on them. Files of equal size keep
+// their discovery order, which keeps the result deterministic.
+func SortBySizeDesc(files []FileInfo) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Size > files[j].Size
+	})
+}
+
+// RejectedBy returns how many files the named filter (e.g. "binary") rejected
+// during the last Discover.
+func (fd *FileDiscovery) RejectedBy(filterName string) int {
+	return fd.rejected[filterName]
+}
+
+// BudgetReached reports whether the last Discover stopped early because the
+// selected files reached the size budget, leaving the remaining ones out.
+func (fd *FileDiscovery) BudgetReached() bool {
+	return fd.budgetReached
+}
+
+func (fd *FileDiscovery) explainf(format string, args ...interface{}) {
+	if fd.explain != nil {
+		fmt.Fprintf(fd.explain, format, args...)
+	}
+}
+
+// shouldProcessFile runs the filter chain and reports the deciding filter.
+// The returned name is the filter that rejected the file, or empty when
+// every filter accepted it.
+func (fd *FileDiscovery) shouldProcessFile(path string, info os.FileInfo) (bool, string, error) {
+	for _, nf := range fd.filters {
+		should, err := nf.filter(path, info)
 		if err != nil {
-			return false, err
+			return false, nf.name, err
 		}
 		if !should {
-			return false, nil
+			return false, nf.name, nil
 		}
 	}
-	return true, nil
+	return true, "", nil
 }
 
-func buildFilters(cfg *config.Config) []FileFilter {
-	var filters []FileFilter
+func buildFilters(cfg *config.Config) []namedFilter {
+	var filters []namedFilter
 
-	filters = append(filters, extensionFilter(cfg))
+	filters = append(filters, namedFilter{name: "extension", filter: extensionFilter(cfg)})
 
 	if len(cfg.Include) > 0 {
-		filters = append(filters, includeFilter(cfg.Include))
+		filters = append(filters, namedFilter{name: "include", filter: includeFilter(cfg.Include)})
 	}
 
 	if len(cfg.Exclude) > 0 {
-		filters = append(filters, excludeFilter(cfg.Exclude))
+		filters = append(filters, namedFilter{name: "exclude", filter: excludeFilter(cfg.Exclude)})
 	}
 
-	filters = append(filters, regularFileFilter())
+	filters = append(filters, namedFilter{name: "regular-file", filter: regularFileFilter(cfg.IncludeHidden, cfg.ProcessBak)})
+
+	if cfg.ModifiedSince != "" || cfg.ModifiedBefore != "" {
+		since, before := cfg.ModifiedWindow(time.Now())
+		filters = append(filters, namedFilter{name: "modified-time", filter: modTimeFilter(since, before)})
+	}
+
+	// Content sniffing reads from disk, so it runs last on files that passed every cheap check
+	if cfg.SkipBinary {
+		filters = append(filters, namedFilter{name: "binary", filter: binaryFilter()})
+	}
+
+	if len(cfg.MimeTypes) > 0 {
+		filters = append(filters, namedFilter{name: "mime-type", filter: mimeTypeFilter(cfg.MimeTypes)})
+	}
+
+	// Content patterns read whole files, the most expensive check of all
+	if cfg.IncludeContent != "" {
+		filters = append(filters, namedFilter{name: "include-content", filter: contentFilter(cfg.IncludeContent, true)})
+	}
+
+	if cfg.ExcludeContent != "" {
+		filters = append(filters, namedFilter{name: "exclude-content", filter: contentFilter(cfg.ExcludeContent, false)})
+	}
 
 	return filters
 }
@@ -125,6 +341,7 @@ func buildFilters(cfg *config.Config) []FileFilter {
 // shouldExcludeDirectory determines if a directory should be excluded from traversal.
 // This method checks both the basename and full path against the ExcludeDir patterns,
 // enabling flexible directory exclusion rules while maintaining performance.
+// Patterns may use "**" to match at any depth (see matchGlob).
 func (fd *FileDiscovery) shouldExcludeDirectory(dirPath string) bool {
 	if len(fd.config.ExcludeDir) == 0 {
 		return false
@@ -143,12 +360,12 @@ func (fd *FileDiscovery) shouldExcludeDirectory(dirPath string) bool {
 			return true
 		}
 
-		// Check if pattern matches using filepath.Match for glob patterns
-		if matched, err := filepath.Match(excludePattern, baseName); err == nil && matched {
+		// Check if pattern matches as a glob, where "**" spans directories
+		if matched, err := matchGlob(excludePattern, baseName); err == nil && matched {
 			return true
 		}
 
-		if matched, err := filepath.Match(excludePattern, dirPath); err == nil && matched {
+		if matched, err := matchGlob(excludePattern, dirPath); err == nil && matched {
 			return true
 		}
 	}
@@ -171,7 +388,7 @@ func includeFilter(patterns []string) FileFilter {
 	return func(path string, _ os.FileInfo) (bool, error) {
 		for _, pattern := range patterns {
 			// Try base name first
-			matched, err := filepath.Match(pattern, filepath.Base(path))
+			matched, err := matchGlob(pattern, filepath.Base(path))
 			if err != nil {
 				return false, errors.NewConfigError("invalid include pattern: "+pattern, err)
 			}
@@ -180,7 +397,7 @@ func includeFilter(patterns []string) FileFilter {
 			}
 
 			// Try full path
-			matched, err = filepath.Match(pattern, path)
+			matched, err = matchGlob(pattern, path)
 			if err != nil {
 				return false, errors.NewConfigError("invalid include pattern: "+pattern, err)
 			}
@@ -191,7 +408,7 @@ func includeFilter(patterns []string) FileFilter {
 			// For absolute paths, also try matching without the leading slash
 			if filepath.IsAbs(path) {
 				relPath := strings.TrimPrefix(path, "/")
-				matched, err = filepath.Match(pattern, relPath)
+				matched, err = matchGlob(pattern, relPath)
 				if err != nil {
 					return false, errors.NewConfigError("invalid include pattern: "+pattern, err)
 				}
@@ -230,7 +447,7 @@ func matchFlexiblePattern(pattern, path string) (bool, error) {
 func excludeFilter(patterns []string) FileFilter {
 	return func(path string, _ os.FileInfo) (bool, error) {
 		for _, pattern := range patterns {
-			matched, err := filepath.Match(pattern, filepath.Base(path))
+			matched, err := matchGlob(pattern, filepath.Base(path))
 			if err != nil {
 				return false, errors.NewConfigError("invalid exclude pattern: "+pattern, err)
 			}
@@ -238,7 +455,7 @@ func excludeFilter(patterns []string) FileFilter {
 				return false, nil
 			}
 
-			matched, err = filepath.Match(pattern, path)
+			matched, err = matchGlob(pattern, path)
 			if err != nil {
 				return false, errors.NewConfigError("invalid exclude pattern: "+pattern, err)
 			}
@@ -250,7 +467,11 @@ func excludeFilter(patterns []string) FileFilter {
 	}
 }
 
-func regularFileFilter() FileFilter {
+// regularFileFilter accepts regular files, skipping dotfiles unless
+// includeHidden is set and remap's own timestamped backups unless processBak
+// is set. Other .bak files are ordinary sources. Dot-directories are skipped
+// during the walk itself, so their files never reach this filter.
+func regularFileFilter(includeHidden, processBak bool) FileFilter {
 	return func(path string, info os.FileInfo) (bool, error) {
 		if info.IsDir() {
 			return false, nil
@@ -260,14 +481,119 @@ func regularFileFilter() FileFilter {
 			return false, nil
 		}
 
-		if strings.HasPrefix(filepath.Base(path), ".") {
+		if !includeHidden && strings.HasPrefix(filepath.Base(path), ".") {
 			return false, nil
 		}
 
-		if strings.HasSuffix(path, ".bak") {
+		if !processBak && backup.IsBackupName(filepath.Base(path)) {
 			return false, nil
 		}
 
 		return true, nil
 	}
 }
+
+// binarySniffLen is how much of a file is searched for NUL bytes, the same
+// heuristic and window git uses to tell binary files from text.
+const binarySniffLen = 8000
+
+// modTimeFilter accepts files modified at or after since and strictly before
+// before. A zero bound leaves that side of the window open.
+func modTimeFilter(since, before time.Time) FileFilter {
+	return func(_ string, info os.FileInfo) (bool, error) {
+		modTime := info.ModTime()
+		if !since.IsZero() && modTime.Before(since) {
+			return false, nil
+		}
+		if !before.IsZero() && !modTime.Before(before) {
+			return false, nil
+		}
+		return true, nil
+	}
+}
+
+// binaryFilter rejects files whose leading bytes contain a NUL byte.
+// Unreadable files are kept so the processor reports the read error.
+func binaryFilter() FileFilter {
+	return func(filePath string, _ os.FileInfo) (bool, error) {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return true, nil
+		}
+		defer file.Close()
+
+		buf := make([]byte, binarySniffLen)
+		n, err := io.ReadFull(file, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return true, nil
+		}
+		return !bytes.Contains(buf[:n], []byte{0}), nil
+	}
+}
+
+// sniffLen is the number of bytes http.DetectContentType considers.
+const sniffLen = 512
+
+// mimeTypeFilter keeps files whose sniffed content type matches one of the patterns.
+// Patterns use path.Match syntax (e.g. "text/*"), which makes extensionless files
+// selectable. Each candidate file is opened and its first 512 bytes are read.
+func mimeTypeFilter(patterns []string) FileFilter {
+	return func(filePath string, _ os.FileInfo) (bool, error) {
+		mimeType, err := detectMimeType(filePath)
+		if err != nil {
+			return false, nil
+		}
+
+		for _, pattern := range patterns {
+			matched, err := path.Match(pattern, mimeType)
+			if err != nil {
+				return false, errors.NewConfigError("invalid mime type pattern: "+pattern, err)
+			}
+			if matched {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// detectMimeType sniffs the media type of a file, without parameters such as charset.
+func detectMimeType(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	mimeType := http.DetectContentType(buf[:n])
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.TrimSpace(mimeType), nil
+}
+
+// contentFilter keeps files whose content matches pattern when include is
+// true, or does not match it otherwise. The file is streamed through the
+// pattern rather than held in memory, and processing reads it again.
+// Unreadable files are kept so the processor reports the read error.
+func contentFilter(pattern string, include bool) FileFilter {
+	re, compileErr := regexp.Compile(pattern)
+	return func(filePath string, _ os.FileInfo) (bool, error) {
+		if compileErr != nil {
+			return false, errors.NewConfigError("invalid content pattern: "+pattern, compileErr)
+		}
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return true, nil
+		}
+		defer file.Close()
+		return re.MatchReader(bufio.NewReader(file)) == include, nil
+	}
+}
@@ -1,9 +1,13 @@
 package filter
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"remap/internal/config"
 )
@@ -60,7 +64,8 @@ func TestFileDiscoveryDiscover(t *testing.T) {
 		{name: "test.txt", content: "text content", isDir: false},
 		{name: "test.py", content: "print('hello')", isDir: false},
 		{name: ".hidden", content: "hidden file", isDir: false},
-		{name: "backup.bak", content: "backup", isDir: false},
+		{name: "legacy.bak", content: "old source", isDir: false},
+		{name: "test.go.20240301_120000.bak", content: "package main", isDir: false},
 		{name: "subdir", content: "", isDir: true},
 	}
 
@@ -91,7 +96,7 @@ func TestFileDiscoveryDiscover(t *testing.T) {
 				Directory:  tempDir,
 				Extensions: []string{},
 			},
-			expectedCount: 3, // go, txt, py (excludes hidden, bak, dir)
+			expectedCount: 4, // go, txt, py, legacy.bak (excludes hidden, backup, dir)
 		},
 		{
 			name: "go files only",
@@ -117,7 +122,16 @@ func TestFileDiscoveryDiscover(t *testing.T) {
 				Directory: tempDir,
 				Exclude:   []string{"*.py"},
 			},
-			expectedCount: 2, // go, txt (excludes py, hidden, bak, dir)
+			expectedCount: 3, // go, txt, legacy.bak (excludes py, hidden, backup, dir)
+		},
+		{
+			name: "process bak",
+			config: &config.Config{
+				Directory:  tempDir,
+				ProcessBak: true,
+			},
+			expectedCount: 5,
+			expectedFiles: []string{"legacy.bak", "test.go.20240301_120000.bak"},
 		},
 	}
 
@@ -266,6 +280,25 @@ func TestIncludeFilter(t *testing.T) {
 			expected: false,
 			hasError: true,
 		},
+		{
+			name:     "globstar pattern at depth",
+			patterns: []string{"src/**/*.go"},
+			filePath: "/home/user/project/src/pkg/util/main.go",
+			expected: true,
+		},
+		{
+			name:     "globstar pattern outside its directory",
+			patterns: []string{"src/**/*.go"},
+			filePath: "/home/user/project/cmd/main.go",
+			expected: false,
+		},
+		{
+			name:     "invalid globstar pattern",
+			patterns: []string{"**/[invalid"},
+			filePath: "/path/to/file.txt",
+			expected: false,
+			hasError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -319,6 +352,19 @@ func TestExcludeFilter(t *testing.T) {
 			expected: true,
 			hasError: true,
 		},
+		{
+			name:     "globstar pattern excludes at depth",
+			patterns: []string{"**/generated/*.go"},
+			filePath: "/path/to/src/generated/types.go",
+			expected: false,
+		},
+		{
+			name:     "invalid globstar pattern",
+			patterns: []string{"src/**/[invalid"},
+			filePath: "/path/to/file.txt",
+			expected: true,
+			hasError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -355,47 +401,51 @@ func TestRegularFileFilter(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	bakFile := filepath.Join(tempDir, "backup.bak")
+	bakFile := filepath.Join(tempDir, "regular.txt.20240301_120000.bak")
 	err = os.WriteFile(bakFile, []byte("backup"), 0644)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	bakSource := filepath.Join(tempDir, "legacy.bak")
+	err = os.WriteFile(bakSource, []byte("source"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	subdir := filepath.Join(tempDir, "subdir")
 	err = os.Mkdir(subdir, 0755)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	hiddenDirFile := filepath.Join(tempDir, ".github", "ci.yml")
+	if err := os.Mkdir(filepath.Dir(hiddenDirFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hiddenDirFile, []byte("on: push"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
 	tests := []struct {
-		name     string
-		filePath string
-		expected bool
+		name          string
+		filePath      string
+		includeHidden bool
+		processBak    bool
+		expected      bool
 	}{
-		{
-			name:     "regular file",
-			filePath: regularFile,
-			expected: true,
-		},
-		{
-			name:     "hidden file",
-			filePath: hiddenFile,
-			expected: false,
-		},
-		{
-			name:     "backup file",
-			filePath: bakFile,
-			expected: false,
-		},
-		{
-			name:     "directory",
-			filePath: subdir,
-			expected: false,
-		},
+		{name: "regular file", filePath: regularFile, expected: true},
+		{name: "hidden file", filePath: hiddenFile, expected: false},
+		{name: "hidden file included", filePath: hiddenFile, includeHidden: true, expected: true},
+		{name: "file in hidden directory", filePath: hiddenDirFile, expected: true},
+		{name: "file in hidden directory included", filePath: hiddenDirFile, includeHidden: true, expected: true},
+		{name: "backup file", filePath: bakFile, expected: false},
+		{name: "backup file with hidden included", filePath: bakFile, includeHidden: true, expected: false},
+		{name: "backup file processed", filePath: bakFile, processBak: true, expected: true},
+		{name: "bak source file", filePath: bakSource, expected: true},
+		{name: "directory", filePath: subdir, expected: false},
 	}
 
-	filter := regularFileFilter()
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			info, err := os.Stat(tt.filePath)
@@ -403,7 +453,7 @@ func TestRegularFileFilter(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			result, err := filter(tt.filePath, info)
+			result, err := regularFileFilter(tt.includeHidden, tt.processBak)(tt.filePath, info)
 			if err != nil {
 				t.Errorf("unexpected error: %v", err)
 			}
@@ -414,6 +464,116 @@ func TestRegularFileFilter(t *testing.T) {
 	}
 }
 
+func TestDiscoverIncludeHidden(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"main.go", ".env", ".git/config", ".github/workflows/ci.yml", ".github/workflows/.cache"} {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		includeHidden bool
+		expected      []string
+	}{
+		{"default", false, []string{"main.go"}},
+		{"include hidden", true, []string{".env", ".git/config", ".github/workflows/.cache", ".github/workflows/ci.yml", "main.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Directory: tempDir, IncludeHidden: tt.includeHidden}
+			files, err := NewFileDiscovery(cfg).Discover()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var found []string
+			for _, file := range files {
+				rel, err := filepath.Rel(tempDir, file.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				found = append(found, filepath.ToSlash(rel))
+			}
+			sort.Strings(found)
+
+			if strings.Join(found, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v, got %v", tt.expected, found)
+			}
+		})
+	}
+}
+
+func TestDiscoverHiddenRoot(t *testing.T) {
+	// The directory to process is walked even when it is a dot-directory
+	root := filepath.Join(t.TempDir(), ".config")
+	if err := os.MkdirAll(filepath.Join(root, ".cache"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"app.yml", ".cache/state"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := NewFileDiscovery(&config.Config{Directory: root}).Discover()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0].Path) != "app.yml" {
+		t.Errorf("expected only app.yml, got %v", files)
+	}
+}
+
+func TestModTimeFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		since    time.Time
+		before   time.Time
+		expected bool
+	}{
+		{"no bounds", time.Time{}, time.Time{}, true},
+		{"modified since earlier", modTime.Add(-time.Hour), time.Time{}, true},
+		{"modified since exact", modTime, time.Time{}, true},
+		{"modified since later", modTime.Add(time.Hour), time.Time{}, false},
+		{"modified before later", time.Time{}, modTime.Add(time.Hour), true},
+		{"modified before exact", time.Time{}, modTime, false},
+		{"inside window", modTime.Add(-time.Hour), modTime.Add(time.Hour), true},
+		{"outside window", modTime.Add(-2 * time.Hour), modTime.Add(-time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := modTimeFilter(tt.since, tt.before)(path, info)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestBuildFilters(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -508,7 +668,7 @@ func TestShouldProcessFile(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			result, err := discovery.shouldProcessFile(tt.filePath, info)
+			result, _, err := discovery.shouldProcessFile(tt.filePath, info)
 			if err != nil {
 				t.Errorf("unexpected error: %v", err)
 			}
@@ -594,3 +754,365 @@ func TestDiscoverPermissionErrors(t *testing.T) {
 		t.Error("expected to find at least one file")
 	}
 }
+
+func TestShouldProcessFileDecidingFilter(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"main.go":      "package main",
+		"main_test.go": "package main",
+		"notes.txt":    "notes",
+		"other.go":     "package other",
+		".hidden.go":   "package hidden",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{
+		Directory:  tempDir,
+		Extensions: []string{".go"},
+		Include:    []string{"main*", ".hidden*"},
+		Exclude:    []string{"*_test.go"},
+	}
+	discovery := NewFileDiscovery(cfg)
+
+	expected := map[string]string{
+		"main.go":      "",
+		"main_test.go": "exclude",
+		"notes.txt":    "extension",
+		"other.go":     "include",
+		".hidden.go":   "regular-file",
+	}
+	for name, decidedBy := range expected {
+		path := filepath.Join(tempDir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		should, filterName, err := discovery.shouldProcessFile(path, info)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if should != (decidedBy == "") {
+			t.Errorf("%s: expected accepted=%v, got %v", name, decidedBy == "", should)
+		}
+		if filterName != decidedBy {
+			t.Errorf("%s: expected deciding filter %q, got %q", name, decidedBy, filterName)
+		}
+	}
+
+	var buf bytes.Buffer
+	discovery.SetExplainWriter(&buf)
+	if _, err := discovery.Discover(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := buf.String()
+	for _, line := range []string{
+		"ACCEPT: " + filepath.Join(tempDir, "main.go") + " (passed all filters)",
+		"REJECT: " + filepath.Join(tempDir, "notes.txt") + " (rejected by extension)",
+		"REJECT: " + filepath.Join(tempDir, "other.go") + " (rejected by include)",
+		"REJECT: " + filepath.Join(tempDir, "main_test.go") + " (rejected by exclude)",
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("expected explanation %q in output:\n%s", line, output)
+		}
+	}
+}
+
+func TestMimeTypeFilter(t *testing.T) {
+	tempDir := t.TempDir()
+
+	pngHeader := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	files := map[string][]byte{
+		"README":    []byte("plain text without an extension\n"),
+		"image.dat": pngHeader,
+		"page.html": []byte("<!DOCTYPE html><html></html>"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		file     string
+		expected bool
+	}{
+		{"text file detected", []string{"text/*"}, "README", true},
+		{"image excluded", []string{"text/*"}, "image.dat", false},
+		{"html is text", []string{"text/*"}, "page.html", true},
+		{"exact type", []string{"image/png"}, "image.dat", true},
+		{"second pattern matches", []string{"text/plain", "image/*"}, "image.dat", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := mimeTypeFilter(tt.patterns)
+			path := filepath.Join(tempDir, tt.file)
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := filter(path, info)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	discovery := NewFileDiscovery(&config.Config{Directory: tempDir, MimeTypes: []string{"text/*"}})
+	found, err := discovery.Discover()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(found) != 2 {
+		t.Errorf("expected 2 text files, got %d", len(found))
+	}
+}
+
+func TestBinaryFilter(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string][]byte{
+		"notes.txt":  []byte("plain text\n"),
+		"image.png":  []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+		"late.bin":   append(bytes.Repeat([]byte("a"), binarySniffLen), 0),
+		"empty.conf": {},
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	discovery := NewFileDiscovery(&config.Config{Directory: tempDir, SkipBinary: true})
+	found, err := discovery.Discover()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, file := range found {
+		names = append(names, filepath.Base(file.Path))
+	}
+	sort.Strings(names)
+
+	// A NUL byte past the sniffed window is not seen, as with git
+	expected := []string{"empty.conf", "late.bin", "notes.txt"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestContentFilters(t *testing.T) {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"main.go":      "package main\n\nfunc main() {}\n",
+		"generated.go": "// Code generated. DO NOT EDIT.\npackage main\n",
+		"lib.go":       "package lib\n",
+		"notes.txt":    "see package main\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		include  string
+		exclude  string
+		expected []string
+	}{
+		{"include", "(?m)^package main$", "", []string{"generated.go", "main.go"}},
+		{"exclude", "", "DO NOT EDIT", []string{"lib.go", "main.go", "notes.txt"}},
+		{"include and exclude", "(?m)^package main$", "DO NOT EDIT", []string{"main.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discovery := NewFileDiscovery(&config.Config{Directory: tempDir, IncludeContent: tt.include, ExcludeContent: tt.exclude})
+			found, err := discovery.Discover()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, file := range found {
+				names = append(names, filepath.Base(file.Path))
+			}
+			sort.Strings(names)
+
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestDiscoverSizeBudget(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), bytes.Repeat([]byte("x"), 100), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		budget        int64
+		expectFiles   int
+		expectReached bool
+	}{
+		{name: "no budget", budget: 0, expectFiles: 4, expectReached: false},
+		{name: "budget fits everything", budget: 400, expectFiles: 4, expectReached: false},
+		{name: "budget reached", budget: 250, expectFiles: 2, expectReached: true},
+		{name: "first file too large", budget: 50, expectFiles: 0, expectReached: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discovery := NewFileDiscovery(&config.Config{Directory: tempDir, SizeBudget: tt.budget})
+			found, err := discovery.Discover()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(found) != tt.expectFiles {
+				t.Errorf("expected %d files, got %d", tt.expectFiles, len(found))
+			}
+			if discovery.BudgetReached() != tt.expectReached {
+				t.Errorf("expected BudgetReached=%v, got %v", tt.expectReached, discovery.BudgetReached())
+			}
+		})
+	}
+}
+
+func TestDiscoverSymlinks(t *testing.T) {
+	tree := t.TempDir()
+	outside := t.TempDir()
+
+	writeFile := func(path string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(tree, "a.txt"))
+	if err := os.Mkdir(filepath.Join(outside, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(filepath.Join(outside, "dir", "secret.txt"))
+	writeFile(filepath.Join(outside, "note.txt"))
+	writeFile(filepath.Join(outside, "readme.md"))
+
+	links := map[string]string{
+		filepath.Join(tree, "linked-dir"):   filepath.Join(outside, "dir"),
+		filepath.Join(tree, "linked.txt"):   filepath.Join(outside, "note.txt"),
+		filepath.Join(tree, "readme.txt"):   filepath.Join(outside, "readme.md"),
+		filepath.Join(tree, "loop"):         tree,
+		filepath.Join(tree, "parent"):       outside,
+		filepath.Join(outside, "dir", "up"): filepath.Join(outside, "dir"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	outsideReal, err := filepath.EvalSymlinks(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                string
+		followSymlinks      bool
+		ignoreSymlinkedDirs bool
+		extensions          []string
+		expected            []string
+	}{
+		{
+			name:     "links not followed",
+			expected: []string{filepath.Join(tree, "a.txt")},
+		},
+		{
+			// Cycles and a link to an ancestor of a walked directory still
+			// list every file once
+			name:           "links followed",
+			followSymlinks: true,
+			expected: []string{
+				filepath.Join(tree, "a.txt"),
+				filepath.Join(outsideReal, "dir", "secret.txt"),
+				filepath.Join(outsideReal, "note.txt"),
+				filepath.Join(outsideReal, "readme.md"),
+			},
+		},
+		{
+			name:                "symlinked directories ignored",
+			ignoreSymlinkedDirs: true,
+			expected: []string{
+				filepath.Join(tree, "a.txt"),
+				filepath.Join(outsideReal, "note.txt"),
+				filepath.Join(outsideReal, "readme.md"),
+			},
+		},
+		{
+			name:                "symlinked directories ignored while following links",
+			followSymlinks:      true,
+			ignoreSymlinkedDirs: true,
+			expected: []string{
+				filepath.Join(tree, "a.txt"),
+				filepath.Join(outsideReal, "note.txt"),
+				filepath.Join(outsideReal, "readme.md"),
+			},
+		},
+		{
+			name:           "filters apply to resolved targets",
+			followSymlinks: true,
+			extensions:     []string{".txt"},
+			expected: []string{
+				filepath.Join(tree, "a.txt"),
+				filepath.Join(outsideReal, "dir", "secret.txt"),
+				filepath.Join(outsideReal, "note.txt"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discovery := NewFileDiscovery(&config.Config{
+				Directory:           tree,
+				FollowSymlinks:      tt.followSymlinks,
+				IgnoreSymlinkedDirs: tt.ignoreSymlinkedDirs,
+				Extensions:          tt.extensions,
+			})
+			found, err := discovery.Discover()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var paths []string
+			for _, file := range found {
+				paths = append(paths, file.Path)
+			}
+			sort.Strings(paths)
+			sort.Strings(tt.expected)
+
+			if strings.Join(paths, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("expected files:\n%s\ngot:\n%s", strings.Join(tt.expected, "\n"), strings.Join(paths, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package filter
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// globStar is the path segment matching any number of directories.
+const globStar = "**"
+
+// matchGlob reports whether name matches pattern. Patterns without "**" are
+// filepath.Match patterns and behave exactly as it does. A "**" segment
+// matches zero or more directories, and such patterns are tried against the
+// whole name and every trailing part of it, so "**/node_modules" and
+// "src/**/generated" match at any depth of an absolute or relative path.
+// Malformed patterns report path.ErrBadPattern whatever the name.
+func matchGlob(pattern, name string) (bool, error) {
+	if !strings.Contains(pattern, globStar) {
+		return filepath.Match(pattern, name)
+	}
+
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return false, err
+		}
+	}
+
+	parts := strings.Split(filepath.ToSlash(name), "/")
+	for start := range parts {
+		if matchSegments(segments, parts[start:]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchSegments matches path parts against pattern segments, letting each
+// "**" segment consume any number of parts.
+func matchSegments(segments, parts []string) bool {
+	if len(segments) == 0 {
+		return len(parts) == 0
+	}
+
+	if segments[0] == globStar {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(segments[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+	matched, _ := path.Match(segments[0], parts[0])
+	return matched && matchSegments(segments[1:], parts[1:])
+}
@@ -0,0 +1,100 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"remap/internal/config"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		// Without "**", patterns behave exactly like filepath.Match
+		{pattern: "*.go", name: "main.go", expected: true},
+		{pattern: "*.go", name: "src/main.go", expected: false},
+		{pattern: "src/*", name: "src/gen", expected: true},
+		{pattern: "src/*", name: "src/gen/types", expected: false},
+
+		{pattern: "**/node_modules", name: "node_modules", expected: true},
+		{pattern: "**/node_modules", name: "/repo/web/app/node_modules", expected: true},
+		{pattern: "**/node_modules", name: "/repo/node_modules_backup", expected: false},
+		{pattern: "src/**/generated", name: "src/generated", expected: true},
+		{pattern: "src/**/generated", name: "/repo/src/a/b/generated", expected: true},
+		{pattern: "src/**/generated", name: "/repo/lib/a/generated", expected: false},
+		{pattern: "src/**", name: "/repo/src/a/b.go", expected: true},
+		{pattern: "**/test*/*.json", name: "pkg/testdata/input.json", expected: true},
+		{pattern: "**/test*/*.json", name: "pkg/testdata/deep/input.json", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			matched, err := matchGlob(tt.pattern, tt.name)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if matched != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, matched)
+			}
+		})
+	}
+
+	for _, pattern := range []string{"[invalid", "**/[invalid", "src/**/a[b"} {
+		if _, err := matchGlob(pattern, "anything"); err == nil {
+			t.Errorf("expected an error for %q", pattern)
+		}
+	}
+}
+
+func TestDiscoverExcludeDirGlobstar(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{
+		"main.go",
+		"pkg/testdata/fixture.go",
+		"pkg/deep/testdata/fixture.go",
+		"pkg/util.go",
+		"src/api/generated/types.go",
+		"src/api/handler.go",
+		"generated/keep.go",
+	} {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{Directory: tempDir, ExcludeDir: []string{"**/testdata", "src/**/generated"}}
+	files, err := NewFileDiscovery(cfg).Discover()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var found []string
+	for _, file := range files {
+		rel, err := filepath.Rel(tempDir, file.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found = append(found, filepath.ToSlash(rel))
+	}
+	sort.Strings(found)
+
+	expected := []string{"generated/keep.go", "main.go", "pkg/util.go", "src/api/handler.go"}
+	if len(found) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, found)
+	}
+	for i := range expected {
+		if found[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, found)
+			break
+		}
+	}
+}
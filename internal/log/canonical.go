@@ -0,0 +1,34 @@
+package log
+
+import (
+	"sort"
+
+	"remap/internal/replacement"
+)
+
+// canonicalEntries returns a copy of entries with everything that varies
+// between identical runs removed: entries are sorted by path, replacements by
+// position, and timestamps and the timestamped backup paths are dropped. Two
+// runs over unchanged input then produce byte-identical reports that can be
+// compared with diff; reverting such a report reverses its replacements.
+func canonicalEntries(entries []Entry) []Entry {
+	canonical := make([]Entry, len(entries))
+	for i, entry := range entries {
+		entry.Timestamp = ""
+		entry.BackupPath = ""
+		entry.Replacements = append([]replacement.Replacement(nil), entry.Replacements...)
+		sort.SliceStable(entry.Replacements, func(a, b int) bool {
+			ra, rb := entry.Replacements[a], entry.Replacements[b]
+			if ra.ByteOffset != rb.ByteOffset {
+				return ra.ByteOffset < rb.ByteOffset
+			}
+			return ra.MappingIndex < rb.MappingIndex
+		})
+		canonical[i] = entry
+	}
+
+	sort.SliceStable(canonical, func(a, b int) bool {
+		return canonical[a].FilePath < canonical[b].FilePath
+	})
+	return canonical
+}
@@ -0,0 +1,59 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"remap/internal/concurrent"
+	"remap/internal/config"
+	"remap/internal/replacement"
+)
+
+func TestWriteJSONReportCanonical(t *testing.T) {
+	// writeReport logs the same results in the given completion order, with
+	// replacements listed in the given order, at distinct times
+	writeReport := func(order []int, reversed bool, now time.Duration) []byte {
+		var buf bytes.Buffer
+		logger := &Logger{
+			config:  &config.Config{LogFormat: config.LogFormatJSON, Canonical: true},
+			writer:  &buf,
+			entries: []Entry{},
+		}
+
+		paths := []string{"/src/a.txt", "/src/b.txt", "/src/c.txt"}
+		for _, i := range order {
+			replacements := []replacement.Replacement{
+				{From: "foo", To: "bar", Line: 1, Column: 1, ByteOffset: 0, MappingIndex: 1},
+				{From: "baz", To: "qux", Line: 2, Column: 3, ByteOffset: 10, MappingIndex: 0},
+			}
+			if reversed {
+				replacements[0], replacements[1] = replacements[1], replacements[0]
+			}
+			logger.LogResult(concurrent.ProcessResult{
+				Job:        concurrent.ProcessJob{FilePath: paths[i], Sequence: i},
+				Result:     &replacement.FileResult{Path: paths[i], Modified: true, Replacements: replacements},
+				BackupPath: paths[i] + "." + now.String() + ".bak",
+			})
+		}
+		logger.SetProcessingTime(now)
+
+		if err := logger.WriteReport(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	first := writeReport([]int{2, 0, 1}, false, time.Second)
+	second := writeReport([]int{1, 2, 0}, true, 3*time.Second)
+
+	if !bytes.Equal(first, second) {
+		t.Errorf("expected byte-identical reports, got:\n%s\nand:\n%s", first, second)
+	}
+	if bytes.Contains(first, []byte("timestamp")) || bytes.Contains(first, []byte("backup_path")) {
+		t.Errorf("expected no timestamps or backup paths in canonical report, got:\n%s", first)
+	}
+	if a, c := bytes.Index(first, []byte("a.txt")), bytes.Index(first, []byte("c.txt")); a > c {
+		t.Errorf("expected entries sorted by path, got:\n%s", first)
+	}
+}
@@ -0,0 +1,38 @@
+package log
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// changedFile is one line of the --changed-files-json report: a modified
+// file and how many replacements it received.
+type changedFile struct {
+	Path         string `json:"path"`
+	Replacements int    `json:"replacements"`
+}
+
+// changedFiles lists the files that were (or in a dry run would be)
+// modified without error, sorted by path so the list is stable across runs.
+func (l *Logger) changedFiles() []changedFile {
+	changed := []changedFile{}
+	for _, entry := range l.entries {
+		if !entry.Modified || entry.Error != "" {
+			continue
+		}
+		changed = append(changed, changedFile{Path: entry.FilePath, Replacements: len(entry.Replacements)})
+	}
+
+	sort.SliceStable(changed, func(i, j int) bool {
+		return changed[i].Path < changed[j].Path
+	})
+	return changed
+}
+
+// writeChangedFilesReport writes only the affected set and its magnitude, a
+// lightweight alternative to the full JSON report for downstream tooling.
+func (l *Logger) writeChangedFilesReport() error {
+	encoder := json.NewEncoder(l.writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(l.changedFiles())
+}
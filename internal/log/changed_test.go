@@ -0,0 +1,68 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"remap/internal/concurrent"
+	"remap/internal/config"
+	"remap/internal/replacement"
+)
+
+func TestWriteChangedFilesReport(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config:  &config.Config{LogFormat: config.LogFormatJSON, DryRun: true, ChangedFilesJSON: true},
+		writer:  &buf,
+		entries: []Entry{},
+	}
+
+	results := []concurrent.ProcessResult{
+		{Job: concurrent.ProcessJob{FilePath: "/src/z.txt"}, Result: &replacement.FileResult{Path: "/src/z.txt", Modified: true,
+			Replacements: []replacement.Replacement{{From: "a", To: "b"}}}},
+		{Job: concurrent.ProcessJob{FilePath: "/src/unchanged.txt"}, Result: &replacement.FileResult{Path: "/src/unchanged.txt"}},
+		{Job: concurrent.ProcessJob{FilePath: "/src/a.txt"}, Result: &replacement.FileResult{Path: "/src/a.txt", Modified: true,
+			Replacements: []replacement.Replacement{{From: "a", To: "b"}, {From: "a", To: "b"}, {From: "c", To: "d"}}}},
+		{Job: concurrent.ProcessJob{FilePath: "/src/failed.txt"}, Error: fmt.Errorf("permission denied")},
+	}
+	for _, result := range results {
+		logger.LogResult(result)
+	}
+
+	if err := logger.WriteReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var changed []changedFile
+	if err := json.Unmarshal(buf.Bytes(), &changed); err != nil {
+		t.Fatalf("expected a JSON list, got %v:\n%s", err, buf.String())
+	}
+
+	expected := []changedFile{{Path: "/src/a.txt", Replacements: 3}, {Path: "/src/z.txt", Replacements: 1}}
+	if len(changed) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, changed)
+	}
+	for i := range expected {
+		if changed[i] != expected[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, expected[i], changed[i])
+		}
+	}
+}
+
+func TestWriteChangedFilesReportEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config:  &config.Config{LogFormat: config.LogFormatJSON, ChangedFilesJSON: true},
+		writer:  &buf,
+		entries: []Entry{},
+	}
+
+	if err := logger.WriteReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := buf.String(); got != "[]\n" {
+		t.Errorf("expected an empty list, got %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"io"
+	"os"
+	"strconv"
+
+	"remap/internal/config"
+)
+
+// ANSI escape sequences used by colored output.
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+)
+
+// useColor decides whether output to w is colored for the --color mode.
+// Reports written to a file or a sink are never colored, so escape codes
+// cannot end up in stored logs. In auto mode, color requires a terminal and
+// an unset or empty NO_COLOR environment variable (https://no-color.org).
+func useColor(cfg *config.Config, w io.Writer) bool {
+	if cfg.LogFile != "" || cfg.LogSink != "" {
+		return false
+	}
+
+	switch cfg.Color {
+	case config.ColorAlways:
+		return true
+	case config.ColorNever:
+		return false
+	default:
+		if os.Getenv("NO_COLOR") != "" {
+			return false
+		}
+		file, ok := w.(*os.File)
+		if !ok {
+			return false
+		}
+		info, err := file.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+}
+
+// paint wraps text in the given color when color output is enabled.
+func (l *Logger) paint(color, text string) string {
+	if !l.color {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// paintCount colors a count when it is non-zero, e.g. errors in red, so the
+// numbers that need attention stand out in the summary.
+func (l *Logger) paintCount(color string, count int) string {
+	text := strconv.Itoa(count)
+	if count == 0 {
+		return text
+	}
+	return l.paint(color, text)
+}
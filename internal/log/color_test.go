@@ -0,0 +1,99 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"remap/internal/concurrent"
+	"remap/internal/config"
+	"remap/internal/replacement"
+)
+
+func TestUseColor(t *testing.T) {
+	// Output redirected to a regular file is not a terminal
+	redirected, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer redirected.Close()
+
+	tests := []struct {
+		name   string
+		config config.Config
+		writer io.Writer
+		want   bool
+	}{
+		{"always", config.Config{Color: config.ColorAlways}, &bytes.Buffer{}, true},
+		{"never", config.Config{Color: config.ColorNever}, &bytes.Buffer{}, false},
+		{"always but logging to a file", config.Config{Color: config.ColorAlways, LogFile: "remap.json"}, &bytes.Buffer{}, false},
+		{"always but logging to a sink", config.Config{Color: config.ColorAlways, LogSink: "syslog"}, &bytes.Buffer{}, false},
+		{"auto with a buffer", config.Config{Color: config.ColorAuto}, &bytes.Buffer{}, false},
+		{"auto with redirected output", config.Config{Color: config.ColorAuto}, redirected, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := useColor(&tt.config, tt.writer); got != tt.want {
+				t.Errorf("useColor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColoredOutput(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config:  &config.Config{Verbose: true, LogFormat: config.LogFormatJSON},
+		writer:  &buf,
+		entries: []Entry{},
+		color:   true,
+	}
+
+	results := []concurrent.ProcessResult{
+		{Job: concurrent.ProcessJob{FilePath: "/test/a.txt"}, Result: &replacement.FileResult{Modified: true,
+			Replacements: []replacement.Replacement{{From: "a", To: "b"}}}},
+		{Job: concurrent.ProcessJob{FilePath: "/test/b.txt"}, Result: &replacement.FileResult{}},
+		{Job: concurrent.ProcessJob{FilePath: "/test/c.txt"}, Error: fmt.Errorf("permission denied")},
+	}
+	for _, result := range results {
+		logger.LogResult(result)
+	}
+
+	verbose := buf.String()
+	for _, want := range []string{
+		colorGreen + "MODIFIED:" + colorReset + " /test/a.txt",
+		colorYellow + "SKIPPED:" + colorReset + " /test/b.txt",
+		colorRed + "ERROR:" + colorReset + " /test/c.txt",
+	} {
+		if !strings.Contains(verbose, want) {
+			t.Errorf("expected %q in verbose output, got %q", want, verbose)
+		}
+	}
+
+	// The structured report itself is never colored
+	buf.Reset()
+	if err := logger.WriteReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "\033[") {
+		t.Errorf("expected no escape codes in the JSON report, got %q", buf.String())
+	}
+	var report map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Errorf("invalid JSON report: %v", err)
+	}
+
+	buf.Reset()
+	if err := logger.writeSummaryReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Errors: "+colorRed+"1"+colorReset) {
+		t.Errorf("expected a red error count, got %q", buf.String())
+	}
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+
+	"remap/internal/replacement"
+)
+
+// contextWindow is a run of lines shown around one or more changes by
+// --context, from line start to line end inclusive.
+type contextWindow struct {
+	start, end int
+}
+
+// contextWindows returns the windows around the replacements' lines, merging
+// windows that overlap or touch so no line is printed twice, and the text of
+// every line they cover.
+func contextWindows(replacements []replacement.Replacement) ([]contextWindow, map[int]string) {
+	sorted := append([]replacement.Replacement(nil), replacements...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Line < sorted[j].Line
+	})
+
+	var windows []contextWindow
+	lines := make(map[int]string)
+	for _, r := range sorted {
+		start := r.Line - len(r.ContextBefore)
+		for i, text := range r.ContextBefore {
+			lines[start+i] = text
+		}
+		lines[r.Line] = r.LineText
+		for i, text := range r.ContextAfter {
+			lines[r.Line+1+i] = text
+		}
+
+		window := contextWindow{start: start, end: r.Line + len(r.ContextAfter)}
+		if last := len(windows) - 1; last >= 0 && window.start <= windows[last].end+1 {
+			windows[last].end = max(windows[last].end, window.end)
+			continue
+		}
+		windows = append(windows, window)
+	}
+	return windows, lines
+}
+
+// logContext prints each changed line of a file with the --context lines
+// around it. Changed lines are marked with '>' and followed by their
+// replacements.
+func (l *Logger) logContext(replacements []replacement.Replacement) {
+	windows, lines := contextWindows(replacements)
+
+	changes := make(map[int][]replacement.Replacement)
+	for _, r := range replacements {
+		changes[r.Line] = append(changes[r.Line], r)
+	}
+
+	for _, window := range windows {
+		fmt.Fprintf(l.writer, "  Lines %d-%d:\n", window.start, window.end)
+		for line := window.start; line <= window.end; line++ {
+			marker := " "
+			if len(changes[line]) > 0 {
+				marker = l.paint(colorGreen, ">")
+			}
+			fmt.Fprintf(l.writer, "  %s %5d  %s\n", marker, line, lines[line])
+			for _, r := range changes[line] {
+				fmt.Fprintf(l.writer, "           %d: '%s' -> '%s'%s\n", r.Column, r.From, r.To, commentSuffix(r))
+			}
+		}
+	}
+}
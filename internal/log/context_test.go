@@ -0,0 +1,51 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/replacement"
+)
+
+func TestLogVerboseContext(t *testing.T) {
+	// Changes on lines 3 and 5 share a window; the one on line 12 gets its own
+	entry := Entry{
+		FilePath: "app.conf",
+		Modified: true,
+		Replacements: []replacement.Replacement{
+			{From: "old", To: "new", Line: 5, Column: 1, LineText: "old 5",
+				ContextBefore: []string{"line 4"}, ContextAfter: []string{"line 6"}},
+			{From: "old", To: "new", Line: 3, Column: 6, LineText: "line old",
+				ContextBefore: []string{"line 2"}, ContextAfter: []string{"line 4"}},
+			{From: "x", To: "y", Line: 3, Column: 1, LineText: "line old",
+				ContextBefore: []string{"line 2"}, ContextAfter: []string{"line 4"}},
+			{From: "old", To: "new", Line: 12, Column: 1, LineText: "old 12",
+				ContextBefore: []string{"line 11"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	logger := &Logger{config: &config.Config{ContextLines: 1}, writer: &buf}
+	logger.logVerbose(entry)
+
+	expected := "  Lines 2-6:\n" +
+		"        2  line 2\n" +
+		"  >     3  line old\n" +
+		"           6: 'old' -> 'new'\n" +
+		"           1: 'x' -> 'y'\n" +
+		"        4  line 4\n" +
+		"  >     5  old 5\n" +
+		"           1: 'old' -> 'new'\n" +
+		"        6  line 6\n" +
+		"  Lines 11-12:\n" +
+		"       11  line 11\n" +
+		"  >    12  old 12\n" +
+		"           1: 'old' -> 'new'\n"
+	// The MODIFIED line comes first, as without --context
+	_, output, _ := strings.Cut(buf.String(), "\n")
+	if output != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", output, expected)
+	}
+}
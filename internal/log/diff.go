@@ -0,0 +1,37 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"remap/internal/diff"
+	"remap/internal/replacement"
+)
+
+// logDiff prints the change to a modified file as a unified diff for --diff,
+// labelled with the path as it appears in the report and with --context
+// lines around each change (three by default). Files whose change was
+// not captured (unmodified or failed files) print nothing.
+func (l *Logger) logDiff(path string, result *replacement.FileResult) {
+	if !l.config.ShouldLog() || !result.Modified || result.NewContent == nil {
+		return
+	}
+
+	oldText, newText := string(result.OriginalContent), string(result.NewContent)
+	unified := diff.Unified(path, path, oldText, newText)
+	if l.config.ContextLines > 0 {
+		unified = diff.UnifiedContext(path, path, oldText, newText, l.config.ContextLines)
+	}
+	fmt.Fprint(l.diffOutput(), unified)
+}
+
+// diffOutput returns where diffs are printed: the logger's writer, unless
+// the report goes to a --log file or a sink, where diffs would corrupt it;
+// they are then printed on standard output instead.
+func (l *Logger) diffOutput() io.Writer {
+	if l.config.LogFile != "" || l.sink != nil {
+		return os.Stdout
+	}
+	return l.writer
+}
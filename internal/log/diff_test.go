@@ -0,0 +1,54 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"remap/internal/concurrent"
+	"remap/internal/config"
+	"remap/internal/replacement"
+)
+
+func TestLogDiff(t *testing.T) {
+	original := "line 1\nline 2\nline 3\nline 4\nfoo\nline 6\nline 7\nline 8\nline 9\nline 10\nline 11\nline 12\nfoo\n"
+	updated := strings.ReplaceAll(original, "foo", "bar")
+
+	var buf bytes.Buffer
+	logger := &Logger{
+		config:  &config.Config{DryRun: true, Diff: true},
+		writer:  &buf,
+		entries: []Entry{},
+	}
+	logger.LogResult(concurrent.ProcessResult{
+		Job: concurrent.ProcessJob{FilePath: "src/a.txt"},
+		Result: &replacement.FileResult{Modified: true,
+			OriginalContent: []byte(original), NewContent: []byte(updated)},
+	})
+	logger.LogResult(concurrent.ProcessResult{
+		Job:    concurrent.ProcessJob{FilePath: "src/unchanged.txt"},
+		Result: &replacement.FileResult{},
+	})
+
+	want := `--- src/a.txt
++++ src/a.txt
+@@ -2,7 +2,7 @@
+ line 2
+ line 3
+ line 4
+-foo
++bar
+ line 6
+ line 7
+ line 8
+@@ -10,4 +10,4 @@
+ line 10
+ line 11
+ line 12
+-foo
++bar
+`
+	if buf.String() != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
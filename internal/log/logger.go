@@ -6,13 +6,19 @@ package log
 import (
 	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"remap/internal/concurrent"
 	"remap/internal/config"
+	"remap/internal/parser"
 	"remap/internal/replacement"
 )
 
@@ -21,36 +27,61 @@ import (
 // including replacements made, backup paths, and errors, enabling comprehensive
 // audit trails and operation analysis.
 type Entry struct {
-	Timestamp    string                    `json:"timestamp"`
-	FilePath     string                    `json:"file_path"`
-	OriginalSize int64                     `json:"original_size"`
-	NewSize      int64                     `json:"new_size"`
-	Modified     bool                      `json:"modified"`
-	Replacements []replacement.Replacement `json:"replacements,omitempty"`
-	BackupPath   string                    `json:"backup_path,omitempty"`
-	Error        string                    `json:"error,omitempty"`
+	Timestamp    string                    `json:"timestamp,omitempty" xml:"timestamp,omitempty"`
+	FilePath     string                    `json:"file_path" xml:"file_path"`
+	OriginalSize int64                     `json:"original_size" xml:"original_size"`
+	NewSize      int64                     `json:"new_size" xml:"new_size"`
+	Modified     bool                      `json:"modified" xml:"modified"`
+	Replacements []replacement.Replacement `json:"replacements,omitempty" xml:"replacements>replacement,omitempty"`
+	BackupPath   string                    `json:"backup_path,omitempty" xml:"backup_path,omitempty"`
+	Error        string                    `json:"error,omitempty" xml:"error,omitempty"`
+	HasBOM       bool                      `json:"has_bom,omitempty" xml:"has_bom,omitempty"`
+
+	sequence int
+	detected int
+	deleted  int64
 }
 
 // Summary provides aggregate statistics for the entire remap operation.
 // This structure enables quick assessment of operation success and provides
 // metrics for performance analysis and reporting purposes.
 type Summary struct {
-	TotalFiles        int           `json:"total_files"`
-	ModifiedFiles     int           `json:"modified_files"`
-	TotalReplacements int           `json:"total_replacements"`
-	ErrorCount        int           `json:"error_count"`
-	ProcessingTime    time.Duration `json:"processing_time"`
-	DryRun            bool          `json:"dry_run"`
+	TotalFiles        int           `json:"total_files" xml:"total_files"`
+	ModifiedFiles     int           `json:"modified_files" xml:"modified_files"`
+	TotalReplacements int           `json:"total_replacements" xml:"total_replacements"`
+	ErrorCount        int           `json:"error_count" xml:"error_count"`
+	DeletedBytes      int64         `json:"deleted_bytes" xml:"deleted_bytes"`
+	ProcessingTime    time.Duration `json:"processing_time" xml:"processing_time"`
+	DryRun            bool          `json:"dry_run" xml:"dry_run"`
+
+	// DetectedReplacements counts every match found, while TotalReplacements
+	// counts only those replaced, so matches left alone make them differ.
+	DetectedReplacements int `json:"detected_replacements" xml:"detected_replacements"`
+
+	// CaseSensitive, WordBoundary and PreserveCase record how the run matched
+	// text, so --revert and --apply can match it the same way.
+	CaseSensitive bool `json:"case_sensitive" xml:"case_sensitive"`
+	WordBoundary  bool `json:"word_boundary" xml:"word_boundary"`
+	PreserveCase  bool `json:"preserve_case" xml:"preserve_case"`
 }
 
 // Logger manages operation logging and reporting with configurable output formats.
 // It maintains both individual entry records and aggregate statistics, supporting
 // real-time progress updates and final comprehensive reports.
 type Logger struct {
-	config  *config.Config
-	writer  io.Writer
-	entries []Entry
-	summary Summary
+	config    *config.Config
+	writer    io.Writer
+	errWriter io.Writer
+	sink      entrySink
+	entries   []Entry
+	summary   Summary
+	streamErr error
+	color     bool
+
+	// mappings lists the rules of the run so mapping statistics include
+	// those that never fired; hits counts the replacements of each rule.
+	mappings []parser.Mapping
+	hits     map[mappingKey]int
 }
 
 // NewLogger creates a Logger with the specified configuration and output destination.
@@ -58,8 +89,15 @@ type Logger struct {
 // the logging system with proper format support and error handling.
 func NewLogger(cfg *config.Config) (*Logger, error) {
 	var writer io.Writer = os.Stdout
+	var sink entrySink
 
-	if cfg.LogFile != "" {
+	if cfg.LogSink == SinkSyslog {
+		var err error
+		sink, err = newSyslogSink()
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+	} else if cfg.LogFile != "" {
 		file, err := os.Create(cfg.LogFile)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create log file %s: %w", cfg.LogFile, err)
@@ -67,14 +105,38 @@ func NewLogger(cfg *config.Config) (*Logger, error) {
 		writer = file
 	}
 
-	return &Logger{
+	logger := &Logger{
 		config:  cfg,
 		writer:  writer,
+		sink:    sink,
 		entries: []Entry{},
 		summary: Summary{
-			DryRun: cfg.DryRun,
+			DryRun:        cfg.DryRun,
+			CaseSensitive: cfg.CaseSensitive,
+			WordBoundary:  cfg.WordBoundary,
+			PreserveCase:  cfg.PreserveCase,
 		},
-	}, nil
+	}
+	logger.color = useColor(cfg, writer)
+	if cfg.ErrorsToStderr {
+		logger.SetErrorWriter(os.Stderr)
+	}
+	return logger, nil
+}
+
+// SetErrorWriter sends error lines to w as they happen, in addition to the
+// report, so failures stay visible when the report goes to a file. Error
+// lines then no longer appear in the verbose output of the main writer,
+// keeping a structured report free of extra text. A nil writer disables it.
+func (l *Logger) SetErrorWriter(w io.Writer) {
+	l.errWriter = w
+}
+
+// SetMappings records the mapping rules of the run. Mapping statistics then
+// list every rule, including those that never matched, and count regex and
+// template matches under the rule that produced them.
+func (l *Logger) SetMappings(mappings []parser.Mapping) {
+	l.mappings = mappings
 }
 
 // LogResult records the outcome of a file processing operation.
@@ -83,28 +145,51 @@ func NewLogger(cfg *config.Config) (*Logger, error) {
 func (l *Logger) LogResult(result concurrent.ProcessResult) {
 	entry := Entry{
 		Timestamp:  time.Now().Format(time.RFC3339),
-		FilePath:   result.Job.FilePath,
-		BackupPath: result.BackupPath,
+		FilePath:   l.reportPath(result.Job.FilePath),
+		BackupPath: l.reportPath(result.BackupPath),
+		sequence:   result.Job.Sequence,
 	}
 
 	if result.Error != nil {
 		entry.Error = result.Error.Error()
 		l.summary.ErrorCount++
+		if l.errWriter != nil {
+			fmt.Fprintf(l.errWriter, "ERROR: %s - %s\n", entry.FilePath, entry.Error)
+		}
 	} else if result.Result != nil {
 		entry.OriginalSize = result.Result.OriginalSize
 		entry.NewSize = result.Result.NewSize
 		entry.Modified = result.Result.Modified
 		entry.Replacements = result.Result.Replacements
+		entry.HasBOM = result.Result.HasBOM
+		entry.detected = result.Result.DetectedMatches
 
+		l.summary.DetectedReplacements += entry.detected
+		l.countHits(entry.Replacements)
 		if result.Result.Modified {
 			l.summary.ModifiedFiles++
 			l.summary.TotalReplacements += len(result.Result.Replacements)
+			l.summary.DeletedBytes += result.Result.DeletedBytes
+			entry.deleted = result.Result.DeletedBytes
 		}
 	}
 
-	l.entries = append(l.entries, entry)
+	if l.config.Diff && result.Error == nil && result.Result != nil {
+		l.logDiff(entry.FilePath, result.Result)
+	}
+
+	if l.streaming() {
+		l.streamEntry(entry)
+	} else {
+		l.entries = append(l.entries, entry)
+	}
 	l.summary.TotalFiles++
 
+	if l.sink != nil {
+		l.emit(entry)
+		return
+	}
+
 	if l.config.IsVerbose() {
 		l.logVerbose(entry)
 	} else if l.config.ShouldLog() && entry.Modified {
@@ -112,6 +197,30 @@ func (l *Logger) LogResult(result concurrent.ProcessResult) {
 	}
 }
 
+// reportPath applies the configured prefix strip to a path written to the report.
+// Only whole leading path components are removed, so "/build" strips
+// "/build/src/a.go" to "src/a.go" but leaves "/builder/a.go" untouched.
+func (l *Logger) reportPath(path string) string {
+	prefix := strings.TrimRight(l.config.ReportPathPrefixStrip, string(filepath.Separator))
+	if prefix == "" || path == "" {
+		return path
+	}
+
+	if path == prefix {
+		return "."
+	}
+	if strings.HasPrefix(path, prefix+string(filepath.Separator)) {
+		return path[len(prefix)+1:]
+	}
+	return path
+}
+
+// Summary returns the aggregate statistics collected so far.
+// Callers use it to derive the process outcome (e.g. exit status) after a run.
+func (l *Logger) Summary() Summary {
+	return l.summary
+}
+
 // SetProcessingTime records the total operation duration for reporting.
 // This method enables performance analysis and helps users understand
 // the time cost of large-scale string replacement operations.
@@ -123,39 +232,205 @@ func (l *Logger) SetProcessingTime(duration time.Duration) {
 // This method supports multiple output formats and provides comprehensive
 // operation summaries with detailed statistics and error information.
 func (l *Logger) WriteReport() error {
+	if l.errWriter != nil && l.summary.ErrorCount > 0 {
+		fmt.Fprintf(l.errWriter, "%d file(s) failed\n", l.summary.ErrorCount)
+	}
+
 	if l.config.Quiet {
 		return nil
 	}
 
+	if l.config.StableOutput {
+		l.entries = orderByDiscovery(l.entries)
+	}
+	if l.config.SortedOutput {
+		sortByPath(l.entries)
+	}
+
+	if l.config.DeduplicateEntries {
+		l.DeduplicateEntries()
+	}
+
+	if l.sink != nil {
+		l.emitSummary()
+		return nil
+	}
+
+	if l.config.LogFormat == config.LogFormatNDJSON {
+		return l.writeNDJSONSummary()
+	}
+
+	if l.config.ChangedFilesJSON {
+		return l.writeChangedFilesReport()
+	}
+
 	switch l.config.LogFormat {
 	case config.LogFormatJSON:
 		return l.writeJSONReport()
 	case config.LogFormatCSV:
 		return l.writeCSVReport()
+	case config.LogFormatXML:
+		return l.writeXMLReport()
 	default:
 		return l.writeSummaryReport()
 	}
 }
 
+// DeduplicateEntries merges entries that refer to the same file path.
+// Replacements of duplicates are appended to the first entry for the path and
+// the summary counters are recomputed from the merged entries, so totals always
+// describe what the report actually lists.
+func (l *Logger) DeduplicateEntries() {
+	index := make(map[string]int, len(l.entries))
+	merged := make([]Entry, 0, len(l.entries))
+	var groups [][]Entry
+
+	for _, entry := range l.entries {
+		i, exists := index[entry.FilePath]
+		if !exists {
+			index[entry.FilePath] = len(merged)
+			merged = append(merged, entry)
+			groups = append(groups, []Entry{entry})
+			continue
+		}
+		groups[i] = append(groups[i], entry)
+
+		target := &merged[i]
+		target.Replacements = append(target.Replacements, entry.Replacements...)
+		target.detected += entry.detected
+		target.deleted += entry.deleted
+		if entry.Modified {
+			target.Modified = true
+		}
+		if target.BackupPath == "" {
+			target.BackupPath = entry.BackupPath
+		}
+		if target.Error == "" {
+			target.Error = entry.Error
+		}
+	}
+
+	for i := range merged {
+		merged[i].OriginalSize, merged[i].NewSize = l.mergedSizes(groups[i])
+	}
+
+	l.entries = merged
+
+	l.summary.TotalFiles = len(merged)
+	l.summary.ModifiedFiles = 0
+	l.summary.TotalReplacements = 0
+	l.summary.DetectedReplacements = 0
+	l.summary.ErrorCount = 0
+	l.summary.DeletedBytes = 0
+	for _, entry := range merged {
+		if entry.Error != "" {
+			l.summary.ErrorCount++
+			continue
+		}
+		l.summary.DetectedReplacements += entry.detected
+		if entry.Modified {
+			l.summary.ModifiedFiles++
+			l.summary.TotalReplacements += len(entry.Replacements)
+			l.summary.DeletedBytes += entry.deleted
+		}
+	}
+}
+
+// mergedSizes returns the sizes of a file logged as several entries: the
+// original size of the first entry that read it, changed by the size delta of
+// every modified entry, so the merged entry's delta is the sum of theirs.
+// When no entry recorded a new size, as in dry runs, the first entry's sizes
+// are kept.
+func (l *Logger) mergedSizes(group []Entry) (int64, int64) {
+	first := group[0]
+	var delta int64
+	read, known := false, false
+	for _, entry := range group {
+		if entry.Error != "" {
+			continue
+		}
+		if !read {
+			first, read = entry, true
+		}
+		if d, ok := l.sizeDelta(entry); ok {
+			delta += d
+			known = true
+		}
+	}
+	if !known {
+		return first.OriginalSize, first.NewSize
+	}
+	return first.OriginalSize, first.OriginalSize + delta
+}
+
+// orderByDiscovery restores the order in which files were discovered.
+// Sequence numbers are dense indexes assigned when jobs are queued, so entries
+// can be placed directly into their slot instead of paying for a full sort.
+func orderByDiscovery(entries []Entry) []Entry {
+	maxSequence := -1
+	for _, entry := range entries {
+		if entry.sequence > maxSequence {
+			maxSequence = entry.sequence
+		}
+	}
+
+	slots := make([][]Entry, maxSequence+1)
+	for _, entry := range entries {
+		slots[entry.sequence] = append(slots[entry.sequence], entry)
+	}
+
+	ordered := make([]Entry, 0, len(entries))
+	for _, slot := range slots {
+		ordered = append(ordered, slot...)
+	}
+	return ordered
+}
+
+// sortByPath orders entries by file path. The sort is stable, so entries for
+// the same path keep their completion order.
+func sortByPath(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].FilePath < entries[j].FilePath
+	})
+}
+
 func (l *Logger) logVerbose(entry Entry) {
 	if entry.Error != "" {
-		fmt.Fprintf(l.writer, "ERROR: %s - %s\n", entry.FilePath, entry.Error)
+		if l.errWriter == nil {
+			fmt.Fprintf(l.writer, "%s %s - %s\n", l.paint(colorRed, "ERROR:"), entry.FilePath, entry.Error)
+		}
 		return
 	}
 
 	if entry.Modified {
-		fmt.Fprintf(l.writer, "MODIFIED: %s (%d replacements)\n", entry.FilePath, len(entry.Replacements))
-		if l.config.IsDebug() {
+		if delta, known := l.sizeDelta(entry); known {
+			fmt.Fprintf(l.writer, "%s %s (%d replacements) %s -> %s (%s)\n", l.paint(colorGreen, "MODIFIED:"), entry.FilePath,
+				len(entry.Replacements), formatBytes(entry.OriginalSize), formatBytes(entry.NewSize), formatBytesDelta(delta))
+		} else {
+			fmt.Fprintf(l.writer, "%s %s (%d replacements)\n", l.paint(colorGreen, "MODIFIED:"), entry.FilePath, len(entry.Replacements))
+		}
+		if l.config.ContextLines > 0 {
+			l.logContext(entry.Replacements)
+		} else if l.config.IsDebug() {
 			for _, replacement := range entry.Replacements {
-				fmt.Fprintf(l.writer, "  Line %d:%d: '%s' -> '%s'\n",
-					replacement.Line, replacement.Column, replacement.From, replacement.To)
+				fmt.Fprintf(l.writer, "  Line %d:%d: '%s' -> '%s'%s\n",
+					replacement.Line, replacement.Column, replacement.From, replacement.To, commentSuffix(replacement))
 			}
 		}
 	} else {
-		fmt.Fprintf(l.writer, "SKIPPED: %s (no changes)\n", entry.FilePath)
+		fmt.Fprintf(l.writer, "%s %s (no changes)\n", l.paint(colorYellow, "SKIPPED:"), entry.FilePath)
 	}
 }
 
+// commentSuffix returns the comment of the mapping behind a replacement as a
+// suffix for verbose lines, or "" when it has none.
+func commentSuffix(r replacement.Replacement) string {
+	if r.Comment == "" {
+		return ""
+	}
+	return "  # " + r.Comment
+}
+
 func (l *Logger) logBasic(entry Entry) {
 	//if entry.Modified {
 	//	fmt.Fprintf(l.writer, "%s (%d replacements)\n", entry.FilePath, len(entry.Replacements))
@@ -164,11 +439,24 @@ func (l *Logger) logBasic(entry Entry) {
 
 func (l *Logger) writeJSONReport() error {
 	report := struct {
-		Summary Summary `json:"summary"`
-		Entries []Entry `json:"entries"`
+		Summary       Summary       `json:"summary"`
+		LargestGrowth []sizeGrowth  `json:"largest_growth,omitempty"`
+		MappingStats  []mappingStat `json:"mapping_stats,omitempty"`
+		Entries       []Entry       `json:"entries"`
 	}{
-		Summary: l.summary,
-		Entries: l.entries,
+		Summary:       l.summary,
+		LargestGrowth: l.largestGrowth(),
+		MappingStats:  l.mappingStats(),
+		Entries:       l.entries,
+	}
+
+	if l.config.Canonical {
+		report.Summary.ProcessingTime = 0
+		report.Entries = canonicalEntries(l.entries)
+	}
+
+	if l.config.SignKey != "" {
+		return l.writeSignedJSONReport(report)
 	}
 
 	encoder := json.NewEncoder(l.writer)
@@ -176,6 +464,40 @@ func (l *Logger) writeJSONReport() error {
 	return encoder.Encode(report)
 }
 
+// writeXMLReport writes the same summary, growth and entries as the JSON
+// report, for tooling that ingests XML. The revert and apply modes read it
+// back with --log-format xml.
+func (l *Logger) writeXMLReport() error {
+	// encoding/xml writes the parent of an empty a>b list, so the growth
+	// list is a pointer that is left nil when there is nothing to list
+	type growthList struct {
+		Files []sizeGrowth `xml:"file"`
+	}
+	report := struct {
+		XMLName       xml.Name    `xml:"remap_report"`
+		Summary       Summary     `xml:"summary"`
+		LargestGrowth *growthList `xml:"largest_growth,omitempty"`
+		Entries       []Entry     `xml:"entries>entry"`
+	}{
+		Summary: l.summary,
+		Entries: l.entries,
+	}
+	if growing := l.largestGrowth(); len(growing) > 0 {
+		report.LargestGrowth = &growthList{Files: growing}
+	}
+
+	if _, err := io.WriteString(l.writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(l.writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(l.writer, "\n")
+	return err
+}
+
 func (l *Logger) writeCSVReport() error {
 	mode := "production"
 	if l.summary.DryRun {
@@ -185,15 +507,27 @@ func (l *Logger) writeCSVReport() error {
 	writer := csv.NewWriter(l.writer)
 	defer writer.Flush()
 
-	header := []string{
-		"file_path", "old_string", "new_string", "line", "column",
+	header := []string{"file_path", "old_string", "new_string", "line", "column"}
+	if l.config.CSVSizeDelta {
+		header = append(header, "size_delta")
+	}
+	if l.config.CSVMappingID {
+		header = append(header, "mapping_index")
 	}
+	// The matched text and where it was let revert restore the original
+	// casing of case-insensitive matches
+	header = append(header, "original_text", "byte_offset")
 	if err := writer.Write(header); err != nil {
 		return err
 	}
 
 	// Write all CSV records first
 	for _, entry := range l.entries {
+		sizeDelta := ""
+		if delta, known := l.sizeDelta(entry); known {
+			sizeDelta = fmt.Sprintf("%d", delta)
+		}
+
 		for _, repl := range entry.Replacements {
 			record := []string{
 				entry.FilePath,
@@ -202,6 +536,13 @@ func (l *Logger) writeCSVReport() error {
 				fmt.Sprintf("%d", repl.Line),
 				fmt.Sprintf("%d", repl.Column),
 			}
+			if l.config.CSVSizeDelta {
+				record = append(record, sizeDelta)
+			}
+			if l.config.CSVMappingID {
+				record = append(record, fmt.Sprintf("%d", repl.MappingIndex))
+			}
+			record = append(record, repl.OriginalText, fmt.Sprintf("%d", repl.ByteOffset))
 			if err := writer.Write(record); err != nil {
 				return err
 			}
@@ -216,11 +557,89 @@ func (l *Logger) writeCSVReport() error {
 	fmt.Fprintf(l.writer, "# Total replacements: %d\n", l.summary.TotalReplacements)
 	fmt.Fprintf(l.writer, "# Errors: %d\n", l.summary.ErrorCount)
 	fmt.Fprintf(l.writer, "# Processing time: %v\n", l.summary.ProcessingTime)
+	fmt.Fprintf(l.writer, "# Case sensitive: %t\n", l.summary.CaseSensitive)
+	fmt.Fprintf(l.writer, "# Word boundary: %t\n", l.summary.WordBoundary)
+	fmt.Fprintf(l.writer, "# Preserve case: %t\n", l.summary.PreserveCase)
+	for _, stat := range l.mappingStats() {
+		fmt.Fprintf(l.writer, "# Mapping %q -> %s: %d\n", stat.From, stat.destination(), stat.Count)
+	}
+	for _, g := range l.largestGrowth() {
+		fmt.Fprintf(l.writer, "# Largest growth: %s %s\n", formatBytesDelta(g.Growth), g.FilePath)
+	}
 	fmt.Fprintf(l.writer, "#\n")
 
 	return nil
 }
 
+// mappingKey identifies a mapping rule by its source and destination, or
+// the case a line-case rule converts lines to.
+type mappingKey struct{ from, to, lineCase string }
+
+// keyOf returns the key of a mapping rule.
+func keyOf(mapping parser.Mapping) mappingKey {
+	return mappingKey{mapping.From, mapping.To, mapping.LineCase}
+}
+
+// mappingStat counts how many replacements a single rule performed.
+type mappingStat struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	LineCase string `json:"line_case,omitempty"`
+	Count    int    `json:"count"`
+}
+
+// destination describes what the rule writes: its quoted destination, or
+// for a line-case rule the case it converts lines to.
+func (s mappingStat) destination() string {
+	if s.LineCase != "" {
+		return "(" + s.LineCase + " line)"
+	}
+	return strconv.Quote(s.To)
+}
+
+// countHits tallies replacements per rule. A replacement is counted under
+// the rule that produced it when the rules are known, otherwise under its
+// own source and destination.
+func (l *Logger) countHits(replacements []replacement.Replacement) {
+	if l.hits == nil {
+		l.hits = make(map[mappingKey]int)
+	}
+	for _, repl := range replacements {
+		key := mappingKey{from: repl.From, to: repl.To}
+		if repl.MappingIndex >= 0 && repl.MappingIndex < len(l.mappings) {
+			key = keyOf(l.mappings[repl.MappingIndex])
+		}
+		l.hits[key]++
+	}
+}
+
+// mappingStats lists the replacements per rule, most frequent first. Rules
+// given to SetMappings that never fired are listed with a zero count.
+func (l *Logger) mappingStats() []mappingStat {
+	counts := make(map[mappingKey]int, len(l.hits)+len(l.mappings))
+	for _, mapping := range l.mappings {
+		counts[keyOf(mapping)] = 0
+	}
+	for key, count := range l.hits {
+		counts[key] += count
+	}
+
+	stats := make([]mappingStat, 0, len(counts))
+	for key, count := range counts {
+		stats = append(stats, mappingStat{From: key.from, To: key.to, LineCase: key.lineCase, Count: count})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		if stats[i].From != stats[j].From {
+			return stats[i].From < stats[j].From
+		}
+		return stats[i].To < stats[j].To
+	})
+	return stats
+}
+
 func (l *Logger) writeSummaryReport() error {
 	mode := "production"
 	if l.summary.DryRun {
@@ -229,11 +648,39 @@ func (l *Logger) writeSummaryReport() error {
 
 	fmt.Fprintf(l.writer, "\n=== Remap Summary (%s) ===\n", mode)
 	fmt.Fprintf(l.writer, "Total files processed: %d\n", l.summary.TotalFiles)
-	fmt.Fprintf(l.writer, "Files modified: %d\n", l.summary.ModifiedFiles)
-	fmt.Fprintf(l.writer, "Total replacements: %d\n", l.summary.TotalReplacements)
-	fmt.Fprintf(l.writer, "Errors: %d\n", l.summary.ErrorCount)
+	fmt.Fprintf(l.writer, "Files modified: %s\n", l.paintCount(colorGreen, l.summary.ModifiedFiles))
+	fmt.Fprintf(l.writer, "Total replacements: %s\n", l.paintCount(colorGreen, l.summary.TotalReplacements))
+	if l.summary.DetectedReplacements != l.summary.TotalReplacements {
+		fmt.Fprintf(l.writer, "Matches detected: %d (%d applied, %d not applied)\n",
+			l.summary.DetectedReplacements, l.summary.TotalReplacements,
+			l.summary.DetectedReplacements-l.summary.TotalReplacements)
+	}
+	if originalSize, newSize, known := l.modifiedSizes(); known {
+		fmt.Fprintf(l.writer, "Modified size: %s -> %s (%s)\n",
+			formatBytes(originalSize), formatBytes(newSize), formatBytesDelta(newSize-originalSize))
+	}
+	fmt.Fprintf(l.writer, "Errors: %s\n", l.paintCount(colorRed, l.summary.ErrorCount))
 	fmt.Fprintf(l.writer, "Processing time: %v\n", l.summary.ProcessingTime)
 
+	if growing := l.largestGrowth(); len(growing) > 0 {
+		fmt.Fprintf(l.writer, "\nLargest growth (top %d):\n", len(growing))
+		for _, g := range growing {
+			fmt.Fprintf(l.writer, "  %s  %s (%s -> %s)\n", formatBytesDelta(g.Growth),
+				g.FilePath, formatBytes(g.OriginalSize), formatBytes(g.NewSize))
+		}
+	}
+
+	if stats := l.mappingStats(); len(stats) > 0 {
+		fmt.Fprintf(l.writer, "\nMapping usage:\n")
+		for _, stat := range stats {
+			unused := ""
+			if stat.Count == 0 {
+				unused = " (never matched)"
+			}
+			fmt.Fprintf(l.writer, "  %d  %q -> %s%s\n", stat.Count, stat.From, stat.destination(), unused)
+		}
+	}
+
 	if l.summary.ErrorCount > 0 {
 		fmt.Fprintf(l.writer, "\nErrors encountered:\n")
 		for _, entry := range l.entries {
@@ -246,11 +693,106 @@ func (l *Logger) writeSummaryReport() error {
 	return nil
 }
 
+// sizeDelta returns the size change of a modified entry in bytes. Dry runs
+// render the new content exactly as a real run writes it, so their new size
+// is the size the file would have, not an estimate.
+func (l *Logger) sizeDelta(entry Entry) (int64, bool) {
+	if !entry.Modified {
+		return 0, false
+	}
+	return entry.NewSize - entry.OriginalSize, true
+}
+
+// sizeGrowth describes how much a file would grow in a dry run.
+type sizeGrowth struct {
+	FilePath     string `json:"file_path" xml:"file_path"`
+	OriginalSize int64  `json:"original_size" xml:"original_size"`
+	NewSize      int64  `json:"new_size" xml:"new_size"`
+	Growth       int64  `json:"growth" xml:"growth"`
+}
+
+// largestGrowth returns, for dry runs, the --top-growth files that would grow
+// the most, largest first, so mappings that inflate files stand out before
+// anything is written. Files that shrink or keep their size are left out.
+func (l *Logger) largestGrowth() []sizeGrowth {
+	if !l.summary.DryRun || l.config.TopGrowth <= 0 {
+		return nil
+	}
+
+	var growing []sizeGrowth
+	for _, entry := range l.entries {
+		if delta, ok := l.sizeDelta(entry); ok && delta > 0 {
+			growing = append(growing, sizeGrowth{
+				FilePath:     entry.FilePath,
+				OriginalSize: entry.OriginalSize,
+				NewSize:      entry.NewSize,
+				Growth:       delta,
+			})
+		}
+	}
+
+	sort.Slice(growing, func(i, j int) bool {
+		if growing[i].Growth != growing[j].Growth {
+			return growing[i].Growth > growing[j].Growth
+		}
+		return growing[i].FilePath < growing[j].FilePath
+	})
+
+	if len(growing) > l.config.TopGrowth {
+		growing = growing[:l.config.TopGrowth]
+	}
+	return growing
+}
+
+// modifiedSizes totals the before/after sizes of all modified entries with a known new size.
+func (l *Logger) modifiedSizes() (int64, int64, bool) {
+	var originalSize, newSize int64
+	known := false
+
+	for _, entry := range l.entries {
+		if _, ok := l.sizeDelta(entry); ok {
+			originalSize += entry.OriginalSize
+			newSize += entry.NewSize
+			known = true
+		}
+	}
+	return originalSize, newSize, known
+}
+
+// formatBytes renders a byte count using binary units (B, KB, MB, GB, TB).
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < 0 {
+		return "-" + formatBytes(-size)
+	}
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit && exp < 3; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGT"[exp])
+}
+
+// formatBytesDelta renders a signed size change, always showing its sign.
+func formatBytesDelta(delta int64) string {
+	if delta >= 0 {
+		return "+" + formatBytes(delta)
+	}
+	return formatBytes(delta)
+}
+
 // Close releases any resources held by the logger, including output files.
 // This method ensures proper cleanup of file handles and should be called
 // when logging operations are complete to prevent resource leaks.
 // Note: os.Stdout is never closed to prevent interfering with coverage tools.
 func (l *Logger) Close() error {
+	if l.sink != nil {
+		return l.sink.Close()
+	}
 	if closer, ok := l.writer.(io.Closer); ok && l.writer != os.Stdout {
 		return closer.Close()
 	}
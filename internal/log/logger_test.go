@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,6 +15,7 @@ import (
 
 	"remap/internal/concurrent"
 	"remap/internal/config"
+	"remap/internal/parser"
 	"remap/internal/replacement"
 )
 
@@ -391,6 +394,7 @@ func TestWriteCSVReport(t *testing.T) {
 			header := records[0]
 			expectedHeaders := []string{
 				"file_path", "old_string", "new_string", "line", "column",
+				"original_text", "byte_offset",
 			}
 			for i, expected := range expectedHeaders {
 				if i >= len(header) || header[i] != expected {
@@ -402,8 +406,8 @@ func TestWriteCSVReport(t *testing.T) {
 			if len(records) > 1 {
 				// Check first replacement row format
 				firstRow := records[1]
-				if len(firstRow) != 5 {
-					t.Errorf("expected 5 columns in replacement row, got %d", len(firstRow))
+				if len(firstRow) != 7 {
+					t.Errorf("expected 7 columns in replacement row, got %d", len(firstRow))
 				}
 			}
 
@@ -611,6 +615,18 @@ func TestLogVerbose(t *testing.T) {
 			debug:    true,
 			expected: []string{"MODIFIED:", "Line 1:5:", "'old' -> 'new'"},
 		},
+		{
+			name: "modified entry with a mapping comment",
+			entry: Entry{
+				FilePath: "/test/comment.txt",
+				Modified: true,
+				Replacements: []replacement.Replacement{
+					{From: "old", To: "new", Line: 2, Column: 3, Comment: "renamed in v2"},
+				},
+			},
+			debug:    true,
+			expected: []string{"Line 2:3: 'old' -> 'new'  # renamed in v2"},
+		},
 		{
 			name: "skipped entry",
 			entry: Entry{
@@ -761,3 +777,808 @@ func TestLoggerIntegration(t *testing.T) {
 		t.Errorf("expected 1 error, got %d", logger.summary.ErrorCount)
 	}
 }
+
+func TestWriteReportStableOutput(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config: &config.Config{
+			LogFormat:    config.LogFormatJSON,
+			StableOutput: true,
+		},
+		writer:  &buf,
+		entries: []Entry{},
+	}
+
+	// Results arrive in completion order, not discovery order
+	discovered := []string{"/z/last.txt", "/a/first.txt", "/m/middle.txt", "/b/other.txt"}
+	for _, sequence := range []int{2, 0, 3, 1} {
+		logger.LogResult(concurrent.ProcessResult{
+			Job:    concurrent.ProcessJob{FilePath: discovered[sequence], Sequence: sequence},
+			Result: &replacement.FileResult{Path: discovered[sequence]},
+		})
+	}
+
+	if err := logger.WriteReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var report struct {
+		Entries []Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	if len(report.Entries) != len(discovered) {
+		t.Fatalf("expected %d entries, got %d", len(discovered), len(report.Entries))
+	}
+	for i, entry := range report.Entries {
+		if entry.FilePath != discovered[i] {
+			t.Errorf("entry %d = %s, expected %s", i, entry.FilePath, discovered[i])
+		}
+	}
+}
+
+func TestWriteReportSortedOutput(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config: &config.Config{
+			LogFormat:    config.LogFormatJSON,
+			SortedOutput: true,
+		},
+		writer:  &buf,
+		entries: []Entry{},
+	}
+
+	// Results arrive in completion order
+	for _, path := range []string{"/z/last.txt", "/a/first.txt", "/m/middle.txt", "/b/other.txt"} {
+		logger.LogResult(concurrent.ProcessResult{
+			Job: concurrent.ProcessJob{FilePath: path},
+			Result: &replacement.FileResult{Path: path, Modified: path != "/m/middle.txt",
+				Replacements: []replacement.Replacement{{From: "a", To: "b", Line: 1, Column: 1}}},
+		})
+	}
+
+	if err := logger.WriteReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var report struct {
+		Summary Summary `json:"summary"`
+		Entries []Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	expected := []string{"/a/first.txt", "/b/other.txt", "/m/middle.txt", "/z/last.txt"}
+	if len(report.Entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(report.Entries))
+	}
+	for i, entry := range report.Entries {
+		if entry.FilePath != expected[i] {
+			t.Errorf("entry %d = %s, expected %s", i, entry.FilePath, expected[i])
+		}
+	}
+	if report.Summary.TotalFiles != 4 || report.Summary.ModifiedFiles != 3 || report.Summary.TotalReplacements != 3 {
+		t.Errorf("unexpected summary after sorting: %+v", report.Summary)
+	}
+}
+
+func TestWriteCSVReportOptionalColumns(t *testing.T) {
+	tests := []struct {
+		name           string
+		mappingID      bool
+		sizeDelta      bool
+		expectedHeader string
+		expectedRows   []string
+	}{
+		{
+			name:           "default columns",
+			expectedHeader: "file_path,old_string,new_string,line,column,original_text,byte_offset",
+			expectedRows:   []string{"/test/file.txt,old,new,1,1,old,0", "/test/file.txt,old,newer,2,1,old,4"},
+		},
+		{
+			name:           "with mapping id",
+			mappingID:      true,
+			expectedHeader: "file_path,old_string,new_string,line,column,mapping_index,original_text,byte_offset",
+			expectedRows:   []string{"/test/file.txt,old,new,1,1,3,old,0", "/test/file.txt,old,newer,2,1,7,old,4"},
+		},
+		{
+			name:           "with size delta",
+			sizeDelta:      true,
+			expectedHeader: "file_path,old_string,new_string,line,column,size_delta,original_text,byte_offset",
+			expectedRows:   []string{"/test/file.txt,old,new,1,1,3,old,0", "/test/file.txt,old,newer,2,1,3,old,4"},
+		},
+		{
+			name:           "with both",
+			mappingID:      true,
+			sizeDelta:      true,
+			expectedHeader: "file_path,old_string,new_string,line,column,size_delta,mapping_index,original_text,byte_offset",
+			expectedRows:   []string{"/test/file.txt,old,new,1,1,3,3,old,0", "/test/file.txt,old,newer,2,1,3,7,old,4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := &Logger{
+				config: &config.Config{LogFormat: config.LogFormatCSV, CSVMappingID: tt.mappingID, CSVSizeDelta: tt.sizeDelta},
+				writer: &buf,
+				entries: []Entry{
+					{
+						FilePath:     "/test/file.txt",
+						Modified:     true,
+						OriginalSize: 8,
+						NewSize:      11,
+						Replacements: []replacement.Replacement{
+							{From: "old", To: "new", OriginalText: "old", Line: 1, Column: 1, MappingIndex: 3},
+							{From: "old", To: "newer", OriginalText: "old", Line: 2, Column: 1, ByteOffset: 4, MappingIndex: 7},
+						},
+					},
+				},
+			}
+
+			if err := logger.writeCSVReport(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var csvLines []string
+			for _, line := range strings.Split(buf.String(), "\n") {
+				if line != "" && !strings.HasPrefix(line, "#") {
+					csvLines = append(csvLines, line)
+				}
+			}
+
+			expected := append([]string{tt.expectedHeader}, tt.expectedRows...)
+			if strings.Join(csvLines, "\n") != strings.Join(expected, "\n") {
+				t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(csvLines, "\n"))
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size     int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1, "1 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{1024 * 1024, "1.0 MB"},
+		{5*1024*1024 + 512*1024, "5.5 MB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GB"},
+		{2 * 1024 * 1024 * 1024 * 1024, "2.0 TB"},
+		{2048 * 1024 * 1024 * 1024 * 1024, "2048.0 TB"},
+		{-2048, "-2.0 KB"},
+	}
+
+	for _, tt := range tests {
+		if result := formatBytes(tt.size); result != tt.expected {
+			t.Errorf("formatBytes(%d) = %q, expected %q", tt.size, result, tt.expected)
+		}
+	}
+}
+
+func TestFormatBytesDelta(t *testing.T) {
+	tests := []struct {
+		delta    int64
+		expected string
+	}{
+		{0, "+0 B"},
+		{10, "+10 B"},
+		{2048, "+2.0 KB"},
+		{-512, "-512 B"},
+	}
+
+	for _, tt := range tests {
+		if result := formatBytesDelta(tt.delta); result != tt.expected {
+			t.Errorf("formatBytesDelta(%d) = %q, expected %q", tt.delta, result, tt.expected)
+		}
+	}
+}
+
+func TestSizeReporting(t *testing.T) {
+	entries := []Entry{
+		{FilePath: "/test/grow.txt", Modified: true, OriginalSize: 1024, NewSize: 3072,
+			Replacements: []replacement.Replacement{{From: "a", To: "bbb", Line: 1, Column: 1}}},
+		{FilePath: "/test/same.txt", Modified: false, OriginalSize: 100},
+	}
+
+	var summary bytes.Buffer
+	logger := &Logger{config: &config.Config{}, writer: &summary, entries: entries}
+	if err := logger.writeSummaryReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(summary.String(), "Modified size: 1.0 KB -> 3.0 KB (+2.0 KB)") {
+		t.Errorf("expected human-readable size line in summary, got:\n%s", summary.String())
+	}
+
+	var verbose bytes.Buffer
+	logger = &Logger{config: &config.Config{Verbose: true}, writer: &verbose}
+	logger.logVerbose(entries[0])
+	if !strings.Contains(verbose.String(), "1.0 KB -> 3.0 KB (+2.0 KB)") {
+		t.Errorf("expected human-readable sizes in verbose output, got %q", verbose.String())
+	}
+
+	var csvOut bytes.Buffer
+	logger = &Logger{config: &config.Config{LogFormat: config.LogFormatCSV, CSVSizeDelta: true}, writer: &csvOut, entries: entries}
+	if err := logger.writeCSVReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(csvOut.String(), "/test/grow.txt,a,bbb,1,1,2048") {
+		t.Errorf("expected size delta in CSV row, got:\n%s", csvOut.String())
+	}
+
+	// Dry runs render the new content, so they report the same sizes, down
+	// to a file emptied by its mappings
+	var dryRun bytes.Buffer
+	logger = &Logger{config: &config.Config{}, writer: &dryRun, summary: Summary{DryRun: true},
+		entries: []Entry{{FilePath: "/test/dry.txt", Modified: true, OriginalSize: 10}}}
+	if err := logger.writeSummaryReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(dryRun.String(), "Modified size: 10 B -> 0 B (-10 B)") {
+		t.Errorf("expected the size line for dry run, got:\n%s", dryRun.String())
+	}
+}
+
+func TestDeduplicateEntries(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config:  &config.Config{LogFormat: config.LogFormatJSON, DeduplicateEntries: true},
+		writer:  &buf,
+		entries: []Entry{},
+	}
+
+	results := []concurrent.ProcessResult{
+		{
+			Job: concurrent.ProcessJob{FilePath: "/test/a.txt"},
+			Result: &replacement.FileResult{Modified: true, OriginalSize: 10, NewSize: 12,
+				Replacements: []replacement.Replacement{{From: "a", To: "b", Line: 1}}},
+			BackupPath: "/test/a.txt.bak",
+		},
+		{
+			Job:    concurrent.ProcessJob{FilePath: "/test/b.txt"},
+			Result: &replacement.FileResult{Modified: false, OriginalSize: 5},
+		},
+		{
+			Job: concurrent.ProcessJob{FilePath: "/test/a.txt"},
+			Result: &replacement.FileResult{Modified: true, OriginalSize: 12, NewSize: 14,
+				Replacements: []replacement.Replacement{{From: "c", To: "d", Line: 2}, {From: "c", To: "d", Line: 3}}},
+		},
+	}
+	for _, result := range results {
+		logger.LogResult(result)
+	}
+
+	if logger.summary.TotalFiles != 3 {
+		t.Fatalf("expected 3 files before deduplication, got %d", logger.summary.TotalFiles)
+	}
+
+	if err := logger.WriteReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var report struct {
+		Summary Summary `json:"summary"`
+		Entries []Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	if len(report.Entries) != 2 {
+		t.Fatalf("expected 2 merged entries, got %d", len(report.Entries))
+	}
+	merged := report.Entries[0]
+	if merged.FilePath != "/test/a.txt" || len(merged.Replacements) != 3 {
+		t.Errorf("expected a.txt with 3 replacements, got %s with %d", merged.FilePath, len(merged.Replacements))
+	}
+	if merged.OriginalSize != 10 || merged.NewSize != 14 || merged.BackupPath != "/test/a.txt.bak" {
+		t.Errorf("unexpected merged entry: %+v", merged)
+	}
+
+	if report.Summary.TotalFiles != 2 || report.Summary.ModifiedFiles != 1 || report.Summary.TotalReplacements != 3 {
+		t.Errorf("summary not consistent with merged entries: %+v", report.Summary)
+	}
+}
+
+func TestDeduplicateEntriesSizes(t *testing.T) {
+	tests := []struct {
+		name                string
+		results             []concurrent.ProcessResult
+		expectOriginalSize  int64
+		expectNewSize       int64
+		expectDeletedBytes  int64
+		expectModifiedFiles int
+		expectError         bool
+	}{
+		{
+			name: "error before a successful pass",
+			results: []concurrent.ProcessResult{
+				{Job: concurrent.ProcessJob{FilePath: "/test/a.txt"}, Error: fmt.Errorf("busy")},
+				{Job: concurrent.ProcessJob{FilePath: "/test/a.txt"}, Result: &replacement.FileResult{
+					Modified: true, OriginalSize: 20, NewSize: 17, DeletedBytes: 3,
+					Replacements: []replacement.Replacement{{From: "foo", To: "", Line: 1}}}},
+			},
+			expectOriginalSize: 20,
+			expectNewSize:      17,
+			expectDeletedBytes: 0,
+			expectError:        true,
+		},
+		{
+			name: "unmodified pass between two deletions",
+			results: []concurrent.ProcessResult{
+				{Job: concurrent.ProcessJob{FilePath: "/test/a.txt"}, Result: &replacement.FileResult{
+					Modified: true, OriginalSize: 20, NewSize: 17, DeletedBytes: 3,
+					Replacements: []replacement.Replacement{{From: "foo", To: "", Line: 1}}}},
+				{Job: concurrent.ProcessJob{FilePath: "/test/a.txt"}, Result: &replacement.FileResult{OriginalSize: 17}},
+				{Job: concurrent.ProcessJob{FilePath: "/test/a.txt"}, Result: &replacement.FileResult{
+					Modified: true, OriginalSize: 17, NewSize: 15, DeletedBytes: 2,
+					Replacements: []replacement.Replacement{{From: "ab", To: "", Line: 2}}}},
+			},
+			expectOriginalSize:  20,
+			expectNewSize:       15,
+			expectDeletedBytes:  5,
+			expectModifiedFiles: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &Logger{
+				config:  &config.Config{LogFormat: config.LogFormatJSON, DeduplicateEntries: true},
+				writer:  &bytes.Buffer{},
+				entries: []Entry{},
+			}
+			for _, result := range tt.results {
+				logger.LogResult(result)
+			}
+			logger.DeduplicateEntries()
+
+			if len(logger.entries) != 1 {
+				t.Fatalf("expected 1 merged entry, got %d", len(logger.entries))
+			}
+			merged := logger.entries[0]
+			if merged.OriginalSize != tt.expectOriginalSize || merged.NewSize != tt.expectNewSize {
+				t.Errorf("expected sizes %d -> %d, got %d -> %d",
+					tt.expectOriginalSize, tt.expectNewSize, merged.OriginalSize, merged.NewSize)
+			}
+			if (merged.Error != "") != tt.expectError {
+				t.Errorf("unexpected merged error %q", merged.Error)
+			}
+			if logger.summary.DeletedBytes != tt.expectDeletedBytes {
+				t.Errorf("expected %d deleted bytes, got %d", tt.expectDeletedBytes, logger.summary.DeletedBytes)
+			}
+			if logger.summary.ModifiedFiles != tt.expectModifiedFiles {
+				t.Errorf("expected %d modified files, got %d", tt.expectModifiedFiles, logger.summary.ModifiedFiles)
+			}
+		})
+	}
+}
+
+func TestReportPathPrefixStrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		path     string
+		expected string
+	}{
+		{"no prefix configured", "", "/build/root/src/a.go", "/build/root/src/a.go"},
+		{"prefix removed", "/build/root", "/build/root/src/a.go", "src/a.go"},
+		{"trailing separator", "/build/root/", "/build/root/src/a.go", "src/a.go"},
+		{"partial component kept", "/build/root", "/build/rootfs/a.go", "/build/rootfs/a.go"},
+		{"unrelated path kept", "/build/root", "/other/a.go", "/other/a.go"},
+		{"empty path kept", "/build/root", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &Logger{
+				config:  &config.Config{Quiet: true, ReportPathPrefixStrip: tt.prefix},
+				writer:  &bytes.Buffer{},
+				entries: []Entry{},
+			}
+
+			logger.LogResult(concurrent.ProcessResult{
+				Job:        concurrent.ProcessJob{FilePath: tt.path},
+				BackupPath: tt.path,
+				Result:     &replacement.FileResult{},
+			})
+
+			entry := logger.entries[0]
+			if entry.FilePath != tt.expected {
+				t.Errorf("expected file path %q, got %q", tt.expected, entry.FilePath)
+			}
+			if entry.BackupPath != tt.expected {
+				t.Errorf("expected backup path %q, got %q", tt.expected, entry.BackupPath)
+			}
+		})
+	}
+}
+
+func TestWriteCSVReportMappingStats(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config: &config.Config{LogFormat: config.LogFormatCSV},
+		writer: &buf,
+	}
+
+	results := []concurrent.ProcessResult{
+		{
+			Job: concurrent.ProcessJob{FilePath: "/test/a.txt"},
+			Result: &replacement.FileResult{
+				Modified: true,
+				Replacements: []replacement.Replacement{
+					{From: "foo", To: "bar", Line: 1, Column: 1},
+					{From: "old", To: "new", Line: 2, Column: 1},
+				},
+			},
+		},
+		{
+			Job: concurrent.ProcessJob{FilePath: "/test/b.txt"},
+			Result: &replacement.FileResult{
+				Modified: true,
+				Replacements: []replacement.Replacement{
+					{From: "old", To: "new", Line: 1, Column: 1},
+					{From: "old", To: "new", Line: 3, Column: 5},
+				},
+			},
+		},
+	}
+	for _, result := range results {
+		logger.LogResult(result)
+	}
+
+	if err := logger.writeCSVReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := buf.String()
+	expected := "# Mapping \"old\" -> \"new\": 3\n# Mapping \"foo\" -> \"bar\": 1\n"
+	if !strings.Contains(output, expected) {
+		t.Errorf("expected per-mapping trailer lines %q in output:\n%s", expected, output)
+	}
+}
+
+func TestMappingStats(t *testing.T) {
+	mappings := []parser.Mapping{
+		{From: "foo", To: "bar", Index: 0},
+		{From: `v(\d+)`, To: "version $1", Regex: true, Index: 1},
+		{From: "stale", To: "fresh", Index: 2},
+	}
+
+	var buf bytes.Buffer
+	logger := &Logger{
+		config: &config.Config{},
+		writer: &buf,
+	}
+	logger.SetMappings(mappings)
+	logger.LogResult(concurrent.ProcessResult{
+		Job: concurrent.ProcessJob{FilePath: "/test/a.txt"},
+		Result: &replacement.FileResult{
+			Modified: true,
+			Replacements: []replacement.Replacement{
+				{From: "v1", To: "version 1", MappingIndex: 1},
+				{From: "v2", To: "version 2", MappingIndex: 1},
+				{From: "foo", To: "bar", MappingIndex: 0},
+			},
+		},
+	})
+
+	expected := []mappingStat{
+		{From: `v(\d+)`, To: "version $1", Count: 2},
+		{From: "foo", To: "bar", Count: 1},
+		{From: "stale", To: "fresh", Count: 0},
+	}
+	stats := logger.mappingStats()
+	if len(stats) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, stats)
+	}
+	for i := range expected {
+		if stats[i] != expected[i] {
+			t.Errorf("stat %d: expected %+v, got %+v", i, expected[i], stats[i])
+		}
+	}
+
+	buf.Reset()
+	if err := logger.writeSummaryReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Mapping usage:\n  2  \"v(\\\\d+)\" -> \"version $1\"\n  1  \"foo\" -> \"bar\"\n  0  \"stale\" -> \"fresh\" (never matched)\n") {
+		t.Errorf("expected a mapping usage section, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := logger.writeJSONReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var report struct {
+		MappingStats []mappingStat `json:"mapping_stats"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON report: %v", err)
+	}
+	if len(report.MappingStats) != 3 || report.MappingStats[2] != expected[2] {
+		t.Errorf("expected mapping_stats %v, got %v", expected, report.MappingStats)
+	}
+}
+
+func TestMappingStatsLineCase(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{config: &config.Config{}, writer: &buf}
+	logger.SetMappings([]parser.Mapping{{From: "heading", LineCase: parser.LineCaseUpper, Index: 0}})
+	logger.LogResult(concurrent.ProcessResult{
+		Job: concurrent.ProcessJob{FilePath: "/test/a.txt"},
+		Result: &replacement.FileResult{
+			Modified:     true,
+			Replacements: []replacement.Replacement{{From: "a heading", To: "A HEADING", MappingIndex: 0}},
+		},
+	})
+
+	if err := logger.writeSummaryReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "  1  \"heading\" -> (upper line)\n") {
+		t.Errorf("expected the line case as the rule's destination, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteSummaryReportLargestGrowth(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config: &config.Config{TopGrowth: 2},
+		writer: &buf,
+		summary: Summary{
+			DryRun: true,
+		},
+		entries: []Entry{
+			{FilePath: "/src/small.txt", Modified: true, OriginalSize: 100, NewSize: 110},
+			{FilePath: "/src/huge.txt", Modified: true, OriginalSize: 100, NewSize: 5000},
+			{FilePath: "/src/shrunk.txt", Modified: true, OriginalSize: 100, NewSize: 50},
+			{FilePath: "/src/medium.txt", Modified: true, OriginalSize: 100, NewSize: 400},
+			{FilePath: "/src/unchanged.txt", OriginalSize: 100},
+		},
+	}
+
+	if err := logger.writeSummaryReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := buf.String()
+
+	section := strings.Index(output, "Largest growth (top 2):")
+	if section == -1 {
+		t.Fatalf("expected a largest growth section, got:\n%s", output)
+	}
+	ranking := output[section:]
+
+	huge := strings.Index(ranking, "+4.8 KB  /src/huge.txt (100 B -> 4.9 KB)")
+	medium := strings.Index(ranking, "+300 B  /src/medium.txt")
+	if huge == -1 || medium == -1 || huge > medium {
+		t.Errorf("expected huge.txt ranked before medium.txt, got:\n%s", ranking)
+	}
+	for _, excluded := range []string{"small.txt", "shrunk.txt", "unchanged.txt"} {
+		if strings.Contains(ranking, excluded) {
+			t.Errorf("expected %s left out of the top 2, got:\n%s", excluded, ranking)
+		}
+	}
+
+	buf.Reset()
+	logger.summary.DryRun = false
+	if err := logger.writeSummaryReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "Largest growth") {
+		t.Errorf("expected no growth section outside dry run, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteJSONReportLargestGrowth(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config:  &config.Config{LogFormat: config.LogFormatJSON, TopGrowth: 2},
+		writer:  &buf,
+		summary: Summary{DryRun: true},
+		entries: []Entry{
+			{FilePath: "/src/a.txt", Modified: true, OriginalSize: 10, NewSize: 20},
+			{FilePath: "/src/b.txt", Modified: true, OriginalSize: 10, NewSize: 900},
+			{FilePath: "/src/c.txt", Modified: true, OriginalSize: 10, NewSize: 50},
+		},
+	}
+
+	if err := logger.writeJSONReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var report struct {
+		LargestGrowth []sizeGrowth `json:"largest_growth"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	var ranked []string
+	for _, g := range report.LargestGrowth {
+		ranked = append(ranked, g.FilePath)
+	}
+	if strings.Join(ranked, ",") != "/src/b.txt,/src/c.txt" {
+		t.Errorf("expected b.txt then c.txt, got %v", ranked)
+	}
+}
+
+func TestWriteXMLReportLargestGrowth(t *testing.T) {
+	entries := []Entry{
+		{FilePath: "/src/a.txt", Modified: true, OriginalSize: 10, NewSize: 20},
+		{FilePath: "/src/b.txt", Modified: true, OriginalSize: 10, NewSize: 5},
+	}
+
+	tests := []struct {
+		name      string
+		topGrowth int
+		expected  string
+	}{
+		{name: "listed", topGrowth: 2, expected: "<largest_growth>\n    <file>\n      <file_path>/src/a.txt</file_path>"},
+		{name: "off by default", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := &Logger{
+				config:  &config.Config{LogFormat: config.LogFormatXML, TopGrowth: tt.topGrowth},
+				writer:  &buf,
+				summary: Summary{DryRun: true},
+				entries: entries,
+			}
+			if err := logger.writeXMLReport(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			output := buf.String()
+			if tt.expected == "" && strings.Contains(output, "largest_growth") {
+				t.Errorf("expected no largest_growth element, got:\n%s", output)
+			}
+			if tt.expected != "" && !strings.Contains(output, tt.expected) {
+				t.Errorf("expected %q in:\n%s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestErrorWriter(t *testing.T) {
+	var report, errors bytes.Buffer
+	logger := &Logger{
+		config:  &config.Config{LogFormat: config.LogFormatJSON, Verbose: true},
+		writer:  &report,
+		entries: []Entry{},
+	}
+	logger.SetErrorWriter(&errors)
+
+	logger.LogResult(concurrent.ProcessResult{
+		Job:   concurrent.ProcessJob{FilePath: "/src/locked.txt"},
+		Error: fmt.Errorf("permission denied"),
+	})
+	logger.LogResult(concurrent.ProcessResult{
+		Job:    concurrent.ProcessJob{FilePath: "/src/ok.txt"},
+		Result: &replacement.FileResult{Path: "/src/ok.txt"},
+	})
+	logger.summary.TotalFiles = 2
+
+	// Verbose progress lines are not part of this check
+	report.Reset()
+	if err := logger.WriteReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{"ERROR: /src/locked.txt - permission denied", "1 file(s) failed"} {
+		if !strings.Contains(errors.String(), expected) {
+			t.Errorf("expected %q on the error writer, got %q", expected, errors.String())
+		}
+	}
+	if strings.Contains(errors.String(), "ok.txt") {
+		t.Errorf("expected only errors on the error writer, got %q", errors.String())
+	}
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(report.Bytes(), &parsed); err != nil {
+		t.Errorf("expected the report to be pure JSON, got %v:\n%s", err, report.String())
+	}
+}
+
+func TestErrorWriterKeepsVerboseOutputClean(t *testing.T) {
+	var report, errors bytes.Buffer
+	logger := &Logger{
+		config:  &config.Config{LogFormat: config.LogFormatJSON, Verbose: true},
+		writer:  &report,
+		entries: []Entry{},
+	}
+	logger.SetErrorWriter(&errors)
+
+	logger.LogResult(concurrent.ProcessResult{
+		Job:   concurrent.ProcessJob{FilePath: "/src/locked.txt"},
+		Error: fmt.Errorf("permission denied"),
+	})
+
+	if strings.Contains(report.String(), "ERROR") {
+		t.Errorf("expected error lines only on the error writer, got %q in the main output", report.String())
+	}
+}
+
+func TestDetectedAndAppliedReplacements(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config:  &config.Config{DryRun: true},
+		writer:  &buf,
+		entries: []Entry{},
+		summary: Summary{DryRun: true},
+	}
+
+	// a.txt replaces only the 2nd of three matches; b.txt's occurrence is
+	// never reached, so it is detected but left unmodified
+	results := []concurrent.ProcessResult{
+		{Job: concurrent.ProcessJob{FilePath: "/test/a.txt"}, Result: &replacement.FileResult{Modified: true, DetectedMatches: 3,
+			Replacements: []replacement.Replacement{{From: "a", To: "b", Line: 2}}}},
+		{Job: concurrent.ProcessJob{FilePath: "/test/b.txt"}, Result: &replacement.FileResult{DetectedMatches: 1}},
+		{Job: concurrent.ProcessJob{FilePath: "/test/c.txt"}, Error: fmt.Errorf("permission denied")},
+	}
+	for _, result := range results {
+		logger.LogResult(result)
+	}
+
+	summary := logger.Summary()
+	if summary.DetectedReplacements != 4 || summary.TotalReplacements != 1 {
+		t.Errorf("expected 4 detected and 1 applied, got %d and %d", summary.DetectedReplacements, summary.TotalReplacements)
+	}
+
+	if err := logger.writeSummaryReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Matches detected: 4 (1 applied, 3 not applied)") {
+		t.Errorf("expected the gap in the summary, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteXMLReport(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config:  &config.Config{LogFormat: config.LogFormatXML},
+		writer:  &buf,
+		entries: []Entry{},
+	}
+	logger.LogResult(concurrent.ProcessResult{
+		Job: concurrent.ProcessJob{FilePath: "/test/a&b.txt"},
+		Result: &replacement.FileResult{Modified: true, OriginalSize: 4, NewSize: 4,
+			Replacements: []replacement.Replacement{{From: "<a>", To: "<b>", OriginalText: "<a>", Line: 1, Column: 1}}},
+	})
+
+	if err := logger.WriteReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		"<remap_report>\n  <summary>\n    <total_files>1</total_files>",
+		"<file_path>/test/a&amp;b.txt</file_path>",
+		"<replacements>\n        <replacement>\n          <from>&lt;a&gt;</from>",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in XML report:\n%s", want, output)
+		}
+	}
+
+	var report struct {
+		Summary Summary `xml:"summary"`
+		Entries []Entry `xml:"entries>entry"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid XML output: %v", err)
+	}
+	if report.Summary.ModifiedFiles != 1 || len(report.Entries) != 1 || report.Entries[0].Replacements[0].To != "<b>" {
+		t.Errorf("unexpected decoded report: %+v", report)
+	}
+}
@@ -0,0 +1,38 @@
+package log
+
+import (
+	"encoding/json"
+
+	"remap/internal/config"
+)
+
+// streaming reports whether entries are written as they arrive (--log-format
+// ndjson) instead of being kept for the final report, so the memory used by
+// a run does not grow with the number of files.
+func (l *Logger) streaming() bool {
+	return l.config.LogFormat == config.LogFormatNDJSON && l.sink == nil && !l.config.Quiet
+}
+
+// streamEntry writes entry as one JSON line. The first write error is kept
+// and returned by WriteReport, since LogResult cannot fail.
+func (l *Logger) streamEntry(entry Entry) {
+	if l.streamErr != nil {
+		return
+	}
+	l.streamErr = l.writeJSONLine(entry)
+}
+
+// writeNDJSONSummary ends an NDJSON log with a {"summary": ...} line.
+func (l *Logger) writeNDJSONSummary() error {
+	if l.streamErr != nil {
+		return l.streamErr
+	}
+	return l.writeJSONLine(struct {
+		Summary Summary `json:"summary"`
+	}{l.summary})
+}
+
+func (l *Logger) writeJSONLine(v interface{}) error {
+	// Encode terminates each value with a newline
+	return json.NewEncoder(l.writer).Encode(v)
+}
@@ -0,0 +1,66 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"remap/internal/concurrent"
+	"remap/internal/config"
+	"remap/internal/replacement"
+)
+
+func TestNDJSONStreaming(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config:  &config.Config{LogFormat: config.LogFormatNDJSON, DryRun: true},
+		writer:  &buf,
+		entries: []Entry{},
+		summary: Summary{DryRun: true},
+	}
+
+	logger.LogResult(concurrent.ProcessResult{
+		Job:    concurrent.ProcessJob{FilePath: "/test/a.txt"},
+		Result: &replacement.FileResult{Modified: true, Replacements: []replacement.Replacement{{From: "a", To: "b"}}},
+	})
+
+	// The entry is written as soon as the result arrives, not kept
+	if !strings.HasSuffix(buf.String(), "\n") || strings.Count(buf.String(), "\n") != 1 {
+		t.Fatalf("expected one streamed line, got %q", buf.String())
+	}
+	if len(logger.entries) != 0 {
+		t.Errorf("expected streamed entries not to be kept, got %d", len(logger.entries))
+	}
+
+	logger.LogResult(concurrent.ProcessResult{
+		Job:    concurrent.ProcessJob{FilePath: "/test/b.txt"},
+		Result: &replacement.FileResult{},
+	})
+	if err := logger.WriteReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected two entries and a summary, got %d lines:\n%s", len(lines), buf.String())
+	}
+
+	var entry Entry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("invalid entry line: %v", err)
+	}
+	if entry.FilePath != "/test/a.txt" || !entry.Modified || len(entry.Replacements) != 1 {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	var summary struct {
+		Summary Summary `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &summary); err != nil {
+		t.Fatalf("invalid summary line: %v", err)
+	}
+	if summary.Summary.TotalFiles != 2 || summary.Summary.ModifiedFiles != 1 || !summary.Summary.DryRun {
+		t.Errorf("unexpected summary: %+v", summary.Summary)
+	}
+}
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"remap/internal/backup"
+)
+
+// signaturePrefix names the algorithm in front of the hex digest, so the
+// scheme can change later without ambiguity.
+const signaturePrefix = "hmac-sha256:"
+
+// Errors returned by VerifyReport.
+var (
+	ErrUnsigned          = stderrors.New("log has no signature")
+	ErrSignatureMismatch = stderrors.New("signature does not match: the log was modified or signed with another key")
+)
+
+// writeSignedJSONReport writes report as indented JSON followed by a final
+// "signature" field holding an HMAC-SHA256, keyed by --sign-key, of the
+// report as serialized without that field.
+func (l *Logger) writeSignedJSONReport(report interface{}) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	_, err := l.writer.Write(signReport(buf.Bytes(), l.config.SignKey))
+	return err
+}
+
+// signReport appends the signature field to report, an indented JSON object
+// ending in "\n}\n".
+func signReport(report []byte, key string) []byte {
+	body := bytes.TrimSuffix(report, []byte("\n}\n"))
+	return append(body, signatureSuffix(reportSignature(report, key))...)
+}
+
+// signatureSuffix is the text signReport puts in place of the closing brace.
+func signatureSuffix(signature string) []byte {
+	return []byte(fmt.Sprintf(",\n  \"signature\": %q\n}\n", signature))
+}
+
+func reportSignature(report []byte, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(report)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyReport checks the signature of a signed JSON report against key.
+// Verbose output written before the report is ignored, like revert does; any
+// other change to the report, including to the signature, fails the check.
+func VerifyReport(content []byte, key string) error {
+	start := backup.JSONReportStart(string(content))
+	if start == -1 {
+		return ErrUnsigned
+	}
+	signed := content[start:]
+
+	var fields struct {
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(signed, &fields); err != nil {
+		return fmt.Errorf("invalid JSON report: %w", err)
+	}
+	if !strings.HasPrefix(fields.Signature, signaturePrefix) {
+		return ErrUnsigned
+	}
+
+	suffix := signatureSuffix(fields.Signature)
+	if !bytes.HasSuffix(signed, suffix) {
+		// The signature must be the last field, exactly as it was written
+		return ErrSignatureMismatch
+	}
+	report := append(bytes.Clone(signed[:len(signed)-len(suffix)]), "\n}\n"...)
+
+	if !hmac.Equal([]byte(reportSignature(report, key)), []byte(fields.Signature)) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package log
+
+import (
+	"bytes"
+	stderrors "errors"
+	"testing"
+
+	"remap/internal/concurrent"
+	"remap/internal/config"
+	"remap/internal/replacement"
+)
+
+func TestSignedJSONReport(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		config:  &config.Config{LogFormat: config.LogFormatJSON, Verbose: true, SignKey: "key"},
+		writer:  &buf,
+		entries: []Entry{},
+	}
+	logger.LogResult(concurrent.ProcessResult{
+		Job:    concurrent.ProcessJob{FilePath: "/test/{d}/a.txt"},
+		Result: &replacement.FileResult{Modified: true, Replacements: []replacement.Replacement{{From: "a", To: "b"}}},
+	})
+	if err := logger.WriteReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The verbose MODIFIED line before the report is not covered, even when
+	// its path holds braces
+	if !bytes.HasPrefix(buf.Bytes(), []byte("MODIFIED: /test/{d}/a.txt")) {
+		t.Fatalf("expected verbose output before the report, got:\n%s", buf.String())
+	}
+	if err := VerifyReport(buf.Bytes(), "key"); err != nil {
+		t.Fatalf("expected a valid signature, got %v", err)
+	}
+	if err := VerifyReport(buf.Bytes(), "other"); !stderrors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("expected a mismatch with another key, got %v", err)
+	}
+
+	tampered := bytes.Replace(buf.Bytes(), []byte(`"total_files": 1`), []byte(`"total_files": 2`), 1)
+	if err := VerifyReport(tampered, "key"); !stderrors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("expected a mismatch after tampering, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package log
+
+import "fmt"
+
+// Supported values for --log-sink.
+const (
+	SinkSyslog = "syslog"
+)
+
+// entrySink receives one message per logged entry at a severity matching the
+// entry: errors, modified files and untouched files. *syslog.Writer implements
+// it; tests substitute a fake.
+type entrySink interface {
+	Err(message string) error
+	Notice(message string) error
+	Info(message string) error
+	Close() error
+}
+
+// emit sends an entry to the sink. Delivery failures are ignored, as a
+// logging outage must not fail the replacement run itself.
+func (l *Logger) emit(entry Entry) {
+	switch {
+	case entry.Error != "":
+		_ = l.sink.Err(fmt.Sprintf("ERROR: %s - %s", entry.FilePath, entry.Error))
+	case entry.Modified:
+		_ = l.sink.Notice(fmt.Sprintf("MODIFIED: %s (%d replacements)", entry.FilePath, len(entry.Replacements)))
+	default:
+		_ = l.sink.Info(fmt.Sprintf("SKIPPED: %s (no changes)", entry.FilePath))
+	}
+}
+
+// emitSummary sends the run totals to the sink in place of a written report.
+func (l *Logger) emitSummary() {
+	mode := "production"
+	if l.summary.DryRun {
+		mode = "dry-run"
+	}
+	_ = l.sink.Info(fmt.Sprintf("SUMMARY (%s): %d files processed, %d modified, %d replacements, %d errors in %v",
+		mode, l.summary.TotalFiles, l.summary.ModifiedFiles, l.summary.TotalReplacements,
+		l.summary.ErrorCount, l.summary.ProcessingTime))
+}
@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package log
+
+import "remap/internal/errors"
+
+// newSyslogSink reports that syslog is unavailable on this platform.
+func newSyslogSink() (entrySink, error) {
+	return nil, errors.NewConfigError("syslog log sink is not supported on this platform", nil)
+}
@@ -0,0 +1,11 @@
+//go:build !windows && !plan9
+
+package log
+
+import "log/syslog"
+
+// newSyslogSink connects to the local syslog daemon (journald forwards it on
+// systemd hosts), tagging messages with "remap".
+func newSyslogSink() (entrySink, error) {
+	return syslog.New(syslog.LOG_INFO|syslog.LOG_USER, "remap")
+}
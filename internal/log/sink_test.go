@@ -0,0 +1,92 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"remap/internal/concurrent"
+	"remap/internal/config"
+	"remap/internal/replacement"
+)
+
+type sinkMessage struct {
+	severity string
+	message  string
+}
+
+// fakeSink records messages instead of sending them to syslog.
+type fakeSink struct {
+	messages []sinkMessage
+	closed   bool
+}
+
+func (s *fakeSink) record(severity, message string) error {
+	s.messages = append(s.messages, sinkMessage{severity, message})
+	return nil
+}
+
+func (s *fakeSink) Err(m string) error    { return s.record("err", m) }
+func (s *fakeSink) Notice(m string) error { return s.record("notice", m) }
+func (s *fakeSink) Info(m string) error   { return s.record("info", m) }
+func (s *fakeSink) Close() error          { s.closed = true; return nil }
+
+func TestLoggerSink(t *testing.T) {
+	sink := &fakeSink{}
+	var buf bytes.Buffer
+	logger := &Logger{
+		config: &config.Config{LogSink: SinkSyslog, Verbose: true},
+		writer: &buf,
+		sink:   sink,
+	}
+
+	logger.LogResult(concurrent.ProcessResult{
+		Job: concurrent.ProcessJob{FilePath: "/src/a.txt"},
+		Result: &replacement.FileResult{
+			Modified:     true,
+			Replacements: []replacement.Replacement{{From: "foo", To: "bar"}},
+		},
+	})
+	logger.LogResult(concurrent.ProcessResult{
+		Job:    concurrent.ProcessJob{FilePath: "/src/b.txt"},
+		Result: &replacement.FileResult{},
+	})
+	logger.LogResult(concurrent.ProcessResult{
+		Job:   concurrent.ProcessJob{FilePath: "/src/c.txt"},
+		Error: errors.New("permission denied"),
+	})
+
+	if err := logger.WriteReport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []sinkMessage{
+		{"notice", "MODIFIED: /src/a.txt (1 replacements)"},
+		{"info", "SKIPPED: /src/b.txt (no changes)"},
+		{"err", "ERROR: /src/c.txt - permission denied"},
+	}
+	if len(sink.messages) != len(expected)+1 {
+		t.Fatalf("expected %d messages, got %d: %v", len(expected)+1, len(sink.messages), sink.messages)
+	}
+	for i, want := range expected {
+		if sink.messages[i] != want {
+			t.Errorf("message %d = %v, expected %v", i, sink.messages[i], want)
+		}
+	}
+
+	summary := sink.messages[len(expected)]
+	if summary.severity != "info" || !strings.HasPrefix(summary.message, "SUMMARY (production): 3 files processed, 1 modified") {
+		t.Errorf("unexpected summary message: %v", summary)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written to the report writer, got %q", buf.String())
+	}
+	if !sink.closed {
+		t.Error("expected sink to be closed")
+	}
+}
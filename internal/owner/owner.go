@@ -0,0 +1,5 @@
+// Package owner preserves file ownership across rewrites. Replacing a file
+// through a temporary file and rename gives it the owner of the process;
+// this package copies the original owner across so a privileged run does
+// not take files over.
+package owner
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package owner
+
+import "os"
+
+// Copy is a no-op where files have no Unix owner to preserve.
+func Copy(_, _ string, _ os.FileInfo) error {
+	return nil
+}
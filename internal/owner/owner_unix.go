@@ -0,0 +1,34 @@
+//go:build unix
+
+package owner
+
+import (
+	stderrors "errors"
+	"io/fs"
+	"os"
+	"syscall"
+
+	"remap/internal/errors"
+)
+
+// Copy gives tempFile the owner and group recorded in info, the FileInfo of
+// filePath, the file it replaces, so a privileged run over files such as
+// those in /etc does not hand them to root. A user without the right to
+// chown keeps the write with its own ownership rather than failing it.
+func Copy(filePath, tempFile string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	uid, gid := int(stat.Uid), int(stat.Gid)
+	if uid == os.Geteuid() && gid == os.Getegid() {
+		return nil
+	}
+
+	if err := os.Chown(tempFile, uid, gid); err != nil && !stderrors.Is(err, fs.ErrPermission) {
+		return errors.NewFileNotWritableError(filePath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+//go:build unix
+
+package owner
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestCopyWithoutPermission(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root may give files away")
+	}
+
+	tempDir := t.TempDir()
+	tempFile := filepath.Join(tempDir, "owned.conf.tmp")
+	if err := os.WriteFile(tempFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	info := fakeOwnedInfo(t, tempFile, 0, 0)
+
+	if err := Copy(filepath.Join(tempDir, "owned.conf"), tempFile, info); err != nil {
+		t.Errorf("expected a refused chown to be ignored, got %v", err)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing a file's owner requires root")
+	}
+
+	tempDir := t.TempDir()
+	tempFile := filepath.Join(tempDir, "owned.conf.tmp")
+	if err := os.WriteFile(tempFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	const uid, gid = 1234, 5678
+	info := fakeOwnedInfo(t, tempFile, uid, gid)
+
+	if err := Copy(filepath.Join(tempDir, "owned.conf"), tempFile, info); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	copied, err := os.Stat(tempFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat := copied.Sys().(*syscall.Stat_t)
+	if stat.Uid != uid || stat.Gid != gid {
+		t.Errorf("expected owner %d:%d, got %d:%d", uid, gid, stat.Uid, stat.Gid)
+	}
+}
+
+// fakeOwnedInfo returns the FileInfo of filePath reporting uid and gid as its owner.
+func fakeOwnedInfo(t *testing.T, filePath string, uid, gid uint32) os.FileInfo {
+	t.Helper()
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat := *info.Sys().(*syscall.Stat_t)
+	stat.Uid, stat.Gid = uid, gid
+	return ownedInfo{FileInfo: info, stat: &stat}
+}
+
+type ownedInfo struct {
+	os.FileInfo
+	stat *syscall.Stat_t
+}
+
+func (i ownedInfo) Sys() any { return i.stat }
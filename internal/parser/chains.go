@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"remap/internal/errors"
+)
+
+// Chain is a pair of mappings where the destination of First is the source
+// of Next, so text replaced by First may be replaced again by Next depending
+// on the order the mappings run in.
+type Chain struct {
+	First Mapping
+	Next  Mapping
+}
+
+// chainKey returns the text a mapping's source or destination is matched as.
+func chainKey(s string, caseSensitive bool) string {
+	if caseSensitive {
+		return s
+	}
+	return strings.ToLower(s)
+}
+
+// chainView holds the chainable mappings that can apply to the same file:
+// those limited to one scope together with the unscoped ones. Mappings
+// limited to different scopes never run on the same file, so they cannot
+// chain.
+type chainView struct {
+	mappings []Mapping
+	bySource map[string]Mapping
+}
+
+// chainViews returns a view for files outside every scope and one for each
+// scope mappings are limited to.
+func (mt *MappingTable) chainViews(caseSensitive bool) []chainView {
+	scopes := []string{""}
+	seen := map[string]bool{"": true}
+	for _, mapping := range mt.mappings {
+		if !seen[mapping.AppliesTo] {
+			seen[mapping.AppliesTo] = true
+			scopes = append(scopes, mapping.AppliesTo)
+		}
+	}
+
+	views := make([]chainView, len(scopes))
+	for i, scope := range scopes {
+		views[i] = mt.chainable(scope, caseSensitive)
+	}
+	return views
+}
+
+// chainable returns the view of the mappings that apply to files in scope,
+// indexed by source: for each source the one used there, which is the
+// scoped mapping over an unscoped one and otherwise the first in file order.
+// Line-case mappings have no destination and mappings onto their own source
+// change nothing, so neither can chain; regex sources are not literal text.
+func (mt *MappingTable) chainable(scope string, caseSensitive bool) chainView {
+	bySource := make(map[string]Mapping)
+	for _, mapping := range mt.mappings {
+		if mapping.AppliesTo != "" && mapping.AppliesTo != scope {
+			continue
+		}
+		from, to := chainKey(mapping.From, caseSensitive), chainKey(mapping.To, caseSensitive)
+		if mapping.LineCase != "" || mapping.Regex || from == to {
+			continue
+		}
+		if used, seen := bySource[from]; seen && (used.AppliesTo != "" || mapping.AppliesTo == "") {
+			continue
+		}
+		bySource[from] = mapping
+	}
+
+	mappings := make([]Mapping, 0, len(bySource))
+	for _, mapping := range bySource {
+		mappings = append(mappings, mapping)
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].Index < mappings[j].Index })
+	return chainView{mappings: mappings, bySource: bySource}
+}
+
+// Chains returns every pair of mappings where one's destination is another's
+// source and both can apply to the same file, in file order. Unless
+// caseSensitive, texts differing only in case chain too.
+func (mt *MappingTable) Chains(caseSensitive bool) []Chain {
+	var chains []Chain
+	seen := make(map[[2]int]bool)
+	for _, view := range mt.chainViews(caseSensitive) {
+		for _, mapping := range view.mappings {
+			next, ok := view.bySource[chainKey(mapping.To, caseSensitive)]
+			if !ok || seen[[2]int{mapping.Index, next.Index}] {
+				continue
+			}
+			seen[[2]int{mapping.Index, next.Index}] = true
+			chains = append(chains, Chain{First: mapping, Next: next})
+		}
+	}
+
+	sort.SliceStable(chains, func(i, j int) bool {
+		if chains[i].First.Index != chains[j].First.Index {
+			return chains[i].First.Index < chains[j].First.Index
+		}
+		return chains[i].Next.Index < chains[j].Next.Index
+	})
+	return chains
+}
+
+// Cycles returns the chains of mappings that lead back to their start, such
+// as a→b and b→a, each listed once from its first mapping in file order.
+// Every mapping of a cycle can apply to the same file.
+func (mt *MappingTable) Cycles(caseSensitive bool) [][]Mapping {
+	var cycles [][]Mapping
+	seen := make(map[string]bool)
+	for _, view := range mt.chainViews(caseSensitive) {
+		for _, cycle := range view.cycles(caseSensitive) {
+			indexes := make([]string, len(cycle))
+			for i, mapping := range cycle {
+				indexes[i] = strconv.Itoa(mapping.Index)
+			}
+			sort.Strings(indexes)
+			key := strings.Join(indexes, ",")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			cycles = append(cycles, cycle)
+		}
+	}
+
+	sort.SliceStable(cycles, func(i, j int) bool { return cycles[i][0].Index < cycles[j][0].Index })
+	return cycles
+}
+
+// cycles returns the cycles among the mappings of the view.
+func (v chainView) cycles(caseSensitive bool) [][]Mapping {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+
+	var cycles [][]Mapping
+	for _, start := range v.mappings {
+		var path []Mapping
+		key := chainKey(start.From, caseSensitive)
+		for state[key] == unvisited {
+			mapping, ok := v.bySource[key]
+			if !ok {
+				break
+			}
+			state[key] = visiting
+			path = append(path, mapping)
+			key = chainKey(mapping.To, caseSensitive)
+		}
+
+		if state[key] == visiting {
+			// The walk came back to a mapping of this path: the cycle runs
+			// from there to the end of the path
+			for i, mapping := range path {
+				if chainKey(mapping.From, caseSensitive) == key {
+					cycles = append(cycles, path[i:])
+					break
+				}
+			}
+		}
+		for _, mapping := range path {
+			state[chainKey(mapping.From, caseSensitive)] = done
+		}
+	}
+	return cycles
+}
+
+// CheckCycles returns a parsing error for source describing every cycle of
+// mappings, or nil when there is none. A cycle undoes its own replacements
+// or depends entirely on the order mappings run in, so it is never intended.
+func (mt *MappingTable) CheckCycles(source string, caseSensitive bool) error {
+	cycles := mt.Cycles(caseSensitive)
+	if len(cycles) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, len(cycles))
+	for i, cycle := range cycles {
+		steps := make([]string, len(cycle))
+		for j, mapping := range cycle {
+			steps[j] = fmt.Sprintf("%q → %q (%s)", mapping.From, mapping.To, mappingLocation(mapping))
+		}
+		descriptions[i] = strings.Join(steps, ", ")
+	}
+
+	return errors.NewParsingError(source, "mapping cycle: "+strings.Join(descriptions, "; "), nil)
+}
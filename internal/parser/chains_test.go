@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChains(t *testing.T) {
+	tests := []struct {
+		name          string
+		mappings      []Mapping
+		caseSensitive bool
+		want          []string
+	}{
+		{
+			name:     "no chain",
+			mappings: []Mapping{{From: "a", To: "b"}, {From: "c", To: "d"}},
+		},
+		{
+			name:     "chain",
+			mappings: []Mapping{{From: "a", To: "b"}, {From: "b", To: "c"}},
+			want:     []string{"a>b"},
+		},
+		{
+			name:     "chain across case",
+			mappings: []Mapping{{From: "a", To: "B"}, {From: "b", To: "c"}},
+			want:     []string{"a>b"},
+		},
+		{
+			name:          "case-sensitive texts differ",
+			mappings:      []Mapping{{From: "a", To: "B"}, {From: "b", To: "c"}},
+			caseSensitive: true,
+		},
+		{
+			name:     "mapping onto itself",
+			mappings: []Mapping{{From: "a", To: "A"}},
+		},
+		{
+			name:     "line-case mapping",
+			mappings: []Mapping{{From: "a", To: "b"}, {From: "b", LineCase: LineCaseUpper}},
+		},
+		{
+			name:     "disjoint scopes",
+			mappings: []Mapping{{From: "a", To: "b", AppliesTo: "*.go"}, {From: "b", To: "c", AppliesTo: "*.md"}},
+		},
+		{
+			name:     "scoped and unscoped",
+			mappings: []Mapping{{From: "a", To: "b", AppliesTo: "*.go"}, {From: "b", To: "c"}},
+			want:     []string{"a>b"},
+		},
+		{
+			name: "scoped mapping overrides unscoped one",
+			mappings: []Mapping{
+				{From: "a", To: "b"}, {From: "b", To: "c"}, {From: "b", To: "d", AppliesTo: "*.go"}, {From: "d", To: "e", AppliesTo: "*.go"},
+			},
+			want: []string{"a>b", "a>b", "b>d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, chain := range NewMappingTable(tt.mappings).Chains(tt.caseSensitive) {
+				got = append(got, chain.First.From+">"+chain.Next.From)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("chains = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCycles(t *testing.T) {
+	tests := []struct {
+		name     string
+		mappings []Mapping
+		want     []string
+	}{
+		{
+			name:     "chain without cycle",
+			mappings: []Mapping{{From: "a", To: "b"}, {From: "b", To: "c"}},
+		},
+		{
+			name:     "swap",
+			mappings: []Mapping{{From: "a", To: "b"}, {From: "b", To: "a"}},
+			want:     []string{"a>b>"},
+		},
+		{
+			name:     "three mappings after a lead-in",
+			mappings: []Mapping{{From: "x", To: "a"}, {From: "a", To: "b"}, {From: "b", To: "C"}, {From: "c", To: "a"}},
+			want:     []string{"a>b>c>"},
+		},
+		{
+			name:     "two separate cycles",
+			mappings: []Mapping{{From: "a", To: "b"}, {From: "b", To: "a"}, {From: "c", To: "d"}, {From: "d", To: "c"}},
+			want:     []string{"a>b>", "c>d>"},
+		},
+		{
+			name:     "disjoint scopes",
+			mappings: []Mapping{{From: "foo", To: "bar", AppliesTo: "*.go"}, {From: "bar", To: "foo", AppliesTo: "*.md"}},
+		},
+		{
+			name:     "same scope",
+			mappings: []Mapping{{From: "foo", To: "bar", AppliesTo: "*.go"}, {From: "bar", To: "foo", AppliesTo: "*.go"}},
+			want:     []string{"foo>bar>"},
+		},
+		{
+			name:     "scoped and unscoped",
+			mappings: []Mapping{{From: "foo", To: "bar", AppliesTo: "*.go"}, {From: "bar", To: "foo"}},
+			want:     []string{"foo>bar>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, cycle := range NewMappingTable(tt.mappings).Cycles(false) {
+				var b strings.Builder
+				for _, mapping := range cycle {
+					b.WriteString(mapping.From + ">")
+				}
+				got = append(got, b.String())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("cycles = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCycles(t *testing.T) {
+	table, err := parseCSVMappings(strings.NewReader("foo,bar\nbar,foo\n"), "mappings.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = table.CheckCycles("mappings.csv", false)
+	if err == nil || !strings.Contains(err.Error(), `"foo" → "bar" (line 1), "bar" → "foo" (line 2)`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
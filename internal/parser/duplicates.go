@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"remap/internal/errors"
+)
+
+// Duplicate groups the mappings of one scope that share a source text, in
+// file order. Only the first of them is ever used.
+type Duplicate struct {
+	From      string
+	AppliesTo string
+	Mappings  []Mapping
+}
+
+// Duplicates returns the sources mapped more than once within the same
+// applies_to scope. Unless caseSensitive, sources differing only in case are
+// duplicates too, since they match the same text. Mappings in different
+// scopes are not duplicates: the most specific one wins by design.
+func (mt *MappingTable) Duplicates(caseSensitive bool) []Duplicate {
+	type scopedKey struct{ from, appliesTo string }
+
+	groups := make(map[scopedKey]int)
+	var duplicates []Duplicate
+	for _, mapping := range mt.mappings {
+		key := scopedKey{mapping.From, mapping.AppliesTo}
+		if !caseSensitive {
+			key.from = strings.ToLower(key.from)
+		}
+
+		i, seen := groups[key]
+		if !seen {
+			groups[key] = len(duplicates)
+			duplicates = append(duplicates, Duplicate{From: mapping.From, AppliesTo: mapping.AppliesTo})
+			i = len(duplicates) - 1
+		}
+		duplicates[i].Mappings = append(duplicates[i].Mappings, mapping)
+	}
+
+	var result []Duplicate
+	for _, d := range duplicates {
+		if len(d.Mappings) > 1 {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// CheckDuplicates returns a parsing error for source listing every
+// duplicated mapping with its line, or nil when there is none.
+func (mt *MappingTable) CheckDuplicates(source string, caseSensitive bool) error {
+	duplicates := mt.Duplicates(caseSensitive)
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	groups := make([]string, len(duplicates))
+	for i, d := range duplicates {
+		entries := make([]string, len(d.Mappings))
+		for j, mapping := range d.Mappings {
+			entries[j] = fmt.Sprintf("%s %q → %q", mappingLocation(mapping), mapping.From, mapping.To)
+		}
+		groups[i] = strings.Join(entries, ", ")
+		if d.AppliesTo != "" {
+			groups[i] += fmt.Sprintf(" (applies_to %s)", d.AppliesTo)
+		}
+	}
+
+	return errors.NewParsingError(source, fmt.Sprintf("duplicate mapping sources (use --allow-duplicates to keep the first): %s",
+		strings.Join(groups, "; ")), nil)
+}
+
+// mappingLocation describes where a mapping was defined: its line in the
+// mapping file, or its position when it was not read from a file.
+func mappingLocation(mapping Mapping) string {
+	if mapping.Line > 0 {
+		return fmt.Sprintf("line %d", mapping.Line)
+	}
+	return fmt.Sprintf("mapping %d", mapping.Index+1)
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMappingsLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+	}{
+		{
+			name:   "csv with header, comments and blank lines",
+			format: "csv",
+			input:  "old,new\n# comment\nfoo,bar\n\nhello,world\n",
+		},
+		{
+			name:   "json",
+			format: "json",
+			input:  "[\n  {\"old\": \"x\", \"new\": \"y\"},\n  {\"old\": \"foo\", \"new\": \"bar\"},\n\n  {\"old\": \"hello\", \"new\": \"world\"}\n]\n",
+		},
+		{
+			name:   "yaml",
+			format: "yaml",
+			input:  "- old: x\n  new: y\n- old: foo\n  new: bar\n- old: hello\n  new: world\n",
+		},
+		{
+			name:   "properties",
+			format: "properties",
+			input:  "# comment\nx = y\nfoo = bar\n\nhello = world\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := parseMappings(strings.NewReader(tt.input), "mappings."+tt.format, tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			lines := make(map[string]int)
+			for _, mapping := range table.GetMappings() {
+				lines[mapping.From] = mapping.Line
+			}
+			if lines["foo"] != 3 || lines["hello"] != 5 {
+				t.Errorf("expected foo on line 3 and hello on line 5, got %v", lines)
+			}
+		})
+	}
+}
+
+func TestCheckDuplicates(t *testing.T) {
+	tests := []struct {
+		name          string
+		csv           string
+		caseSensitive bool
+		want          []string
+	}{
+		{
+			name: "no duplicates",
+			csv:  "foo,bar\nhello,world\n",
+		},
+		{
+			name: "same source twice",
+			csv:  "foo,bar\nhello,world\nfoo,baz\n",
+			want: []string{`line 1 "foo" → "bar"`, `line 3 "foo" → "baz"`},
+		},
+		{
+			name: "case-insensitive duplicates",
+			csv:  "Foo,Bar\nfoo,bar\n",
+			want: []string{`line 1 "Foo" → "Bar"`, `line 2 "foo" → "bar"`},
+		},
+		{
+			name:          "case-sensitive sources differ",
+			csv:           "Foo,Bar\nfoo,bar\n",
+			caseSensitive: true,
+		},
+		{
+			name: "different scopes",
+			csv:  "old,new,applies_to\nfoo,bar,*.go\nfoo,baz,\n",
+		},
+		{
+			name: "same scope",
+			csv:  "old,new,applies_to\nfoo,bar,*.go\nfoo,baz,*.go\n",
+			want: []string{`line 2 "foo" → "bar"`, `line 3 "foo" → "baz" (applies_to *.go)`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := parseCSVMappings(strings.NewReader(tt.csv), "mappings.csv")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = table.CheckDuplicates("mappings.csv", tt.caseSensitive)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected a duplicate error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q in %q", want, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDuplicatesInlinePairs(t *testing.T) {
+	table, err := NewMappingTableFromPairs([]string{"foo=bar", "hello=world", "foo=baz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = table.CheckDuplicates("--map", false)
+	if err == nil || !strings.Contains(err.Error(), `mapping 1 "foo" → "bar", mapping 3 "foo" → "baz"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -1,26 +1,176 @@
 // Package parser provides functionality for loading and parsing mapping tables.
-// It supports both CSV and JSON formats, converting them into internal data
+// It supports CSV, JSON, YAML and properties formats, converting them into internal data
 // structures optimized for fast string replacement operations with proper ordering.
 package parser
 
 import (
+	"bytes"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"unicode"
 
 	"remap/internal/errors"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Mapping represents a single string replacement rule.
-// The JSON tags enable loading from JSON files while maintaining
+// The JSON and YAML tags enable loading from those files while maintaining
 // clear field names that match the domain terminology.
+// A mapping with a LineCase does not replace its source: any line the source
+// appears on is converted to that case as a whole. A mapping with an
+// OccurrenceIndex of N replaces only the Nth match (1-based) in each file.
+// CaseSensitive and WholeWord, when set, override --case-sensitive and
+// --word-boundary for this mapping alone. A Regex mapping's source is a
+// regular expression matched within single lines, and its destination may
+// refer to capture groups as $1 or ${name}. Comment documents why the rule
+// exists and is carried into verbose output and reports.
 type Mapping struct {
-	From string `json:"old"`
-	To   string `json:"new"`
+	From            string             `json:"old" yaml:"old"`
+	To              string             `json:"new" yaml:"new"`
+	AppliesTo       string             `json:"applies_to,omitempty" yaml:"applies_to,omitempty"`
+	LineCase        string             `json:"line_case,omitempty" yaml:"line_case,omitempty"`
+	OccurrenceIndex int                `json:"occurrence,omitempty" yaml:"occurrence,omitempty"`
+	CaseSensitive   *bool              `json:"case_sensitive,omitempty" yaml:"case_sensitive,omitempty"`
+	WholeWord       *bool              `json:"whole_word,omitempty" yaml:"whole_word,omitempty"`
+	Regex           bool               `json:"regex,omitempty" yaml:"regex,omitempty"`
+	Comment         string             `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Index           int                `json:"-" yaml:"-"`
+	Line            int                `json:"-" yaml:"-"` // line in the mapping file, 0 when not read from one
+	ToTemplate      *template.Template `json:"-" yaml:"-"`
+
+	// pattern and foldPattern are the compiled source of a Regex mapping,
+	// matching case-sensitively and not
+	pattern, foldPattern *regexp.Regexp
+}
+
+// IsCaseSensitive reports whether the mapping matches case-sensitively: as
+// its own case_sensitive field says, or as the run does (global) without one.
+func (m Mapping) IsCaseSensitive(global bool) bool {
+	if m.CaseSensitive != nil {
+		return *m.CaseSensitive
+	}
+	return global
+}
+
+// IsWholeWord reports whether the mapping only matches whole words: as its
+// own whole_word field says, or as the run does (global) without one.
+func (m Mapping) IsWholeWord(global bool) bool {
+	if m.WholeWord != nil {
+		return *m.WholeWord
+	}
+	return global
+}
+
+// Regexp returns the compiled source of a Regex mapping, matching
+// case-insensitively unless caseSensitive, or nil when the source is not a
+// valid expression. Mappings loaded from a file are compiled once there;
+// others are compiled on each call.
+func (m Mapping) Regexp(caseSensitive bool) *regexp.Regexp {
+	if m.pattern == nil {
+		if err := m.compileRegex(); err != nil {
+			return nil
+		}
+	}
+	if caseSensitive {
+		return m.pattern
+	}
+	return m.foldPattern
+}
+
+// compileRegex compiles the source of a Regex mapping both ways Regexp may
+// need it.
+func (m *Mapping) compileRegex() error {
+	pattern, err := regexp.Compile(m.From)
+	if err != nil {
+		return err
+	}
+	m.pattern = pattern
+	m.foldPattern = regexp.MustCompile("(?i)" + m.From)
+	return nil
+}
+
+// Line cases accepted in the line_case field of a mapping.
+const (
+	LineCaseUpper = "upper"
+	LineCaseLower = "lower"
+	LineCaseTitle = "title"
+)
+
+// validateRegex compiles the source of a regex mapping, which cannot also
+// convert lines or target a single occurrence.
+func validateRegex(mapping *Mapping) error {
+	if mapping.LineCase != "" {
+		return fmt.Errorf("line_case cannot be combined with regex")
+	}
+	if mapping.OccurrenceIndex > 0 {
+		return fmt.Errorf("occurrence cannot be combined with regex")
+	}
+	return mapping.compileRegex()
+}
+
+// validateOccurrence checks an occurrence index; zero replaces every match.
+func validateOccurrence(occurrence int) error {
+	if occurrence < 0 {
+		return fmt.Errorf("occurrence must be 1 or greater, got %d", occurrence)
+	}
+	return nil
+}
+
+// validateLineCase checks a line_case value; empty means a normal mapping.
+func validateLineCase(lineCase string) error {
+	switch lineCase {
+	case "", LineCaseUpper, LineCaseLower, LineCaseTitle:
+		return nil
+	default:
+		return fmt.Errorf("line_case must be %q, %q or %q", LineCaseUpper, LineCaseLower, LineCaseTitle)
+	}
+}
+
+// Validate applies the checks a mapping file gets to a mapping built in code,
+// without trimming any field, and compiles its source if it is a regex.
+func (m *Mapping) Validate() error {
+	if m.From == "" {
+		return fmt.Errorf("old value is empty")
+	}
+	if err := validateAppliesTo(m.AppliesTo); err != nil {
+		return fmt.Errorf("invalid applies_to pattern %q: %w", m.AppliesTo, err)
+	}
+	if err := validateLineCase(m.LineCase); err != nil {
+		return err
+	}
+	if err := validateOccurrence(m.OccurrenceIndex); err != nil {
+		return err
+	}
+	if m.Regex {
+		return validateRegex(m)
+	}
+	return nil
+}
+
+// AppliesToPath reports whether the mapping should be used for the given file.
+// Unscoped mappings apply everywhere; scoped mappings match their glob against
+// the file's base name first and then its full path, like the include filter.
+func (m Mapping) AppliesToPath(path string) bool {
+	if m.AppliesTo == "" {
+		return true
+	}
+
+	if matched, err := filepath.Match(m.AppliesTo, filepath.Base(path)); err == nil && matched {
+		return true
+	}
+
+	matched, err := filepath.Match(m.AppliesTo, path)
+	return err == nil && matched
 }
 
 // MappingTable holds string replacement mappings with optimized access patterns.
@@ -34,12 +184,18 @@ type MappingTable struct {
 // NewMappingTable creates a MappingTable with optimized sorting for replacements.
 // The constructor sorts mappings by decreasing string length to ensure that
 // longer patterns are matched first, preventing incorrect partial replacements.
+// Each mapping is tagged with its position in the original order so that
+// replacements can be traced back to the rule that produced them.
 func NewMappingTable(mappings []Mapping) *MappingTable {
 	mt := &MappingTable{
-		mappings: mappings,
+		mappings: make([]Mapping, len(mappings)),
 		sorted:   make([]Mapping, len(mappings)),
 	}
-	copy(mt.sorted, mappings)
+	copy(mt.mappings, mappings)
+	for i := range mt.mappings {
+		mt.mappings[i].Index = i
+	}
+	copy(mt.sorted, mt.mappings)
 
 	sort.Slice(mt.sorted, func(i, j int) bool {
 		return len(mt.sorted[i].From) > len(mt.sorted[j].From)
@@ -48,6 +204,24 @@ func NewMappingTable(mappings []Mapping) *MappingTable {
 	return mt
 }
 
+// NewMappingTableFromPairs builds a table from "old=new" pairs given on the
+// command line. Pairs are split on their first "=", so the replacement may
+// itself contain "=" (e.g. "a=b=c" maps "a" to "b=c").
+func NewMappingTableFromPairs(pairs []string) (*MappingTable, error) {
+	mappings := make([]Mapping, 0, len(pairs))
+	for _, pair := range pairs {
+		from, to, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, errors.NewParsingError("--map", fmt.Sprintf("invalid mapping %q: expected old=new", pair), nil)
+		}
+		if from == "" {
+			return nil, errors.NewParsingError("--map", fmt.Sprintf("invalid mapping %q: old value is empty", pair), nil)
+		}
+		mappings = append(mappings, Mapping{From: from, To: to})
+	}
+	return NewMappingTable(mappings), nil
+}
+
 // GetMappings returns the original unsorted mappings.
 // This method provides access to mappings in their original order,
 // useful for reporting and debugging purposes where order matters.
@@ -69,34 +243,151 @@ func (mt *MappingTable) Size() int {
 	return len(mt.mappings)
 }
 
+// WithMappings returns a new table combining the current mappings with extra ones.
+// Mappings whose From already exists in the table are ignored, so explicit rules
+// always win over rules discovered at runtime (e.g. by the definition pre-scan).
+func (mt *MappingTable) WithMappings(extra []Mapping) *MappingTable {
+	seen := make(map[string]bool, len(mt.mappings)+len(extra))
+	combined := make([]Mapping, 0, len(mt.mappings)+len(extra))
+
+	for _, mapping := range mt.mappings {
+		seen[mapping.From] = true
+		combined = append(combined, mapping)
+	}
+
+	for _, mapping := range extra {
+		if mapping.From == "" || seen[mapping.From] {
+			continue
+		}
+		seen[mapping.From] = true
+		combined = append(combined, mapping)
+	}
+
+	return NewMappingTable(combined)
+}
+
+// CollapseWhitespace returns a new table in which every run of two or more
+// whitespace characters inside a source is collapsed to a single space, so a
+// mapping written with doubled spaces still matches single-spaced content.
+// Destinations are left untouched.
+func (mt *MappingTable) CollapseWhitespace() *MappingTable {
+	mappings := make([]Mapping, len(mt.mappings))
+	for i, mapping := range mt.mappings {
+		mapping.From = collapseWhitespace(mapping.From)
+		mappings[i] = mapping
+	}
+	return NewMappingTable(mappings)
+}
+
+// collapseWhitespace replaces each whitespace run longer than one character
+// with a single space. Lone tabs or newlines are kept as written.
+func collapseWhitespace(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if !unicode.IsSpace(runes[i]) {
+			b.WriteRune(runes[i])
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && unicode.IsSpace(runes[end]) {
+			end++
+		}
+		if end-i > 1 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteRune(runes[i])
+		}
+		i = end - 1
+	}
+	return b.String()
+}
+
+// CompileToTemplates parses every To as a text/template, for mappings whose
+// replacement depends on the match context (e.g. "{{.File}}"). Parsing once at
+// load time reports syntax errors before any file is touched, and executing
+// each template against data, the zero value of what templates are executed
+// with, reports references to fields it does not have, such as "{{.Nope}}".
+func (mt *MappingTable) CompileToTemplates(data interface{}) error {
+	templates := make(map[int]*template.Template, len(mt.mappings))
+
+	for i, mapping := range mt.mappings {
+		tmpl, err := template.New(fmt.Sprintf("mapping-%d", mapping.Index)).Parse(mapping.To)
+		if err != nil {
+			return errors.NewParsingError("", fmt.Sprintf("invalid template for %q", mapping.From), err)
+		}
+		if err := tmpl.Execute(io.Discard, data); err != nil {
+			return errors.NewParsingError("", fmt.Sprintf("invalid template for %q", mapping.From), err)
+		}
+		mt.mappings[i].ToTemplate = tmpl
+		templates[mapping.Index] = tmpl
+	}
+
+	for i := range mt.sorted {
+		mt.sorted[i].ToTemplate = templates[mt.sorted[i].Index]
+	}
+	return nil
+}
+
 // LoadMappingTable loads and parses a mapping table from a file.
 // This function provides the main entry point for loading mapping tables,
 // automatically dispatching to the appropriate parser based on format.
+// A filePath of StdinPath reads the table from standard input.
 func LoadMappingTable(filePath, format string) (*MappingTable, error) {
+	if filePath == StdinPath {
+		return parseMappings(stdin, "<stdin>", format)
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, errors.WrapFileError(filePath, err)
 	}
 	defer file.Close()
 
+	return parseMappings(file, filePath, format)
+}
+
+// StdinPath is the mapping file name that stands for standard input.
+const StdinPath = "-"
+
+// stdin is where StdinPath mappings are read from; tests replace it.
+var stdin io.Reader = os.Stdin
+
+func parseMappings(reader io.Reader, filePath, format string) (*MappingTable, error) {
 	switch format {
 	case "csv":
-		return parseCSVMappings(file, filePath)
+		return parseCSVMappings(reader, filePath)
 	case "json":
-		return parseJSONMappings(file, filePath)
+		return parseJSONMappings(reader, filePath)
+	case "yaml":
+		return parseYAMLMappings(reader, filePath)
+	case "properties":
+		return parsePropertiesMappings(reader, filePath)
 	default:
 		return nil, errors.NewParsingError(filePath, fmt.Sprintf("unsupported format: %s", format), nil)
 	}
 }
 
 func parseCSVMappings(reader io.Reader, filePath string) (*MappingTable, error) {
-	records, err := readCSVRecords(reader, filePath)
+	records, lines, err := readCSVRecords(reader, filePath)
 	if err != nil {
 		return nil, err
 	}
 
 	startIndex := determineCSVStartIndex(records)
-	mappings, err := extractCSVMappings(records, startIndex, filePath)
+	// Without a header, a third column is the rule's comment
+	columns := csvColumns{appliesTo: -1, lineCase: -1, occurrence: -1, comment: 2}
+	if startIndex == 1 {
+		columns.appliesTo = findCSVColumn(records[0], "applies_to")
+		columns.lineCase = findCSVColumn(records[0], "line_case")
+		columns.occurrence = findCSVColumn(records[0], "occurrence")
+		columns.comment = findCSVColumn(records[0], "comment")
+		if columns.comment == -1 {
+			columns.comment = findCSVColumn(records[0], "description")
+		}
+	}
+
+	mappings, err := extractCSVMappings(records, lines, startIndex, columns, filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -108,45 +399,63 @@ func parseCSVMappings(reader io.Reader, filePath string) (*MappingTable, error)
 	return NewMappingTable(mappings), nil
 }
 
-func readCSVRecords(reader io.Reader, filePath string) ([][]string, error) {
+// readCSVRecords parses the CSV content without its blank and comment lines,
+// returning each record with the line of the file it starts on.
+func readCSVRecords(reader io.Reader, filePath string) ([][]string, []int, error) {
 	// Read all content first to filter comment lines
 	content, err := io.ReadAll(reader)
 	if err != nil {
-		return nil, errors.NewParsingError(filePath, "failed to read CSV content", err)
+		return nil, nil, errors.NewParsingError(filePath, "failed to read CSV content", err)
 	}
 
-	// Filter out comment lines (lines starting with #)
+	// Filter out comment lines (lines starting with #), remembering where
+	// each kept line was in the file
 	lines := strings.Split(string(content), "\n")
 	var filteredLines []string
+	var fileLines []int
 
-	for _, line := range lines {
+	for i, line := range lines {
 		trimmed := strings.TrimSpace(line)
 		// Skip empty lines and comment lines (starting with #)
 		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
 			continue
 		}
 		filteredLines = append(filteredLines, line)
+		fileLines = append(fileLines, i+1)
 	}
 
 	if len(filteredLines) == 0 {
-		return nil, errors.NewParsingError(filePath, "CSV file contains no data after filtering comments", nil)
+		return nil, nil, errors.NewParsingError(filePath, "CSV file contains no data after filtering comments", nil)
 	}
 
 	// Parse the filtered content
 	filteredContent := strings.Join(filteredLines, "\n")
 	csvReader := csv.NewReader(strings.NewReader(filteredContent))
 	csvReader.TrimLeadingSpace = true
-
-	records, err := csvReader.ReadAll()
-	if err != nil {
-		return nil, errors.NewParsingError(filePath, "failed to parse CSV", err)
+	// Optional trailing columns such as a comment may be left off a row;
+	// rows missing a source or destination are rejected by the caller
+	csvReader.FieldsPerRecord = -1
+
+	var records [][]string
+	var recordLines []int
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.NewParsingError(filePath, "failed to parse CSV", err)
+		}
+		line, _ := csvReader.FieldPos(0)
+		records = append(records, record)
+		recordLines = append(recordLines, fileLines[line-1])
 	}
 
 	if len(records) == 0 {
-		return nil, errors.NewParsingError(filePath, "CSV file is empty", nil)
+		return nil, nil, errors.NewParsingError(filePath, "CSV file is empty", nil)
 	}
 
-	return records, nil
+	return records, recordLines, nil
 }
 
 func determineCSVStartIndex(records [][]string) int {
@@ -163,16 +472,43 @@ func determineCSVStartIndex(records [][]string) int {
 
 func isHeaderRow(row []string) bool {
 	return strings.EqualFold(row[0], "old") || strings.EqualFold(row[0], "source") ||
-		strings.EqualFold(row[1], "new") || strings.EqualFold(row[1], "destination")
+		strings.EqualFold(row[1], "new") || strings.EqualFold(row[1], "destination") ||
+		(len(row) > 2 && (strings.EqualFold(row[2], "comment") || strings.EqualFold(row[2], "description")))
+}
+
+// csvColumns holds the positions of the optional CSV columns, -1 when absent.
+type csvColumns struct {
+	appliesTo  int
+	lineCase   int
+	occurrence int
+	comment    int
+}
+
+// field returns the trimmed value of the optional column at index, or "".
+func (c csvColumns) field(record []string, index int) string {
+	if index < 0 || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
 }
 
-func extractCSVMappings(records [][]string, startIndex int, filePath string) ([]Mapping, error) {
+// findCSVColumn returns the index of a named optional column in the header row, or -1.
+func findCSVColumn(header []string, name string) int {
+	for i, column := range header {
+		if strings.EqualFold(strings.TrimSpace(column), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func extractCSVMappings(records [][]string, lines []int, startIndex int, columns csvColumns, filePath string) ([]Mapping, error) {
 	var mappings []Mapping
 
 	for i := startIndex; i < len(records); i++ {
-		record := records[i]
+		record, line := records[i], lines[i]
 		if len(record) < 2 {
-			return nil, errors.NewParsingError(filePath, fmt.Sprintf("invalid CSV row at line %d: expected 2 columns", i+1), nil)
+			return nil, errors.NewParsingError(filePath, fmt.Sprintf("invalid CSV row at line %d: expected 2 columns", line), nil)
 		}
 
 		from := strings.TrimSpace(record[0])
@@ -182,46 +518,202 @@ func extractCSVMappings(records [][]string, startIndex int, filePath string) ([]
 			continue
 		}
 
+		appliesTo := columns.field(record, columns.appliesTo)
+		if err := validateAppliesTo(appliesTo); err != nil {
+			return nil, errors.NewParsingError(filePath, fmt.Sprintf("invalid applies_to pattern at line %d: %s", line, appliesTo), err)
+		}
+
+		lineCase := strings.ToLower(columns.field(record, columns.lineCase))
+		if err := validateLineCase(lineCase); err != nil {
+			return nil, errors.NewParsingError(filePath, fmt.Sprintf("invalid line_case at line %d", line), err)
+		}
+
+		occurrence := 0
+		if text := columns.field(record, columns.occurrence); text != "" {
+			n, err := strconv.Atoi(text)
+			if err == nil {
+				err = validateOccurrence(n)
+			}
+			if err != nil {
+				return nil, errors.NewParsingError(filePath, fmt.Sprintf("invalid occurrence at line %d: %s", line, text), err)
+			}
+			occurrence = n
+		}
+
 		mappings = append(mappings, Mapping{
-			From: from,
-			To:   to,
+			From:            from,
+			To:              to,
+			AppliesTo:       appliesTo,
+			LineCase:        lineCase,
+			OccurrenceIndex: occurrence,
+			Comment:         columns.field(record, columns.comment),
+			Line:            line,
 		})
 	}
 
 	return mappings, nil
 }
 
+// validateAppliesTo rejects malformed globs at load time instead of letting
+// them silently never match during processing.
+func validateAppliesTo(pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	_, err := filepath.Match(pattern, "")
+	return err
+}
+
 func parseJSONMappings(reader io.Reader, filePath string) (*MappingTable, error) {
 	var mappings []Mapping
 
-	decoder := json.NewDecoder(reader)
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.NewParsingError(filePath, "failed to read JSON content", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
 	if err := decoder.Decode(&mappings); err != nil {
 		return nil, errors.NewParsingError(filePath, "failed to parse JSON", err)
 	}
+	if lines := jsonElementLines(content); len(lines) == len(mappings) {
+		for i := range mappings {
+			mappings[i].Line = lines[i]
+		}
+	}
 
 	if len(mappings) == 0 {
 		return nil, errors.NewParsingError(filePath, "no mappings found in JSON", nil)
 	}
 
-	var validMappings []Mapping
-	for _, mapping := range mappings {
-		if mapping.From == "" {
-			continue
+	validMappings, err := cleanMappings(mappings, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(validMappings) == 0 {
+		return nil, errors.NewParsingError(filePath, "no valid mappings found in JSON", nil)
+	}
+
+	return NewMappingTable(validMappings), nil
+}
+
+// jsonElementLines returns the line on which each element of the top-level
+// JSON array in content starts, or nil if content is not an array.
+func jsonElementLines(content []byte) []int {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil
+	}
+
+	var lines []int
+	for decoder.More() {
+		// The offset is just past the previous token: skip to the element
+		offset := int(decoder.InputOffset())
+		for offset < len(content) && strings.IndexByte(" \t\r\n,", content[offset]) >= 0 {
+			offset++
 		}
+		lines = append(lines, bytes.Count(content[:offset], []byte("\n"))+1)
 
-		if mapping.To == "" {
-			mapping.To = ""
+		var skipped json.RawMessage
+		if err := decoder.Decode(&skipped); err != nil {
+			return nil
 		}
+	}
+	return lines
+}
 
-		validMappings = append(validMappings, Mapping{
-			From: strings.TrimSpace(mapping.From),
-			To:   strings.TrimSpace(mapping.To),
-		})
+// parseYAMLMappings reads a list of {old, new, applies_to} entries. A file
+// may hold several YAML documents; their lists are concatenated.
+func parseYAMLMappings(reader io.Reader, filePath string) (*MappingTable, error) {
+	var mappings []Mapping
+
+	decoder := yaml.NewDecoder(reader)
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if err == io.EOF {
+			break
+		}
+		var document []Mapping
+		if err == nil {
+			err = node.Decode(&document)
+		}
+		if err != nil {
+			return nil, errors.NewParsingError(filePath, "failed to parse YAML", err)
+		}
+
+		// Decoding through the node keeps the line of each entry
+		sequence := &node
+		if sequence.Kind == yaml.DocumentNode && len(sequence.Content) > 0 {
+			sequence = sequence.Content[0]
+		}
+		if sequence.Kind == yaml.SequenceNode && len(sequence.Content) == len(document) {
+			for i := range document {
+				document[i].Line = sequence.Content[i].Line
+			}
+		}
+		mappings = append(mappings, document...)
+	}
+
+	if len(mappings) == 0 {
+		return nil, errors.NewParsingError(filePath, "no mappings found in YAML", nil)
+	}
+
+	validMappings, err := cleanMappings(mappings, filePath)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(validMappings) == 0 {
-		return nil, errors.NewParsingError(filePath, "no valid mappings found in JSON", nil)
+		return nil, errors.NewParsingError(filePath, "no valid mappings found in YAML", nil)
 	}
 
 	return NewMappingTable(validMappings), nil
 }
+
+// cleanMappings applies the rules shared by structured mapping files: entries
+// without a source are skipped, fields are trimmed, applies_to globs and
+// line_case values are validated, and regex sources are compiled.
+func cleanMappings(mappings []Mapping, filePath string) ([]Mapping, error) {
+	var validMappings []Mapping
+	for _, mapping := range mappings {
+		if mapping.From == "" {
+			continue
+		}
+
+		appliesTo := strings.TrimSpace(mapping.AppliesTo)
+		if err := validateAppliesTo(appliesTo); err != nil {
+			return nil, errors.NewParsingError(filePath, "invalid applies_to pattern: "+appliesTo, err)
+		}
+
+		lineCase := strings.ToLower(strings.TrimSpace(mapping.LineCase))
+		if err := validateLineCase(lineCase); err != nil {
+			return nil, errors.NewParsingError(filePath, "invalid line_case for "+mapping.From, err)
+		}
+
+		if err := validateOccurrence(mapping.OccurrenceIndex); err != nil {
+			return nil, errors.NewParsingError(filePath, "invalid occurrence for "+mapping.From, err)
+		}
+
+		cleaned := Mapping{
+			From:            strings.TrimSpace(mapping.From),
+			To:              strings.TrimSpace(mapping.To),
+			AppliesTo:       appliesTo,
+			LineCase:        lineCase,
+			OccurrenceIndex: mapping.OccurrenceIndex,
+			CaseSensitive:   mapping.CaseSensitive,
+			WholeWord:       mapping.WholeWord,
+			Regex:           mapping.Regex,
+			Comment:         strings.TrimSpace(mapping.Comment),
+			Line:            mapping.Line,
+		}
+		if cleaned.Regex {
+			if err := validateRegex(&cleaned); err != nil {
+				return nil, errors.NewParsingError(filePath, "invalid regex mapping "+cleaned.From, err)
+			}
+		}
+		validMappings = append(validMappings, cleaned)
+	}
+	return validMappings, nil
+}
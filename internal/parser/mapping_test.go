@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"os"
 	"strings"
 	"testing"
 )
@@ -158,3 +159,459 @@ func TestMappingTableSorting(t *testing.T) {
 		}
 	}
 }
+
+func TestMappingTableWithMappings(t *testing.T) {
+	base := NewMappingTable([]Mapping{{From: "foo", To: "bar"}})
+
+	combined := base.WithMappings([]Mapping{
+		{From: "foo", To: "ignored"},
+		{From: "longer", To: "short"},
+		{From: "", To: "empty"},
+	})
+
+	if combined.Size() != 2 {
+		t.Fatalf("expected 2 mappings, got %d", combined.Size())
+	}
+	if combined.GetMappings()[0].To != "bar" {
+		t.Errorf("explicit mapping should win, got %q", combined.GetMappings()[0].To)
+	}
+	if combined.GetSortedMappings()[0].From != "longer" {
+		t.Errorf("expected longest mapping first, got %q", combined.GetSortedMappings()[0].From)
+	}
+	if base.Size() != 1 {
+		t.Errorf("original table should be unchanged, got %d mappings", base.Size())
+	}
+}
+
+func TestMappingTableCollapseWhitespace(t *testing.T) {
+	base := NewMappingTable([]Mapping{
+		{From: "foo  bar", To: "a  b"},
+		{From: "x \t\n y", To: "z"},
+		{From: "tab\tkept", To: "t"},
+	})
+
+	collapsed := base.CollapseWhitespace()
+
+	expected := []string{"foo bar", "x y", "tab\tkept"}
+	for i, mapping := range collapsed.GetMappings() {
+		if mapping.From != expected[i] {
+			t.Errorf("mapping %d: expected From %q, got %q", i, expected[i], mapping.From)
+		}
+	}
+	if to := collapsed.GetMappings()[0].To; to != "a  b" {
+		t.Errorf("expected To to be left untouched, got %q", to)
+	}
+	if base.GetMappings()[0].From != "foo  bar" {
+		t.Errorf("original table should be unchanged, got %q", base.GetMappings()[0].From)
+	}
+}
+
+func TestParseMappingsAppliesTo(t *testing.T) {
+	csvTable, err := parseCSVMappings(strings.NewReader("old,new,applies_to\nfoo,bar,*.go\nhello,world,\n"), "test.csv")
+	if err != nil {
+		t.Fatalf("unexpected CSV error: %v", err)
+	}
+	jsonTable, err := parseJSONMappings(strings.NewReader(`[{"old": "foo", "new": "bar", "applies_to": "*.go"}, {"old": "hello", "new": "world"}]`), "test.json")
+	if err != nil {
+		t.Fatalf("unexpected JSON error: %v", err)
+	}
+
+	for name, table := range map[string]*MappingTable{"csv": csvTable, "json": jsonTable} {
+		mappings := table.GetMappings()
+		if len(mappings) != 2 {
+			t.Fatalf("%s: expected 2 mappings, got %d", name, len(mappings))
+		}
+		if mappings[0].AppliesTo != "*.go" {
+			t.Errorf("%s: expected applies_to '*.go', got %q", name, mappings[0].AppliesTo)
+		}
+		if mappings[1].AppliesTo != "" {
+			t.Errorf("%s: expected unscoped mapping, got %q", name, mappings[1].AppliesTo)
+		}
+	}
+
+	if _, err := parseCSVMappings(strings.NewReader("old,new,applies_to\nfoo,bar,[\n"), "test.csv"); err == nil {
+		t.Error("expected error for invalid CSV applies_to pattern")
+	}
+	if _, err := parseJSONMappings(strings.NewReader(`[{"old": "foo", "new": "bar", "applies_to": "["}]`), "test.json"); err == nil {
+		t.Error("expected error for invalid JSON applies_to pattern")
+	}
+}
+
+func TestParseMappingsLineCase(t *testing.T) {
+	csvTable, err := parseCSVMappings(strings.NewReader("old,new,line_case\nwarning,,Upper\nfoo,bar,\n"), "test.csv")
+	if err != nil {
+		t.Fatalf("unexpected CSV error: %v", err)
+	}
+	yamlTable, err := parseYAMLMappings(strings.NewReader("- old: warning\n  line_case: upper\n- old: foo\n  new: bar\n"), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected YAML error: %v", err)
+	}
+
+	for name, table := range map[string]*MappingTable{"csv": csvTable, "yaml": yamlTable} {
+		mappings := table.GetMappings()
+		if mappings[0].LineCase != LineCaseUpper {
+			t.Errorf("%s: expected line_case %q, got %q", name, LineCaseUpper, mappings[0].LineCase)
+		}
+		if mappings[1].LineCase != "" {
+			t.Errorf("%s: expected a normal mapping, got line_case %q", name, mappings[1].LineCase)
+		}
+	}
+
+	if _, err := parseCSVMappings(strings.NewReader("old,new,line_case\nfoo,,shout\n"), "test.csv"); err == nil {
+		t.Error("expected error for an unknown CSV line_case")
+	}
+	if _, err := parseJSONMappings(strings.NewReader(`[{"old": "foo", "line_case": "shout"}]`), "test.json"); err == nil {
+		t.Error("expected error for an unknown JSON line_case")
+	}
+}
+
+func TestParseMappingsOccurrence(t *testing.T) {
+	csvTable, err := parseCSVMappings(strings.NewReader("old,new,occurrence\nfoo,bar,2\nhello,world,\n"), "test.csv")
+	if err != nil {
+		t.Fatalf("unexpected CSV error: %v", err)
+	}
+	jsonTable, err := parseJSONMappings(strings.NewReader(`[{"old": "foo", "new": "bar", "occurrence": 2}, {"old": "hello", "new": "world"}]`), "test.json")
+	if err != nil {
+		t.Fatalf("unexpected JSON error: %v", err)
+	}
+
+	for name, table := range map[string]*MappingTable{"csv": csvTable, "json": jsonTable} {
+		mappings := table.GetMappings()
+		if mappings[0].OccurrenceIndex != 2 || mappings[1].OccurrenceIndex != 0 {
+			t.Errorf("%s: expected occurrences 2 and 0, got %d and %d", name, mappings[0].OccurrenceIndex, mappings[1].OccurrenceIndex)
+		}
+	}
+
+	for _, content := range []string{"old,new,occurrence\nfoo,bar,second\n", "old,new,occurrence\nfoo,bar,-1\n"} {
+		if _, err := parseCSVMappings(strings.NewReader(content), "test.csv"); err == nil {
+			t.Errorf("expected error for %q", content)
+		}
+	}
+}
+
+func TestParseMappingsRuleOptions(t *testing.T) {
+	jsonTable, err := parseJSONMappings(strings.NewReader(`[
+		{"old": "Foo", "new": "Bar", "case_sensitive": true},
+		{"old": "id", "new": "key", "whole_word": false},
+		{"old": "v(\\d+)", "new": "version $1", "regex": true},
+		{"old": "plain", "new": "text"}
+	]`), "test.json")
+	if err != nil {
+		t.Fatalf("unexpected JSON error: %v", err)
+	}
+	yamlTable, err := parseYAMLMappings(strings.NewReader("- old: Foo\n  new: Bar\n  case_sensitive: true\n- old: id\n  new: key\n  whole_word: false\n- old: 'v(\\d+)'\n  new: version $1\n  regex: true\n- old: plain\n  new: text\n"), "test.yaml")
+	if err != nil {
+		t.Fatalf("unexpected YAML error: %v", err)
+	}
+
+	for name, table := range map[string]*MappingTable{"json": jsonTable, "yaml": yamlTable} {
+		mappings := table.GetMappings()
+		if !mappings[0].IsCaseSensitive(false) || mappings[3].IsCaseSensitive(false) || !mappings[3].IsCaseSensitive(true) {
+			t.Errorf("%s: case_sensitive should override the global setting only where set", name)
+		}
+		if mappings[1].IsWholeWord(true) || !mappings[3].IsWholeWord(true) {
+			t.Errorf("%s: whole_word should override the global setting only where set", name)
+		}
+		if !mappings[2].Regex || mappings[2].Regexp(true) == nil || !mappings[2].Regexp(false).MatchString("V2") {
+			t.Errorf("%s: expected a compiled regex matching case-insensitively", name)
+		}
+	}
+
+	for _, content := range []string{
+		`[{"old": "v(", "new": "x", "regex": true}]`,
+		`[{"old": "v\\d", "new": "x", "regex": true, "occurrence": 2}]`,
+		`[{"old": "v\\d", "new": "", "regex": true, "line_case": "upper"}]`,
+	} {
+		if _, err := parseJSONMappings(strings.NewReader(content), "test.json"); err == nil {
+			t.Errorf("expected error for %s", content)
+		}
+	}
+}
+
+func TestParseMappingsComment(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		input    string
+		comments []string
+	}{
+		{
+			name:     "comment header",
+			format:   "csv",
+			input:    "old,new,comment\nfoo,bar,legacy name\nhello,world\n",
+			comments: []string{"legacy name", ""},
+		},
+		{
+			name:     "description header",
+			format:   "csv",
+			input:    "source,destination,applies_to,description\nfoo,bar,*.go, renamed in v2\n",
+			comments: []string{"renamed in v2"},
+		},
+		{
+			name:     "third column without a header",
+			format:   "csv",
+			input:    "foo,bar,legacy name\nhello,world\n",
+			comments: []string{"legacy name", ""},
+		},
+		{
+			name:     "header without a comment column",
+			format:   "csv",
+			input:    "old,new,applies_to\nfoo,bar,*.go\n",
+			comments: []string{""},
+		},
+		{
+			name:     "json",
+			format:   "json",
+			input:    `[{"old": "foo", "new": "bar", "comment": "legacy name"}, {"old": "hello", "new": "world"}]`,
+			comments: []string{"legacy name", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := parseMappings(strings.NewReader(tt.input), "test."+tt.format, tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			mappings := table.GetMappings()
+			if len(mappings) != len(tt.comments) {
+				t.Fatalf("expected %d mappings, got %d", len(tt.comments), len(mappings))
+			}
+			for i, mapping := range mappings {
+				if mapping.Comment != tt.comments[i] {
+					t.Errorf("mapping %d: expected comment %q, got %q", i, tt.comments[i], mapping.Comment)
+				}
+			}
+		})
+	}
+
+	if _, err := parseCSVMappings(strings.NewReader("old,new,comment\nfoo\n"), "test.csv"); err == nil {
+		t.Error("expected a row with too few fields to be rejected")
+	}
+}
+
+func TestMappingAppliesToPath(t *testing.T) {
+	tests := []struct {
+		appliesTo string
+		path      string
+		expected  bool
+	}{
+		{"", "/src/main.txt", true},
+		{"*.go", "/src/main.go", true},
+		{"*.go", "/src/main.txt", false},
+		{"/src/*.txt", "/src/main.txt", true},
+		{"/other/*.txt", "/src/main.txt", false},
+	}
+
+	for _, tt := range tests {
+		mapping := Mapping{From: "a", To: "b", AppliesTo: tt.appliesTo}
+		if result := mapping.AppliesToPath(tt.path); result != tt.expected {
+			t.Errorf("AppliesToPath(%q) with %q = %v, expected %v", tt.path, tt.appliesTo, result, tt.expected)
+		}
+	}
+}
+
+func TestMappingValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		mapping     Mapping
+		expectError bool
+	}{
+		{"plain", Mapping{From: " foo ", To: "bar"}, false},
+		{"regex", Mapping{From: `v(\d+)`, To: "$1", Regex: true}, false},
+		{"empty source", Mapping{To: "bar"}, true},
+		{"bad applies_to", Mapping{From: "foo", AppliesTo: "["}, true},
+		{"bad line_case", Mapping{From: "foo", LineCase: "camel"}, true},
+		{"negative occurrence", Mapping{From: "foo", OccurrenceIndex: -1}, true},
+		{"bad regex", Mapping{From: "(", Regex: true}, true},
+		{"regex with occurrence", Mapping{From: "a+", Regex: true, OccurrenceIndex: 2}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.mapping.Validate()
+			if tt.expectError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestParseYAMLMappings(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectError bool
+		expectCount int
+	}{
+		{
+			name:        "valid YAML",
+			input:       "- old: foo\n  new: bar\n- old: hello\n  new: world\n  applies_to: \"*.go\"\n",
+			expectCount: 2,
+		},
+		{
+			name:        "several documents",
+			input:       "- old: foo\n  new: bar\n---\n- old: hello\n  new: world\n",
+			expectCount: 2,
+		},
+		{
+			name:        "empty old field skipped and fields trimmed",
+			input:       "- old: \"\"\n  new: bar\n- old: \" hello \"\n  new: world\n",
+			expectCount: 1,
+		},
+		{
+			name:        "empty document set",
+			input:       "",
+			expectError: true,
+		},
+		{
+			name:        "empty list",
+			input:       "[]\n",
+			expectError: true,
+		},
+		{
+			name:        "malformed YAML",
+			input:       "- old: foo\n  new: [bar\n",
+			expectError: true,
+		},
+		{
+			name:        "not a list",
+			input:       "old: foo\nnew: bar\n",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := parseYAMLMappings(strings.NewReader(tt.input), "test.yaml")
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if table.Size() != tt.expectCount {
+				t.Errorf("expected %d mappings, got %d", tt.expectCount, table.Size())
+			}
+			for _, mapping := range table.GetMappings() {
+				if mapping.From != strings.TrimSpace(mapping.From) {
+					t.Errorf("expected trimmed source, got %q", mapping.From)
+				}
+			}
+		})
+	}
+}
+
+func TestNewMappingTableFromPairs(t *testing.T) {
+	tests := []struct {
+		name        string
+		pairs       []string
+		expectError bool
+		expected    []Mapping
+	}{
+		{
+			name:     "simple pairs",
+			pairs:    []string{"foo=bar", "hello=hi"},
+			expected: []Mapping{{From: "foo", To: "bar"}, {From: "hello", To: "hi"}},
+		},
+		{
+			name:     "value containing equals",
+			pairs:    []string{"a=b=c", "key==value"},
+			expected: []Mapping{{From: "a", To: "b=c"}, {From: "key", To: "=value"}},
+		},
+		{
+			name:     "empty replacement",
+			pairs:    []string{"debug="},
+			expected: []Mapping{{From: "debug", To: ""}},
+		},
+		{name: "missing separator", pairs: []string{"foo=bar", "foobar"}, expectError: true},
+		{name: "empty source", pairs: []string{"=bar"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := NewMappingTableFromPairs(tt.pairs)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			mappings := table.GetMappings()
+			if len(mappings) != len(tt.expected) {
+				t.Fatalf("expected %d mappings, got %d", len(tt.expected), len(mappings))
+			}
+			for i, expected := range tt.expected {
+				if mappings[i].From != expected.From || mappings[i].To != expected.To {
+					t.Errorf("mapping %d: expected %q -> %q, got %q -> %q",
+						i, expected.From, expected.To, mappings[i].From, mappings[i].To)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadMappingTableFromStdin(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		input       string
+		expectError bool
+		expectCount int
+	}{
+		{
+			name:        "CSV with comments and header",
+			format:      "csv",
+			input:       "# generated by CI\nold,new\nfoo,bar\n# trailing note\nhello,world\n",
+			expectCount: 2,
+		},
+		{
+			name:        "CSV without header",
+			format:      "csv",
+			input:       "foo,bar\n",
+			expectCount: 1,
+		},
+		{
+			name:        "JSON",
+			format:      "json",
+			input:       `[{"old": "foo", "new": "bar"}]`,
+			expectCount: 1,
+		},
+		{
+			name:        "empty input",
+			format:      "csv",
+			input:       "",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin = strings.NewReader(tt.input)
+			defer func() { stdin = os.Stdin }()
+
+			table, err := LoadMappingTable(StdinPath, tt.format)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if table.Size() != tt.expectCount {
+				t.Errorf("expected %d mappings, got %d", tt.expectCount, table.Size())
+			}
+		})
+	}
+}
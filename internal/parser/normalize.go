@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"remap/internal/errors"
+)
+
+// Conflict records a source string mapped to different destinations within
+// the same scope. The first destination in file order is the one kept.
+type Conflict struct {
+	From      string
+	AppliesTo string
+	Kept      string
+	Dropped   string
+}
+
+// Normalize returns the table's mappings in canonical form: exact duplicates
+// are removed, conflicting rules keep their first destination, and the result
+// is ordered like GetSortedMappings (longest source first) with ties broken
+// alphabetically so the output is stable across runs.
+func (mt *MappingTable) Normalize() ([]Mapping, []Conflict) {
+	type scopedKey struct{ from, appliesTo string }
+
+	kept := make(map[scopedKey]Mapping)
+	var conflicts []Conflict
+
+	for _, mapping := range mt.mappings {
+		key := scopedKey{mapping.From, mapping.AppliesTo}
+		existing, seen := kept[key]
+		if !seen {
+			kept[key] = mapping
+			continue
+		}
+		if existing.To != mapping.To {
+			conflicts = append(conflicts, Conflict{
+				From:      mapping.From,
+				AppliesTo: mapping.AppliesTo,
+				Kept:      existing.To,
+				Dropped:   mapping.To,
+			})
+		}
+	}
+
+	var normalized []Mapping
+	for _, mapping := range mt.GetSortedMappings() {
+		key := scopedKey{mapping.From, mapping.AppliesTo}
+		if first, ok := kept[key]; ok && first.Index == mapping.Index {
+			normalized = append(normalized, mapping)
+		}
+	}
+
+	sort.SliceStable(normalized, func(i, j int) bool {
+		a, b := normalized[i], normalized[j]
+		if len(a.From) != len(b.From) {
+			return len(a.From) > len(b.From)
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.AppliesTo < b.AppliesTo
+	})
+
+	return normalized, conflicts
+}
+
+// WriteMappings writes mappings in the given format ("csv" or "json") using
+// the same layout LoadMappingTable reads. The applies_to, line_case and
+// occurrence columns are only emitted when at least one mapping uses them.
+func WriteMappings(writer io.Writer, mappings []Mapping, format string) error {
+	switch format {
+	case "csv":
+		return writeCSVMappings(writer, mappings)
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(mappings); err != nil {
+			return errors.NewParsingError("", "failed to encode JSON mappings", err)
+		}
+		return nil
+	default:
+		return errors.NewParsingError("", fmt.Sprintf("unsupported format: %s", format), nil)
+	}
+}
+
+func writeCSVMappings(writer io.Writer, mappings []Mapping) error {
+	scoped, lineCased, counted, commented := false, false, false, false
+	for _, mapping := range mappings {
+		scoped = scoped || mapping.AppliesTo != ""
+		lineCased = lineCased || mapping.LineCase != ""
+		counted = counted || mapping.OccurrenceIndex != 0
+		commented = commented || mapping.Comment != ""
+	}
+
+	csvWriter := csv.NewWriter(writer)
+
+	header := []string{"old", "new"}
+	if scoped {
+		header = append(header, "applies_to")
+	}
+	if lineCased {
+		header = append(header, "line_case")
+	}
+	if counted {
+		header = append(header, "occurrence")
+	}
+	if commented {
+		header = append(header, "comment")
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return errors.NewParsingError("", "failed to write CSV header", err)
+	}
+
+	for _, mapping := range mappings {
+		record := []string{mapping.From, mapping.To}
+		if scoped {
+			record = append(record, mapping.AppliesTo)
+		}
+		if lineCased {
+			record = append(record, mapping.LineCase)
+		}
+		if counted {
+			occurrence := ""
+			if mapping.OccurrenceIndex != 0 {
+				occurrence = strconv.Itoa(mapping.OccurrenceIndex)
+			}
+			record = append(record, occurrence)
+		}
+		if commented {
+			record = append(record, mapping.Comment)
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return errors.NewParsingError("", "failed to write CSV mapping", err)
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return errors.NewParsingError("", "failed to write CSV mappings", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMappingTableNormalize(t *testing.T) {
+	table := NewMappingTable([]Mapping{
+		{From: "b", To: "2"},
+		{From: "foo", To: "bar"},
+		{From: "a", To: "1"},
+		{From: "foo", To: "bar"},
+		{From: "foo", To: "baz"},
+		{From: "foo", To: "qux", AppliesTo: "*.go"},
+	})
+
+	mappings, conflicts := table.Normalize()
+
+	expected := []Mapping{
+		{From: "foo", To: "bar"},
+		{From: "foo", To: "qux", AppliesTo: "*.go"},
+		{From: "a", To: "1"},
+		{From: "b", To: "2"},
+	}
+	if len(mappings) != len(expected) {
+		t.Fatalf("expected %d mappings, got %d: %v", len(expected), len(mappings), mappings)
+	}
+	for i, mapping := range expected {
+		got := mappings[i]
+		if got.From != mapping.From || got.To != mapping.To || got.AppliesTo != mapping.AppliesTo {
+			t.Errorf("mappings[%d] = %+v, expected %+v", i, got, mapping)
+		}
+	}
+
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %d: %v", len(conflicts), conflicts)
+	}
+	if conflicts[0].Kept != "bar" || conflicts[0].Dropped != "baz" {
+		t.Errorf("unexpected conflict: %+v", conflicts[0])
+	}
+}
+
+func TestWriteMappings(t *testing.T) {
+	mappings := []Mapping{{From: "foo", To: "bar"}, {From: "a,b", To: ""}}
+
+	var csvOut bytes.Buffer
+	if err := WriteMappings(&csvOut, mappings, "csv"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "old,new\nfoo,bar\n\"a,b\",\n"; csvOut.String() != expected {
+		t.Errorf("CSV output = %q, expected %q", csvOut.String(), expected)
+	}
+
+	var jsonOut bytes.Buffer
+	if err := WriteMappings(&jsonOut, mappings, "json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	table, err := parseJSONMappings(&jsonOut, "out.json")
+	if err != nil {
+		t.Fatalf("JSON output does not load back: %v", err)
+	}
+	if table.Size() != 2 {
+		t.Errorf("expected 2 mappings after round trip, got %d", table.Size())
+	}
+
+	if err := WriteMappings(&bytes.Buffer{}, mappings, "xml"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestWriteMappingsComments(t *testing.T) {
+	mappings := []Mapping{
+		{From: "foo", To: "bar", Comment: "renamed in v2"},
+		{From: "old", To: "new", LineCase: LineCaseUpper},
+	}
+
+	var csvOut bytes.Buffer
+	if err := WriteMappings(&csvOut, mappings, "csv"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "old,new,line_case,comment\nfoo,bar,,renamed in v2\nold,new,upper,\n"
+	if csvOut.String() != expected {
+		t.Errorf("CSV output = %q, expected %q", csvOut.String(), expected)
+	}
+
+	table, err := parseCSVMappings(&csvOut, "out.csv")
+	if err != nil {
+		t.Fatalf("CSV output does not load back: %v", err)
+	}
+	loaded := table.GetMappings()
+	if len(loaded) != 2 {
+		t.Fatalf("expected 2 mappings after round trip, got %d", len(loaded))
+	}
+	if loaded[0].Comment != "renamed in v2" || loaded[1].Comment != "" {
+		t.Errorf("comments not preserved: %q, %q", loaded[0].Comment, loaded[1].Comment)
+	}
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// specificity ranks how narrowly a mapping is scoped. Scoped mappings beat
+// unscoped ones, deeper directory globs beat shallower ones, and among globs
+// of equal depth the one with more literal characters is the narrower.
+type specificity struct {
+	scoped  bool
+	depth   int
+	literal int
+}
+
+func (m Mapping) specificity() specificity {
+	if m.AppliesTo == "" {
+		return specificity{}
+	}
+
+	pattern := filepath.ToSlash(m.AppliesTo)
+	literal := 0
+	for _, r := range pattern {
+		if !strings.ContainsRune("*?[]", r) {
+			literal++
+		}
+	}
+
+	return specificity{scoped: true, depth: strings.Count(pattern, "/"), literal: literal}
+}
+
+// moreSpecific reports whether a should win over b for the same source text.
+// Equal specificity falls back to file order so resolution is deterministic.
+func moreSpecific(a, b Mapping) bool {
+	sa, sb := a.specificity(), b.specificity()
+	if sa.scoped != sb.scoped {
+		return sa.scoped
+	}
+	if sa.depth != sb.depth {
+		return sa.depth > sb.depth
+	}
+	if sa.literal != sb.literal {
+		return sa.literal > sb.literal
+	}
+	return a.Index < b.Index
+}
+
+// ForPath returns the mappings that apply to path, in replacement order
+// (longest source first). When several mappings share a source text (compared
+// case-insensitively unless caseSensitive or the mapping's own case_sensitive
+// says otherwise), only the most specific one is kept, so a rule scoped to a
+// directory overrides a conflicting global rule. Regex sources only conflict
+// with each other.
+func (mt *MappingTable) ForPath(path string, caseSensitive bool) []Mapping {
+	winners := make(map[string]int)
+	var resolved []Mapping
+
+	for _, mapping := range mt.sorted {
+		if !mapping.AppliesToPath(path) {
+			continue
+		}
+
+		key := mapping.From
+		if !mapping.IsCaseSensitive(caseSensitive) {
+			key = strings.ToLower(key)
+		}
+		if mapping.Regex {
+			key = "regex:" + key
+		}
+
+		if i, seen := winners[key]; seen {
+			if moreSpecific(mapping, resolved[i]) {
+				resolved[i] = mapping
+			}
+			continue
+		}
+
+		winners[key] = len(resolved)
+		resolved = append(resolved, mapping)
+	}
+
+	return resolved
+}
@@ -0,0 +1,56 @@
+package parser
+
+import "testing"
+
+func TestMappingTableForPath(t *testing.T) {
+	table := NewMappingTable([]Mapping{
+		{From: "db.old", To: "db.global"},
+		{From: "db.old", To: "db.go", AppliesTo: "*.go"},
+		{From: "db.old", To: "db.legacy", AppliesTo: "/src/legacy/*.go"},
+		{From: "DB.OLD", To: "db.shouted"},
+		{From: "cache", To: "redis"},
+	})
+
+	tests := []struct {
+		name          string
+		path          string
+		caseSensitive bool
+		expected      map[string]string
+	}{
+		{
+			name:     "directory-local rule wins",
+			path:     "/src/legacy/main.go",
+			expected: map[string]string{"db.old": "db.legacy", "cache": "redis"},
+		},
+		{
+			name:     "glob-scoped rule wins over global",
+			path:     "/src/app/main.go",
+			expected: map[string]string{"db.old": "db.go", "cache": "redis"},
+		},
+		{
+			name:     "global rule elsewhere, first in file order",
+			path:     "/src/app/notes.txt",
+			expected: map[string]string{"db.old": "db.global", "cache": "redis"},
+		},
+		{
+			name:          "case-sensitive sources do not conflict",
+			path:          "/src/app/notes.txt",
+			caseSensitive: true,
+			expected:      map[string]string{"db.old": "db.global", "DB.OLD": "db.shouted", "cache": "redis"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved := table.ForPath(tt.path, tt.caseSensitive)
+			if len(resolved) != len(tt.expected) {
+				t.Fatalf("expected %d mappings, got %d: %v", len(tt.expected), len(resolved), resolved)
+			}
+			for _, mapping := range resolved {
+				if want := tt.expected[mapping.From]; mapping.To != want {
+					t.Errorf("%q resolved to %q, expected %q", mapping.From, mapping.To, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"remap/internal/errors"
+)
+
+// parsePropertiesMappings reads "from = to" lines. Blank lines and lines
+// starting with # are ignored, and a backslash escapes the next character so
+// keys and values may contain "=" or "#" (\n and \t stand for a newline and a tab).
+func parsePropertiesMappings(reader io.Reader, filePath string) (*MappingTable, error) {
+	var mappings []Mapping
+
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		from, to, ok := splitProperty(line)
+		if !ok {
+			return nil, errors.NewParsingError(filePath, fmt.Sprintf("invalid properties line %d: expected 'from = to'", lineNum), nil)
+		}
+		if from == "" {
+			continue
+		}
+
+		mappings = append(mappings, Mapping{From: from, To: to, Line: lineNum})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.NewParsingError(filePath, "failed to read properties content", err)
+	}
+
+	if len(mappings) == 0 {
+		return nil, errors.NewParsingError(filePath, "no valid mappings found in properties file", nil)
+	}
+
+	return NewMappingTable(mappings), nil
+}
+
+// splitProperty splits a line at its first unescaped "=" and unescapes both
+// sides, trimming the whitespace around them. It reports false when the line
+// has no separator.
+func splitProperty(line string) (string, string, bool) {
+	var key, value strings.Builder
+	current := &key
+	separated := false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line):
+			i++
+			switch line[i] {
+			case 'n':
+				current.WriteByte('\n')
+			case 't':
+				current.WriteByte('\t')
+			default:
+				current.WriteByte(line[i])
+			}
+		case c == '=' && !separated:
+			separated = true
+			current = &value
+		default:
+			current.WriteByte(c)
+		}
+	}
+
+	return strings.TrimSpace(key.String()), strings.TrimSpace(value.String()), separated
+}
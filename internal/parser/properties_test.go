@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePropertiesMappings(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectError bool
+		expected    []Mapping
+	}{
+		{
+			name:  "comments and blank lines",
+			input: "# hosts\n\nold.example.com = new.example.com\n  # indented comment\nfoo=bar\n",
+			expected: []Mapping{
+				{From: "old.example.com", To: "new.example.com"},
+				{From: "foo", To: "bar"},
+			},
+		},
+		{
+			name:  "escaped equals signs",
+			input: "a\\=b = c\\=d\nx = y = z\n",
+			expected: []Mapping{
+				{From: "a=b", To: "c=d"},
+				{From: "x", To: "y = z"},
+			},
+		},
+		{
+			name:  "other escapes",
+			input: "\\#tag = \\#label\nC:\\\\tmp = /tmp\nBEGIN = first\\n  second\n",
+			expected: []Mapping{
+				{From: "#tag", To: "#label"},
+				{From: "C:\\tmp", To: "/tmp"},
+				{From: "BEGIN", To: "first\n  second"},
+			},
+		},
+		{
+			name:     "empty value deletes",
+			input:    "debug =\n",
+			expected: []Mapping{{From: "debug", To: ""}},
+		},
+		{
+			name:        "missing separator",
+			input:       "foo bar\n",
+			expectError: true,
+		},
+		{
+			name:        "only comments",
+			input:       "# nothing\n",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := parsePropertiesMappings(strings.NewReader(tt.input), "test.properties")
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			mappings := table.GetMappings()
+			if len(mappings) != len(tt.expected) {
+				t.Fatalf("expected %d mappings, got %d", len(tt.expected), len(mappings))
+			}
+			for i, expected := range tt.expected {
+				if mappings[i].From != expected.From || mappings[i].To != expected.To {
+					t.Errorf("mapping %d: expected %q -> %q, got %q -> %q",
+						i, expected.From, expected.To, mappings[i].From, mappings[i].To)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package replacement
+
+import (
+	"fmt"
+
+	"remap/internal/config"
+	"remap/internal/errors"
+	"remap/internal/parser"
+)
+
+// Options select how ReplaceString matches. They mirror the command-line
+// flags of the same names, and a mapping's own case_sensitive and whole_word
+// fields still take precedence over them.
+type Options struct {
+	CaseSensitive bool // --case-sensitive
+	WordBoundary  bool // --word-boundary: matches must not touch word characters
+	PreserveCase  bool // --preserve-case: ignored when matching case-sensitively
+	Regex         bool // treat every mapping's source as a regular expression
+}
+
+// ReplaceString runs mappings over content in memory, the way the command
+// replaces a file's content, and returns the new content together with the
+// replacements made. Nothing is read from or written to disk. Mappings are
+// validated as mapping files are and are not modified; as content has no
+// path, mappings scoped with applies_to never match. An invalid mapping
+// returns an error and content as is.
+func ReplaceString(content string, mappings []parser.Mapping, opts Options) (string, []Replacement, error) {
+	validated := make([]parser.Mapping, len(mappings))
+	for i, mapping := range mappings {
+		mapping.Regex = mapping.Regex || opts.Regex
+		if err := mapping.Validate(); err != nil {
+			return content, nil, errors.NewReplacementError("", fmt.Sprintf("invalid mapping %d (%q): %v", i+1, mapping.From, err), err)
+		}
+		validated[i] = mapping
+	}
+
+	cfg := &config.Config{
+		CaseSensitive: opts.CaseSensitive,
+		WordBoundary:  opts.WordBoundary,
+		PreserveCase:  opts.PreserveCase,
+		// Diff makes the engine hand back the new content
+		Diff: true,
+	}
+
+	result := NewEngine(cfg).ProcessFile("", []byte(content), parser.NewMappingTable(validated))
+	if result.Error != nil {
+		return content, nil, result.Error
+	}
+	if !result.Modified || result.NewContent == nil {
+		return content, nil, nil
+	}
+	return string(result.NewContent), result.Replacements, nil
+}
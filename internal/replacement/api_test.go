@@ -0,0 +1,129 @@
+package replacement
+
+import (
+	"testing"
+
+	"remap/internal/parser"
+)
+
+func TestReplaceString(t *testing.T) {
+	caseSensitive := true
+
+	tests := []struct {
+		name         string
+		content      string
+		mappings     []parser.Mapping
+		opts         Options
+		expected     string
+		replacements int
+		expectError  bool
+	}{
+		{
+			name:         "case insensitive by default",
+			content:      "Foo foo FOO",
+			mappings:     []parser.Mapping{{From: "foo", To: "bar"}},
+			expected:     "bar bar bar",
+			replacements: 3,
+		},
+		{
+			name:         "case sensitive",
+			content:      "Foo foo FOO",
+			mappings:     []parser.Mapping{{From: "foo", To: "bar"}},
+			opts:         Options{CaseSensitive: true},
+			expected:     "Foo bar FOO",
+			replacements: 1,
+		},
+		{
+			name:         "preserve case",
+			content:      "Foo foo FOO",
+			mappings:     []parser.Mapping{{From: "foo", To: "bar"}},
+			opts:         Options{PreserveCase: true},
+			expected:     "Bar bar BAR",
+			replacements: 3,
+		},
+		{
+			name:         "word boundary",
+			content:      "id idle id",
+			mappings:     []parser.Mapping{{From: "id", To: "key"}},
+			opts:         Options{WordBoundary: true},
+			expected:     "key idle key",
+			replacements: 2,
+		},
+		{
+			name:         "regex option",
+			content:      "v1.2 and v3.4\n",
+			mappings:     []parser.Mapping{{From: `v(\d+)\.(\d+)`, To: "$1-$2"}},
+			opts:         Options{Regex: true},
+			expected:     "1-2 and 3-4\n",
+			replacements: 2,
+		},
+		{
+			name:         "mapping overrides options",
+			content:      "Foo foo",
+			mappings:     []parser.Mapping{{From: "foo", To: "bar", CaseSensitive: &caseSensitive}},
+			expected:     "Foo bar",
+			replacements: 1,
+		},
+
+		{
+			name:     "no match",
+			content:  "nothing here",
+			mappings: []parser.Mapping{{From: "foo", To: "bar"}},
+			expected: "nothing here",
+		},
+		{
+			name:     "scoped mapping never matches",
+			content:  "foo",
+			mappings: []parser.Mapping{{From: "foo", To: "bar", AppliesTo: "*.go"}},
+			expected: "foo",
+		},
+		{
+			name:        "invalid regex",
+			content:     "foo",
+			mappings:    []parser.Mapping{{From: "(", To: "bar"}},
+			opts:        Options{Regex: true},
+			expected:    "foo",
+			expectError: true,
+		},
+		{
+			name:        "empty source",
+			content:     "foo",
+			mappings:    []parser.Mapping{{From: "", To: "bar"}},
+			expected:    "foo",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, replacements, err := ReplaceString(tt.content, tt.mappings, tt.opts)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+			if len(replacements) != tt.replacements {
+				t.Errorf("expected %d replacements, got %d", tt.replacements, len(replacements))
+			}
+		})
+	}
+}
+
+func TestReplaceStringKeepsMappings(t *testing.T) {
+	mappings := []parser.Mapping{{From: "a+", To: "b"}}
+
+	result, replacements, err := ReplaceString("caaat", mappings, Options{Regex: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "cbt" {
+		t.Errorf("expected %q, got %q", "cbt", result)
+	}
+	if len(replacements) != 1 || replacements[0].OriginalText != "aaa" || replacements[0].Column != 2 {
+		t.Errorf("unexpected replacements: %+v", replacements)
+	}
+	if mappings[0].Regex {
+		t.Error("ReplaceString modified the caller's mappings")
+	}
+}
@@ -0,0 +1,84 @@
+package replacement
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MatchCase returns to in the casing of matched, the text it replaces:
+// an all-caps match gives an all-caps destination, a capitalized match a
+// capitalized one, and a lowercase match leaves to as written. Any other
+// mix is copied letter by letter, with letters of to past the end of
+// matched following the case of its last letter.
+func MatchCase(matched, to string) string {
+	var upper, lower int
+	firstUpper := false
+	for _, r := range matched {
+		switch {
+		case unicode.IsUpper(r):
+			if upper+lower == 0 {
+				firstUpper = true
+			}
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+
+	switch {
+	case upper == 0:
+		return to
+	case firstUpper && upper == 1:
+		return capitalize(to)
+	case lower == 0:
+		return strings.ToUpper(to)
+	}
+	return copyCase(matched, to)
+}
+
+// capitalize upper-cases the first letter of s.
+func capitalize(s string) string {
+	for i, r := range s {
+		if unicode.IsLetter(r) {
+			return s[:i] + string(unicode.ToUpper(r)) + s[i+len(string(r)):]
+		}
+	}
+	return s
+}
+
+// copyCase gives each letter of to the case of the rune at the same position
+// in matched, or of the last cased letter of matched once matched runs out.
+func copyCase(matched, to string) string {
+	pattern := []rune(matched)
+	lastUpper := false
+	for _, r := range pattern {
+		if unicode.IsUpper(r) {
+			lastUpper = true
+		} else if unicode.IsLower(r) {
+			lastUpper = false
+		}
+	}
+
+	var result strings.Builder
+	i := 0
+	for _, r := range to {
+		upper := lastUpper
+		if i < len(pattern) {
+			switch {
+			case unicode.IsUpper(pattern[i]):
+				upper = true
+			case unicode.IsLower(pattern[i]):
+				upper = false
+			default:
+				upper = unicode.IsUpper(r)
+			}
+		}
+		if upper {
+			result.WriteRune(unicode.ToUpper(r))
+		} else {
+			result.WriteRune(unicode.ToLower(r))
+		}
+		i++
+	}
+	return result.String()
+}
@@ -0,0 +1,71 @@
+package replacement
+
+import (
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestMatchCase(t *testing.T) {
+	tests := []struct {
+		name     string
+		matched  string
+		to       string
+		expected string
+	}{
+		{name: "lowercase keeps destination", matched: "color", to: "colour", expected: "colour"},
+		{name: "lowercase keeps destination casing", matched: "foo", to: "BarBaz", expected: "BarBaz"},
+		{name: "title case", matched: "Color", to: "colour", expected: "Colour"},
+		{name: "all caps longer destination", matched: "COLOR", to: "colour", expected: "COLOUR"},
+		{name: "all caps shorter destination", matched: "COLOUR", to: "color", expected: "COLOR"},
+		{name: "single capital letter", matched: "X", to: "why", expected: "Why"},
+		{name: "mixed case extends last letter", matched: "CoLoR", to: "colour", expected: "CoLoUR"},
+		{name: "camel case", matched: "fooBar", to: "bazqux", expected: "bazQux"},
+		{name: "mixed case shorter destination", matched: "heLLo", to: "hi", expected: "hi"},
+		{name: "title case with leading punctuation", matched: "-Color", to: "-colour", expected: "-Colour"},
+		{name: "no letters", matched: "123", to: "abc", expected: "abc"},
+		{name: "non-ASCII", matched: "ÉTÉ", to: "été", expected: "ÉTÉ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchCase(tt.matched, tt.to); got != tt.expected {
+				t.Errorf("MatchCase(%q, %q) = %q, want %q", tt.matched, tt.to, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEnginePreserveCase(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{{From: "color", To: "colour"}})
+	content := []byte("color Color COLOR CoLoR\n")
+
+	tests := []struct {
+		name         string
+		wordBoundary bool
+	}{
+		{name: "plain replace"},
+		{name: "match options", wordBoundary: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{DryRun: true, Diff: true, PreserveCase: true, WordBoundary: tt.wordBoundary}
+			result := NewEngine(cfg).ProcessFile("test.txt", content, table)
+
+			want := []string{"colour", "Colour", "COLOUR", "CoLoUR"}
+			if len(result.Replacements) != len(want) {
+				t.Fatalf("expected %d replacements, got %d", len(want), len(result.Replacements))
+			}
+			for i, r := range result.Replacements {
+				if r.To != want[i] {
+					t.Errorf("replacement %d: To = %q, want %q", i, r.To, want[i])
+				}
+			}
+			if got := string(result.NewContent); got != "colour Colour COLOUR CoLoUR\n" {
+				t.Errorf("unexpected output %q", got)
+			}
+		})
+	}
+}
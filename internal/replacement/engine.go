@@ -4,11 +4,14 @@
 package replacement
 
 import (
-	"bufio"
+	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
 	"remap/internal/config"
+	"remap/internal/errors"
 	"remap/internal/parser"
 )
 
@@ -16,13 +19,21 @@ import (
 // This structure captures detailed information about each replacement,
 // enabling precise reporting and potential reversal operations.
 type Replacement struct {
-	From       string
-	To         string
-	Line       int
-	Column     int
-	LineText   string
-	NewText    string
-	ByteOffset int64
+	From         string `xml:"from"`
+	To           string `xml:"to"`
+	OriginalText string `json:"original_text,omitempty" xml:"original_text"` // matched text as it appeared, e.g. "COLOR" for From "color"
+	Line         int    `xml:"line"`
+	Column       int    `xml:"column"` // 1-based, in characters (runes) rather than bytes
+	LineText     string `xml:"line_text"`
+	NewText      string `xml:"new_text,omitempty"`
+	ByteOffset   int64  `xml:"byte_offset"`
+	MappingIndex int    `json:"mapping_index,omitempty" xml:"mapping_index"`
+	Comment      string `json:",omitempty" xml:"comment,omitempty"` // the mapping's comment, if any
+
+	// ContextBefore and ContextAfter hold up to --context lines around the
+	// match for verbose output; reports leave them out.
+	ContextBefore []string `json:"-" xml:"-"`
+	ContextAfter  []string `json:"-" xml:"-"`
 }
 
 // FileResult contains the complete result of processing a single file.
@@ -34,6 +45,27 @@ type FileResult struct {
 	Modified     bool
 	OriginalSize int64
 	NewSize      int64
+	DeletedBytes int64
+	HasBOM       bool
+
+	// DetectedMatches counts every match found, including those a limit
+	// such as a mapping's occurrence leaves alone; Replacements only holds
+	// the matches that are (or, in a dry run, would be) replaced.
+	DetectedMatches int
+
+	// UsedMappings holds the indexes of the mappings behind Replacements,
+	// including those merged into a whole-line record where mappings chain
+	// or overlap.
+	UsedMappings []int
+
+	// OriginalContent and NewContent hold both sides of the change for
+	// --diff; they are only captured when it is enabled.
+	OriginalContent []byte
+	NewContent      []byte
+
+	// Error is set when a middleware rejected the file, which is then left
+	// unmodified.
+	Error error
 }
 
 // Middleware defines a processing step in the replacement pipeline.
@@ -72,7 +104,9 @@ func NewEngine(config *config.Config) *Engine {
 	}
 
 	engine.Use(validateInputMiddleware)
+	engine.Use(lineCaseMiddleware)
 	engine.Use(detectReplacementsMiddleware)
+	engine.Use(replacementLimitMiddleware)
 	engine.Use(applyReplacementsMiddleware)
 	engine.Use(validateOutputMiddleware)
 
@@ -99,17 +133,24 @@ func (e *Engine) ProcessFile(filePath string, content []byte, mappings *parser.M
 			Path:         filePath,
 			OriginalSize: int64(len(content)),
 		},
-		Metadata: make(map[string]interface{}),
+		Metadata: map[string]interface{}{originalContentKey: content},
 	}
 
 	for _, mw := range e.middleware {
 		ctx = mw(ctx)
 		if ctx.Error != nil {
 			ctx.Result.Modified = false
+			ctx.Result.Error = ctx.Error
 			return ctx.Result
 		}
 	}
 
+	// Middleware may rewrite ctx.Content (e.g. line-case mappings), so the
+	// original side of the diff is the content the pipeline started from
+	if ctx.Result.NewContent != nil {
+		ctx.Result.OriginalContent = content
+	}
+
 	return ctx.Result
 }
 
@@ -129,9 +170,14 @@ func validateInputMiddleware(ctx ProcessContext) ProcessContext {
 
 func detectReplacementsMiddleware(ctx ProcessContext) ProcessContext {
 	content := string(ctx.Content)
-	var replacements []Replacement
-
-	scanner := bufio.NewScanner(strings.NewReader(content))
+	replacements, _ := ctx.Metadata[lineCaseReplacementsKey].([]Replacement)
+
+	scanner := newLineScanner(content)
+	sections := newSectionTracker(ctx.Config)
+	mappings := WithLineEnding(ctx.Mappings.ForPath(ctx.FilePath, ctx.Config.CaseSensitive), LineEnding(content))
+	matchOptions := NewMatchOptions(ctx.Config)
+	occurrences := OccurrenceCounter{}
+	detected := len(replacements)
 	lineNum := 0
 	byteOffset := int64(0)
 
@@ -140,28 +186,91 @@ func detectReplacementsMiddleware(ctx ProcessContext) ProcessContext {
 		lineText := scanner.Text()
 		lineBytes := scanner.Bytes()
 
-		for _, mapping := range ctx.Mappings.GetSortedMappings() {
-			searchText := mapping.From
-			if !ctx.Config.CaseSensitive {
-				searchText = strings.ToLower(searchText)
-				lineText = strings.ToLower(lineText)
+		if sections.excludes(strings.TrimSuffix(lineText, "\r")) {
+			byteOffset += int64(len(lineBytes)) + 1
+			continue
+		}
+
+		lowerLine := ""
+
+		for _, mapping := range mappings {
+			if mapping.LineCase != "" {
+				continue
+			}
+			opts := matchOptions.For(mapping)
+
+			if mapping.Regex {
+				matches := detectRegexMatches(mapping, opts, string(lineBytes), lineNum, byteOffset)
+				replacements = append(replacements, matches...)
+				detected += len(matches)
+				continue
+			}
+
+			if tp, ok := parseTemplate(mapping.From); ok && ctx.Config.TemplateMappings {
+				matches := detectTemplateMatches(tp, mapping, string(lineBytes), lineNum, byteOffset, opts.CaseSensitive)
+				replacements = append(replacements, matches...)
+				detected += len(matches)
+				continue
+			}
+
+			// Matching runs on a case-folded copy so lineText keeps the line as
+			// written for the following mappings and for what is recorded
+			searchLine, searchText := lineText, mapping.From
+			if !opts.CaseSensitive {
+				if lowerLine == "" {
+					lowerLine = FoldCase(lineText)
+				}
+				searchLine, searchText = lowerLine, FoldCase(searchText)
 			}
 
 			startIndex := 0
 			for {
-				index := strings.Index(lineText[startIndex:], searchText)
+				index := strings.Index(searchLine[startIndex:], searchText)
 				if index == -1 {
 					break
 				}
 
 				actualIndex := startIndex + index
+				if !opts.accepts(lineText, actualIndex, actualIndex+len(mapping.From)) {
+					_, size := utf8.DecodeRuneInString(searchLine[actualIndex:])
+					startIndex = actualIndex + size
+					continue
+				}
+
+				detected++
+				if mapping.OccurrenceIndex > 0 && mapping.ToTemplate == nil && !occurrences.seen(mapping) {
+					startIndex = actualIndex + len(mapping.From)
+					continue
+				}
+
+				to := mapping.To
+				if mapping.ToTemplate != nil {
+					to = expandTo(mapping, MatchContext{
+						File:  filepath.Base(ctx.FilePath),
+						Path:  ctx.FilePath,
+						Line:  lineNum,
+						Match: string(lineBytes[actualIndex : actualIndex+len(mapping.From)]),
+					})
+				} else {
+					to = opts.destination(string(lineBytes), actualIndex, actualIndex+len(mapping.From), to)
+				}
+				if to == string(lineBytes[actualIndex:actualIndex+len(mapping.From)]) {
+					// The text already reads as its destination: nothing to change
+					detected--
+					startIndex = actualIndex + len(mapping.From)
+					continue
+				}
+
 				replacement := Replacement{
-					From:       mapping.From,
-					To:         mapping.To,
-					Line:       lineNum,
-					Column:     actualIndex + 1,
-					LineText:   string(lineBytes),
-					ByteOffset: byteOffset + int64(actualIndex),
+					From:         mapping.From,
+					To:           to,
+					OriginalText: string(lineBytes[actualIndex : actualIndex+len(mapping.From)]),
+					Line:         lineNum,
+					Column:       runeColumn(string(lineBytes), actualIndex),
+					LineText:     string(lineBytes),
+					ByteOffset:   byteOffset + int64(actualIndex),
+					MappingIndex: mapping.Index,
+					Comment:      mapping.Comment,
 				}
 
 				replacements = append(replacements, replacement)
@@ -172,31 +281,120 @@ func detectReplacementsMiddleware(ctx ProcessContext) ProcessContext {
 		byteOffset += int64(len(lineBytes)) + 1 // +1 for newline
 	}
 
+	if ctx.Config.ContextLines > 0 {
+		attachContext(replacements, content, ctx.Config.ContextLines)
+	}
+
 	ctx.Result.Replacements = replacements
+	ctx.Result.DetectedMatches = detected
 	ctx.Result.Modified = len(replacements) > 0
+	ctx.Result.DeletedBytes = deletedBytes(replacements)
 
 	return ctx
 }
 
-func applyReplacementsMiddleware(ctx ProcessContext) ProcessContext {
-	if !ctx.Result.Modified || ctx.Config.DryRun {
+// replacementLimitMiddleware rejects a file with more replacements than
+// --max-replacements-per-file allows before anything is applied, so a
+// runaway mapping such as a one-character source cannot rewrite it.
+func replacementLimitMiddleware(ctx ProcessContext) ProcessContext {
+	limit := ctx.Config.MaxReplacementsPerFile
+	if limit <= 0 || len(ctx.Result.Replacements) <= limit {
 		return ctx
 	}
 
-	content := string(ctx.Content)
+	ctx.Error = errors.NewReplacementError(ctx.FilePath,
+		fmt.Sprintf("%d replacements exceed the limit of %d per file (--max-replacements-per-file)", len(ctx.Result.Replacements), limit), nil)
+	return ctx
+}
 
-	for _, mapping := range ctx.Mappings.GetSortedMappings() {
-		if ctx.Config.CaseSensitive {
-			content = strings.ReplaceAll(content, mapping.From, mapping.To)
-		} else {
-			content = caseInsensitiveReplace(content, mapping.From, mapping.To)
+// deletedBytes sums the matched text removed by replacements with an empty
+// destination, so callers can warn before a run erases large amounts of text.
+func deletedBytes(replacements []Replacement) int64 {
+	var total int64
+	for _, r := range replacements {
+		if r.To == "" {
+			total += int64(len(r.From))
+		}
+	}
+	return total
+}
+
+// attachContext records the n lines before and after each replacement's line
+// of content, without their line endings.
+func attachContext(replacements []Replacement, content string, n int) {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+
+	for i := range replacements {
+		index := replacements[i].Line - 1
+		if index < 0 || index >= len(lines) {
+			continue
+		}
+		replacements[i].ContextBefore = lines[max(0, index-n):index]
+		replacements[i].ContextAfter = lines[index+1 : min(len(lines), index+1+n)]
+	}
+}
+
+// runeColumn returns the 1-based column of the byte index in line, counted
+// in runes so editors land on the match in lines with multibyte characters.
+func runeColumn(line string, index int) int {
+	return utf8.RuneCountInString(line[:index]) + 1
+}
+
+// detectTemplateMatches records every template match on a line.
+// The recorded From/To are the concrete matched and expanded texts, so reports
+// and reverts see real strings rather than the template.
+func detectTemplateMatches(tp templatePattern, mapping parser.Mapping, line string, lineNum int, byteOffset int64, caseSensitive bool) []Replacement {
+	var replacements []Replacement
+
+	start := 0
+	for {
+		matchStart, matchEnd, captured := tp.find(line, start, caseSensitive)
+		if matchStart == -1 {
+			return replacements
 		}
+
+		replacements = append(replacements, Replacement{
+			From:         line[matchStart:matchEnd],
+			To:           expandTemplate(mapping.To, captured),
+			OriginalText: line[matchStart:matchEnd],
+			Line:         lineNum,
+			Column:       runeColumn(line, matchStart),
+			LineText:     line,
+			ByteOffset:   byteOffset + int64(matchStart),
+			MappingIndex: mapping.Index,
+			Comment:      mapping.Comment,
+		})
+		start = matchEnd
 	}
+}
 
-	newContent := []byte(content)
-	ctx.Content = newContent
-	ctx.Result.NewSize = int64(len(newContent))
+func applyReplacementsMiddleware(ctx ProcessContext) ProcessContext {
+	if !ctx.Result.Modified {
+		return ctx
+	}
+
+	// Rendered from the content the pipeline started from, exactly as the
+	// processor renders what it writes, so previews match the written file
+	original, _ := ctx.Metadata[originalContentKey].([]byte)
+	content := Render(ctx.Config, ctx.Mappings, ctx.FilePath, string(original))
+	ctx.Metadata[renderedContentKey] = content
+
+	ctx.Result.UsedMappings = usedMappings(ctx.Result.Replacements)
+	ctx.Result.Replacements = reconcileReplacements(string(original), content, ctx.Result.Replacements)
+	ctx.Result.DeletedBytes = deletedBytes(ctx.Result.Replacements)
+	ctx.Result.NewSize = int64(len(content))
 
+	if ctx.Config.Diff {
+		ctx.Result.NewContent = []byte(content)
+	}
+	if ctx.Config.DryRun {
+		return ctx
+	}
+
+	ctx.Content = []byte(content)
 	for i := range ctx.Result.Replacements {
 		ctx.Result.Replacements[i].NewText = content
 	}
@@ -204,6 +402,68 @@ func applyReplacementsMiddleware(ctx ProcessContext) ProcessContext {
 	return ctx
 }
 
+// Render returns content, the text of filePath, with every mapping applied:
+// within each section, whole lines change case first, then every other
+// mapping runs in turn. It is the one place replaced text is produced, so
+// files written, diffs, patches and in-memory results always agree.
+func Render(cfg *config.Config, mappings *parser.MappingTable, filePath, content string) string {
+	occurrences := OccurrenceCounter{}
+	lineEnding := LineEnding(content)
+	return ReplaceInSections(cfg, content, func(text string, firstLine int) string {
+		return applyMappings(cfg, mappings, filePath, text, firstLine, lineEnding, occurrences)
+	})
+}
+
+// applyMappings runs every mapping applicable to filePath over text, which
+// starts at line firstLine of the file and whose line ending is lineEnding.
+// occurrences carries the match counts of occurrence-limited mappings between
+// the sections of the file.
+func applyMappings(cfg *config.Config, table *parser.MappingTable, filePath, text string, firstLine int, lineEnding string, occurrences OccurrenceCounter) string {
+	matchOptions := NewMatchOptions(cfg)
+	mappings := WithLineEnding(table.ForPath(filePath, cfg.CaseSensitive), lineEnding)
+
+	text = ApplyLineCase(text, mappings, matchOptions)
+
+	for _, mapping := range mappings {
+		if mapping.LineCase != "" {
+			continue
+		}
+		opts := matchOptions.For(mapping)
+
+		if mapping.Regex {
+			text = ReplaceRegex(text, mapping, opts)
+			continue
+		}
+
+		if cfg.TemplateMappings && IsTemplate(mapping.From) {
+			text = TemplateReplaceAll(text, mapping.From, mapping.To, opts.CaseSensitive)
+			continue
+		}
+
+		if mapping.ToTemplate != nil {
+			text = ExpandReplaceAll(text, mapping, filePath, firstLine, opts.CaseSensitive)
+			continue
+		}
+
+		if mapping.OccurrenceIndex > 0 {
+			text = ReplaceOccurrence(text, mapping, opts, occurrences)
+			continue
+		}
+
+		if !opts.IsPlain() {
+			text = ReplaceMatches(text, mapping.From, mapping.To, opts)
+			continue
+		}
+
+		if opts.CaseSensitive {
+			text = strings.ReplaceAll(text, mapping.From, mapping.To)
+		} else {
+			text = caseInsensitiveReplace(text, mapping.From, mapping.To, opts.PreserveCase)
+		}
+	}
+	return text
+}
+
 func validateOutputMiddleware(ctx ProcessContext) ProcessContext {
 	if ctx.Result.Modified && !ctx.Config.DryRun {
 		if len(ctx.Content) == 0 && ctx.Result.OriginalSize > 0 {
@@ -211,16 +471,41 @@ func validateOutputMiddleware(ctx ProcessContext) ProcessContext {
 		}
 	}
 
+	// Mappings can rewrite text to itself or cancel each other out; a file
+	// that ends up byte-identical is neither backed up nor rewritten
+	if ctx.Result.Modified {
+		rendered, _ := ctx.Metadata[renderedContentKey].(string)
+		original, _ := ctx.Metadata[originalContentKey].([]byte)
+		if rendered == string(original) {
+			ctx.Result.Modified = false
+			ctx.Result.Replacements = nil
+			ctx.Result.UsedMappings = nil
+			ctx.Result.DeletedBytes = 0
+			ctx.Result.NewSize = 0
+			ctx.Result.NewContent = nil
+		}
+	}
+
 	return ctx
 }
 
-func caseInsensitiveReplace(content, from, to string) string {
+// originalContentKey is the metadata key holding the content the pipeline
+// started from, before any middleware rewrote ctx.Content.
+const originalContentKey = "originalContent"
+
+// renderedContentKey is the metadata key holding the content with every
+// mapping applied, as it is (or would be) written.
+const renderedContentKey = "renderedContent"
+
+// caseInsensitiveReplace replaces every match of from in content regardless
+// of case. With preserveCase each match gets to in its own casing.
+func caseInsensitiveReplace(content, from, to string, preserveCase bool) string {
 	if from == "" {
 		return content
 	}
 
-	lowerContent := strings.ToLower(content)
-	lowerFrom := strings.ToLower(from)
+	lowerContent := FoldCase(content)
+	lowerFrom := FoldCase(from)
 
 	var result strings.Builder
 	start := 0
@@ -234,7 +519,11 @@ func caseInsensitiveReplace(content, from, to string) string {
 
 		actualIndex := start + index
 		result.WriteString(content[start:actualIndex])
-		result.WriteString(to)
+		if preserveCase {
+			result.WriteString(MatchCase(content[actualIndex:actualIndex+len(from)], to))
+		} else {
+			result.WriteString(to)
+		}
 
 		start = actualIndex + len(from)
 	}
@@ -245,6 +534,7 @@ func caseInsensitiveReplace(content, from, to string) string {
 // ProcessReader applies string replacements to content from an io.Reader.
 // This function provides a streaming interface for processing content without
 // requiring file system access, enabling flexible content transformation workflows.
+// It returns the content as read; ReplaceString also returns the new content.
 func ProcessReader(reader io.Reader, mappings *parser.MappingTable, caseSensitive bool) ([]byte, []Replacement, error) {
 	content, err := io.ReadAll(reader)
 	if err != nil {
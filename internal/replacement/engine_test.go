@@ -1,6 +1,7 @@
 package replacement
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -10,10 +11,11 @@ import (
 
 func TestCaseInsensitiveReplace(t *testing.T) {
 	tests := []struct {
-		content  string
-		from     string
-		to       string
-		expected string
+		content      string
+		from         string
+		to           string
+		preserveCase bool
+		expected     string
 	}{
 		{
 			content:  "Hello World",
@@ -33,10 +35,17 @@ func TestCaseInsensitiveReplace(t *testing.T) {
 			to:       "abc",
 			expected: "nothing to replace",
 		},
+		{
+			content:      "color Color COLOR CoLoR",
+			from:         "color",
+			to:           "colour",
+			preserveCase: true,
+			expected:     "colour Colour COLOUR CoLoUR",
+		},
 	}
 
 	for _, tt := range tests {
-		result := caseInsensitiveReplace(tt.content, tt.from, tt.to)
+		result := caseInsensitiveReplace(tt.content, tt.from, tt.to, tt.preserveCase)
 		if result != tt.expected {
 			t.Errorf("caseInsensitiveReplace(%q, %q, %q) = %q, expected %q",
 				tt.content, tt.from, tt.to, result, tt.expected)
@@ -97,3 +106,344 @@ func TestProcessReader(t *testing.T) {
 		t.Errorf("expected content, got none")
 	}
 }
+
+func TestReplacementMappingIndex(t *testing.T) {
+	// "foobar" sorts before "foo", so the index must follow the original order
+	table := parser.NewMappingTable([]parser.Mapping{
+		{From: "foo", To: "baz"},
+		{From: "foobar", To: "qux"},
+	})
+
+	engine := NewEngine(&config.Config{CaseSensitive: true, DryRun: true})
+	result := engine.ProcessFile("test.txt", []byte("foobar\nfoo\n"), table)
+
+	if len(result.Replacements) == 0 {
+		t.Fatal("expected replacements")
+	}
+	expected := map[string]int{"foo": 0, "foobar": 1}
+	for _, repl := range result.Replacements {
+		if repl.MappingIndex != expected[repl.From] {
+			t.Errorf("line %d %q: expected mapping index %d, got %d",
+				repl.Line, repl.From, expected[repl.From], repl.MappingIndex)
+		}
+	}
+}
+
+func TestReplacementComment(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{
+		{From: "foo", To: "baz", Comment: "renamed in v2"},
+		{From: "bar", To: "qux"},
+	})
+
+	result := NewEngine(&config.Config{DryRun: true}).ProcessFile("test.txt", []byte("foo bar\n"), table)
+	if len(result.Replacements) != 2 {
+		t.Fatalf("expected 2 replacements, got %d", len(result.Replacements))
+	}
+
+	expected := map[string]string{"foo": "renamed in v2", "bar": ""}
+	for _, repl := range result.Replacements {
+		if repl.Comment != expected[repl.From] {
+			t.Errorf("%q: expected comment %q, got %q", repl.From, expected[repl.From], repl.Comment)
+		}
+		logged, err := json.Marshal(repl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(logged), `"Comment"`) != (repl.Comment != "") {
+			t.Errorf("%q: comment should be logged only when set, got %s", repl.From, logged)
+		}
+	}
+}
+
+func TestEngineScopedMappings(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{
+		{From: "foo", To: "bar", AppliesTo: "*.go"},
+		{From: "hello", To: "hi"},
+	})
+	engine := NewEngine(&config.Config{CaseSensitive: true})
+
+	goResult := engine.ProcessFile("/src/main.go", []byte("foo hello"), table)
+	if len(goResult.Replacements) != 2 {
+		t.Errorf("expected 2 replacements in .go file, got %d", len(goResult.Replacements))
+	}
+
+	txtResult := engine.ProcessFile("/src/notes.txt", []byte("foo hello"), table)
+	if len(txtResult.Replacements) != 1 {
+		t.Fatalf("expected 1 replacement in .txt file, got %d", len(txtResult.Replacements))
+	}
+	if txtResult.Replacements[0].From != "hello" {
+		t.Errorf("expected only the unscoped mapping to fire, got %q", txtResult.Replacements[0].From)
+	}
+}
+
+func TestEngineDeletedBytes(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{
+		{From: "debug", To: ""},
+		{From: "foo", To: "bar"},
+	})
+	engine := NewEngine(&config.Config{DryRun: true})
+
+	result := engine.ProcessFile("test.txt", []byte("debug foo\nDEBUG\n"), table)
+	if result.DeletedBytes != 10 {
+		t.Errorf("expected 10 deleted bytes, got %d", result.DeletedBytes)
+	}
+}
+
+func TestEngineDryRunNewSize(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{
+		{From: "id", To: "identifier"},
+		{From: "debug", To: ""},
+	})
+	content := []byte("id debug\nID\n")
+
+	dryRun := NewEngine(&config.Config{DryRun: true}).ProcessFile("test.txt", content, table)
+	applied := NewEngine(&config.Config{}).ProcessFile("test.txt", content, table)
+
+	if dryRun.NewSize != applied.NewSize {
+		t.Errorf("expected dry-run size %d to match applied size %d", dryRun.NewSize, applied.NewSize)
+	}
+}
+
+func TestEngineDiffContent(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{
+		{From: "id", To: "identifier"},
+		{From: "warning", LineCase: parser.LineCaseUpper},
+	})
+	content := []byte("id\nwarning: low\n")
+
+	for _, dryRun := range []bool{true, false} {
+		result := NewEngine(&config.Config{DryRun: dryRun, Diff: true}).ProcessFile("test.txt", content, table)
+		if string(result.OriginalContent) != string(content) {
+			t.Errorf("dry run %v: original side = %q, want %q", dryRun, result.OriginalContent, content)
+		}
+		if want := "identifier\nWARNING: LOW\n"; string(result.NewContent) != want {
+			t.Errorf("dry run %v: new side = %q, want %q", dryRun, result.NewContent, want)
+		}
+	}
+
+	if result := NewEngine(&config.Config{DryRun: true}).ProcessFile("test.txt", content, table); result.NewContent != nil {
+		t.Errorf("expected no captured content without --diff, got %q", result.NewContent)
+	}
+}
+
+func TestEngineCaseInsensitiveMappingsOnOneLine(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{
+		{From: "FOO", To: "x"},
+		{From: "bar", To: "y"},
+	})
+	line := "Foo and BAR, foo and Bar"
+
+	result := NewEngine(&config.Config{DryRun: true}).ProcessFile("test.txt", []byte(line+"\n"), table)
+
+	want := []struct {
+		original string
+		column   int
+	}{
+		{"Foo", 1}, {"foo", 14}, {"BAR", 9}, {"Bar", 22},
+	}
+	if len(result.Replacements) != len(want) {
+		t.Fatalf("expected %d replacements, got %d", len(want), len(result.Replacements))
+	}
+	for i, r := range result.Replacements {
+		if r.OriginalText != want[i].original || r.Column != want[i].column {
+			t.Errorf("replacement %d: %q at column %d, want %q at column %d",
+				i, r.OriginalText, r.Column, want[i].original, want[i].column)
+		}
+		if r.LineText != line {
+			t.Errorf("replacement %d: line text %q, want %q", i, r.LineText, line)
+		}
+	}
+}
+
+func TestEngineRuneColumns(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		column     int
+		byteOffset int64
+	}{
+		{name: "ASCII", line: "x = foo", column: 5, byteOffset: 4},
+		{name: "accented", line: "café foo", column: 6, byteOffset: 6},
+		{name: "CJK", line: "日本語 foo", column: 5, byteOffset: 10},
+		{name: "after a 3-byte emoji", line: "⌚ foo", column: 3, byteOffset: 4},
+		{name: "after a 4-byte emoji", line: "🚀 foo", column: 3, byteOffset: 5},
+		// Their lowercase forms have another byte length than the runes
+		{name: "after Ⱥ", line: "ȺȺȺ foo", column: 5, byteOffset: 7},
+		{name: "after the Kelvin sign", line: "\u212a foo", column: 3, byteOffset: 4},
+	}
+
+	table := parser.NewMappingTable([]parser.Mapping{{From: "foo", To: "bar"}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewEngine(&config.Config{DryRun: true}).ProcessFile("test.txt", []byte(tt.line+"\n"), table)
+			if len(result.Replacements) != 1 {
+				t.Fatalf("expected 1 replacement, got %d", len(result.Replacements))
+			}
+			r := result.Replacements[0]
+			if r.Column != tt.column {
+				t.Errorf("column = %d, want %d", r.Column, tt.column)
+			}
+			if r.ByteOffset != tt.byteOffset {
+				t.Errorf("byte offset = %d, want %d", r.ByteOffset, tt.byteOffset)
+			}
+			if r.OriginalText != "foo" {
+				t.Errorf("original text = %q, want %q", r.OriginalText, "foo")
+			}
+		})
+	}
+}
+
+func TestEngineReplacementLimit(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{{From: "a", To: "b"}})
+	content := []byte("a a\na\n")
+
+	tests := []struct {
+		name      string
+		limit     int
+		wantError bool
+	}{
+		{name: "unlimited", limit: 0},
+		{name: "at the limit", limit: 3},
+		{name: "over the limit", limit: 2, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{MaxReplacementsPerFile: tt.limit}
+			result := NewEngine(cfg).ProcessFile("test.txt", content, table)
+			if (result.Error != nil) != tt.wantError {
+				t.Fatalf("error = %v, want error %v", result.Error, tt.wantError)
+			}
+			if result.Modified == tt.wantError {
+				t.Errorf("modified = %v, want %v", result.Modified, !tt.wantError)
+			}
+		})
+	}
+}
+
+func TestEngineDetectedMatches(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{
+		{From: "id", To: "key", OccurrenceIndex: 2},
+		{From: "name", To: "label"},
+	})
+	content := []byte("id id\nid name\n")
+
+	result := NewEngine(&config.Config{DryRun: true}).ProcessFile("test.txt", content, table)
+	if result.DetectedMatches != 4 {
+		t.Errorf("expected 4 detected matches, got %d", result.DetectedMatches)
+	}
+	if len(result.Replacements) != 2 {
+		t.Errorf("expected 2 replacements to apply, got %d", len(result.Replacements))
+	}
+}
+
+func TestEngineUnchangedContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		mappings []parser.Mapping
+		config   config.Config
+	}{
+		{
+			name:     "identity mapping",
+			mappings: []parser.Mapping{{From: "foo", To: "foo"}},
+		},
+		{
+			name:     "case-insensitive identity mapping",
+			mappings: []parser.Mapping{{From: "FOO", To: "foo"}},
+			config:   config.Config{PreserveCase: true},
+		},
+		{
+			name:     "mappings cancelling out",
+			mappings: []parser.Mapping{{From: "foo", To: "bar"}, {From: "bar", To: "foo"}},
+		},
+		{
+			name:     "mappings cancelling out in a dry run diff",
+			mappings: []parser.Mapping{{From: "foo", To: "bar"}, {From: "bar", To: "foo"}},
+			config:   config.Config{DryRun: true, Diff: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.config
+			result := NewEngine(&cfg).ProcessFile("test.txt", []byte("foo\nfoo baz\n"), parser.NewMappingTable(tt.mappings))
+			if result.Error != nil {
+				t.Fatalf("unexpected error: %v", result.Error)
+			}
+			if result.Modified {
+				t.Error("expected byte-identical content to be reported as not modified")
+			}
+			if len(result.Replacements) != 0 {
+				t.Errorf("expected no replacements, got %d", len(result.Replacements))
+			}
+		})
+	}
+}
+
+func TestEngineContextLines(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{{From: "old", To: "new"}})
+	content := []byte("line 1\r\nold 2\nline 3\nline 4\nold 5\n")
+
+	result := NewEngine(&config.Config{DryRun: true, ContextLines: 2}).ProcessFile("test.txt", content, table)
+	if len(result.Replacements) != 2 {
+		t.Fatalf("expected 2 replacements, got %d", len(result.Replacements))
+	}
+
+	tests := []struct {
+		before []string
+		after  []string
+	}{
+		{before: []string{"line 1"}, after: []string{"line 3", "line 4"}},
+		{before: []string{"line 3", "line 4"}, after: []string{}},
+	}
+	for i, tt := range tests {
+		r := result.Replacements[i]
+		if strings.Join(r.ContextBefore, "|") != strings.Join(tt.before, "|") || strings.Join(r.ContextAfter, "|") != strings.Join(tt.after, "|") {
+			t.Errorf("line %d: context %q / %q, expected %q / %q", r.Line, r.ContextBefore, r.ContextAfter, tt.before, tt.after)
+		}
+	}
+
+	result = NewEngine(&config.Config{DryRun: true}).ProcessFile("test.txt", content, table)
+	if result.Replacements[0].ContextBefore != nil {
+		t.Errorf("expected no context without --context, got %q", result.Replacements[0].ContextBefore)
+	}
+}
+
+func TestReplacementJSONKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		replacement Replacement
+		expected    string
+	}{
+		{
+			name:        "first mapping matching its source",
+			replacement: Replacement{From: "foo", To: "bar", Line: 1, Column: 1},
+			expected:    `{"From":"foo","To":"bar","Line":1,"Column":1,"LineText":"","NewText":"","ByteOffset":0}`,
+		},
+		{
+			name:        "later mapping matching other casing",
+			replacement: Replacement{From: "foo", To: "bar", OriginalText: "Foo", Line: 2, Column: 3, ByteOffset: 6, MappingIndex: 1},
+			expected:    `{"From":"foo","To":"bar","original_text":"Foo","Line":2,"Column":3,"LineText":"","NewText":"","ByteOffset":6,"mapping_index":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := json.Marshal(tt.replacement)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(encoded) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, encoded)
+			}
+
+			var decoded Replacement
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !equalReplacement(decoded, tt.replacement) {
+				t.Errorf("expected %+v after a round trip, got %+v", tt.replacement, decoded)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package replacement
+
+import (
+	"path/filepath"
+	"strings"
+
+	"remap/internal/parser"
+)
+
+// MatchContext is the data a To template is evaluated with.
+type MatchContext struct {
+	File  string // base name of the file
+	Path  string // full path of the file
+	Line  int    // 1-based line of the match
+	Match string // matched text as it appears in the file
+}
+
+// expandTo evaluates the mapping's To template for one match. A template that
+// fails at run time leaves the match unchanged rather than writing partial output.
+func expandTo(mapping parser.Mapping, ctx MatchContext) string {
+	var out strings.Builder
+	if err := mapping.ToTemplate.Execute(&out, ctx); err != nil {
+		return ctx.Match
+	}
+	return out.String()
+}
+
+// ExpandReplaceAll replaces every occurrence of the mapping's From in content
+// with its To template evaluated for that match. content starts at line
+// firstLine of filePath, so {{.Line}} reports positions in the whole file.
+func ExpandReplaceAll(content string, mapping parser.Mapping, filePath string, firstLine int, caseSensitive bool) string {
+	if mapping.From == "" {
+		return content
+	}
+
+	searchContent, searchFrom := content, mapping.From
+	if !caseSensitive {
+		searchContent, searchFrom = FoldCase(content), FoldCase(mapping.From)
+	}
+
+	ctx := MatchContext{File: filepath.Base(filePath), Path: filePath, Line: firstLine}
+
+	var result strings.Builder
+	start := 0
+	for {
+		index := strings.Index(searchContent[start:], searchFrom)
+		if index == -1 {
+			result.WriteString(content[start:])
+			return result.String()
+		}
+
+		matchStart := start + index
+		matchEnd := matchStart + len(mapping.From)
+
+		ctx.Line += strings.Count(content[start:matchStart], "\n")
+		ctx.Match = content[matchStart:matchEnd]
+
+		result.WriteString(content[start:matchStart])
+		result.WriteString(expandTo(mapping, ctx))
+
+		ctx.Line += strings.Count(ctx.Match, "\n")
+		start = matchEnd
+	}
+}
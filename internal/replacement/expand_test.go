@@ -0,0 +1,58 @@
+package replacement
+
+import (
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestExpandReplaceAll(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{
+		{From: "@FILE@", To: "{{.File}}"},
+		{From: "todo", To: "{{.Match}} (line {{.Line}})"},
+	})
+	if err := table.CompileToTemplates(MatchContext{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mappings := table.GetMappings()
+
+	result := ExpandReplaceAll("// @FILE@\npackage main\n", mappings[0], "/src/cmd/main.go", 1, true)
+	if expected := "// main.go\npackage main\n"; result != expected {
+		t.Errorf("expected %q, got %q", expected, result)
+	}
+
+	result = ExpandReplaceAll("a\nTODO b\nc todo\n", mappings[1], "/src/notes.txt", 10, false)
+	if expected := "a\nTODO (line 11) b\nc todo (line 12)\n"; result != expected {
+		t.Errorf("expected %q, got %q", expected, result)
+	}
+}
+
+func TestEngineToTemplate(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{{From: "@FILE@", To: "{{.File}}"}})
+	if err := table.CompileToTemplates(MatchContext{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	engine := NewEngine(&config.Config{CaseSensitive: true, DryRun: true})
+	result := engine.ProcessFile("/src/app/config.yaml", []byte("name: @FILE@\n"), table)
+
+	if len(result.Replacements) != 1 {
+		t.Fatalf("expected 1 replacement, got %d", len(result.Replacements))
+	}
+	if result.Replacements[0].To != "config.yaml" {
+		t.Errorf("expected reported replacement 'config.yaml', got %q", result.Replacements[0].To)
+	}
+}
+
+func TestCompileToTemplatesInvalid(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{{From: "x", To: "{{.File"}})
+	if err := table.CompileToTemplates(MatchContext{}); err == nil {
+		t.Error("expected error for malformed template")
+	}
+
+	table = parser.NewMappingTable([]parser.Mapping{{From: "x", To: "{{.Nope}}"}})
+	if err := table.CompileToTemplates(MatchContext{}); err == nil {
+		t.Error("expected error for template referring to an unknown field")
+	}
+}
@@ -0,0 +1,27 @@
+package replacement
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FoldCase lowercases s for case-insensitive matching while keeping the byte
+// length of every rune, so an index found in the folded text is also a valid
+// index into s. Runes whose lowercase form has a different length, such as
+// 'Ⱥ' or the Kelvin sign 'K', are left as they are and only match themselves.
+func FoldCase(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i, r := range s {
+		lower := unicode.ToLower(r)
+		if lower == r || utf8.RuneLen(lower) != utf8.RuneLen(r) {
+			// Copied from s rather than r so invalid bytes stay as they were
+			_, size := utf8.DecodeRuneInString(s[i:])
+			b.WriteString(s[i : i+size])
+			continue
+		}
+		b.WriteRune(lower)
+	}
+	return b.String()
+}
@@ -0,0 +1,31 @@
+package replacement
+
+import "testing"
+
+func TestFoldCase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"HELLO", "hello"},
+		{"Hello World", "hello world"},
+		{"Hello123!@#", "hello123!@#"},
+		{"", ""},
+		{"ÉTÉ", "été"},
+		{"ȺȺȺ Foo", "ȺȺȺ foo"},
+		{"K Foo", "K foo"},
+		{"A\xffB", "a\xffb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := FoldCase(tt.input)
+			if result != tt.expected {
+				t.Errorf("FoldCase(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+			if len(result) != len(tt.input) {
+				t.Errorf("FoldCase(%q) changed the length from %d to %d", tt.input, len(tt.input), len(result))
+			}
+		})
+	}
+}
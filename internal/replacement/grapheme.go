@@ -0,0 +1,68 @@
+package replacement
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const zeroWidthJoiner = '\u200d'
+
+// isGraphemeExtender reports whether r continues the grapheme cluster before it:
+// combining and spacing marks (which include variation selectors), the
+// zero-width joiner, emoji skin-tone modifiers and emoji tag characters.
+func isGraphemeExtender(r rune) bool {
+	switch {
+	case r == zeroWidthJoiner:
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF: // emoji modifiers
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // emoji tags
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isGraphemeBoundary reports whether byte offset i of s falls between two
+// grapheme clusters. It implements the extended grapheme cluster rules that
+// matter for text replacement (CR LF, extenders, ZWJ sequences and flag
+// pairs), which is enough to keep emoji and accented letters intact.
+func isGraphemeBoundary(s string, i int) bool {
+	if i <= 0 || i >= len(s) {
+		return true
+	}
+
+	next, _ := utf8.DecodeRuneInString(s[i:])
+	prev, _ := utf8.DecodeLastRuneInString(s[:i])
+
+	if prev == '\r' && next == '\n' {
+		return false
+	}
+	if isGraphemeExtender(next) || prev == zeroWidthJoiner {
+		return false
+	}
+
+	if isRegionalIndicator(prev) && isRegionalIndicator(next) {
+		// Flags are pairs of indicators: break only after an even-length run
+		count := 0
+		for j := i; j > 0; {
+			r, size := utf8.DecodeLastRuneInString(s[:j])
+			if !isRegionalIndicator(r) {
+				break
+			}
+			count++
+			j -= size
+		}
+		return count%2 == 0
+	}
+
+	return true
+}
+
+// isGraphemeMatch reports whether s[start:end] starts and ends on grapheme boundaries.
+func isGraphemeMatch(s string, start, end int) bool {
+	return isGraphemeBoundary(s, start) && isGraphemeBoundary(s, end)
+}
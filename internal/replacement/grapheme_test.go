@@ -0,0 +1,63 @@
+package replacement
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestReplaceMatchesGraphemeAware(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		from     string
+		to       string
+		expected string
+	}{
+		{"emoji with skin-tone modifier", "👍🏽 👍", "👍", "ok", "👍🏽 ok"},
+		{"zwj sequence", "👩‍💻 👩", "👩", "W", "👩‍💻 W"},
+		{"combining accent", "é e", "e", "x", "é x"},
+		{"whole cluster", "hi 👍🏽!", "👍🏽", "ok", "hi ok!"},
+		{"flag pair", "🇫🇷🇩🇪", "🇷🇩", "X", "🇫🇷🇩🇪"},
+		{"plain text", "foo bar foo", "foo", "baz", "baz bar baz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ReplaceMatches(tt.content, tt.from, tt.to, MatchOptions{CaseSensitive: true, GraphemeAware: true})
+			if result != tt.expected {
+				t.Errorf("ReplaceMatches(%q, %q, %q) = %q, expected %q", tt.content, tt.from, tt.to, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEngineGraphemeAware(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{{From: "👍", To: "ok"}})
+	content := []byte("👍🏽 and 👍\n")
+
+	engine := NewEngine(&config.Config{CaseSensitive: true, GraphemeAware: true})
+	ctx := ProcessContext{
+		Config:   engine.config,
+		FilePath: "test.txt",
+		Content:  content,
+		Mappings: table,
+		Result:   &FileResult{Path: "test.txt"},
+		Metadata: map[string]interface{}{originalContentKey: content},
+	}
+	for _, mw := range engine.middleware {
+		ctx = mw(ctx)
+	}
+
+	if len(ctx.Result.Replacements) != 1 {
+		t.Fatalf("expected 1 replacement, got %d", len(ctx.Result.Replacements))
+	}
+	if ctx.Result.Replacements[0].Column != utf8.RuneCountInString("👍🏽 and ")+1 {
+		t.Errorf("expected the standalone emoji to match, got column %d", ctx.Result.Replacements[0].Column)
+	}
+	if string(ctx.Content) != "👍🏽 and ok\n" {
+		t.Errorf("expected modifier sequence intact, got %q", ctx.Content)
+	}
+}
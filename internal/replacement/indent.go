@@ -0,0 +1,31 @@
+package replacement
+
+import "strings"
+
+// leadingWhitespace returns the spaces and tabs that start line.
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// lineIndent returns the indentation of the line of content containing offset.
+func lineIndent(content string, offset int) string {
+	lineStart := strings.LastIndexByte(content[:offset], '\n') + 1
+	return leadingWhitespace(content[lineStart:])
+}
+
+// Reindent prefixes every line of a multi-line replacement after the first
+// with indent, so an inserted block lines up with the line it lands on. The
+// first line follows the match in place and blank lines are left empty.
+func Reindent(to, indent string) string {
+	if indent == "" || !strings.Contains(to, "\n") {
+		return to
+	}
+
+	lines := strings.Split(to, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
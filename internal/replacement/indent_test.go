@@ -0,0 +1,68 @@
+package replacement
+
+import "testing"
+
+func TestReindent(t *testing.T) {
+	tests := []struct {
+		name     string
+		to       string
+		indent   string
+		expected string
+	}{
+		{name: "single line unchanged", to: "bar", indent: "    ", expected: "bar"},
+		{name: "no indent", to: "a\nb", indent: "", expected: "a\nb"},
+		{name: "continuation lines indented", to: "a\nb\n  c", indent: "\t", expected: "a\n\tb\n\t  c"},
+		{name: "blank lines kept empty", to: "a\n\nb", indent: "  ", expected: "a\n\n  b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Reindent(tt.to, tt.indent); got != tt.expected {
+				t.Errorf("Reindent(%q, %q) = %q, want %q", tt.to, tt.indent, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReplaceMatchesPreserveIndent(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		from          string
+		to            string
+		caseSensitive bool
+		expected      string
+	}{
+		{
+			name:          "block adopts indentation of each match",
+			content:       "func a() {\n    TODO\n}\n\tTODO\n",
+			from:          "TODO",
+			to:            "x := 1\ny := 2",
+			caseSensitive: true,
+			expected:      "func a() {\n    x := 1\n    y := 2\n}\n\tx := 1\n\ty := 2\n",
+		},
+		{
+			name:          "match in the middle of a line",
+			content:       "  call(ARGS)\n",
+			from:          "ARGS",
+			to:            "a,\nb",
+			caseSensitive: true,
+			expected:      "  call(a,\n  b)\n",
+		},
+		{
+			name:     "case insensitive",
+			content:  "  todo\n",
+			from:     "TODO",
+			to:       "a\nb",
+			expected: "  a\n  b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReplaceMatches(tt.content, tt.from, tt.to, MatchOptions{CaseSensitive: tt.caseSensitive, PreserveIndent: true}); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
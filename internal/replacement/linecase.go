@@ -0,0 +1,177 @@
+package replacement
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"remap/internal/parser"
+)
+
+// lineCaseMappings returns the mappings that convert whole lines, keeping
+// their order.
+func lineCaseMappings(mappings []parser.Mapping) []parser.Mapping {
+	var lineCase []parser.Mapping
+	for _, mapping := range mappings {
+		if mapping.LineCase != "" {
+			lineCase = append(lineCase, mapping)
+		}
+	}
+	return lineCase
+}
+
+// convertLine converts line with the first line-case mapping whose source
+// appears on it, reporting the mapping used. A line already in the target
+// case is left alone and reported as unconverted.
+func convertLine(line string, mappings []parser.Mapping, opts MatchOptions) (parser.Mapping, string, bool) {
+	for _, mapping := range mappings {
+		if !containsMatch(line, mapping.From, opts.For(mapping)) {
+			continue
+		}
+		converted := convertCase(line, mapping.LineCase)
+		return mapping, converted, converted != line
+	}
+	return parser.Mapping{}, line, false
+}
+
+// ApplyLineCase converts every line of text on which the source of a
+// line-case mapping appears. Mappings without a line case are ignored.
+func ApplyLineCase(text string, mappings []parser.Mapping, opts MatchOptions) string {
+	lineCase := lineCaseMappings(mappings)
+	if len(lineCase) == 0 {
+		return text
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		body := strings.TrimSuffix(line, "\n")
+		if _, converted, ok := convertLine(body, lineCase, opts); ok {
+			lines[i] = converted + line[len(body):]
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// containsMatch reports whether from appears in line at a position the
+// options accept.
+func containsMatch(line, from string, opts MatchOptions) bool {
+	if from == "" {
+		return false
+	}
+
+	searchLine, searchFrom := line, from
+	if !opts.CaseSensitive {
+		searchLine, searchFrom = FoldCase(line), FoldCase(from)
+	}
+
+	start := 0
+	for {
+		index := strings.Index(searchLine[start:], searchFrom)
+		if index == -1 {
+			return false
+		}
+		matchStart := start + index
+		if opts.accepts(line, matchStart, matchStart+len(from)) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(searchLine[matchStart:])
+		start = matchStart + size
+	}
+}
+
+// convertCase returns line in the given line case.
+func convertCase(line, lineCase string) string {
+	switch lineCase {
+	case parser.LineCaseUpper:
+		return strings.ToUpper(line)
+	case parser.LineCaseLower:
+		return strings.ToLower(line)
+	case parser.LineCaseTitle:
+		return titleCase(line)
+	default:
+		return line
+	}
+}
+
+// titleCase upper-cases the first letter of every word and lower-cases the
+// rest, keeping the spacing between words.
+func titleCase(line string) string {
+	var b strings.Builder
+	startOfWord := true
+	for _, r := range line {
+		switch {
+		case unicode.IsSpace(r):
+			startOfWord = true
+		case startOfWord:
+			r = unicode.ToUpper(r)
+			startOfWord = false
+		default:
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// lineCaseMiddleware converts the lines selected by line-case mappings before
+// detection, so the other mappings are detected and applied on the converted
+// lines, in the same order the processor applies them. Each converted line is
+// recorded as a whole-line replacement.
+func lineCaseMiddleware(ctx ProcessContext) ProcessContext {
+	if ctx.Mappings == nil {
+		return ctx
+	}
+	mappings := lineCaseMappings(ctx.Mappings.ForPath(ctx.FilePath, ctx.Config.CaseSensitive))
+	if len(mappings) == 0 {
+		return ctx
+	}
+
+	matchOptions := NewMatchOptions(ctx.Config)
+	sections := newSectionTracker(ctx.Config)
+	scanner := newLineScanner(string(ctx.Content))
+	var replacements []Replacement
+	lineNum := 0
+	byteOffset := int64(0)
+
+	for scanner.Scan() {
+		lineNum++
+		lineStart := byteOffset
+		byteOffset += int64(len(scanner.Bytes())) + 1 // +1 for newline
+		// The line is recorded without a CRLF's '\r', which conversion keeps
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if sections.excludes(line) {
+			continue
+		}
+
+		mapping, converted, ok := convertLine(line, mappings, matchOptions)
+		if !ok {
+			continue
+		}
+		replacements = append(replacements, Replacement{
+			From:         line,
+			To:           converted,
+			OriginalText: line,
+			Line:         lineNum,
+			Column:       1,
+			LineText:     line,
+			ByteOffset:   lineStart,
+			MappingIndex: mapping.Index,
+			Comment:      mapping.Comment,
+		})
+	}
+
+	if len(replacements) == 0 {
+		return ctx
+	}
+
+	ctx.Content = []byte(ReplaceInSections(ctx.Config, string(ctx.Content), func(text string, _ int) string {
+		return ApplyLineCase(text, mappings, matchOptions)
+	}))
+	ctx.Metadata[lineCaseReplacementsKey] = replacements
+	return ctx
+}
+
+// lineCaseReplacementsKey is the metadata key under which lineCaseMiddleware
+// hands its replacements to detection.
+const lineCaseReplacementsKey = "lineCaseReplacements"
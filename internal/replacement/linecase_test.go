@@ -0,0 +1,99 @@
+package replacement
+
+import (
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestApplyLineCase(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		mappings []parser.Mapping
+		opts     MatchOptions
+		expected string
+	}{
+		{
+			name:     "upper-cases lines containing the trigger",
+			text:     "# Deprecated API\nkeep me\nthis is deprecated too\n",
+			mappings: []parser.Mapping{{From: "deprecated", LineCase: parser.LineCaseUpper}},
+			expected: "# DEPRECATED API\nkeep me\nTHIS IS DEPRECATED TOO\n",
+		},
+		{
+			name:     "lower case",
+			text:     "NOTE: SHOUTING\nOTHER\n",
+			mappings: []parser.Mapping{{From: "note", LineCase: parser.LineCaseLower}},
+			expected: "note: shouting\nOTHER\n",
+		},
+		{
+			name:     "title case",
+			text:     "## getting  STARTED",
+			mappings: []parser.Mapping{{From: "##", LineCase: parser.LineCaseTitle}},
+			expected: "## Getting  Started",
+		},
+		{
+			name:     "case-sensitive trigger",
+			text:     "Todo list\nTODO: fix\n",
+			mappings: []parser.Mapping{{From: "TODO", LineCase: parser.LineCaseLower}},
+			opts:     MatchOptions{CaseSensitive: true},
+			expected: "Todo list\ntodo: fix\n",
+		},
+		{
+			name:     "word boundary trigger",
+			text:     "warnings here\nwarning here\n",
+			mappings: []parser.Mapping{{From: "warning", LineCase: parser.LineCaseUpper}},
+			opts:     MatchOptions{WordBoundary: true},
+			expected: "warnings here\nWARNING HERE\n",
+		},
+		{
+			name:     "normal mappings are ignored",
+			text:     "foo\n",
+			mappings: []parser.Mapping{{From: "foo", To: "bar"}},
+			expected: "foo\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyLineCase(tt.text, tt.mappings, tt.opts); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEngineLineCase(t *testing.T) {
+	mappings := parser.NewMappingTable([]parser.Mapping{
+		{From: "warning", LineCase: parser.LineCaseUpper},
+		{From: "old", To: "new"},
+	})
+	content := "intro\nwarning: old flag\nALREADY A WARNING\n"
+
+	dryRun := NewEngine(&config.Config{DryRun: true}).ProcessFile("notes.md", []byte(content), mappings)
+	if !dryRun.Modified {
+		t.Fatal("expected the file to be modified")
+	}
+	// The upper-case line needs no conversion and is not reported; "old" is
+	// matched on the converted line, so both changes are recorded as one
+	// whole-line replacement that revert can undo in place
+	if len(dryRun.Replacements) != 1 {
+		t.Fatalf("expected 1 replacement, got %+v", dryRun.Replacements)
+	}
+	line := dryRun.Replacements[0]
+	if line.Line != 2 || line.Column != 1 || line.OriginalText != "warning: old flag" || line.To != "WARNING: new FLAG" {
+		t.Errorf("unexpected line replacement %+v", line)
+	}
+	if line.ByteOffset != int64(len("intro\n")) {
+		t.Errorf("expected the line to start at byte %d, got %d", len("intro\n"), line.ByteOffset)
+	}
+	if dryRun.NewSize != int64(len("intro\nWARNING: new FLAG\nALREADY A WARNING\n")) {
+		t.Errorf("expected the dry run to report the exact new size, got %d", dryRun.NewSize)
+	}
+
+	applied := NewEngine(&config.Config{}).ProcessFile("notes.md", []byte(content), mappings)
+	if expected := int64(len("intro\nWARNING: new FLAG\nALREADY A WARNING\n")); applied.NewSize != expected {
+		t.Errorf("expected new size %d, got %d", expected, applied.NewSize)
+	}
+}
@@ -0,0 +1,90 @@
+package replacement
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"remap/internal/parser"
+)
+
+// scanLines is bufio.ScanLines without the removal of a trailing '\r', so
+// lines of CRLF files are matched as they are and byte offsets stay exact.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// newLineScanner returns a scanner over the lines of content, as split by
+// scanLines. Its buffer may grow to the whole content, so a single long line
+// such as a minified bundle is scanned instead of stopping the scan at the
+// default 64KB token limit.
+func newLineScanner(content string) *bufio.Scanner {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Split(scanLines)
+	scanner.Buffer(make([]byte, 0, min(len(content)+1, bufio.MaxScanTokenSize)), len(content)+1)
+	return scanner
+}
+
+// LineEnding returns the line ending content uses throughout: "\r\n" when
+// every line break is CRLF, "\n" when none is, and "" when the endings are
+// mixed or there is no line break at all.
+func LineEnding(content string) string {
+	lf := strings.Count(content, "\n")
+	if lf == 0 {
+		return ""
+	}
+	switch strings.Count(content, "\r\n") {
+	case lf:
+		return "\r\n"
+	case 0:
+		return "\n"
+	}
+	return ""
+}
+
+// WithLineEnding returns mappings with the line breaks of their destinations
+// converted to ending, so a multi-line replacement keeps a CRLF file CRLF and
+// an LF file LF. An empty ending, for mixed files, leaves destinations as
+// written. mappings itself is not modified.
+func WithLineEnding(mappings []parser.Mapping, ending string) []parser.Mapping {
+	if ending == "" {
+		return mappings
+	}
+
+	var converted []parser.Mapping
+	for i, mapping := range mappings {
+		to := convertLineEnding(mapping.To, ending)
+		if to == mapping.To {
+			continue
+		}
+		if converted == nil {
+			converted = append([]parser.Mapping(nil), mappings...)
+		}
+		converted[i].To = to
+	}
+	if converted == nil {
+		return mappings
+	}
+	return converted
+}
+
+// convertLineEnding rewrites every line break of s to ending.
+func convertLineEnding(s, ending string) string {
+	if !strings.Contains(s, "\n") {
+		return s
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if ending == "\r\n" {
+		s = strings.ReplaceAll(s, "\n", "\r\n")
+	}
+	return s
+}
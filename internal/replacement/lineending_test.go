@@ -0,0 +1,114 @@
+package replacement
+
+import (
+	"strings"
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestLineEnding(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "LF", content: "a\nb\n", expected: "\n"},
+		{name: "CRLF", content: "a\r\nb\r\n", expected: "\r\n"},
+		{name: "CRLF without final newline", content: "a\r\nb", expected: "\r\n"},
+		{name: "mixed", content: "a\r\nb\n", expected: ""},
+		{name: "no line break", content: "a", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LineEnding(tt.content); got != tt.expected {
+				t.Errorf("LineEnding(%q) = %q, want %q", tt.content, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEngineLineEndings(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		to       string
+		expected string
+	}{
+		{
+			name:     "CRLF kept",
+			content:  "one foo\r\ntwo foo\r\n",
+			to:       "bar",
+			expected: "one bar\r\ntwo bar\r\n",
+		},
+		{
+			name:     "multi-line destination in CRLF file",
+			content:  "x\r\nfoo\r\ny\r\n",
+			to:       "a\nb",
+			expected: "x\r\na\r\nb\r\ny\r\n",
+		},
+		{
+			name:     "CRLF destination in LF file",
+			content:  "x\nfoo\ny\n",
+			to:       "a\r\nb",
+			expected: "x\na\nb\ny\n",
+		},
+		{
+			name:     "mixed file left as is",
+			content:  "foo\r\nfoo\n",
+			to:       "a\nb",
+			expected: "a\nb\r\na\nb\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := parser.NewMappingTable([]parser.Mapping{{From: "foo", To: tt.to}})
+			result := NewEngine(&config.Config{DryRun: true, Diff: true}).ProcessFile("test.txt", []byte(tt.content), table)
+			if got := string(result.NewContent); got != tt.expected {
+				t.Errorf("output = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEngineCRLFOffsets(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{{From: "foo", To: "bar"}})
+	content := "a\r\nb foo\r\n"
+
+	result := NewEngine(&config.Config{DryRun: true}).ProcessFile("test.txt", []byte(content), table)
+	if len(result.Replacements) != 1 {
+		t.Fatalf("expected 1 replacement, got %d", len(result.Replacements))
+	}
+	r := result.Replacements[0]
+	if content[r.ByteOffset:r.ByteOffset+3] != "foo" {
+		t.Errorf("byte offset %d does not point at the match", r.ByteOffset)
+	}
+	if r.Line != 2 || r.Column != 3 || r.LineText != "b foo\r" {
+		t.Errorf("unexpected position: line %d, column %d, line text %q", r.Line, r.Column, r.LineText)
+	}
+}
+
+func TestEngineLongLine(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{
+		{From: "foo", To: "bar"},
+		{From: "warning", LineCase: parser.LineCaseUpper},
+	})
+	padding := strings.Repeat("x", 2<<20)
+	content := "var a=1;" + padding + "foo;\nwarning\n"
+
+	result := NewEngine(&config.Config{DryRun: true}).ProcessFile("bundle.min.js", []byte(content), table)
+	if len(result.Replacements) != 2 {
+		t.Fatalf("expected 2 replacements, got %d", len(result.Replacements))
+	}
+
+	lineCase, foo := result.Replacements[0], result.Replacements[1]
+	if lineCase.Line != 2 || lineCase.ByteOffset != int64(len(content)-len("warning\n")) {
+		t.Errorf("unexpected line-case position: line %d, offset %d", lineCase.Line, lineCase.ByteOffset)
+	}
+	if want := len("var a=1;") + len(padding) + 1; foo.Line != 1 || foo.Column != want {
+		t.Errorf("expected line 1, column %d; got line %d, column %d", want, foo.Line, foo.Column)
+	}
+}
@@ -0,0 +1,108 @@
+package replacement
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+// MatchOptions are the per-match rules for literal mappings: which matches
+// may be replaced and how the destination is adjusted to its surroundings.
+// Detection and both apply paths share them so they always agree.
+type MatchOptions struct {
+	CaseSensitive  bool
+	GraphemeAware  bool // --grapheme-aware: matches must start and end on cluster boundaries
+	WordBoundary   bool // --word-boundary: matches must not touch word characters
+	PreserveIndent bool // --preserve-indent: multi-line destinations follow the match's indentation
+	PreserveCase   bool // --preserve-case: destinations take the casing of the matched text
+}
+
+// NewMatchOptions returns the match options selected by cfg.
+func NewMatchOptions(cfg *config.Config) MatchOptions {
+	return MatchOptions{
+		CaseSensitive:  cfg.CaseSensitive,
+		GraphemeAware:  cfg.GraphemeAware,
+		WordBoundary:   cfg.WordBoundary,
+		PreserveIndent: cfg.PreserveIndent,
+		PreserveCase:   cfg.PreserveCase && !cfg.CaseSensitive,
+	}
+}
+
+// For returns the options for mapping: its own case_sensitive and whole_word
+// settings, when it has them, override those of the run.
+func (o MatchOptions) For(mapping parser.Mapping) MatchOptions {
+	caseSensitive := mapping.IsCaseSensitive(o.CaseSensitive)
+	o.PreserveCase = o.PreserveCase && !caseSensitive
+	o.CaseSensitive = caseSensitive
+	o.WordBoundary = mapping.IsWholeWord(o.WordBoundary)
+	return o
+}
+
+// IsPlain reports whether every match is replaced as is, in which case the
+// plain replace functions give the same result faster. Those functions
+// handle PreserveCase themselves.
+func (o MatchOptions) IsPlain() bool {
+	return !o.GraphemeAware && !o.WordBoundary && !o.PreserveIndent
+}
+
+// accepts reports whether the match s[start:end] may be replaced.
+func (o MatchOptions) accepts(s string, start, end int) bool {
+	if o.GraphemeAware && !isGraphemeMatch(s, start, end) {
+		return false
+	}
+	if o.WordBoundary && !isWordMatch(s, start, end) {
+		return false
+	}
+	return true
+}
+
+// destination returns to as written in place of the match s[start:end].
+func (o MatchOptions) destination(s string, start, end int, to string) string {
+	if o.PreserveCase {
+		to = MatchCase(s[start:end], to)
+	}
+	if o.PreserveIndent {
+		return Reindent(to, lineIndent(s, start))
+	}
+	return to
+}
+
+// ReplaceMatches replaces every occurrence of from in content that the
+// options accept, adjusting to for each match as the options require.
+func ReplaceMatches(content, from, to string, opts MatchOptions) string {
+	if from == "" {
+		return content
+	}
+
+	searchContent, searchFrom := content, from
+	if !opts.CaseSensitive {
+		searchContent, searchFrom = FoldCase(content), FoldCase(from)
+	}
+
+	var result strings.Builder
+	start, written := 0, 0
+
+	for {
+		index := strings.Index(searchContent[start:], searchFrom)
+		if index == -1 {
+			break
+		}
+
+		matchStart := start + index
+		matchEnd := matchStart + len(from)
+		if !opts.accepts(content, matchStart, matchEnd) {
+			_, size := utf8.DecodeRuneInString(searchContent[matchStart:])
+			start = matchStart + size
+			continue
+		}
+
+		result.WriteString(content[written:matchStart])
+		result.WriteString(opts.destination(content, matchStart, matchEnd, to))
+		start, written = matchEnd, matchEnd
+	}
+
+	result.WriteString(content[written:])
+	return result.String()
+}
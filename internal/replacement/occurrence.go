@@ -0,0 +1,55 @@
+package replacement
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"remap/internal/parser"
+)
+
+// OccurrenceCounter counts, per mapping index, the matches of mappings with
+// an OccurrenceIndex seen so far in a file. One counter is shared by every
+// call made for the same file, so the Nth match is counted across sections.
+type OccurrenceCounter map[int]int
+
+// seen records one more accepted match of mapping and reports whether it is
+// the occurrence to replace.
+func (c OccurrenceCounter) seen(mapping parser.Mapping) bool {
+	c[mapping.Index]++
+	return c[mapping.Index] == mapping.OccurrenceIndex
+}
+
+// ReplaceOccurrence replaces only the match of mapping.From whose position
+// among the file's accepted matches is mapping.OccurrenceIndex, counting the
+// matches seen by earlier calls with the same counter.
+func ReplaceOccurrence(content string, mapping parser.Mapping, opts MatchOptions, counter OccurrenceCounter) string {
+	if mapping.From == "" || counter[mapping.Index] >= mapping.OccurrenceIndex {
+		return content
+	}
+
+	searchContent, searchFrom := content, mapping.From
+	if !opts.CaseSensitive {
+		searchContent, searchFrom = FoldCase(content), FoldCase(mapping.From)
+	}
+
+	start := 0
+	for {
+		index := strings.Index(searchContent[start:], searchFrom)
+		if index == -1 {
+			return content
+		}
+
+		matchStart := start + index
+		matchEnd := matchStart + len(mapping.From)
+		if !opts.accepts(content, matchStart, matchEnd) {
+			_, size := utf8.DecodeRuneInString(searchContent[matchStart:])
+			start = matchStart + size
+			continue
+		}
+
+		if counter.seen(mapping) {
+			return content[:matchStart] + opts.destination(content, matchStart, matchEnd, mapping.To) + content[matchEnd:]
+		}
+		start = matchEnd
+	}
+}
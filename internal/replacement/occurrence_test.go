@@ -0,0 +1,78 @@
+package replacement
+
+import (
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestReplaceOccurrence(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		occurrence int
+		opts       MatchOptions
+		expected   string
+	}{
+		{"second occurrence", "foo foo foo", 2, MatchOptions{}, "foo bar foo"},
+		{"first occurrence", "foo foo", 1, MatchOptions{}, "bar foo"},
+		{"fewer matches than the index", "foo foo", 3, MatchOptions{}, "foo foo"},
+		{"case-insensitive counting", "Foo FOO foo", 2, MatchOptions{}, "Foo bar foo"},
+		{"case-sensitive counting", "Foo foo foo", 2, MatchOptions{CaseSensitive: true}, "Foo foo bar"},
+		{"rejected matches are not counted", "food foo foo", 2, MatchOptions{WordBoundary: true}, "food foo bar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping := parser.Mapping{From: "foo", To: "bar", OccurrenceIndex: tt.occurrence}
+			if got := ReplaceOccurrence(tt.content, mapping, tt.opts, OccurrenceCounter{}); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReplaceOccurrenceAcrossCalls(t *testing.T) {
+	mapping := parser.Mapping{From: "foo", To: "bar", OccurrenceIndex: 2, Index: 3}
+	counter := OccurrenceCounter{}
+
+	// The first section holds the first match, the second section the second
+	first := ReplaceOccurrence("foo", mapping, MatchOptions{}, counter)
+	second := ReplaceOccurrence("foo foo", mapping, MatchOptions{}, counter)
+	third := ReplaceOccurrence("foo", mapping, MatchOptions{}, counter)
+
+	if first != "foo" || second != "bar foo" || third != "foo" {
+		t.Errorf("expected only the second match of the file replaced, got %q, %q, %q", first, second, third)
+	}
+}
+
+func TestEngineOccurrence(t *testing.T) {
+	mappings := parser.NewMappingTable([]parser.Mapping{
+		{From: "foo", To: "bar", OccurrenceIndex: 2},
+		{From: "baz", To: "qux"},
+	})
+	content := "foo baz\nfoo foo baz\n"
+
+	dryRun := NewEngine(&config.Config{DryRun: true}).ProcessFile("file.txt", []byte(content), mappings)
+	var fooReplacements []Replacement
+	for _, r := range dryRun.Replacements {
+		if r.From == "foo" {
+			fooReplacements = append(fooReplacements, r)
+		}
+	}
+	if len(fooReplacements) != 1 {
+		t.Fatalf("expected a single foo replacement, got %+v", fooReplacements)
+	}
+	if r := fooReplacements[0]; r.Line != 2 || r.Column != 1 {
+		t.Errorf("expected the second foo (line 2, column 1) to be detected, got line %d column %d", r.Line, r.Column)
+	}
+	if len(dryRun.Replacements) != 3 {
+		t.Errorf("expected other mappings to be unaffected, got %d replacements", len(dryRun.Replacements))
+	}
+
+	applied := NewEngine(&config.Config{}).ProcessFile("file.txt", []byte(content), mappings)
+	if expected := int64(len("foo qux\nbar foo qux\n")); applied.NewSize != expected {
+		t.Errorf("expected new size %d, got %d", expected, applied.NewSize)
+	}
+}
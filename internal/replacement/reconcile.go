@@ -0,0 +1,153 @@
+package replacement
+
+import (
+	"sort"
+	"strings"
+)
+
+// reconcileReplacements returns replacements that turn original into
+// rendered when each one's OriginalText at its ByteOffset is swapped for its
+// To, and rendered back into original the other way round, which is how
+// --apply and --revert replay a log.
+//
+// Detection records every mapping against the text it started from, while
+// rendering chains the mappings: with alpha→beta and beta→gamma, the "beta"
+// written by the first mapping is rewritten by the second, and a match on a
+// line converted by a line-case mapping lies inside that line's record. On
+// each line where the records do not add up to the rendered line, they are
+// replaced by a single whole-line replacement, as line-case mappings record.
+func reconcileReplacements(original, rendered string, replacements []Replacement) []Replacement {
+	ordered := byOffset(replacements)
+	if applied, ok := applyAt(original, ordered, 0); ok && applied == rendered {
+		return replacements
+	}
+
+	originalLines := strings.SplitAfter(original, "\n")
+	renderedLines := strings.SplitAfter(rendered, "\n")
+	if len(originalLines) != len(renderedLines) {
+		// Multi-line mappings moved lines around: only the span between the
+		// unchanged leading and trailing lines can be recorded
+		return []Replacement{spanReplacement(originalLines, renderedLines, ordered)}
+	}
+
+	var reconciled []Replacement
+	lineStart, next := 0, 0
+	for i, line := range originalLines {
+		lineEnd := lineStart + len(line)
+		first := next
+		for next < len(ordered) && int(ordered[next].ByteOffset) < lineEnd {
+			next++
+		}
+		onLine := ordered[first:next]
+
+		if line != renderedLines[i] {
+			if applied, ok := applyAt(line, onLine, lineStart); ok && applied == renderedLines[i] {
+				reconciled = append(reconciled, onLine...)
+			} else {
+				reconciled = append(reconciled, lineReplacement(line, renderedLines[i], i+1, lineStart, onLine))
+			}
+		}
+		lineStart = lineEnd
+	}
+	return reconciled
+}
+
+// usedMappings returns the distinct mapping indexes of replacements, in the
+// order they first appear.
+func usedMappings(replacements []Replacement) []int {
+	var used []int
+	seen := make(map[int]bool)
+	for _, repl := range replacements {
+		if !seen[repl.MappingIndex] {
+			seen[repl.MappingIndex] = true
+			used = append(used, repl.MappingIndex)
+		}
+	}
+	return used
+}
+
+// byOffset returns a copy of replacements ordered by position.
+func byOffset(replacements []Replacement) []Replacement {
+	ordered := append([]Replacement(nil), replacements...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].ByteOffset < ordered[j].ByteOffset
+	})
+	return ordered
+}
+
+// applyAt swaps the OriginalText of each replacement, ordered by position,
+// for its To in text, which starts at byte base of the file. It fails when a
+// replacement overlaps another or text does not hold its OriginalText.
+func applyAt(text string, ordered []Replacement, base int) (string, bool) {
+	var result strings.Builder
+	written := 0
+	for _, repl := range ordered {
+		start := int(repl.ByteOffset) - base
+		end := start + len(repl.OriginalText)
+		if start < written || end > len(text) || text[start:end] != repl.OriginalText {
+			return "", false
+		}
+		result.WriteString(text[written:start])
+		result.WriteString(repl.To)
+		written = end
+	}
+	result.WriteString(text[written:])
+	return result.String(), true
+}
+
+// lineReplacement records the change of a whole line, without its line
+// feed, on behalf of the replacements made on it.
+func lineReplacement(line, rendered string, lineNum, lineStart int, onLine []Replacement) Replacement {
+	body := strings.TrimSuffix(line, "\n")
+	repl := Replacement{
+		From:         body,
+		To:           strings.TrimSuffix(rendered, "\n"),
+		OriginalText: body,
+		Line:         lineNum,
+		Column:       1,
+		LineText:     body,
+		ByteOffset:   int64(lineStart),
+		MappingIndex: -1,
+	}
+	if len(onLine) > 0 {
+		repl.MappingIndex = onLine[0].MappingIndex
+		repl.Comment = onLine[0].Comment
+		repl.ContextBefore = onLine[0].ContextBefore
+		repl.ContextAfter = onLine[0].ContextAfter
+	}
+	return repl
+}
+
+// spanReplacement records the change of every line between the leading and
+// trailing lines original and rendered have in common.
+func spanReplacement(originalLines, renderedLines []string, ordered []Replacement) Replacement {
+	lead := 0
+	for lead < len(originalLines) && lead < len(renderedLines) && originalLines[lead] == renderedLines[lead] {
+		lead++
+	}
+	trail := 0
+	for trail < len(originalLines)-lead && trail < len(renderedLines)-lead &&
+		originalLines[len(originalLines)-1-trail] == renderedLines[len(renderedLines)-1-trail] {
+		trail++
+	}
+
+	start := len(strings.Join(originalLines[:lead], ""))
+	from := strings.Join(originalLines[lead:len(originalLines)-trail], "")
+	repl := Replacement{
+		From:         from,
+		To:           strings.Join(renderedLines[lead:len(renderedLines)-trail], ""),
+		OriginalText: from,
+		Line:         lead + 1,
+		Column:       1,
+		ByteOffset:   int64(start),
+		MappingIndex: -1,
+	}
+	if len(ordered) > 0 {
+		repl.MappingIndex = ordered[0].MappingIndex
+		repl.Comment = ordered[0].Comment
+	}
+	if lead < len(originalLines) {
+		repl.LineText = strings.TrimSuffix(originalLines[lead], "\n")
+	}
+	return repl
+}
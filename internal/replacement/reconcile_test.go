@@ -0,0 +1,92 @@
+package replacement
+
+import (
+	"testing"
+)
+
+func TestReconcileReplacements(t *testing.T) {
+	tests := []struct {
+		name         string
+		original     string
+		rendered     string
+		replacements []Replacement
+		expected     []Replacement
+	}{
+		{
+			name:     "records that add up are kept",
+			original: "foo\nfoo bar\n",
+			rendered: "baz\nfoo qux\n",
+			replacements: []Replacement{
+				{From: "bar", To: "qux", OriginalText: "bar", Line: 2, Column: 5, ByteOffset: 8, MappingIndex: 1},
+				{From: "foo", To: "baz", OriginalText: "foo", Line: 1, Column: 1, ByteOffset: 0},
+			},
+			expected: []Replacement{
+				{From: "bar", To: "qux", OriginalText: "bar", Line: 2, Column: 5, ByteOffset: 8, MappingIndex: 1},
+				{From: "foo", To: "baz", OriginalText: "foo", Line: 1, Column: 1, ByteOffset: 0},
+			},
+		},
+		{
+			name:     "chained mappings become a whole-line record",
+			original: "keep alpha\nalpha beta\n",
+			rendered: "keep gamma\ngamma gamma\n",
+			replacements: []Replacement{
+				{From: "alpha", To: "beta", OriginalText: "alpha", Line: 1, Column: 6, ByteOffset: 5},
+				{From: "alpha", To: "beta", OriginalText: "alpha", Line: 2, Column: 1, ByteOffset: 11},
+				{From: "beta", To: "gamma", OriginalText: "beta", Line: 2, Column: 7, ByteOffset: 17, MappingIndex: 1},
+			},
+			expected: []Replacement{
+				{From: "keep alpha", To: "keep gamma", OriginalText: "keep alpha", Line: 1, Column: 1, LineText: "keep alpha", ByteOffset: 0},
+				{From: "alpha beta", To: "gamma gamma", OriginalText: "alpha beta", Line: 2, Column: 1, LineText: "alpha beta", ByteOffset: 11},
+			},
+		},
+		{
+			name:     "lines left as they were are not recorded",
+			original: "a\nb\n",
+			rendered: "a\nc\n",
+			replacements: []Replacement{
+				{From: "a", To: "b", OriginalText: "a", Line: 1, Column: 1, ByteOffset: 0},
+				{From: "b", To: "a", OriginalText: "b", Line: 2, Column: 1, ByteOffset: 2, MappingIndex: 1},
+			},
+			expected: []Replacement{
+				{From: "b", To: "c", OriginalText: "b", Line: 2, Column: 1, LineText: "b", ByteOffset: 2, MappingIndex: 1},
+			},
+		},
+		{
+			name:     "lines added are recorded as one span",
+			original: "head\nx y\ntail\n",
+			rendered: "head\nx\ny\ntail\n",
+			replacements: []Replacement{
+				{From: " ", To: "\n", OriginalText: " ", Line: 2, Column: 2, ByteOffset: 6},
+				{From: "x", To: "x", OriginalText: "z", Line: 2, Column: 1, ByteOffset: 5},
+			},
+			expected: []Replacement{
+				{From: "x y\n", To: "x\ny\n", OriginalText: "x y\n", Line: 2, Column: 1, LineText: "x y", ByteOffset: 5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reconcileReplacements(tt.original, tt.rendered, tt.replacements)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %d replacements, got %+v", len(tt.expected), got)
+			}
+			for i := range got {
+				if !equalReplacement(got[i], tt.expected[i]) {
+					t.Errorf("replacement %d: expected %+v, got %+v", i, tt.expected[i], got[i])
+				}
+			}
+
+			// Replaying the records must reproduce the rendered content
+			if applied, ok := applyAt(tt.original, byOffset(got), 0); !ok || applied != tt.rendered {
+				t.Errorf("records replay to %q, expected %q", applied, tt.rendered)
+			}
+		})
+	}
+}
+
+func equalReplacement(a, b Replacement) bool {
+	return a.From == b.From && a.To == b.To && a.OriginalText == b.OriginalText &&
+		a.Line == b.Line && a.Column == b.Column && a.LineText == b.LineText &&
+		a.ByteOffset == b.ByteOffset && a.MappingIndex == b.MappingIndex
+}
@@ -0,0 +1,88 @@
+package replacement
+
+import (
+	"strings"
+
+	"remap/internal/parser"
+)
+
+// regexMatch is one replaceable match of a regex mapping within a line.
+type regexMatch struct {
+	start, end int
+	to         string
+}
+
+// findRegexMatches returns the matches of a regex mapping in line, which must
+// not hold its line ending, with each destination expanded from the match's
+// capture groups and adjusted as opts require. Empty matches and matches opts
+// does not accept are skipped.
+func findRegexMatches(mapping parser.Mapping, opts MatchOptions, line string) []regexMatch {
+	re := mapping.Regexp(opts.CaseSensitive)
+	if re == nil {
+		return nil
+	}
+
+	var matches []regexMatch
+	for _, submatches := range re.FindAllStringSubmatchIndex(line, -1) {
+		start, end := submatches[0], submatches[1]
+		if start == end || !opts.accepts(line, start, end) {
+			continue
+		}
+		to := string(re.ExpandString(nil, mapping.To, line, submatches))
+		matches = append(matches, regexMatch{start: start, end: end, to: opts.destination(line, start, end, to)})
+	}
+	return matches
+}
+
+// detectRegexMatches records every match of a regex mapping on a line.
+// Like template matches, the recorded From/To are the concrete matched and
+// expanded texts, so reports and reverts see real strings rather than the
+// expression.
+func detectRegexMatches(mapping parser.Mapping, opts MatchOptions, line string, lineNum int, byteOffset int64) []Replacement {
+	body := strings.TrimSuffix(line, "\r")
+
+	var replacements []Replacement
+	for _, match := range findRegexMatches(mapping, opts, body) {
+		matched := body[match.start:match.end]
+		if match.to == matched {
+			// The text already reads as its destination: nothing to change
+			continue
+		}
+		replacements = append(replacements, Replacement{
+			From:         matched,
+			To:           match.to,
+			OriginalText: matched,
+			Line:         lineNum,
+			Column:       runeColumn(line, match.start),
+			LineText:     line,
+			ByteOffset:   byteOffset + int64(match.start),
+			MappingIndex: mapping.Index,
+			Comment:      mapping.Comment,
+		})
+	}
+	return replacements
+}
+
+// ReplaceRegex replaces every match of a regex mapping in content, line by
+// line, so expressions never match across a line ending, as in detection.
+func ReplaceRegex(content string, mapping parser.Mapping, opts MatchOptions) string {
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		body := strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		matches := findRegexMatches(mapping, opts, body)
+		if len(matches) == 0 {
+			continue
+		}
+
+		var b strings.Builder
+		written := 0
+		for _, match := range matches {
+			b.WriteString(line[written:match.start])
+			b.WriteString(match.to)
+			written = match.end
+		}
+		b.WriteString(line[written:])
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "")
+}
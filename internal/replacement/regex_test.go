@@ -0,0 +1,101 @@
+package replacement
+
+import (
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestEngineRuleOptions(t *testing.T) {
+	sensitive, insensitive := true, false
+
+	tests := []struct {
+		name            string
+		config          config.Config
+		mappings        []parser.Mapping
+		content         string
+		expectedContent string
+		expectedCount   int
+	}{
+		{
+			name: "case-sensitive rule with a case-insensitive one",
+			mappings: []parser.Mapping{
+				{From: "Foo", To: "Bar", CaseSensitive: &sensitive},
+				{From: "baz", To: "qux"},
+			},
+			content:         "Foo foo FOO baz BAZ Baz\n",
+			expectedContent: "Bar foo FOO qux qux qux\n",
+			expectedCount:   4,
+		},
+		{
+			name:   "case-insensitive rule in a case-sensitive run",
+			config: config.Config{CaseSensitive: true},
+			mappings: []parser.Mapping{
+				{From: "foo", To: "bar", CaseSensitive: &insensitive},
+				{From: "baz", To: "qux"},
+			},
+			content:         "Foo foo baz BAZ\n",
+			expectedContent: "bar bar qux BAZ\n",
+			expectedCount:   3,
+		},
+		{
+			name:   "whole-word rule overrides the run",
+			config: config.Config{WordBoundary: true},
+			mappings: []parser.Mapping{
+				{From: "id", To: "key", WholeWord: &insensitive},
+				{From: "x", To: "y"},
+			},
+			content:         "id width x xx\n",
+			expectedContent: "key wkeyth y xx\n",
+			expectedCount:   3,
+		},
+		{
+			name: "regex rule with capture groups",
+			mappings: []parser.Mapping{
+				{From: `v(\d+)\.(\d+)`, To: "version $1 (minor ${2})", Regex: true, CaseSensitive: &sensitive},
+			},
+			content:         "v1.2 and V3.4\nv10.0\n",
+			expectedContent: "version 1 (minor 2) and V3.4\nversion 10 (minor 0)\n",
+			expectedCount:   2,
+		},
+		{
+			name: "case-insensitive whole-word regex",
+			mappings: []parser.Mapping{
+				{From: `colou?r`, To: "hue", Regex: true, WholeWord: &sensitive},
+			},
+			content:         "Color colour colors\r\nCOLOR\r\n",
+			expectedContent: "hue hue colors\r\nhue\r\n",
+			expectedCount:   3,
+		},
+		{
+			name: "regex anchors match each line",
+			mappings: []parser.Mapping{
+				{From: `^(\w+)$`, To: "[$1]", Regex: true},
+			},
+			content:         "one\ntwo words\nthree\n",
+			expectedContent: "[one]\ntwo words\n[three]\n",
+			expectedCount:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.config
+			cfg.DryRun, cfg.Diff = true, true
+			result := NewEngine(&cfg).ProcessFile("test.txt", []byte(tt.content), parser.NewMappingTable(tt.mappings))
+
+			if len(result.Replacements) != tt.expectedCount {
+				t.Errorf("expected %d replacements, got %d", tt.expectedCount, len(result.Replacements))
+			}
+			if got := string(result.NewContent); got != tt.expectedContent {
+				t.Errorf("expected %q, got %q", tt.expectedContent, got)
+			}
+			for _, r := range result.Replacements {
+				if tt.content[r.ByteOffset:r.ByteOffset+int64(len(r.OriginalText))] != r.OriginalText {
+					t.Errorf("replacement %+v is not recorded where it matched", r)
+				}
+			}
+		})
+	}
+}
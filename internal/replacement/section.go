@@ -0,0 +1,102 @@
+package replacement
+
+import (
+	"strings"
+
+	"remap/internal/config"
+)
+
+// sectionTracker follows marker-delimited sections (e.g. "# BEGIN managed" /
+// "# END managed") and --lines ranges line by line. Marker lines themselves
+// are never part of a section, and a section left open runs to the end of the
+// file. With both, a line must be inside a section and within a range.
+type sectionTracker struct {
+	begin   string
+	end     string
+	inside  bool
+	ranges  []config.LineRange
+	lineNum int
+}
+
+// newSectionTracker returns a tracker for the configured markers and line
+// ranges, or nil when replacement is not restricted to sections.
+func newSectionTracker(cfg *config.Config) *sectionTracker {
+	ranges := cfg.LineRanges()
+	if cfg.SectionBegin == "" && len(ranges) == 0 {
+		return nil
+	}
+	return &sectionTracker{begin: cfg.SectionBegin, end: cfg.SectionEnd, ranges: ranges}
+}
+
+// excludes advances the tracker past line, which must be the next line of
+// the file, and reports whether the line lies outside any section. A nil
+// tracker excludes nothing.
+func (st *sectionTracker) excludes(line string) bool {
+	if st == nil {
+		return false
+	}
+
+	st.lineNum++
+	outsideMarkers := st.outsideMarkers(line)
+	return outsideMarkers || !st.inRanges()
+}
+
+// inRanges reports whether the current line lies within a --lines range.
+func (st *sectionTracker) inRanges() bool {
+	if len(st.ranges) == 0 {
+		return true
+	}
+	for _, r := range st.ranges {
+		if r.Contains(st.lineNum) {
+			return true
+		}
+	}
+	return false
+}
+
+// outsideMarkers advances the marker state past line and reports whether the
+// line lies outside a marker-delimited section.
+func (st *sectionTracker) outsideMarkers(line string) bool {
+	if st.begin == "" {
+		return false
+	}
+
+	if !st.inside {
+		st.inside = strings.Contains(line, st.begin)
+		return true
+	}
+
+	if strings.Contains(line, st.end) {
+		st.inside = false
+		return true
+	}
+	return false
+}
+
+// ReplaceInSections applies replace to the content of each section configured
+// in cfg and leaves everything else untouched. Without section markers or
+// line ranges the whole content is replaced. replace also receives the 1-based line number at
+// which its text starts in the file.
+func ReplaceInSections(cfg *config.Config, content string, replace func(text string, firstLine int) string) string {
+	sections := newSectionTracker(cfg)
+	if sections == nil {
+		return replace(content, 1)
+	}
+
+	var result, section strings.Builder
+	lineNum, sectionStart := 0, 1
+	for _, line := range strings.SplitAfter(content, "\n") {
+		lineNum++
+		if sections.excludes(strings.TrimRight(line, "\r\n")) {
+			result.WriteString(replace(section.String(), sectionStart))
+			section.Reset()
+			result.WriteString(line)
+			sectionStart = lineNum + 1
+			continue
+		}
+		section.WriteString(line)
+	}
+	result.WriteString(replace(section.String(), sectionStart))
+
+	return result.String()
+}
@@ -0,0 +1,134 @@
+package replacement
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestReplaceInSections(t *testing.T) {
+	cfg := &config.Config{SectionBegin: "# BEGIN managed", SectionEnd: "# END managed"}
+	upper := func(text string, _ int) string { return strings.ReplaceAll(text, "host", "HOST") }
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "single section",
+			content:  "host\n# BEGIN managed\nhost\n# END managed\nhost\n",
+			expected: "host\n# BEGIN managed\nHOST\n# END managed\nhost\n",
+		},
+		{
+			name:     "two sections",
+			content:  "# BEGIN managed\nhost\n# END managed\nhost\n# BEGIN managed\nhost\n# END managed\n",
+			expected: "# BEGIN managed\nHOST\n# END managed\nhost\n# BEGIN managed\nHOST\n# END managed\n",
+		},
+		{
+			name:     "unclosed section",
+			content:  "host\n# BEGIN managed\nhost",
+			expected: "host\n# BEGIN managed\nHOST",
+		},
+		{
+			name:     "no section",
+			content:  "host\r\nhost\r\n",
+			expected: "host\r\nhost\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := ReplaceInSections(cfg, tt.content, upper); result != tt.expected {
+				t.Errorf("ReplaceInSections() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+
+	if result := ReplaceInSections(&config.Config{}, "host\n", upper); result != "HOST\n" {
+		t.Errorf("expected whole content replaced without markers, got %q", result)
+	}
+}
+
+func TestEngineSections(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{{From: "old.example.com", To: "new.example.com"}})
+	cfg := &config.Config{SectionBegin: "# BEGIN managed", SectionEnd: "# END managed"}
+	content := "server old.example.com\n# BEGIN managed\nupstream old.example.com\n# END managed\nbackup old.example.com\n"
+
+	result := NewEngine(cfg).ProcessFile("nginx.conf", []byte(content), table)
+	if len(result.Replacements) != 1 {
+		t.Fatalf("expected 1 replacement inside the section, got %d", len(result.Replacements))
+	}
+	if result.Replacements[0].Line != 3 {
+		t.Errorf("expected replacement on line 3, got line %d", result.Replacements[0].Line)
+	}
+	if expected := int64(strings.Index(content, "upstream ") + len("upstream ")); result.Replacements[0].ByteOffset != expected {
+		t.Errorf("expected byte offset %d, got %d", expected, result.Replacements[0].ByteOffset)
+	}
+}
+
+func TestEngineLineRanges(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{{From: "old", To: "new"}})
+	content := "old 1\nold 2\nold 3\r\nold 4\nold 5\nold 6\n"
+
+	tests := []struct {
+		name          string
+		lines         []string
+		sectionBegin  string
+		sectionEnd    string
+		expectedLines []int
+		expected      string
+	}{
+		{
+			name:          "closed range",
+			lines:         []string{"2:3"},
+			expectedLines: []int{2, 3},
+			expected:      "old 1\nnew 2\nnew 3\r\nold 4\nold 5\nold 6\n",
+		},
+		{
+			name:          "open ranges",
+			lines:         []string{":1", "5:"},
+			expectedLines: []int{1, 5, 6},
+			expected:      "new 1\nold 2\nold 3\r\nold 4\nnew 5\nnew 6\n",
+		},
+		{
+			name:          "range beyond the end of the file",
+			lines:         []string{"10:20"},
+			expectedLines: nil,
+			expected:      content,
+		},
+		{
+			name:          "range and section",
+			lines:         []string{"3:"},
+			sectionBegin:  "old 2",
+			sectionEnd:    "old 5",
+			expectedLines: []int{3, 4},
+			expected:      "old 1\nold 2\nnew 3\r\nnew 4\nold 5\nold 6\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Lines: tt.lines, SectionBegin: tt.sectionBegin, SectionEnd: tt.sectionEnd}
+			result := NewEngine(cfg).ProcessFile("app.conf", []byte(content), table)
+
+			var lines []int
+			for _, r := range result.Replacements {
+				lines = append(lines, r.Line)
+			}
+			if fmt.Sprint(lines) != fmt.Sprint(tt.expectedLines) {
+				t.Errorf("expected replacements on lines %v, got %v", tt.expectedLines, lines)
+			}
+
+			applied := ReplaceInSections(cfg, content, func(text string, _ int) string {
+				return strings.ReplaceAll(text, "old", "new")
+			})
+			if applied != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, applied)
+			}
+		})
+	}
+}
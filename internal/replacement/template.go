@@ -0,0 +1,121 @@
+package replacement
+
+import (
+	"strings"
+)
+
+// templatePlaceholder and templateReference define the lightweight template syntax:
+// a single '*' in From captures a run of word characters, and '{}' in To inserts it.
+const (
+	templatePlaceholder = "*"
+	templateReference   = "{}"
+)
+
+// templatePattern is a From value split around its placeholder.
+type templatePattern struct {
+	prefix string
+	suffix string
+}
+
+// parseTemplate splits a template From into prefix and suffix.
+// Only values containing exactly one placeholder are templates; anything else
+// is matched literally so existing mappings keep working in template mode.
+func parseTemplate(from string) (templatePattern, bool) {
+	if strings.Count(from, templatePlaceholder) != 1 || from == templatePlaceholder {
+		return templatePattern{}, false
+	}
+
+	index := strings.Index(from, templatePlaceholder)
+	return templatePattern{prefix: from[:index], suffix: from[index+1:]}, true
+}
+
+// IsTemplate reports whether a mapping From is a template in template mode.
+func IsTemplate(from string) bool {
+	_, ok := parseTemplate(from)
+	return ok
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+func hasPrefixFold(s, prefix string, caseSensitive bool) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	if caseSensitive {
+		return s[:len(prefix)] == prefix
+	}
+	return strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// matchAt tries to match the template at position i of content.
+// The capture is a non-empty run of word characters: the shortest run followed
+// by the suffix, or the longest run when the suffix is empty. It returns the
+// end of the match and the captured text.
+func (tp templatePattern) matchAt(content string, i int, caseSensitive bool) (int, string, bool) {
+	if !hasPrefixFold(content[i:], tp.prefix, caseSensitive) {
+		return 0, "", false
+	}
+
+	// Without a prefix the capture must start a word, not land in the middle of one
+	if tp.prefix == "" && i > 0 && isWordByte(content[i-1]) {
+		return 0, "", false
+	}
+
+	captureStart := i + len(tp.prefix)
+	for end := captureStart; end < len(content) && isWordByte(content[end]); end++ {
+		if tp.suffix == "" {
+			if end+1 == len(content) || !isWordByte(content[end+1]) {
+				return end + 1, content[captureStart : end+1], true
+			}
+			continue
+		}
+		if hasPrefixFold(content[end+1:], tp.suffix, caseSensitive) {
+			return end + 1 + len(tp.suffix), content[captureStart : end+1], true
+		}
+	}
+	return 0, "", false
+}
+
+// find returns the first template match at or after start.
+func (tp templatePattern) find(content string, start int, caseSensitive bool) (int, int, string) {
+	for i := start; i < len(content); i++ {
+		if end, captured, ok := tp.matchAt(content, i, caseSensitive); ok {
+			return i, end, captured
+		}
+	}
+	return -1, -1, ""
+}
+
+// expandTemplate substitutes the captured text into a To value.
+func expandTemplate(to, captured string) string {
+	return strings.ReplaceAll(to, templateReference, captured)
+}
+
+// TemplateReplaceAll replaces every match of a template From in content.
+// It is shared by the engine and the file writer so detection and application
+// agree on exactly which text is replaced.
+func TemplateReplaceAll(content, from, to string, caseSensitive bool) string {
+	tp, ok := parseTemplate(from)
+	if !ok {
+		return content
+	}
+
+	var result strings.Builder
+	start := 0
+
+	for start < len(content) {
+		matchStart, matchEnd, captured := tp.find(content, start, caseSensitive)
+		if matchStart == -1 {
+			break
+		}
+
+		result.WriteString(content[start:matchStart])
+		result.WriteString(expandTemplate(to, captured))
+		start = matchEnd
+	}
+	result.WriteString(content[start:])
+
+	return result.String()
+}
@@ -0,0 +1,90 @@
+package replacement
+
+import (
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		from     string
+		expectOK bool
+		prefix   string
+		suffix   string
+	}{
+		{"getFoo*()", true, "getFoo", "()"},
+		{"get*Bar()", true, "get", "Bar()"},
+		{"*_suffix", true, "", "_suffix"},
+		{"plain", false, "", ""},
+		{"a*b*c", false, "", ""},
+		{"*", false, "", ""},
+	}
+
+	for _, tt := range tests {
+		tp, ok := parseTemplate(tt.from)
+		if ok != tt.expectOK {
+			t.Errorf("parseTemplate(%q) ok = %v, expected %v", tt.from, ok, tt.expectOK)
+			continue
+		}
+		if ok && (tp.prefix != tt.prefix || tp.suffix != tt.suffix) {
+			t.Errorf("parseTemplate(%q) = %q/%q, expected %q/%q", tt.from, tp.prefix, tp.suffix, tt.prefix, tt.suffix)
+		}
+	}
+}
+
+func TestTemplateReplaceAll(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		from          string
+		to            string
+		caseSensitive bool
+		expected      string
+	}{
+		{"method family", "x.getFooBar(); y.getFooBaz()", "getFoo*()", "get{}()", true, "x.getBar(); y.getBaz()"},
+		{"capture before suffix", "getFooBar()", "get*Bar()", "fetch{}Bar()", true, "fetchFooBar()"},
+		{"no match without capture", "getFoo()", "getFoo*()", "get{}()", true, "getFoo()"},
+		{"capture stops at non-word", "getFoo.Bar()", "getFoo*()", "get{}()", true, "getFoo.Bar()"},
+		{"empty suffix takes whole word", "oldName oldValue", "old*", "new{}", true, "newName newValue"},
+		{"case-insensitive prefix", "GETFOOBar()", "getFoo*()", "get{}()", false, "getBar()"},
+		{"case-sensitive mismatch", "GETFOOBar()", "getFoo*()", "get{}()", true, "GETFOOBar()"},
+		{"empty prefix starts a word", "xBar_suffix a_suffix", "*_suffix", "{}", true, "xBar a"},
+		{"repeated capture", "setX", "set*", "{}={}", true, "X=X"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := TemplateReplaceAll(tt.content, tt.from, tt.to, tt.caseSensitive)
+			if result != tt.expected {
+				t.Errorf("TemplateReplaceAll(%q, %q, %q) = %q, expected %q", tt.content, tt.from, tt.to, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEngineTemplateMappings(t *testing.T) {
+	table := parser.NewMappingTable([]parser.Mapping{{From: "getFoo*()", To: "get{}()"}})
+	content := []byte("a := getFooBar()\nb := getFooBaz() + getFooBar()\n")
+
+	engine := NewEngine(&config.Config{CaseSensitive: true, TemplateMappings: true})
+	result := engine.ProcessFile("test.go", content, table)
+
+	if len(result.Replacements) != 3 {
+		t.Fatalf("expected 3 replacements, got %d", len(result.Replacements))
+	}
+	first := result.Replacements[0]
+	if first.From != "getFooBar()" || first.To != "getBar()" || first.Line != 1 || first.Column != 6 {
+		t.Errorf("unexpected first replacement: %+v", first)
+	}
+	if second := result.Replacements[1]; second.From != "getFooBaz()" || second.To != "getBaz()" || second.Line != 2 {
+		t.Errorf("unexpected second replacement: %+v", second)
+	}
+
+	// Without template mode the '*' is literal and nothing matches
+	literal := NewEngine(&config.Config{CaseSensitive: true}).ProcessFile("test.go", content, table)
+	if len(literal.Replacements) != 0 {
+		t.Errorf("expected no literal matches, got %d", len(literal.Replacements))
+	}
+}
@@ -0,0 +1,14 @@
+package replacement
+
+// isWordMatch reports whether the match s[start:end] stands on its own: the
+// bytes just before and after it, if any, are not word characters
+// ([A-Za-z0-9_]). So "id" matches twice in "id.id" but not in "width" or "valid".
+func isWordMatch(s string, start, end int) bool {
+	if start > 0 && isWordByte(s[start-1]) {
+		return false
+	}
+	if end < len(s) && isWordByte(s[end]) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package replacement
+
+import (
+	"testing"
+
+	"remap/internal/config"
+	"remap/internal/parser"
+)
+
+func TestReplaceMatchesWordBoundary(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		from          string
+		caseSensitive bool
+		expected      string
+	}{
+		{name: "inside words", content: "width valid id", from: "id", caseSensitive: true, expected: "width valid identifier"},
+		{name: "start and end of content", content: "id", from: "id", caseSensitive: true, expected: "identifier"},
+		{name: "adjacent words", content: "id.id", from: "id", caseSensitive: true, expected: "identifier.identifier"},
+		{name: "joined words", content: "idid id_x", from: "id", caseSensitive: true, expected: "idid id_x"},
+		{name: "line boundaries", content: "id\nid\n", from: "id", caseSensitive: true, expected: "identifier\nidentifier\n"},
+		{name: "digits are word characters", content: "id2 2id (id)", from: "id", caseSensitive: true, expected: "id2 2id (identifier)"},
+		{name: "case insensitive", content: "ID Id valid", from: "id", expected: "identifier identifier valid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := MatchOptions{CaseSensitive: tt.caseSensitive, WordBoundary: true}
+			if got := ReplaceMatches(tt.content, tt.from, "identifier", opts); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEngineWordBoundary(t *testing.T) {
+	content := "id width\nvalid id.id\n"
+	table := parser.NewMappingTable([]parser.Mapping{{From: "id", To: "identifier"}})
+
+	tests := []struct {
+		name                 string
+		wordBoundary         bool
+		expectedReplacements int
+		expectedContent      string
+	}{
+		{
+			name:                 "without flag",
+			expectedReplacements: 5,
+			expectedContent:      "identifier widentifierth\nvalidentifier identifier.identifier\n",
+		},
+		{
+			name:                 "with flag",
+			wordBoundary:         true,
+			expectedReplacements: 3,
+			expectedContent:      "identifier width\nvalid identifier.identifier\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{CaseSensitive: true, WordBoundary: tt.wordBoundary}
+			engine := NewEngine(cfg)
+
+			result := engine.ProcessFile("test.go", []byte(content), table)
+			if len(result.Replacements) != tt.expectedReplacements {
+				t.Errorf("expected %d detected replacements, got %d", tt.expectedReplacements, len(result.Replacements))
+			}
+			if len(result.Replacements) > 0 && result.Replacements[0].NewText != tt.expectedContent {
+				t.Errorf("expected content %q, got %q", tt.expectedContent, result.Replacements[0].NewText)
+			}
+		})
+	}
+}
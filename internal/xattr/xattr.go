@@ -0,0 +1,5 @@
+// Package xattr provides best-effort preservation of extended attributes.
+// Rewriting a file through a temporary file and rename drops attributes such as
+// security labels or ACLs stored as xattrs; this package copies them across so
+// the rewritten file keeps the metadata of the original.
+package xattr
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package xattr
+
+// Supported reports whether Copy preserves extended attributes on this
+// platform. Only Linux and macOS have the xattr calls Copy relies on.
+const Supported = false
+
+// Copy is a no-op on platforms without extended attribute support;
+// configuration rejects --preserve-xattrs there.
+func Copy(_, _ string) error {
+	return nil
+}
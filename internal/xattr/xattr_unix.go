@@ -0,0 +1,80 @@
+//go:build linux || darwin
+
+package xattr
+
+import (
+	"bytes"
+	stderrors "errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// Supported reports whether Copy preserves extended attributes on this
+// platform.
+const Supported = true
+
+// Copy copies every extended attribute of src onto dst.
+// Filesystems without xattr support are treated as having no attributes,
+// so callers can use Copy unconditionally.
+func Copy(src, dst string) error {
+	names, err := list(src)
+	if err != nil {
+		if isUnsupported(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, name := range names {
+		value, err := get(src, name)
+		if err != nil {
+			return err
+		}
+		if err := unix.Setxattr(dst, name, value, 0); err != nil {
+			if isUnsupported(err) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func list(path string) ([]string, error) {
+	size, err := unix.Listxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+
+	buf := make([]byte, size)
+	size, err = unix.Listxattr(path, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names, nil
+}
+
+func get(path, name string) ([]byte, error) {
+	size, err := unix.Getxattr(path, name, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+
+	value := make([]byte, size)
+	size, err = unix.Getxattr(path, name, value)
+	if err != nil {
+		return nil, err
+	}
+	return value[:size], nil
+}
+
+func isUnsupported(err error) bool {
+	return stderrors.Is(err, unix.ENOTSUP) || stderrors.Is(err, unix.EOPNOTSUPP)
+}
@@ -0,0 +1,55 @@
+//go:build linux || darwin
+
+package xattr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCopy(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "src.txt")
+	dst := filepath.Join(tempDir, "dst.txt")
+
+	for _, path := range []string{src, dst} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := unix.Setxattr(src, "user.remap.test", []byte("kept"), 0); err != nil {
+		t.Skipf("filesystem does not support user xattrs: %v", err)
+	}
+
+	if err := Copy(src, dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	value, err := get(dst, "user.remap.test")
+	if err != nil {
+		t.Fatalf("attribute not copied: %v", err)
+	}
+	if string(value) != "kept" {
+		t.Errorf("expected attribute value 'kept', got %q", value)
+	}
+}
+
+func TestCopyWithoutAttributes(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "src.txt")
+	dst := filepath.Join(tempDir, "dst.txt")
+
+	for _, path := range []string{src, dst} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Copy(src, dst); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+// Package remap runs remap's replacement engine over strings in memory, for
+// programs that want the command's matching rules without its file
+// handling. Nothing is read from or written to disk.
+package remap
+
+import (
+	"remap/internal/parser"
+	"remap/internal/replacement"
+)
+
+// Line-case conversions a Mapping can apply to every line holding its source.
+const (
+	LineCaseUpper = parser.LineCaseUpper
+	LineCaseLower = parser.LineCaseLower
+	LineCaseTitle = parser.LineCaseTitle
+)
+
+// Mapping replaces From with To, like a row of a mapping file.
+type Mapping struct {
+	From string
+	To   string
+
+	// LineCase, when set, converts every line holding From to this case
+	// instead of replacing From; To is then ignored
+	LineCase string
+	// Occurrence, when positive, replaces only the nth match of From
+	Occurrence int
+	// CaseSensitive and WholeWord, when set, override the Options
+	CaseSensitive *bool
+	WholeWord     *bool
+	// Regex treats From as a regular expression and To as its expansion
+	Regex   bool
+	Comment string
+}
+
+// Options select how ReplaceString matches. They mirror the command-line
+// flags of the same names, and a mapping's own CaseSensitive and WholeWord
+// fields still take precedence over them.
+type Options struct {
+	CaseSensitive bool // --case-sensitive
+	WordBoundary  bool // --word-boundary: matches must not touch word characters
+	PreserveCase  bool // --preserve-case: ignored when matching case-sensitively
+	Regex         bool // treat every mapping's source as a regular expression
+}
+
+// Replacement records one replacement ReplaceString made.
+type Replacement struct {
+	From         string
+	To           string
+	OriginalText string // matched text as it appeared, e.g. "COLOR" for From "color"
+	Line         int
+	Column       int // 1-based, in characters (runes) rather than bytes
+	ByteOffset   int64
+	MappingIndex int // index of the mapping in the slice given to ReplaceString, -1 if none
+}
+
+// ReplaceString runs mappings over content the way the command replaces a
+// file's content, and returns the new content together with the
+// replacements made. Mappings are validated as mapping files are; an
+// invalid mapping returns an error and content as is.
+func ReplaceString(content string, mappings []Mapping, opts Options) (string, []Replacement, error) {
+	converted := make([]parser.Mapping, len(mappings))
+	for i, mapping := range mappings {
+		converted[i] = parser.Mapping{
+			From:            mapping.From,
+			To:              mapping.To,
+			LineCase:        mapping.LineCase,
+			OccurrenceIndex: mapping.Occurrence,
+			CaseSensitive:   mapping.CaseSensitive,
+			WholeWord:       mapping.WholeWord,
+			Regex:           mapping.Regex,
+			Comment:         mapping.Comment,
+		}
+	}
+
+	result, made, err := replacement.ReplaceString(content, converted, replacement.Options{
+		CaseSensitive: opts.CaseSensitive,
+		WordBoundary:  opts.WordBoundary,
+		PreserveCase:  opts.PreserveCase,
+		Regex:         opts.Regex,
+	})
+	if err != nil {
+		return result, nil, err
+	}
+
+	replacements := make([]Replacement, len(made))
+	for i, repl := range made {
+		replacements[i] = Replacement{
+			From:         repl.From,
+			To:           repl.To,
+			OriginalText: repl.OriginalText,
+			Line:         repl.Line,
+			Column:       repl.Column,
+			ByteOffset:   repl.ByteOffset,
+			MappingIndex: repl.MappingIndex,
+		}
+	}
+	return result, replacements, nil
+}
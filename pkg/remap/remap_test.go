@@ -0,0 +1,85 @@
+package remap
+
+import (
+	"testing"
+)
+
+func TestReplaceString(t *testing.T) {
+	caseSensitive := true
+
+	tests := []struct {
+		name         string
+		content      string
+		mappings     []Mapping
+		opts         Options
+		expected     string
+		replacements []Replacement
+		expectError  bool
+	}{
+		{
+			name:     "case insensitive by default",
+			content:  "Foo\nfoo",
+			mappings: []Mapping{{From: "x", To: "y"}, {From: "foo", To: "bar"}},
+			expected: "bar\nbar",
+			replacements: []Replacement{
+				{From: "foo", To: "bar", OriginalText: "Foo", Line: 1, Column: 1, ByteOffset: 0, MappingIndex: 1},
+				{From: "foo", To: "bar", OriginalText: "foo", Line: 2, Column: 1, ByteOffset: 4, MappingIndex: 1},
+			},
+		},
+		{
+			name:     "mapping overrides options",
+			content:  "Foo foo",
+			mappings: []Mapping{{From: "foo", To: "bar", CaseSensitive: &caseSensitive}},
+			expected: "Foo bar",
+			replacements: []Replacement{
+				{From: "foo", To: "bar", OriginalText: "foo", Line: 1, Column: 5, ByteOffset: 4},
+			},
+		},
+		{
+			name:     "regex option",
+			content:  "v1.2",
+			mappings: []Mapping{{From: `v(\d+)\.(\d+)`, To: "$1-$2"}},
+			opts:     Options{Regex: true},
+			expected: "1-2",
+			replacements: []Replacement{
+				{From: "v1.2", To: "1-2", OriginalText: "v1.2", Line: 1, Column: 1, ByteOffset: 0},
+			},
+		},
+		{
+			name:     "line case",
+			content:  "a title\nbody\n",
+			mappings: []Mapping{{From: "title", LineCase: LineCaseUpper}},
+			expected: "A TITLE\nbody\n",
+			replacements: []Replacement{
+				{From: "a title", To: "A TITLE", OriginalText: "a title", Line: 1, Column: 1, ByteOffset: 0},
+			},
+		},
+		{
+			name:        "invalid mapping",
+			content:     "foo",
+			mappings:    []Mapping{{From: "", To: "bar"}},
+			expected:    "foo",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, replacements, err := ReplaceString(tt.content, tt.mappings, tt.opts)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+			if len(replacements) != len(tt.replacements) {
+				t.Fatalf("expected %d replacements, got %+v", len(tt.replacements), replacements)
+			}
+			for i := range replacements {
+				if replacements[i] != tt.replacements[i] {
+					t.Errorf("replacement %d: expected %+v, got %+v", i, tt.replacements[i], replacements[i])
+				}
+			}
+		})
+	}
+}